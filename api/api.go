@@ -1,39 +1,103 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/inventory"
 	"github.com/EdgarH78/jurassic-park/models"
 	"github.com/gin-gonic/gin"
 )
 
-const baseUrl = "jurassicpark/v1"
+const (
+	baseUrl   = "jurassicpark/v1"
+	baseUrlV2 = "jurassicpark/v2"
+)
 
+// parkManager makes changes on behalf of the actor in the context it is given, and records them in the audit log.
 type parkManager interface {
-	AddCage(cage models.Cage) error
+	AddCage(ctx context.Context, cage models.Cage) error
 	GetCage(cageLabel string) (*models.Cage, error)
-	GetCages(filter models.CageFilter) ([]models.Cage, error)
-	AddDinosaur(dinosaur models.Dinosaur) error
-	GetDinosaurs(filter models.DinosaurFilter) ([]models.Dinosaur, error)
+	GetCages(filter models.CageFilter) ([]models.Cage, models.PageInfo, error)
+	AddDinosaur(ctx context.Context, dinosaur models.Dinosaur) error
+	GetDinosaurs(filter models.DinosaurFilter) ([]models.Dinosaur, models.PageInfo, error)
 	GetDinosaur(name string) (*models.Dinosaur, error)
-	AddDinosaurToCage(dinosaurName, targetCage string) error
-	GetDinosaursInCage(cageLabel string) ([]models.Dinosaur, error)
-	UpdateCagePowerStatus(cageLabel string, powerOn bool) error
+	UpdateDinosaur(ctx context.Context, name string, update models.UpdateDinosaurRequest) (*models.Dinosaur, error)
+	AddDinosaurToCage(ctx context.Context, dinosaurName, targetCage string) error
+	GetDinosaursInCage(cageLabel string, page models.Pagination) ([]models.Dinosaur, models.PageInfo, error)
+	RemoveDinosaurFromCage(ctx context.Context, dinosaurName, cageLabel string) error
+	TransferDinosaur(ctx context.Context, dinosaurName, targetCage string) error
+	UpdateCage(ctx context.Context, cageLabel string, update models.UpdateCageRequest) (*models.Cage, error)
+	DeleteCage(ctx context.Context, cageLabel string) error
+	AddSpecies(ctx context.Context, species models.Species) error
+	GetSpecies(name string) (*models.Species, error)
+	GetAllSpecies() ([]models.Species, error)
+	UpdateSpecies(ctx context.Context, species models.Species) error
+	DeleteSpecies(ctx context.Context, name string) error
+	AddWebhook(ctx context.Context, webhook models.Webhook) (*models.Webhook, error)
+	GetWebhooks() ([]models.Webhook, error)
+	GetWebhook(id int) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	GetWebhookDeliveries(webhookId int, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, models.PageInfo, error)
+	GetAuditEvents(filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error)
+	AddAPIKey(ctx context.Context, apiKey models.APIKey) (*models.APIKey, error)
+	GetAPIKeys() ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	AddFeed(ctx context.Context, feed models.Feed) error
+	GetFeed(name string) (*models.Feed, error)
+	GetFeeds(filter models.FeedFilter) ([]models.Feed, error)
+	RestockFeed(ctx context.Context, name string, quantity int) (*models.Feed, error)
+	AddFeedingSchedule(ctx context.Context, schedule models.FeedingSchedule) (*models.FeedingSchedule, error)
+	GetFeedingSchedule(id int) (*models.FeedingSchedule, error)
+	GetFeedingSchedules(filter models.FeedingScheduleFilter) ([]models.FeedingSchedule, error)
+	DeleteFeedingSchedule(ctx context.Context, id int) error
+	RecordFeeding(ctx context.Context, feeding models.Feeding) (*models.Feeding, *models.Feed, error)
+	GetFeedings(filter models.FeedingFilter) ([]models.Feeding, error)
+	AddHealthRecord(ctx context.Context, record models.HealthRecord) (*models.HealthRecord, error)
+	GetHealthRecord(dinosaurName string, id int) (*models.HealthRecord, error)
+	GetHealthRecords(dinosaurName string, filter models.HealthRecordFilter) ([]models.HealthRecord, error)
+	UpdateHealthRecord(ctx context.Context, update models.HealthRecord) (*models.HealthRecord, error)
+	DeleteHealthRecord(ctx context.Context, dinosaurName string, id int) error
+	AddZone(ctx context.Context, zone models.Zone) error
+	GetZones() ([]models.Zone, error)
+	UpdateZone(ctx context.Context, name string, update models.UpdateZoneRequest) (*models.Zone, error)
+	DeleteZone(ctx context.Context, name string) error
+	ImportTransaction(ctx context.Context, fn func(store inventory.Store) error) error
 }
 
 type API struct {
-	engine      *gin.Engine
-	parkManager parkManager
+	engine         *gin.Engine
+	parkManager    parkManager
+	events         *events.Broker
+	authenticators []auth.Authenticator
+}
+
+// Option configures an API.
+type Option func(api *API)
+
+// WithEvents publishes the changes made through the API to the broker. Without it the API publishes to a broker of
+// its own.
+func WithEvents(broker *events.Broker) Option {
+	return func(api *API) {
+		api.events = broker
+	}
 }
 
-func NewAPI(parkManager parkManager, engine *gin.Engine) *API {
+func NewAPI(parkManager parkManager, engine *gin.Engine, opts ...Option) *API {
 	api := &API{
 		parkManager: parkManager,
 		engine:      engine,
 	}
+	for _, opt := range opts {
+		opt(api)
+	}
+	if api.events == nil {
+		api.events = events.NewBroker(events.DefaultReplaySize)
+	}
 
 	api.registerHandlers()
 	return api
@@ -43,186 +107,428 @@ func (api *API) Run() {
 	api.engine.Run(":8080")
 }
 
+// registerHandlers registers every route behind the roles that can use it. Cages are updated by keepers and power
+// operators alike, and the handlers check that the principal holds the role for the fields being changed.
 func (api *API) registerHandlers() {
-	api.engine.POST(baseUrl+"/cages", api.CreateCage)
-	api.engine.GET(baseUrl+"/cages", api.GetCages)
-	api.engine.GET(baseUrl+"/cages/:cageLabel", api.GetCage)
-	api.engine.PATCH(baseUrl+"/cages/:cageLabel", api.UpdateCagePowerStatus)
-	api.engine.GET(baseUrl+"/cages/:cageLabel/dinosaurs", api.GetDinosaursInCage)
-	api.engine.POST(baseUrl+"/cages/:cageLabel/dinosaurs", api.AddDinosaurToCage)
-	api.engine.POST(baseUrl+"/dinosaurs", api.AddDinosaur)
-	api.engine.GET(baseUrl+"/dinosaurs", api.GetDinosaurs)
-	api.engine.GET(baseUrl+"/dinosaurs/:name", api.GetDinosaur)
+	viewer := api.engine.Group("", api.authorize(models.RoleViewer))
+	keeper := api.engine.Group("", api.authorize(models.RoleKeeper))
+	cageOperator := api.engine.Group("", api.authorize(models.RoleKeeper, models.RolePowerOperator))
+	vet := api.engine.Group("", api.authorize(models.RoleVet))
+	admin := api.engine.Group("", api.authorize(models.RoleAdmin))
+
+	keeper.POST(baseUrl+"/cages", api.CreateCage)
+	viewer.GET(baseUrl+"/cages", api.GetCages)
+	viewer.GET(baseUrl+"/cages/:cageLabel", api.GetCage)
+	cageOperator.PATCH(baseUrl+"/cages/:cageLabel", api.UpdateCage)
+
+	// v2 tracks cage power as a power status rather than the hasPower flag
+	keeper.POST(baseUrlV2+"/cages", api.CreateCageV2)
+	viewer.GET(baseUrlV2+"/cages", api.GetCagesV2)
+	viewer.GET(baseUrlV2+"/cages/:cageLabel", api.GetCageV2)
+	cageOperator.PATCH(baseUrlV2+"/cages/:cageLabel", api.UpdateCageV2)
+
+	// the rest of the API is the same in both versions
+	for _, base := range []string{baseUrl, baseUrlV2} {
+		keeper.DELETE(base+"/cages/:cageLabel", api.DeleteCage)
+		viewer.GET(base+"/cages/:cageLabel/dinosaurs", api.GetDinosaursInCage)
+		keeper.POST(base+"/cages/:cageLabel/dinosaurs", api.AddDinosaurToCage)
+		keeper.DELETE(base+"/cages/:cageLabel/dinosaurs/:name", api.RemoveDinosaurFromCage)
+		keeper.POST(base+"/dinosaurs", api.AddDinosaur)
+		viewer.GET(base+"/dinosaurs", api.GetDinosaurs)
+		viewer.GET(base+"/dinosaurs/:name", api.GetDinosaur)
+		keeper.PATCH(base+"/dinosaurs/:name", api.UpdateDinosaur)
+		keeper.POST(base+"/dinosaurs/:name/transfer", api.TransferDinosaur)
+		vet.POST(base+"/dinosaurs/:name/health-records", api.CreateHealthRecord)
+		viewer.GET(base+"/dinosaurs/:name/health-records", api.GetHealthRecords)
+		viewer.GET(base+"/dinosaurs/:name/health-records/:id", api.GetHealthRecord)
+		vet.PUT(base+"/dinosaurs/:name/health-records/:id", api.UpdateHealthRecord)
+		vet.DELETE(base+"/dinosaurs/:name/health-records/:id", api.DeleteHealthRecord)
+		keeper.POST(base+"/zones", api.CreateZone)
+		viewer.GET(base+"/zones", api.GetZones)
+		viewer.GET(base+"/zones/:name", api.GetZone)
+		keeper.PATCH(base+"/zones/:name", api.UpdateZone)
+		keeper.DELETE(base+"/zones/:name", api.DeleteZone)
+		keeper.POST(base+"/species", api.CreateSpecies)
+		viewer.GET(base+"/species", api.GetAllSpecies)
+		viewer.GET(base+"/species/:name", api.GetSpecies)
+		keeper.PUT(base+"/species/:name", api.UpdateSpecies)
+		keeper.DELETE(base+"/species/:name", api.DeleteSpecies)
+		viewer.GET(base+"/events", api.StreamEvents)
+		admin.POST(base+"/webhooks", api.CreateWebhook)
+		admin.GET(base+"/webhooks", api.GetWebhooks)
+		admin.GET(base+"/webhooks/:id", api.GetWebhook)
+		admin.DELETE(base+"/webhooks/:id", api.DeleteWebhook)
+		admin.GET(base+"/webhooks/:id/deliveries", api.GetWebhookDeliveries)
+		admin.GET(base+"/audit", api.GetAuditEvents)
+		admin.POST(base+"/apikeys", api.CreateAPIKey)
+		admin.GET(base+"/apikeys", api.GetAPIKeys)
+		admin.DELETE(base+"/apikeys/:id", api.RevokeAPIKey)
+		keeper.POST(base+"/feeding/feeds", api.CreateFeed)
+		viewer.GET(base+"/feeding/feeds", api.GetFeeds)
+		keeper.POST(base+"/feeding/feeds/:name/restock", api.RestockFeed)
+		keeper.POST(base+"/feeding/schedules", api.CreateFeedingSchedule)
+		viewer.GET(base+"/feeding/schedules", api.GetFeedingSchedules)
+		keeper.DELETE(base+"/feeding/schedules/:id", api.DeleteFeedingSchedule)
+		viewer.GET(base+"/feeding/roster", api.GetFeedingRoster)
+		keeper.POST(base+"/feeding/feedings", api.RecordFeeding)
+		viewer.GET(base+"/feeding/feedings", api.GetFeedings)
+		keeper.POST(base+"/import", api.ImportInventory)
+		viewer.GET(base+"/export", api.ExportInventory)
+	}
 }
 
 func (api *API) CreateCage(c *gin.Context) {
 
 	var cage models.Cage
-	err := json.NewDecoder(c.Request.Body).Decode(&cage)
-	if err != nil {
-		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
-			ErrorMessage: "Request body is in the incorrect format",
-		})
+	if !decodeRequest(c, &cage) || !validateRequest(c, cage) {
 		return
 	}
-	err = api.parkManager.AddCage(cage)
+	err := api.parkManager.AddCage(actorContext(c), cage)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			ErrorMessage: "An error occured while adding the cage",
-		})
+		respondWithCreateCageError(c, err, cage.Label, cage.RequestedPowerStatus(), cage.Zone)
 	} else {
+		api.publishCageCreated(cage)
 		c.JSON(http.StatusCreated, cage)
 	}
 }
 
+// respondWithCreateCageError writes the error response for a failed AddCage call in either API version.
+func respondWithCreateCageError(c *gin.Context, err error, label string, powerStatus models.PowerStatus, zone *string) {
+	if errors.Is(err, models.EntityAlreadyExists) {
+		respondWithError(c, err, fmt.Sprintf("There is already a cage with the label %s", label), gin.H{
+			"cage": label,
+		})
+	} else if errors.Is(err, models.InvalidPowerStatus) {
+		respondWithError(c, err, fmt.Sprintf("%s is not a valid power status", powerStatus), gin.H{
+			"powerStatus": powerStatus,
+		})
+	} else if errors.Is(err, models.InvalidCageZone) {
+		respondWithInvalidCageZone(c, err, zone)
+	} else if errors.Is(err, models.InvalidRequest) {
+		respondWithValidationError(c, err)
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
+
 func (api *API) GetCages(c *gin.Context) {
 	filter := models.CageFilter{}
 	if c.Query("hasPower") != "" {
 		hasPower := c.Query("hasPower") == "true"
 		filter.HasPower = &hasPower
 	}
-	cages, err := api.parkManager.GetCages(filter)
+	if c.Query("canHouse") != "" {
+		canHouse := c.Query("canHouse")
+		filter.CanHouse = &canHouse
+	}
+	if c.Query("zone") != "" {
+		zone := c.Query("zone")
+		filter.Zone = &zone
+	}
+	page, ok := parsePagination(c, models.CageSortFields)
+	if !ok {
+		return
+	}
+	filter.Page = page
+	cages, pageInfo, err := api.parkManager.GetCages(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			ErrorMessage: "unexpected error",
-		})
+		respondWithGetCagesError(c, err, filter)
 		return
 	}
+	setPageHeaders(c, pageInfo)
 	c.JSON(http.StatusOK, cages)
 }
 
+// respondWithGetCagesError writes the error response for a failed GetCages call in either API version.
+func respondWithGetCagesError(c *gin.Context, err error, filter models.CageFilter) {
+	if errors.Is(err, models.EntityNotFound) && filter.CanHouse != nil && filter.Zone != nil {
+		respondWithError(c, err, fmt.Sprintf("could not find either the dinosaur %s or the zone %s", *filter.CanHouse, *filter.Zone), gin.H{
+			"dinosaur": *filter.CanHouse,
+			"zone":     *filter.Zone,
+		})
+	} else if errors.Is(err, models.EntityNotFound) && filter.CanHouse != nil {
+		respondWithError(c, err, fmt.Sprintf("dinosaur with name %s not found", *filter.CanHouse), gin.H{
+			"dinosaur": *filter.CanHouse,
+		})
+	} else if errors.Is(err, models.EntityNotFound) && filter.Zone != nil {
+		respondWithError(c, err, fmt.Sprintf("zone with name %s not found", *filter.Zone), gin.H{
+			"zone": *filter.Zone,
+		})
+	} else if errors.Is(err, models.InvalidSort) && filter.CanHouse != nil {
+		respondWithError(c, err, "sort can't be used with canHouse, which ranks the cages itself", gin.H{
+			"parameter": "sort",
+		})
+	} else if errors.Is(err, models.InvalidCursor) {
+		respondWithInvalidCursor(c)
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
+
 func (api *API) GetCage(c *gin.Context) {
 	cageLabel := c.Param("cageLabel")
 	cage, err := api.parkManager.GetCage(cageLabel)
 	if err != nil {
-		if errors.Is(err, models.EntityNotFound) {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				ErrorMessage: fmt.Sprintf("cage with label %s not found", cageLabel),
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				ErrorMessage: "unexpected error",
-			})
-		}
+		respondWithCageNotFound(c, err, cageLabel)
 		return
 	}
 
 	c.JSON(http.StatusOK, cage)
 }
 
-func (api *API) UpdateCagePowerStatus(c *gin.Context) {
+// respondWithCageNotFound writes the error response for a cage that couldn't be read.
+func respondWithCageNotFound(c *gin.Context, err error, cageLabel string) {
+	if errors.Is(err, models.EntityNotFound) {
+		respondWithError(c, err, fmt.Sprintf("cage with label %s not found", cageLabel), gin.H{
+			"cage": cageLabel,
+		})
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
+
+func (api *API) UpdateCage(c *gin.Context) {
 	cageLabel := c.Param("cageLabel")
-	var updatePowerStatusRequest models.UpdateCagePowerStatusRequest
-	err := json.NewDecoder(c.Request.Body).Decode(&updatePowerStatusRequest)
+	var updateCageRequest models.UpdateCageRequest
+	if !decodeRequest(c, &updateCageRequest) || !validateRequest(c, updateCageRequest) {
+		return
+	}
+	// v1 only changed power, and a body without hasPower has always turned power off. Bodies without the fields
+	// added since keep that meaning and response, so existing callers aren't broken.
+	powerOnly := updateCageRequest.Label == nil && updateCageRequest.MaxOccupancy == nil && updateCageRequest.Zone == nil
+	if powerOnly && updateCageRequest.HasPower == nil {
+		hasPower := false
+		updateCageRequest.HasPower = &hasPower
+	}
+	if !authorizeCageUpdate(c, updateCageRequest) {
+		return
+	}
+
+	cage, err := api.parkManager.UpdateCage(actorContext(c), cageLabel, updateCageRequest)
 	if err != nil {
-		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
-			ErrorMessage: "Request body is in the incorrect format",
+		if errors.Is(err, models.IncompatibleCagePowerState) {
+			api.publishPowerCutRefused(cageLabel, updateCageRequest)
+		}
+		api.respondWithUpdateCageError(c, err, cageLabel, updateCageRequest)
+		return
+	}
+	api.publishCageUpdated(*cage, updateCageRequest)
+	if powerOnly {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "power status updated",
 		})
 		return
 	}
+	c.JSON(http.StatusOK, cage)
+}
+
+// respondWithUpdateCageError writes the error response for a failed UpdateCage call in either API version.
+func (api *API) respondWithUpdateCageError(c *gin.Context, err error, cageLabel string, update models.UpdateCageRequest) {
+	if errors.Is(err, models.IncompatibleCagePowerState) {
+		context := api.cageContext(cageLabel)
+		context["requestedPowerStatus"] = *update.RequestedPowerStatus()
+		respondWithError(c, err, "the cage has dinosaurs in it and cannot be powered off", context)
+	} else if errors.Is(err, models.InvalidPowerStatusTransition) {
+		context := api.cageContext(cageLabel)
+		context["requestedPowerStatus"] = *update.RequestedPowerStatus()
+		respondWithError(c, err, fmt.Sprintf("the cage can't move to the power status %s from its current power status", *update.RequestedPowerStatus()), context)
+	} else if errors.Is(err, models.InvalidPowerStatus) {
+		respondWithError(c, err, fmt.Sprintf("%s is not a valid power status", *update.RequestedPowerStatus()), gin.H{
+			"powerStatus": *update.RequestedPowerStatus(),
+		})
+	} else if errors.Is(err, models.CageCapacityBelowOccupancy) {
+		context := api.cageContext(cageLabel)
+		context["requestedMaxOccupancy"] = *update.MaxOccupancy
+		respondWithError(c, err, "the cage holds more dinosaurs than the requested capacity", context)
+	} else if errors.Is(err, models.IncompatibleSpecies) {
+		context := api.cageContext(cageLabel)
+		context["requestedMaxOccupancy"] = *update.MaxOccupancy
+		respondWithError(c, err, "the requested capacity would leave the species in the cage without room for a full pack", context)
+	} else if errors.Is(err, models.EntityAlreadyExists) {
+		// a cage that keeps its label can clash with a cage in the zone it moves to
+		label := cageLabel
+		if update.Label != nil {
+			label = *update.Label
+		}
+		respondWithError(c, err, fmt.Sprintf("There is already a cage with the label %s", label), gin.H{
+			"cage": label,
+		})
+	} else if errors.Is(err, models.InvalidCageZone) {
+		respondWithInvalidCageZone(c, err, update.Zone)
+	} else if errors.Is(err, models.InvalidRequest) {
+		respondWithValidationError(c, err)
+	} else if errors.Is(err, models.EntityNotFound) {
+		respondWithError(c, err, "could not find cage", gin.H{
+			"cage": cageLabel,
+		})
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
 
-	err = api.parkManager.UpdateCagePowerStatus(cageLabel, updatePowerStatusRequest.HasPower)
+func (api *API) DeleteCage(c *gin.Context) {
+	cageLabel := c.Param("cageLabel")
+	err := api.parkManager.DeleteCage(actorContext(c), cageLabel)
 	if err != nil {
-		if errors.Is(err, models.IncompatibleCagePowerState) {
-			c.JSON(http.StatusConflict, models.ErrorResponse{
-				ErrorMessage: "the cage has dinosaurs in it and cannot be powered off",
-			})
-		} else if errors.Is(err, models.EntityNotFound) {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				ErrorMessage: "could not find cage",
-			})
+		if errors.Is(err, models.CageNotEmpty) {
+			respondWithError(c, err, "the cage has dinosaurs in it and cannot be decommissioned", api.cageContext(cageLabel))
 		} else {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				ErrorMessage: "unexpected error",
-			})
+			respondWithCageNotFound(c, err, cageLabel)
 		}
 		return
 	}
+	api.events.Publish(events.CageDeleted, models.DeletedCage{Label: cageLabel})
 	c.JSON(http.StatusOK, gin.H{
-		"message": "power status updated",
+		"message": "cage deleted",
 	})
 }
 
 func (api *API) GetDinosaursInCage(c *gin.Context) {
 	cageLabel := c.Param("cageLabel")
-	dinosaurs, err := api.parkManager.GetDinosaursInCage(cageLabel)
+	page, ok := parsePagination(c, models.DinosaurSortFields)
+	if !ok {
+		return
+	}
+	dinosaurs, pageInfo, err := api.parkManager.GetDinosaursInCage(cageLabel, page)
 	if err != nil {
 		if errors.Is(err, models.EntityNotFound) {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				ErrorMessage: fmt.Sprintf("the cage %s was not found", cageLabel),
+			respondWithError(c, err, fmt.Sprintf("the cage %s was not found", cageLabel), gin.H{
+				"cage": cageLabel,
 			})
+		} else if errors.Is(err, models.InvalidCursor) {
+			respondWithInvalidCursor(c)
 		} else {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				ErrorMessage: "unexpected error",
-			})
+			respondWithError(c, err, "", nil)
 		}
 		return
 	}
+	setPageHeaders(c, pageInfo)
 	c.JSON(http.StatusOK, dinosaurs)
 }
 
 func (api *API) AddDinosaurToCage(c *gin.Context) {
 	var addDinosaurRequest models.AddDinosaurToCageRequest
-	err := json.NewDecoder(c.Request.Body).Decode(&addDinosaurRequest)
-	if err != nil {
-		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
-			ErrorMessage: "Request body is in the incorrect format",
-		})
+	if !decodeRequest(c, &addDinosaurRequest) || !validateRequest(c, addDinosaurRequest) {
 		return
 	}
 	targetCage := c.Param("cageLabel")
-	err = api.parkManager.AddDinosaurToCage(addDinosaurRequest.Name, targetCage)
+	err := api.parkManager.AddDinosaurToCage(actorContext(c), addDinosaurRequest.Name, targetCage)
 	if err != nil {
-		if errors.Is(err, models.CageCapacityExceeded) {
-			c.JSON(http.StatusConflict, models.ErrorResponse{
-				ErrorMessage: "the cage is at capacity",
-			})
-		} else if errors.Is(err, models.IncompatibleCagePowerState) {
-			c.JSON(http.StatusConflict, models.ErrorResponse{
-				ErrorMessage: "the cage is unavailable at this time, because it does not have power",
-			})
-		} else if errors.Is(err, models.IncompatibleSpecies) {
-			c.JSON(http.StatusConflict, models.ErrorResponse{
-				ErrorMessage: "the cage already contains species of dinosaur that are incompatible with this dinosaur's specie",
-			})
+		api.respondWithCageAssignmentError(c, err, addDinosaurRequest.Name, targetCage)
+		return
+	}
+	api.events.Publish(events.DinosaurAssigned, models.CageAssignment{Dinosaur: addDinosaurRequest.Name, Cage: targetCage})
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "dinosaur added",
+	})
+}
+
+// respondWithCageAssignmentError writes the error response for a dinosaur that couldn't be put in the target cage,
+// either by adding it to the cage or by transferring it there.
+func (api *API) respondWithCageAssignmentError(c *gin.Context, err error, dinosaurName, targetCage string) {
+	if errors.Is(err, models.CageCapacityExceeded) {
+		respondWithError(c, err, "the cage is at capacity", api.cageContext(targetCage))
+	} else if errors.Is(err, models.IncompatibleCagePowerState) {
+		respondWithError(c, err, "the cage is unavailable at this time, because its power status does not allow new dinosaurs", api.cageContext(targetCage))
+	} else if errors.Is(err, models.DinosaurQuarantined) {
+		context := api.cageContext(targetCage)
+		context["dinosaur"] = dinosaurName
+		respondWithError(c, err, "quarantined dinosaurs can't share a cage, so they can only be put in empty cages and other dinosaurs can't join them", context)
+	} else if errors.Is(err, models.IncompatibleSpecies) {
+		respondWithError(c, err, "the cage already contains species of dinosaur that are incompatible with this dinosaur's specie", gin.H{
+			"cage":     targetCage,
+			"dinosaur": dinosaurName,
+		})
+	} else if errors.Is(err, models.BreedingPairNotAllowed) {
+		respondWithError(c, err, "the cage already contains a dinosaur of the same species and the opposite sex, and the breeding policy does not allow them to share a cage", gin.H{
+			"cage":     targetCage,
+			"dinosaur": dinosaurName,
+		})
+	} else if errors.Is(err, models.EntityNotFound) {
+		respondWithError(c, err, "could not find either the cage or dinosaur", gin.H{
+			"cage":     targetCage,
+			"dinosaur": dinosaurName,
+		})
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
+
+func (api *API) RemoveDinosaurFromCage(c *gin.Context) {
+	cageLabel := c.Param("cageLabel")
+	dinosaurName := c.Param("name")
+	err := api.parkManager.RemoveDinosaurFromCage(actorContext(c), dinosaurName, cageLabel)
+	if err != nil {
+		context := gin.H{
+			"cage":     cageLabel,
+			"dinosaur": dinosaurName,
+		}
+		if errors.Is(err, models.DinosaurNotInCage) {
+			respondWithError(c, err, fmt.Sprintf("the dinosaur %s is not in the cage %s", dinosaurName, cageLabel), context)
 		} else if errors.Is(err, models.EntityNotFound) {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				ErrorMessage: "could not find either the cage or dinosaur",
-			})
+			respondWithError(c, err, "could not find either the cage or dinosaur", context)
 		} else {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				ErrorMessage: "unexpected error",
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	api.events.Publish(events.DinosaurRemoved, models.CageAssignment{Dinosaur: dinosaurName, Cage: cageLabel})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dinosaur removed",
+	})
+}
+
+func (api *API) TransferDinosaur(c *gin.Context) {
+	var transferRequest models.TransferDinosaurRequest
+	if !decodeRequest(c, &transferRequest) || !validateRequest(c, transferRequest) {
+		return
+	}
+	dinosaurName := c.Param("name")
+	err := api.parkManager.TransferDinosaur(actorContext(c), dinosaurName, transferRequest.Cage)
+	if err != nil {
+		if errors.Is(err, models.DinosaurNotCaged) {
+			respondWithError(c, err, fmt.Sprintf("the dinosaur %s is not in a cage, add it to a cage instead", dinosaurName), gin.H{
+				"dinosaur": dinosaurName,
 			})
+		} else {
+			api.respondWithCageAssignmentError(c, err, dinosaurName, transferRequest.Cage)
 		}
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "dinosaur added",
+	api.events.Publish(events.DinosaurTransferred, models.CageAssignment{Dinosaur: dinosaurName, Cage: transferRequest.Cage})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dinosaur transferred",
 	})
 }
 
 func (api *API) AddDinosaur(c *gin.Context) {
 	var dinosaur models.Dinosaur
-	err := json.NewDecoder(c.Request.Body).Decode(&dinosaur)
-	if err != nil {
-		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
-			ErrorMessage: "Request body is in the incorrect format",
-		})
+	if !decodeRequest(c, &dinosaur) || !validateRequest(c, dinosaur) {
 		return
 	}
 
-	err = api.parkManager.AddDinosaur(dinosaur)
+	dinosaur.Sex = dinosaur.RequestedSex()
+	err := api.parkManager.AddDinosaur(actorContext(c), dinosaur)
 	if err != nil {
 		if errors.Is(err, models.InvalidDinosaurSpecies) {
-			c.JSON(http.StatusConflict, models.ErrorResponse{
-				ErrorMessage: fmt.Sprintf("The species %s is not a valid dinosaur species", dinosaur.Species)})
+			respondWithError(c, err, fmt.Sprintf("The species %s is not a valid dinosaur species", dinosaur.Species), gin.H{
+				"species": dinosaur.Species,
+			})
+		} else if errors.Is(err, models.InvalidDinosaurSex) {
+			respondWithError(c, err, fmt.Sprintf("%s is not a valid sex, it must be %s or %s", dinosaur.Sex, models.SexFemale, models.SexMale), gin.H{
+				"sex":     dinosaur.Sex,
+				"allowed": []models.Sex{models.SexFemale, models.SexMale},
+			})
 		} else if errors.Is(err, models.EntityAlreadyExists) {
-			c.JSON(http.StatusConflict, models.ErrorResponse{
-				ErrorMessage: fmt.Sprintf("There is already a dinosaur with the name %s", dinosaur.Name),
+			respondWithError(c, err, fmt.Sprintf("There is already a dinosaur with the name %s", dinosaur.Name), gin.H{
+				"dinosaur": dinosaur.Name,
 			})
+		} else {
+			respondWithError(c, err, "", nil)
 		}
 		return
 	}
+	api.events.Publish(events.DinosaurAdded, dinosaur)
 	c.JSON(http.StatusCreated, dinosaur)
 }
 
@@ -232,6 +538,10 @@ func (api *API) GetDinosaurs(c *gin.Context) {
 		species := c.Query("species")
 		filter.Species = &species
 	}
+	if c.Query("sex") != "" {
+		sex := models.Sex(c.Query("sex"))
+		filter.Sex = &sex
+	}
 	if c.Query("diet") != "" {
 		diet := c.Query("diet")
 		filter.Diet = &diet
@@ -240,14 +550,27 @@ func (api *API) GetDinosaurs(c *gin.Context) {
 		needsCageAssignment := c.Query("needsCageAssignment") == "true"
 		filter.NeedsCageAssignment = &needsCageAssignment
 	}
+	if c.Query("quarantined") != "" {
+		quarantined := c.Query("quarantined") == "true"
+		filter.Quarantined = &quarantined
+	}
+
+	page, ok := parsePagination(c, models.DinosaurSortFields)
+	if !ok {
+		return
+	}
+	filter.Page = page
 
-	dinosaurs, err := api.parkManager.GetDinosaurs(filter)
+	dinosaurs, pageInfo, err := api.parkManager.GetDinosaurs(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			ErrorMessage: "unexpected error",
-		})
+		if errors.Is(err, models.InvalidCursor) {
+			respondWithInvalidCursor(c)
+		} else {
+			respondWithError(c, err, "", nil)
+		}
 		return
 	}
+	setPageHeaders(c, pageInfo)
 	c.JSON(http.StatusOK, dinosaurs)
 }
 
@@ -255,16 +578,70 @@ func (api *API) GetDinosaur(c *gin.Context) {
 	dinosaurName := c.Param("name")
 	dinosaur, err := api.parkManager.GetDinosaur(dinosaurName)
 	if err != nil {
-		if errors.Is(err, models.EntityNotFound) {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				ErrorMessage: fmt.Sprintf("dinosaur with name %s not found", dinosaurName),
+		respondWithDinosaurNotFound(c, err, dinosaurName)
+		return
+	}
+	c.JSON(http.StatusOK, dinosaur)
+}
+
+func (api *API) UpdateDinosaur(c *gin.Context) {
+	dinosaurName := c.Param("name")
+	var update models.UpdateDinosaurRequest
+	if !decodeRequest(c, &update) {
+		return
+	}
+	if update.Sex == nil {
+		respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidRequestBody,
+			"Request body must contain sex", nil)
+		return
+	}
+	dinosaur, err := api.parkManager.UpdateDinosaur(actorContext(c), dinosaurName, update)
+	if err != nil {
+		if errors.Is(err, models.InvalidDinosaurSex) {
+			respondWithError(c, err, fmt.Sprintf("%s is not a valid sex, it must be %s or %s", *update.Sex, models.SexFemale, models.SexMale), gin.H{
+				"sex":     *update.Sex,
+				"allowed": []models.Sex{models.SexFemale, models.SexMale},
 			})
+		} else if errors.Is(err, models.BreedingPairNotAllowed) {
+			context := gin.H{
+				"dinosaur": dinosaurName,
+				"sex":      *update.Sex,
+			}
+			// the cage is looked up on a best effort basis, as in cageContext
+			if current, err := api.parkManager.GetDinosaur(dinosaurName); err == nil && current.Cage != nil {
+				context["cage"] = *current.Cage
+			}
+			respondWithError(c, err, "the dinosaur's cage contains a dinosaur of the same species and the opposite sex, and the breeding policy does not allow them to share a cage", context)
 		} else {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				ErrorMessage: "unexpected error",
-			})
+			respondWithDinosaurNotFound(c, err, dinosaurName)
 		}
 		return
 	}
+	api.events.Publish(events.DinosaurUpdated, dinosaur)
 	c.JSON(http.StatusOK, dinosaur)
 }
+
+// respondWithDinosaurNotFound writes the error response for a dinosaur that couldn't be read.
+func respondWithDinosaurNotFound(c *gin.Context, err error, dinosaurName string) {
+	if errors.Is(err, models.EntityNotFound) {
+		respondWithError(c, err, fmt.Sprintf("dinosaur with name %s not found", dinosaurName), gin.H{
+			"dinosaur": dinosaurName,
+		})
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
+
+// cageContext describes a cage for an error response about it. The cage is looked up on a best effort basis, as
+// the error may have been caused by the cage changing, so only the label is given when it can't be found.
+func (api *API) cageContext(cageLabel string) gin.H {
+	context := gin.H{
+		"cage": cageLabel,
+	}
+	if cage, err := api.parkManager.GetCage(cageLabel); err == nil {
+		context["occupancy"] = cage.Occupancy
+		context["maxOccupancy"] = cage.MaxOccupancy
+		context["powerStatus"] = cage.RequestedPowerStatus()
+	}
+	return context
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKey issues a key with a name and roles. The key is only returned in the response to this request.
+func (api *API) CreateAPIKey(c *gin.Context) {
+	var apiKey models.APIKey
+	if !decodeRequest(c, &apiKey) || !validateRequest(c, apiKey) {
+		return
+	}
+	if err := auth.Issue(&apiKey); err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+
+	created, err := api.parkManager.AddAPIKey(actorContext(c), apiKey)
+	if err != nil {
+		if errors.Is(err, models.EntityAlreadyExists) {
+			respondWithError(c, err, fmt.Sprintf("There is already an API key with the name %s", apiKey.Name), gin.H{
+				"apiKey": apiKey.Name,
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	created.Key = apiKey.Key
+	c.JSON(http.StatusCreated, created)
+}
+
+// GetAPIKeys lists every API key, including the revoked ones, without the keys themselves.
+func (api *API) GetAPIKeys(c *gin.Context) {
+	apiKeys, err := api.parkManager.GetAPIKeys()
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+// RevokeAPIKey stops an API key from authenticating. The key is kept, so that it can still be looked up.
+func (api *API) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err == nil {
+		err = api.parkManager.RevokeAPIKey(actorContext(c), id)
+	} else {
+		// ids that aren't numbers can't match a key
+		err = models.EntityNotFound
+	}
+	if err != nil {
+		if errors.Is(err, models.EntityNotFound) {
+			respondWithError(c, err, fmt.Sprintf("API key with id %s not found", c.Param("id")), gin.H{
+				"apiKey": c.Param("id"),
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked",
+	})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// actorContext returns the context of the request, carrying the actor that the changes it makes are recorded
+// against, which is the principal the request was authenticated as.
+func actorContext(c *gin.Context) context.Context {
+	return models.WithActor(c.Request.Context(), principalOf(c).Name)
+}
+
+// GetAuditEvents lists the changes made to the park, oldest first, optionally only those to one entity or those
+// made since a time.
+func (api *API) GetAuditEvents(c *gin.Context) {
+	filter := models.AuditFilter{}
+	if c.Query("entity") != "" {
+		entity := c.Query("entity")
+		if !models.IsValidAuditEntity(entity) {
+			respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidParameter,
+				"entity must be a type of entity, such as cage, dinosaur or feed, followed by a colon and its key, as in cage:C-1", gin.H{
+					"parameter": "entity",
+				})
+			return
+		}
+		filter.Entity = &entity
+	}
+	if c.Query("since") != "" {
+		since, err := time.Parse(time.RFC3339, c.Query("since"))
+		if err != nil {
+			respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidParameter,
+				"since must be an RFC 3339 time, as in 2023-06-01T12:00:00Z", gin.H{
+					"parameter": "since",
+				})
+			return
+		}
+		since = since.UTC()
+		filter.Since = &since
+	}
+	page, ok := parsePagination(c, []string{})
+	if !ok {
+		return
+	}
+	filter.Page = page
+
+	auditEvents, pageInfo, err := api.parkManager.GetAuditEvents(filter)
+	if err != nil {
+		if errors.Is(err, models.InvalidCursor) {
+			respondWithInvalidCursor(c)
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, auditEvents)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// principalKey is where the principal of a request is kept in its gin context.
+	principalKey = "principal"
+	// actorHeader names who is making the request, for the audit log, when the API doesn't authenticate requests.
+	actorHeader = "X-Actor"
+	// anonymousActor is recorded for unauthenticated requests that don't name an actor.
+	anonymousActor = "anonymous"
+)
+
+// WithAuthenticators requires every request to be authenticated by one of the authenticators, and to hold the role
+// that its route needs. Without it every request is allowed, and is recorded against the actor in its X-Actor
+// header.
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(api *API) {
+		api.authenticators = append(api.authenticators, authenticators...)
+	}
+}
+
+// authorize is the middleware of a route that needs one of the roles. It authenticates the request, responding
+// with 401 if it can't be, and 403 if its principal holds none of the roles.
+func (api *API) authorize(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := api.authenticate(c.Request)
+		if err != nil {
+			var credentialsErr *models.CredentialsError
+			if errors.Is(err, models.MissingCredentials) {
+				respondWithError(c, err, fmt.Sprintf("the request must send an API key in the %s header or a bearer token in the Authorization header", auth.APIKeyHeader), nil)
+			} else if errors.As(err, &credentialsErr) {
+				respondWithError(c, err, credentialsErr.Reason, nil)
+			} else {
+				respondWithError(c, err, "", nil)
+			}
+			return
+		}
+		c.Set(principalKey, *principal)
+		for _, role := range roles {
+			if principal.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+		respondWithForbidden(c, roles...)
+	}
+}
+
+// authenticate works out the principal of a request with the first authenticator that finds credentials in it.
+// When the API has no authenticators, requests are made by the actor they name and are allowed to do anything.
+func (api *API) authenticate(r *http.Request) (*models.Principal, error) {
+	if len(api.authenticators) == 0 {
+		actor := r.Header.Get(actorHeader)
+		if actor == "" {
+			actor = anonymousActor
+		}
+		return &models.Principal{Name: actor, Roles: []models.Role{models.RoleAdmin}}, nil
+	}
+	for _, authenticator := range api.authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, models.MissingCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, models.MissingCredentials
+}
+
+// requireRole checks that the principal of the request holds the role, for requests that need more than their
+// route does. If it doesn't, the forbidden response is written and false is returned.
+func requireRole(c *gin.Context, role models.Role) bool {
+	if principalOf(c).HasRole(role) {
+		return true
+	}
+	respondWithForbidden(c, role)
+	return false
+}
+
+// authorizeCageUpdate checks that the principal can make the update. Changing a cage's power needs the
+// power-operator role, and changing anything else about it needs the keeper role.
+func authorizeCageUpdate(c *gin.Context, update models.UpdateCageRequest) bool {
+	if update.RequestedPowerStatus() != nil && !requireRole(c, models.RolePowerOperator) {
+		return false
+	}
+	if (update.Label != nil || update.MaxOccupancy != nil || update.Zone != nil) && !requireRole(c, models.RoleKeeper) {
+		return false
+	}
+	return true
+}
+
+// principalOf returns the principal that the authorize middleware attached to the request.
+func principalOf(c *gin.Context) models.Principal {
+	principal, _ := c.Get(principalKey)
+	p, _ := principal.(models.Principal)
+	return p
+}
+
+func respondWithForbidden(c *gin.Context, roles ...models.Role) {
+	names := []string{}
+	for _, role := range roles {
+		names = append(names, string(role))
+	}
+	respondWithProblem(c, http.StatusForbidden, models.CodeForbidden,
+		fmt.Sprintf("%s does not have the %s role", principalOf(c).Name, strings.Join(names, " or ")), gin.H{
+			"principal": principalOf(c).Name,
+			"roles":     roles,
+		})
+}
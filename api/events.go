@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// keepAliveInterval is how often a comment is sent on an idle event stream, so that proxies don't close it.
+const keepAliveInterval = 15 * time.Second
+
+// StreamEvents streams changes to the park as Server-Sent Events. A client that reconnects with the Last-Event-ID
+// header is first sent the events it missed, as long as they are still in the replay buffer.
+func (api *API) StreamEvents(c *gin.Context) {
+	var lastEventID *uint64
+	if c.GetHeader("Last-Event-ID") != "" {
+		id, err := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+		if err != nil {
+			respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidParameter, "Last-Event-ID must be the id of an event", gin.H{
+				"header": "Last-Event-ID",
+			})
+			return
+		}
+		lastEventID = &id
+	}
+	replay, stream, unsubscribe := api.events.Subscribe(lastEventID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	for _, event := range replay {
+		renderEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				// the client fell too far behind, and has to reconnect to resume from the replay buffer
+				return
+			}
+			renderEvent(c, event)
+		case <-keepAlive.C:
+			c.Writer.WriteString(": keep-alive\n\n")
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func renderEvent(c *gin.Context, event events.Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: event.Type,
+		Data:  event.Data,
+	})
+}
+
+// publishCageCreated publishes a new cage. Cages are published in their v2 representation for both API versions,
+// because it carries the full power status.
+func (api *API) publishCageCreated(cage models.Cage) {
+	created := models.NewCageV2(cage)
+	created.PowerStatus = cage.RequestedPowerStatus()
+	api.events.Publish(events.CageCreated, created)
+}
+
+// publishCageUpdated publishes an updated cage, as a power change when the update changed its power.
+func (api *API) publishCageUpdated(cage models.Cage, update models.UpdateCageRequest) {
+	eventType := events.CageUpdated
+	if update.RequestedPowerStatus() != nil {
+		eventType = events.CagePowerChanged
+	}
+	api.events.Publish(eventType, models.NewCageV2(cage))
+}
+
+// publishPowerCutRefused publishes a refused request to cut the power to an occupied cage, which security is paged
+// about.
+func (api *API) publishPowerCutRefused(cageLabel string, update models.UpdateCageRequest) {
+	refused := models.RefusedPowerCut{
+		Label:                cageLabel,
+		RequestedPowerStatus: *update.RequestedPowerStatus(),
+	}
+	if cage, err := api.parkManager.GetCage(cageLabel); err == nil {
+		refused.Occupancy = cage.Occupancy
+	}
+	api.events.Publish(events.CagePowerCutRefused, refused)
+}
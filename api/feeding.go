@@ -0,0 +1,267 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/feeding"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) CreateFeed(c *gin.Context) {
+	var feed models.Feed
+	if !decodeRequest(c, &feed) || !validateRequest(c, feed) {
+		return
+	}
+	err := api.parkManager.AddFeed(actorContext(c), feed)
+	if err != nil {
+		if errors.Is(err, models.InvalidSpeciesDiet) {
+			respondWithError(c, err, fmt.Sprintf("%s is not a valid diet", feed.Diet), gin.H{
+				"diet": feed.Diet,
+			})
+		} else if errors.Is(err, models.EntityAlreadyExists) {
+			respondWithError(c, err, fmt.Sprintf("There is already a feed with the name %s", feed.Name), gin.H{
+				"feed": feed.Name,
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, feed)
+}
+
+// GetFeeds lists the feeds and their stock. lowStock=true lists only the feeds that have to be restocked.
+func (api *API) GetFeeds(c *gin.Context) {
+	filter := models.FeedFilter{}
+	if c.Query("lowStock") != "" {
+		lowStock := c.Query("lowStock") == "true"
+		filter.LowStock = &lowStock
+	}
+	feeds, err := api.parkManager.GetFeeds(filter)
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	c.JSON(http.StatusOK, feeds)
+}
+
+func (api *API) RestockFeed(c *gin.Context) {
+	var restockRequest models.RestockRequest
+	if !decodeRequest(c, &restockRequest) || !validateRequest(c, restockRequest) {
+		return
+	}
+	name := c.Param("name")
+	feed, err := api.parkManager.RestockFeed(actorContext(c), name, restockRequest.Quantity)
+	if err != nil {
+		if errors.Is(err, models.EntityNotFound) {
+			respondWithError(c, err, fmt.Sprintf("feed with name %s not found", name), gin.H{
+				"feed": name,
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, feed)
+}
+
+func (api *API) CreateFeedingSchedule(c *gin.Context) {
+	var schedule models.FeedingSchedule
+	if !decodeRequest(c, &schedule) || !validateRequest(c, schedule) {
+		return
+	}
+	created, err := api.parkManager.AddFeedingSchedule(actorContext(c), schedule)
+	if err != nil {
+		if errors.Is(err, models.EntityNotFound) {
+			respondWithError(c, err, "could not find either the cage or feed", gin.H{
+				"cage": schedule.Cage,
+				"feed": schedule.Feed,
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (api *API) GetFeedingSchedules(c *gin.Context) {
+	filter := models.FeedingScheduleFilter{}
+	if c.Query("cage") != "" {
+		cage := c.Query("cage")
+		filter.Cage = &cage
+	}
+	schedules, err := api.parkManager.GetFeedingSchedules(filter)
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (api *API) DeleteFeedingSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err == nil {
+		err = api.parkManager.DeleteFeedingSchedule(actorContext(c), id)
+	} else {
+		// ids that aren't numbers can't match a schedule
+		err = models.EntityNotFound
+	}
+	if err != nil {
+		if errors.Is(err, models.EntityNotFound) {
+			respondWithError(c, err, fmt.Sprintf("feeding schedule with id %s not found", c.Param("id")), gin.H{
+				"schedule": c.Param("id"),
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "feeding schedule deleted",
+	})
+}
+
+// GetFeedingRoster lists the feedings due on a day, by default today, and the dinosaurs they feed.
+func (api *API) GetFeedingRoster(c *gin.Context) {
+	date, ok := parseFeedingDate(c)
+	if !ok {
+		return
+	}
+	filter := models.FeedingScheduleFilter{}
+	if c.Query("cage") != "" {
+		cage := c.Query("cage")
+		filter.Cage = &cage
+	}
+	roster, err := feeding.Roster(api.parkManager, date, filter)
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	c.JSON(http.StatusOK, roster)
+}
+
+// RecordFeeding records that a scheduled feeding was made, feeding the dinosaurs that are in the cage now, and
+// takes the feed out of stock.
+func (api *API) RecordFeeding(c *gin.Context) {
+	var recordRequest models.RecordFeedingRequest
+	if !decodeRequest(c, &recordRequest) {
+		return
+	}
+	date := feeding.Today()
+	if recordRequest.Date != "" {
+		var err error
+		date, err = feeding.ParseDate(recordRequest.Date)
+		if err != nil {
+			respondWithInvalidFeedingDate(c)
+			return
+		}
+	}
+
+	// the context of an error response grows as more is known about the feeding
+	context := gin.H{
+		"schedule": recordRequest.ScheduleID,
+	}
+	schedule, err := api.parkManager.GetFeedingSchedule(recordRequest.ScheduleID)
+	if err != nil {
+		respondWithRecordFeedingError(c, err, recordRequest, context)
+		return
+	}
+	context["cage"] = schedule.Cage
+	context["feed"] = schedule.Feed
+	entry, err := feeding.Entry(api.parkManager, *schedule)
+	if err == nil && entry.Quantity == 0 {
+		err = models.NoDinosaursToFeed
+	}
+	if err != nil {
+		respondWithRecordFeedingError(c, err, recordRequest, context)
+		return
+	}
+	context["quantity"] = entry.Quantity
+	recorded, feed, err := api.parkManager.RecordFeeding(actorContext(c), models.Feeding{
+		ScheduleID: schedule.ID,
+		Cage:       schedule.Cage,
+		Feed:       schedule.Feed,
+		Quantity:   entry.Quantity,
+		Date:       date,
+	})
+	if err != nil {
+		if errors.Is(err, models.InsufficientFeedStock) {
+			if feed, err := api.parkManager.GetFeed(schedule.Feed); err == nil {
+				context["stock"] = feed.Stock
+			}
+		}
+		respondWithRecordFeedingError(c, err, recordRequest, context)
+		return
+	}
+
+	api.events.Publish(events.FeedingRecorded, recorded)
+	// alert once, when the feeding takes the feed down to its threshold
+	if feed.IsLowOnStock() && feed.Stock+recorded.Quantity > feed.LowStockThreshold {
+		api.events.Publish(events.FeedLowStock, models.LowFeedStock{
+			Feed:              feed.Name,
+			Unit:              feed.Unit,
+			Stock:             feed.Stock,
+			LowStockThreshold: feed.LowStockThreshold,
+		})
+	}
+	c.JSON(http.StatusCreated, recorded)
+}
+
+// respondWithRecordFeedingError writes the error response for a feeding that couldn't be recorded, with what is known
+// about the feeding as its context.
+func respondWithRecordFeedingError(c *gin.Context, err error, recordRequest models.RecordFeedingRequest, context gin.H) {
+	if errors.Is(err, models.NoDinosaursToFeed) {
+		respondWithError(c, err, "the cage holds no dinosaurs that eat the scheduled feed", context)
+	} else if errors.Is(err, models.InsufficientFeedStock) {
+		respondWithError(c, err, "there is not enough of the feed in stock, it must be restocked first", context)
+	} else if errors.Is(err, models.EntityAlreadyExists) {
+		respondWithError(c, err, fmt.Sprintf("the feeding schedule %d was already fed on that date", recordRequest.ScheduleID), context)
+	} else if errors.Is(err, models.EntityNotFound) {
+		respondWithError(c, err, fmt.Sprintf("feeding schedule with id %d not found", recordRequest.ScheduleID), context)
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
+
+// GetFeedings lists the feedings that were made on a day, by default today.
+func (api *API) GetFeedings(c *gin.Context) {
+	date, ok := parseFeedingDate(c)
+	if !ok {
+		return
+	}
+	filter := models.FeedingFilter{Date: &date}
+	if c.Query("cage") != "" {
+		cage := c.Query("cage")
+		filter.Cage = &cage
+	}
+	feedings, err := api.parkManager.GetFeedings(filter)
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	c.JSON(http.StatusOK, feedings)
+}
+
+// parseFeedingDate reads the date query parameter, which defaults to today. It writes the error response and
+// returns false if the date is invalid.
+func parseFeedingDate(c *gin.Context) (string, bool) {
+	if c.Query("date") == "" {
+		return feeding.Today(), true
+	}
+	date, err := feeding.ParseDate(c.Query("date"))
+	if err != nil {
+		respondWithInvalidFeedingDate(c)
+		return "", false
+	}
+	return date, true
+}
+
+func respondWithInvalidFeedingDate(c *gin.Context) {
+	respondWithError(c, models.InvalidFeedingDate, "date must be a date such as 2023-06-01, and can't be in the future", nil)
+}
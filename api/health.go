@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateHealthRecord adds a record to a dinosaur's medical history. Quarantine and release records put the dinosaur
+// in and out of quarantine.
+func (api *API) CreateHealthRecord(c *gin.Context) {
+	record, ok := decodeHealthRecord(c)
+	if !ok {
+		return
+	}
+	created, err := api.parkManager.AddHealthRecord(actorContext(c), record)
+	if err != nil {
+		respondWithHealthRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// GetHealthRecords reads a dinosaur's medical history, oldest first. type can be used to read only one type of
+// record, such as weight.
+func (api *API) GetHealthRecords(c *gin.Context) {
+	filter := models.HealthRecordFilter{}
+	if c.Query("type") != "" {
+		recordType := models.HealthRecordType(c.Query("type"))
+		filter.Type = &recordType
+	}
+	records, err := api.parkManager.GetHealthRecords(c.Param("name"), filter)
+	if err != nil {
+		respondWithHealthRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+func (api *API) GetHealthRecord(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		// ids that aren't numbers can't match a record
+		respondWithHealthRecordError(c, models.EntityNotFound)
+		return
+	}
+	record, err := api.parkManager.GetHealthRecord(c.Param("name"), id)
+	if err != nil {
+		respondWithHealthRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// UpdateHealthRecord replaces the type, time and details of a health record. Who recorded it is kept.
+func (api *API) UpdateHealthRecord(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithHealthRecordError(c, models.EntityNotFound)
+		return
+	}
+	record, ok := decodeHealthRecord(c)
+	if !ok {
+		return
+	}
+	record.ID = id
+	updated, err := api.parkManager.UpdateHealthRecord(actorContext(c), record)
+	if err != nil {
+		respondWithHealthRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func (api *API) DeleteHealthRecord(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err == nil {
+		err = api.parkManager.DeleteHealthRecord(actorContext(c), c.Param("name"), id)
+	} else {
+		err = models.EntityNotFound
+	}
+	if err != nil {
+		respondWithHealthRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "health record deleted",
+	})
+}
+
+// decodeHealthRecord reads and validates the health record in the request body, for the dinosaur in the path. The
+// time defaults to now. It writes the error response and returns false if the record is invalid.
+func decodeHealthRecord(c *gin.Context) (models.HealthRecord, bool) {
+	var record models.HealthRecord
+	if !decodeRequest(c, &record) {
+		return models.HealthRecord{}, false
+	}
+	record.Dinosaur = c.Param("name")
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	if !validateRequest(c, record) {
+		return models.HealthRecord{}, false
+	}
+	return record, true
+}
+
+func respondWithHealthRecordError(c *gin.Context, err error) {
+	if errors.Is(err, models.EntityNotFound) {
+		context := gin.H{
+			"dinosaur": c.Param("name"),
+		}
+		if c.Param("id") != "" {
+			context["healthRecord"] = c.Param("id")
+		}
+		respondWithError(c, err, fmt.Sprintf("could not find either the dinosaur %s or the health record", c.Param("name")), context)
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
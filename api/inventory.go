@@ -0,0 +1,194 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/inventory"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize is the largest import file, in bytes.
+const maxImportSize = 16 << 20
+
+// inventoryContentTypes maps the content types of import and export files to their formats.
+var inventoryContentTypes = map[string]models.InventoryFormat{
+	"text/csv":             models.InventoryFormatCSV,
+	"application/x-ndjson": models.InventoryFormatNDJSON,
+	"application/ndjson":   models.InventoryFormatNDJSON,
+}
+
+// ImportInventory adds the cages, dinosaurs and cage assignments in a CSV or NDJSON file, in the order they are in
+// the file. Every row is held to the same rules as the endpoint that adds it, and the outcome of each row is
+// reported. In atomic mode, the default, nothing is kept unless every row succeeds, and in per-row mode the rows
+// that succeed are kept. A dry run reports what would happen and keeps nothing.
+func (api *API) ImportInventory(c *gin.Context) {
+	format, ok := parseInventoryFormat(c, c.ContentType())
+	if !ok {
+		return
+	}
+	options := models.ImportOptions{Mode: models.ImportModeAtomic, DryRun: c.Query("dryRun") == "true"}
+	if c.Query("mode") != "" {
+		options.Mode = models.ImportMode(c.Query("mode"))
+		if options.Mode != models.ImportModeAtomic && options.Mode != models.ImportModePerRow {
+			respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidParameter,
+				fmt.Sprintf("mode must be %s or %s", models.ImportModeAtomic, models.ImportModePerRow), gin.H{
+					"parameter": "mode",
+					"allowed":   models.ImportModes,
+				})
+			return
+		}
+	}
+
+	rows, err := inventory.Read(format, http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+	if err != nil {
+		respondWithImportFileError(c, err, format)
+		return
+	}
+	report, err := inventory.Import(actorContext(c), api.parkManager, rows, options)
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	report.Format = format
+	for i := range report.Rows {
+		describeImportFailure(&report.Rows[i])
+	}
+	if report.Committed {
+		api.publishImported(*report)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// parseInventoryFormat reads the format of an import or export file from the format query parameter, falling back
+// to the content type given. If the format isn't known the error response is written and false is returned.
+func parseInventoryFormat(c *gin.Context, contentType string) (models.InventoryFormat, bool) {
+	format := models.InventoryFormat(c.Query("format"))
+	if format == "" {
+		format = inventoryContentTypes[contentType]
+	}
+	if format != models.InventoryFormatCSV && format != models.InventoryFormatNDJSON {
+		respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidParameter,
+			fmt.Sprintf("format must be %s or %s, or be given by a Content-Type of text/csv or application/x-ndjson", models.InventoryFormatCSV, models.InventoryFormatNDJSON), gin.H{
+				"parameter": "format",
+				"allowed":   models.InventoryFormats,
+			})
+		return "", false
+	}
+	return format, true
+}
+
+func respondWithImportFileError(c *gin.Context, err error, format models.InventoryFormat) {
+	var fileErr *models.ImportFileError
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &fileErr) {
+		respondWithError(c, err, fmt.Sprintf("the file is not valid %s at line %d: %s", format, fileErr.Line, fileErr.Reason), gin.H{
+			"format": format,
+			"line":   fileErr.Line,
+		})
+	} else if errors.As(err, &maxBytesErr) {
+		respondWithProblem(c, http.StatusRequestEntityTooLarge, models.CodeImportFileTooLarge,
+			fmt.Sprintf("the file is larger than %d bytes", maxImportSize), gin.H{
+				"maxBytes": maxImportSize,
+			})
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
+
+// describeImportFailure explains why a row failed, with the code and detail that the endpoint that adds it would
+// have responded with.
+func describeImportFailure(result *models.ImportRowResult) {
+	if result.Err == nil {
+		return
+	}
+	_, code, ok := problemFor(result.Err)
+	if !ok {
+		result.Code = models.CodeInternalError
+		result.Detail = "unexpected error"
+		return
+	}
+	result.Code = code
+	result.Violations, result.Fields = problemDetails(result.Err)
+
+	row := result.Row
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(result.Err, &validationErr):
+		result.Detail = "the row has invalid fields: " + fieldReasons(validationErr)
+	case errors.Is(result.Err, models.EntityAlreadyExists) && row.Kind == models.InventoryKindZone:
+		result.Detail = fmt.Sprintf("There is already a zone with the name %s", row.Name)
+	case errors.Is(result.Err, models.InvalidZoneParent):
+		result.Detail = fmt.Sprintf("the zone can't be put in %s, which must be another zone that isn't inside it", *row.Parent)
+	case errors.Is(result.Err, models.EntityAlreadyExists) && row.Kind == models.InventoryKindCage:
+		result.Detail = fmt.Sprintf("There is already a cage with the label %s", row.Label)
+	case errors.Is(result.Err, models.EntityAlreadyExists) && row.Kind == models.InventoryKindDinosaur:
+		result.Detail = fmt.Sprintf("There is already a dinosaur with the name %s", row.Name)
+	case errors.Is(result.Err, models.InvalidDinosaurSpecies):
+		result.Detail = fmt.Sprintf("The species %s is not a valid dinosaur species", row.Species)
+	case errors.Is(result.Err, models.InvalidDinosaurSex):
+		result.Detail = fmt.Sprintf("%s is not a valid sex, it must be %s or %s", row.Sex, models.SexFemale, models.SexMale)
+	case errors.Is(result.Err, models.InvalidPowerStatus):
+		result.Detail = fmt.Sprintf("%s is not a valid power status", row.PowerStatus)
+	case errors.Is(result.Err, models.InvalidCageZone):
+		result.Detail = fmt.Sprintf("zone with name %s not found", *row.Zone)
+	case errors.Is(result.Err, models.EntityNotFound):
+		result.Detail = fmt.Sprintf("could not find either the cage %s or the dinosaur %s", row.Cage, row.Dinosaur)
+	default:
+		result.Detail = result.Err.Error()
+	}
+}
+
+// publishImported publishes the cages, dinosaurs and cage assignments that an import added. Zones have no events.
+func (api *API) publishImported(report models.ImportReport) {
+	for _, result := range report.Rows {
+		if result.Status != models.ImportStatusApplied {
+			continue
+		}
+		switch result.Row.Kind {
+		case models.InventoryKindCage:
+			api.publishCageCreated(result.Row.CageV2().Cage())
+		case models.InventoryKindDinosaur:
+			dinosaur := result.Row.DinosaurModel()
+			dinosaur.Sex = dinosaur.RequestedSex()
+			api.events.Publish(events.DinosaurAdded, dinosaur)
+		case models.InventoryKindAssignment:
+			api.events.Publish(events.DinosaurAssigned, result.Row.Assignment())
+		}
+	}
+}
+
+// ExportInventory streams every cage, dinosaur and cage assignment as a CSV or NDJSON file that can be imported.
+// The format is given by the format query parameter or the Accept header. The file is written a page at a time,
+// so it isn't a snapshot of a single moment if the park is changed while it is being exported.
+func (api *API) ExportInventory(c *gin.Context) {
+	accept, _, _ := mime.ParseMediaType(c.GetHeader("Accept"))
+	format, ok := parseInventoryFormat(c, accept)
+	if !ok {
+		return
+	}
+	contentType := "text/csv"
+	if format == models.InventoryFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="jurassic-park-inventory.%s"`, format))
+	c.Status(http.StatusOK)
+
+	w, err := inventory.NewWriter(format, c.Writer)
+	if err == nil {
+		err = inventory.Export(api.parkManager, w)
+	}
+	if err != nil && !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		respondWithError(c, err, "", nil)
+	} else if err != nil {
+		// the status has already been sent, so the export can only be cut short
+		c.Error(err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+const maxPageLimit = 1000
+
+// parsePagination reads the limit, sort, cursor and includeTotal query parameters of a list endpoint, where
+// sortFields are the fields the list can be sorted on. If the parameters are invalid the error response is
+// written and false is returned.
+func parsePagination(c *gin.Context, sortFields []string) (models.Pagination, bool) {
+	page := models.Pagination{}
+	if c.Query("limit") != "" {
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidParameter,
+				fmt.Sprintf("limit must be a number between 1 and %d", maxPageLimit), gin.H{
+					"parameter": "limit",
+					"min":       1,
+					"max":       maxPageLimit,
+				})
+			return page, false
+		}
+		page.Limit = limit
+	}
+	if c.Query("sort") != "" {
+		sort, err := models.ParseSort(c.Query("sort"), sortFields)
+		if err != nil {
+			respondWithError(c, err, fmt.Sprintf("sort must be one of %s, with a leading - to sort in descending order", strings.Join(sortFields, ", ")), gin.H{
+				"parameter": "sort",
+				"allowed":   sortFields,
+			})
+			return page, false
+		}
+		page.Sort = sort
+	}
+	if c.Query("cursor") != "" {
+		cursor, err := models.DecodeCursor(c.Query("cursor"))
+		if err != nil {
+			respondWithInvalidCursor(c)
+			return page, false
+		}
+		page.After = cursor
+	}
+	page.IncludeTotal = c.Query("includeTotal") == "true"
+	return page, true
+}
+
+func respondWithInvalidCursor(c *gin.Context) {
+	respondWithError(c, models.InvalidCursor, "cursor is not valid for this list and sort", gin.H{
+		"parameter": "cursor",
+	})
+}
+
+// setPageHeaders describes the page in the response headers. When there is another page its cursor is set in
+// X-Next-Cursor, along with a Link to it, and when the total was asked for it is set in X-Total-Count.
+func setPageHeaders(c *gin.Context, pageInfo models.PageInfo) {
+	if pageInfo.Next != nil {
+		cursor := pageInfo.Next.Encode()
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("cursor", cursor)
+		next.RawQuery = query.Encode()
+		c.Header("X-Next-Cursor", cursor)
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	}
+	if pageInfo.Total != nil {
+		c.Header("X-Total-Count", strconv.Itoa(*pageInfo.Total))
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// errorProblems maps the errors in models to the status and code they are reported with. Every handler reports
+// errors through respondWithError, so an error is reported the same way by every endpoint. Clients map the codes
+// back to the errors with models.ErrorForCode, so an error added here is added there too.
+var errorProblems = []struct {
+	err    error
+	status int
+	code   models.ErrorCode
+}{
+	{models.EntityNotFound, http.StatusNotFound, models.CodeNotFound},
+	{models.EntityAlreadyExists, http.StatusConflict, models.CodeAlreadyExists},
+	{models.EntityInUse, http.StatusConflict, models.CodeEntityInUse},
+	{models.InvalidCursor, http.StatusUnprocessableEntity, models.CodeInvalidCursor},
+	{models.InvalidSort, http.StatusUnprocessableEntity, models.CodeInvalidSort},
+	{models.InvalidRequest, http.StatusUnprocessableEntity, models.CodeValidationFailed},
+	{models.MissingCredentials, http.StatusUnauthorized, models.CodeMissingCredentials},
+	{models.InvalidCredentials, http.StatusUnauthorized, models.CodeInvalidCredentials},
+	// adding a dinosaur of an unknown species has always been reported as a conflict with the species list
+	{models.InvalidDinosaurSpecies, http.StatusConflict, models.CodeInvalidDinosaurSpecies},
+	{models.InvalidDinosaurSex, http.StatusUnprocessableEntity, models.CodeInvalidDinosaurSex},
+	{models.InvalidSpeciesDiet, http.StatusUnprocessableEntity, models.CodeInvalidSpeciesDiet},
+	{models.CageCapacityExceeded, http.StatusConflict, models.CodeCageCapacityExceeded},
+	{models.CageCapacityBelowOccupancy, http.StatusConflict, models.CodeCageCapacityBelowOccupancy},
+	{models.CageNotEmpty, http.StatusConflict, models.CodeCageNotEmpty},
+	{models.IncompatibleSpecies, http.StatusConflict, models.CodeIncompatibleSpecies},
+	{models.BreedingPairNotAllowed, http.StatusConflict, models.CodeBreedingPairNotAllowed},
+	{models.DinosaurNotInCage, http.StatusNotFound, models.CodeDinosaurNotInCage},
+	// a dinosaur with no cage to transfer from is a conflict with its state, rather than a missing resource
+	{models.DinosaurNotCaged, http.StatusConflict, models.CodeDinosaurNotCaged},
+	{models.DinosaurQuarantined, http.StatusConflict, models.CodeDinosaurQuarantined},
+	{models.IncompatibleCagePowerState, http.StatusConflict, models.CodeIncompatibleCagePowerState},
+	{models.InvalidPowerStatus, http.StatusUnprocessableEntity, models.CodeInvalidPowerStatus},
+	{models.InvalidPowerStatusTransition, http.StatusConflict, models.CodeInvalidPowerStatusTransition},
+	{models.InvalidFeedingDate, http.StatusUnprocessableEntity, models.CodeInvalidFeedingDate},
+	{models.InsufficientFeedStock, http.StatusConflict, models.CodeInsufficientFeedStock},
+	{models.NoDinosaursToFeed, http.StatusConflict, models.CodeNoDinosaursToFeed},
+	{models.InvalidZoneParent, http.StatusUnprocessableEntity, models.CodeInvalidZoneParent},
+	{models.InvalidCageZone, http.StatusUnprocessableEntity, models.CodeInvalidCageZone},
+	{models.ZoneNotEmpty, http.StatusConflict, models.CodeZoneNotEmpty},
+	{models.InvalidImportFile, http.StatusUnprocessableEntity, models.CodeInvalidImportFile},
+}
+
+// respondWithError writes the problem response for an error from models, with the detail that explains it and the
+// values it is about. Errors that aren't in errorProblems are reported as an unexpected 500.
+func respondWithError(c *gin.Context, err error, detail string, context gin.H) {
+	status, code, ok := problemFor(err)
+	if !ok {
+		respondWithUnexpectedError(c)
+		return
+	}
+	response := newProblem(c, status, code, detail, context)
+	response.Violations, response.Fields = problemDetails(err)
+	writeProblem(c, response)
+}
+
+// problemFor returns the status and code that an error from models is reported with, or false if the error isn't
+// in errorProblems.
+func problemFor(err error) (int, models.ErrorCode, bool) {
+	for _, problem := range errorProblems {
+		if errors.Is(err, problem.err) {
+			return problem.status, problem.code, true
+		}
+	}
+	return 0, "", false
+}
+
+// problemDetails returns the compatibility rules that an error says were broken and the fields it says are invalid.
+func problemDetails(err error) ([]models.RuleViolation, []models.FieldError) {
+	var violations []models.RuleViolation
+	var fields []models.FieldError
+	var compatibilityErr *models.CompatibilityError
+	if errors.As(err, &compatibilityErr) {
+		violations = compatibilityErr.Violations
+	}
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		fields = validationErr.Fields
+	}
+	return violations, fields
+}
+
+// respondWithProblem writes a problem response that doesn't come from an error in models, such as a request body
+// that can't be read, or an error that the endpoint reports differently from the rest of the API.
+func respondWithProblem(c *gin.Context, status int, code models.ErrorCode, detail string, context gin.H) {
+	writeProblem(c, newProblem(c, status, code, detail, context))
+}
+
+func respondWithUnexpectedError(c *gin.Context) {
+	respondWithProblem(c, http.StatusInternalServerError, models.CodeInternalError, "unexpected error", nil)
+}
+
+// respondWithInvalidBody writes the problem response for a request body that isn't in the expected format.
+func respondWithInvalidBody(c *gin.Context) {
+	respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidRequestBody, "Request body is in the incorrect format", nil)
+}
+
+func newProblem(c *gin.Context, status int, code models.ErrorCode, detail string, context gin.H) models.ErrorResponse {
+	return models.ErrorResponse{
+		Type:         "about:blank",
+		Title:        http.StatusText(status),
+		Status:       status,
+		Detail:       detail,
+		Code:         code,
+		Instance:     c.Request.URL.Path,
+		Context:      context,
+		ErrorMessage: detail,
+	}
+}
+
+// writeProblem writes the response and aborts the request, so that it also ends a request in middleware.
+func writeProblem(c *gin.Context, response models.ErrorResponse) {
+	c.Header("Content-Type", models.ProblemContentType)
+	c.AbortWithStatusJSON(response.Status, response)
+}
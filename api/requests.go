@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// decodeRequest reads the JSON body of the request into request. A field with a value of the wrong type is reported
+// as an invalid field, and a body that isn't JSON as being in the incorrect format. If the body can't be read the
+// error response is written and false is returned.
+func decodeRequest(c *gin.Context, request any) bool {
+	err := json.NewDecoder(c.Request.Body).Decode(request)
+	if err == nil {
+		return true
+	}
+	if typeErr := models.JSONTypeError(err); typeErr != nil {
+		respondWithValidationError(c, typeErr)
+		return false
+	}
+	respondWithInvalidBody(c)
+	return false
+}
+
+// validateRequest checks the request against the validate tags on its fields. If any field is invalid, the error
+// response listing them is written and false is returned.
+func validateRequest(c *gin.Context, request any) bool {
+	err := models.ValidateRequest(request)
+	if err != nil {
+		respondWithValidationError(c, err)
+		return false
+	}
+	return true
+}
+
+func respondWithValidationError(c *gin.Context, err error) {
+	var validationErr *models.ValidationError
+	if !errors.As(err, &validationErr) {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	respondWithError(c, err, "the request has invalid fields: "+fieldReasons(validationErr), nil)
+}
+
+// fieldReasons lists why each field is invalid.
+func fieldReasons(validationErr *models.ValidationError) string {
+	reasons := []string{}
+	for _, field := range validationErr.Fields {
+		reasons = append(reasons, field.Field+" "+field.Reason)
+	}
+	return strings.Join(reasons, "; ")
+}
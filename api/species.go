@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) CreateSpecies(c *gin.Context) {
+	var species models.Species
+	if !decodeRequest(c, &species) || !validateRequest(c, species) {
+		return
+	}
+
+	err := api.parkManager.AddSpecies(actorContext(c), species)
+	if err != nil {
+		if errors.Is(err, models.InvalidSpeciesDiet) {
+			respondWithError(c, err, fmt.Sprintf("%s is not a valid diet", species.Diet), gin.H{
+				"diet": species.Diet,
+			})
+		} else if errors.Is(err, models.EntityAlreadyExists) {
+			respondWithError(c, err, fmt.Sprintf("There is already a species with the name %s", species.Name), gin.H{
+				"species": species.Name,
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, species)
+}
+
+func (api *API) GetAllSpecies(c *gin.Context) {
+	species, err := api.parkManager.GetAllSpecies()
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	c.JSON(http.StatusOK, species)
+}
+
+func (api *API) GetSpecies(c *gin.Context) {
+	name := c.Param("name")
+	species, err := api.parkManager.GetSpecies(name)
+	if err != nil {
+		if errors.Is(err, models.EntityNotFound) {
+			respondWithError(c, err, fmt.Sprintf("species with name %s not found", name), gin.H{
+				"species": name,
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, species)
+}
+
+func (api *API) UpdateSpecies(c *gin.Context) {
+	var species models.Species
+	if !decodeRequest(c, &species) {
+		return
+	}
+	// the species is identified by the path, so the name in the body is ignored
+	species.Name = c.Param("name")
+	if !validateRequest(c, species) {
+		return
+	}
+
+	err := api.parkManager.UpdateSpecies(actorContext(c), species)
+	if err != nil {
+		if errors.Is(err, models.EntityNotFound) {
+			respondWithError(c, err, fmt.Sprintf("species with name %s not found", species.Name), gin.H{
+				"species": species.Name,
+			})
+		} else if errors.Is(err, models.InvalidSpeciesDiet) {
+			respondWithError(c, err, fmt.Sprintf("%s is not a valid diet", species.Diet), gin.H{
+				"diet": species.Diet,
+			})
+		} else if errors.Is(err, models.EntityInUse) {
+			respondWithError(c, err, fmt.Sprintf("the diet of %s can't be changed while there are dinosaurs of that species in the park", species.Name), gin.H{
+				"species": species.Name,
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, species)
+}
+
+func (api *API) DeleteSpecies(c *gin.Context) {
+	name := c.Param("name")
+	err := api.parkManager.DeleteSpecies(actorContext(c), name)
+	if err != nil {
+		if errors.Is(err, models.EntityNotFound) {
+			respondWithError(c, err, fmt.Sprintf("species with name %s not found", name), gin.H{
+				"species": name,
+			})
+		} else if errors.Is(err, models.EntityInUse) {
+			respondWithError(c, err, fmt.Sprintf("the species %s can't be deleted while there are dinosaurs of that species in the park", name), gin.H{
+				"species": name,
+			})
+		} else {
+			respondWithError(c, err, "", nil)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "species deleted",
+	})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) CreateCageV2(c *gin.Context) {
+	var cage models.CageV2
+	if !decodeRequest(c, &cage) || !validateRequest(c, cage) {
+		return
+	}
+	if cage.PowerStatus == "" {
+		cage.PowerStatus = models.PowerStatusActive
+	}
+	err := api.parkManager.AddCage(actorContext(c), cage.Cage())
+	if err != nil {
+		respondWithCreateCageError(c, err, cage.Label, cage.PowerStatus, cage.Zone)
+		return
+	}
+	api.publishCageCreated(cage.Cage())
+	c.JSON(http.StatusCreated, cage)
+}
+
+func (api *API) GetCagesV2(c *gin.Context) {
+	filter := models.CageFilter{}
+	if c.Query("hasPower") != "" {
+		hasPower := c.Query("hasPower") == "true"
+		filter.HasPower = &hasPower
+	}
+	if c.Query("powerStatus") != "" {
+		powerStatus := models.PowerStatus(c.Query("powerStatus"))
+		filter.PowerStatus = &powerStatus
+	}
+	if c.Query("canHouse") != "" {
+		canHouse := c.Query("canHouse")
+		filter.CanHouse = &canHouse
+	}
+	if c.Query("zone") != "" {
+		zone := c.Query("zone")
+		filter.Zone = &zone
+	}
+	page, ok := parsePagination(c, models.CageSortFields)
+	if !ok {
+		return
+	}
+	filter.Page = page
+	cages, pageInfo, err := api.parkManager.GetCages(filter)
+	if err != nil {
+		respondWithGetCagesError(c, err, filter)
+		return
+	}
+	setPageHeaders(c, pageInfo)
+	cagesV2 := []models.CageV2{}
+	for _, cage := range cages {
+		cagesV2 = append(cagesV2, models.NewCageV2(cage))
+	}
+	c.JSON(http.StatusOK, cagesV2)
+}
+
+func (api *API) GetCageV2(c *gin.Context) {
+	cageLabel := c.Param("cageLabel")
+	cage, err := api.parkManager.GetCage(cageLabel)
+	if err != nil {
+		respondWithCageNotFound(c, err, cageLabel)
+		return
+	}
+	c.JSON(http.StatusOK, models.NewCageV2(*cage))
+}
+
+func (api *API) UpdateCageV2(c *gin.Context) {
+	cageLabel := c.Param("cageLabel")
+	var updateCageRequest models.UpdateCageV2Request
+	if !decodeRequest(c, &updateCageRequest) || !validateRequest(c, updateCageRequest) {
+		return
+	}
+	if updateCageRequest.Label == nil && updateCageRequest.MaxOccupancy == nil && updateCageRequest.PowerStatus == nil &&
+		updateCageRequest.Zone == nil {
+		respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidRequestBody,
+			"Request body must contain at least one of label, maxOccupancy, powerStatus or zone", nil)
+		return
+	}
+
+	update := updateCageRequest.UpdateCageRequest()
+	if !authorizeCageUpdate(c, update) {
+		return
+	}
+	cage, err := api.parkManager.UpdateCage(actorContext(c), cageLabel, update)
+	if err != nil {
+		if errors.Is(err, models.IncompatibleCagePowerState) {
+			api.publishPowerCutRefused(cageLabel, update)
+		}
+		api.respondWithUpdateCageError(c, err, cageLabel, update)
+		return
+	}
+	api.publishCageUpdated(*cage, update)
+	c.JSON(http.StatusOK, models.NewCageV2(*cage))
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhook subscribes a URL to events. The secret that deliveries are signed with is generated when none is
+// given, and is only returned in the response to this request.
+func (api *API) CreateWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if !decodeRequest(c, &webhook) || !validateRequest(c, webhook) {
+		return
+	}
+	if webhook.Secret == "" {
+		var err error
+		webhook.Secret, err = webhooks.NewSecret()
+		if err != nil {
+			respondWithError(c, err, "", nil)
+			return
+		}
+	}
+
+	created, err := api.parkManager.AddWebhook(actorContext(c), webhook)
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (api *API) GetWebhooks(c *gin.Context) {
+	all, err := api.parkManager.GetWebhooks()
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	for i := range all {
+		all[i].Secret = ""
+	}
+	c.JSON(http.StatusOK, all)
+}
+
+func (api *API) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	webhook, err := api.parkManager.GetWebhook(id)
+	if err != nil {
+		respondWithWebhookError(c, err, c.Param("id"))
+		return
+	}
+	webhook.Secret = ""
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (api *API) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	err := api.parkManager.DeleteWebhook(actorContext(c), id)
+	if err != nil {
+		respondWithWebhookError(c, err, c.Param("id"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook deleted",
+	})
+}
+
+// GetWebhookDeliveries lists the deliveries made to a webhook, oldest first, optionally only those with a status.
+func (api *API) GetWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	filter := models.WebhookDeliveryFilter{}
+	if c.Query("status") != "" {
+		status := models.DeliveryStatus(c.Query("status"))
+		if status != models.DeliveryPending && status != models.DeliveryDelivered && status != models.DeliveryDeadLettered {
+			respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidParameter,
+				fmt.Sprintf("status must be one of %s, %s, %s", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDeadLettered), gin.H{
+					"parameter": "status",
+					"allowed":   []models.DeliveryStatus{models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDeadLettered},
+				})
+			return
+		}
+		filter.Status = &status
+	}
+	page, ok := parsePagination(c, []string{})
+	if !ok {
+		return
+	}
+	filter.Page = page
+
+	deliveries, pageInfo, err := api.parkManager.GetWebhookDeliveries(id, filter)
+	if err != nil {
+		if errors.Is(err, models.InvalidCursor) {
+			respondWithInvalidCursor(c)
+		} else {
+			respondWithWebhookError(c, err, c.Param("id"))
+		}
+		return
+	}
+	setPageHeaders(c, pageInfo)
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// webhookID reads the id of the webhook from the path. Ids that aren't numbers can't match a webhook, so the not
+// found response is written and false is returned.
+func webhookID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithWebhookError(c, models.EntityNotFound, c.Param("id"))
+		return 0, false
+	}
+	return id, true
+}
+
+func respondWithWebhookError(c *gin.Context, err error, id string) {
+	if errors.Is(err, models.EntityNotFound) {
+		respondWithError(c, err, fmt.Sprintf("webhook with id %s not found", id), gin.H{
+			"webhook": id,
+		})
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/zones"
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) CreateZone(c *gin.Context) {
+	var zone models.Zone
+	if !decodeRequest(c, &zone) || !validateRequest(c, zone) {
+		return
+	}
+	err := api.parkManager.AddZone(actorContext(c), zone)
+	if err != nil {
+		if errors.Is(err, models.EntityAlreadyExists) {
+			respondWithError(c, err, fmt.Sprintf("There is already a zone with the name %s", zone.Name), gin.H{
+				"zone": zone.Name,
+			})
+		} else {
+			respondWithZoneError(c, err, zone.Parent)
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, zone)
+}
+
+// GetZones lists every zone by name, with the totals for the cages in it and in the zones nested in it.
+func (api *API) GetZones(c *gin.Context) {
+	summaries, err := zones.Summaries(api.parkManager)
+	if err != nil {
+		respondWithError(c, err, "", nil)
+		return
+	}
+	c.JSON(http.StatusOK, summaries)
+}
+
+func (api *API) GetZone(c *gin.Context) {
+	summary, err := zones.Summary(api.parkManager, c.Param("name"))
+	if err != nil {
+		respondWithZoneError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// UpdateZone moves a zone to another parent, or to the top of the park when the parent is empty, and changes its
+// description.
+func (api *API) UpdateZone(c *gin.Context) {
+	var update models.UpdateZoneRequest
+	if !decodeRequest(c, &update) || !validateRequest(c, update) {
+		return
+	}
+	if update.Parent == nil && update.Description == nil {
+		respondWithProblem(c, http.StatusUnprocessableEntity, models.CodeInvalidRequestBody,
+			"Request body must contain at least one of parent or description", nil)
+		return
+	}
+	zone, err := api.parkManager.UpdateZone(actorContext(c), c.Param("name"), update)
+	if err != nil {
+		respondWithZoneError(c, err, update.Parent)
+		return
+	}
+	c.JSON(http.StatusOK, zone)
+}
+
+func (api *API) DeleteZone(c *gin.Context) {
+	err := api.parkManager.DeleteZone(actorContext(c), c.Param("name"))
+	if err != nil {
+		respondWithZoneError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "zone deleted",
+	})
+}
+
+func respondWithZoneError(c *gin.Context, err error, parent *string) {
+	if errors.Is(err, models.InvalidZoneParent) {
+		respondWithError(c, err, fmt.Sprintf("the zone can't be put in %s, which must be another zone that isn't inside it", *parent), gin.H{
+			"parent": *parent,
+		})
+	} else if errors.Is(err, models.ZoneNotEmpty) {
+		respondWithError(c, err, "the zone has cages or zones in it and cannot be deleted", gin.H{
+			"zone": c.Param("name"),
+		})
+	} else if errors.Is(err, models.EntityNotFound) {
+		respondWithError(c, err, fmt.Sprintf("zone with name %s not found", c.Param("name")), gin.H{
+			"zone": c.Param("name"),
+		})
+	} else {
+		respondWithError(c, err, "", nil)
+	}
+}
+
+// respondWithInvalidCageZone writes the error response for a cage that was put in a zone that doesn't exist.
+func respondWithInvalidCageZone(c *gin.Context, err error, zone *string) {
+	name := ""
+	if zone != nil {
+		name = *zone
+	}
+	respondWithError(c, err, fmt.Sprintf("there is no zone with the name %s to put the cage in", name), gin.H{
+		"zone": name,
+	})
+}
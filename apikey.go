@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/data"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+const apiKeyUsage = `usage: jurassic-park apikey [create name role... | list | revoke id]
+  create  issues a key with the name and roles, and prints it
+  list    lists the keys, without the keys themselves
+  revoke  stops the key with the id from authenticating`
+
+// runAPIKey runs the apikey subcommand, which manages API keys without starting the server. It is how the first
+// admin key is issued, since issuing keys through the API needs one.
+func runAPIKey(parkSqlDao *data.ParkSqlDao, args []string) error {
+	ctx := models.WithActor(context.Background(), "cli")
+	if len(args) == 0 {
+		return errors.New(apiKeyUsage)
+	}
+	switch {
+	case args[0] == "create" && len(args) >= 3:
+		apiKey := models.APIKey{Name: args[1]}
+		for _, role := range args[2:] {
+			apiKey.Roles = append(apiKey.Roles, models.Role(role))
+		}
+		if err := models.ValidateRequest(apiKey); err != nil {
+			return fmt.Errorf("%w\n%s", err, apiKeyUsage)
+		}
+		if err := auth.Issue(&apiKey); err != nil {
+			return err
+		}
+		created, err := parkSqlDao.AddAPIKey(ctx, apiKey)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created API key %d %s, which is only shown this once:\n%s\n", created.ID, created.Name, apiKey.Key)
+	case args[0] == "list" && len(args) == 1:
+		apiKeys, err := parkSqlDao.GetAPIKeys()
+		if err != nil {
+			return err
+		}
+		for _, apiKey := range apiKeys {
+			roles := []string{}
+			for _, role := range apiKey.Roles {
+				roles = append(roles, string(role))
+			}
+			status := "active"
+			if apiKey.RevokedTime != nil {
+				status = "revoked"
+			}
+			fmt.Printf("%-4d %-24s %-16s %-7s %s\n", apiKey.ID, apiKey.Name, apiKey.Prefix, status, strings.Join(roles, ","))
+		}
+	case args[0] == "revoke" && len(args) == 2:
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("id must be a number\n%s", apiKeyUsage)
+		}
+		if err := parkSqlDao.RevokeAPIKey(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("revoked API key %d\n", id)
+	default:
+		return errors.New(apiKeyUsage)
+	}
+	return nil
+}
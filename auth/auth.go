@@ -0,0 +1,83 @@
+// Package auth authenticates requests to the park. Requests are authenticated with API keys, which are generated
+// here and stored as hashes, or with JWTs issued by the park's single sign-on. The principal they authenticate as
+// holds roles that grant access to the API.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// APIKeyHeader is the header that requests send their API key in.
+const APIKeyHeader = "X-API-Key"
+
+const (
+	// keyPrefix starts every API key, which makes a leaked key easy to recognize.
+	keyPrefix = "jpk_"
+	// displayedKeyLength is how much of a key is kept as its prefix.
+	displayedKeyLength = len(keyPrefix) + 8
+)
+
+// Authenticator works out who a request was made by. Requests that don't carry the kind of credentials it
+// authenticates are MissingCredentials, and those with credentials it doesn't accept are a CredentialsError.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*models.Principal, error)
+}
+
+// KeyStore looks up the API keys that authenticate requests.
+type KeyStore interface {
+	// GetAPIKeyByHash returns the key with the hash, or EntityNotFound.
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error)
+}
+
+// APIKeyAuthenticator authenticates requests that send an API key in the APIKeyHeader.
+type APIKeyAuthenticator struct {
+	store KeyStore
+}
+
+func NewAPIKeyAuthenticator(store KeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{store: store}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*models.Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, models.MissingCredentials
+	}
+	apiKey, err := a.store.GetAPIKeyByHash(HashKey(key))
+	if err != nil {
+		if errors.Is(err, models.EntityNotFound) {
+			return nil, &models.CredentialsError{Reason: "the API key is not valid"}
+		}
+		return nil, err
+	}
+	if apiKey.RevokedTime != nil {
+		return nil, &models.CredentialsError{Reason: "the API key has been revoked"}
+	}
+	principal := apiKey.Principal()
+	return &principal, nil
+}
+
+// Issue generates the key of an API key that is being created, and fills in its hash and prefix.
+func Issue(apiKey *models.APIKey) error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	apiKey.Key = keyPrefix + hex.EncodeToString(secret)
+	apiKey.KeyHash = HashKey(apiKey.Key)
+	apiKey.Prefix = apiKey.Key[:displayedKeyLength]
+	return nil
+}
+
+// HashKey returns the hex encoded SHA-256 of a key, which is what is stored in its place. Keys are random, so
+// unlike passwords they don't need a slow hash to hold up against guessing.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+)
+
+// maxJWKSSize limits how much of a JWKS response is read.
+const maxJWKSSize = 1 << 20
+
+// KeySet is a JSON Web Key Set, the public keys that the single sign-on signs JWTs with. It is loaded once, so that
+// tokens are validated without calling out to the single sign-on, and has to be loaded again when its keys rotate.
+type KeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+// jwk is a JSON Web Key, as defined by RFC 7517. Only RSA and elliptic curve public keys are read.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS reads a JSON Web Key Set. Keys that aren't used for signatures, such as encryption keys, and key types
+// other than RSA and EC are skipped.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("the JWKS is not valid JSON: %w", err)
+	}
+	keySet := &KeySet{keys: map[string]crypto.PublicKey{}}
+	for _, key := range document.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		var publicKey crypto.PublicKey
+		var err error
+		switch key.Kty {
+		case "RSA":
+			publicKey, err = key.rsaPublicKey()
+		case "EC":
+			publicKey, err = key.ecPublicKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the key %q in the JWKS is not valid: %w", key.Kid, err)
+		}
+		if _, ok := keySet.keys[key.Kid]; ok {
+			return nil, fmt.Errorf("there is more than one key with the id %q in the JWKS", key.Kid)
+		}
+		keySet.keys[key.Kid] = publicKey
+	}
+	if len(keySet.keys) == 0 {
+		return nil, fmt.Errorf("the JWKS has no RSA or EC signing keys")
+	}
+	return keySet, nil
+}
+
+// LoadJWKSFile reads a JSON Web Key Set from a file.
+func LoadJWKSFile(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keySet, err := ParseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("loading the JWKS from %s: %w", path, err)
+	}
+	return keySet, nil
+}
+
+// FetchJWKS downloads a JSON Web Key Set, usually from the jwks_uri of the single sign-on.
+func FetchJWKS(ctx context.Context, client *http.Client, url string) (*KeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching the JWKS from %s: status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, err
+	}
+	keySet, err := ParseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("fetching the JWKS from %s: %w", url, err)
+	}
+	return keySet, nil
+}
+
+// key returns the key with the id. Tokens without a key id can only be checked against a set with one key.
+func (s *KeySet) key(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := decodeBase64URLInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBase64URLInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("the exponent is out of range")
+	}
+	if n.BitLen() < 2048 {
+		return nil, fmt.Errorf("RSA keys must be at least 2048 bits")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("the curve %q is not supported", k.Crv)
+	}
+	x, err := decodeBase64URLInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBase64URLInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("the point is not on the curve %s", k.Crv)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBase64URLInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("a key parameter is missing")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
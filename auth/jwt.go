@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultRolesClaim is the claim that roles are read from when JWTConfig doesn't name one.
+const DefaultRolesClaim = "roles"
+
+// signingMethods are the algorithms that tokens can be signed with. Only public key algorithms are accepted, so that
+// a token can't be signed with HMAC using a public key from the JWKS as the secret.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// JWTConfig configures which JWTs are accepted and the roles they grant.
+type JWTConfig struct {
+	// Issuer and Audience must match the iss and aud claims of every token.
+	Issuer   string
+	Audience string
+	// RolesClaim names the claim that lists the roles or groups of the token's subject, as an array of strings or
+	// a space separated string. Claims nested in objects are named with dots, as in realm_access.roles.
+	RolesClaim string
+	// RoleMapping maps the values of the roles claim to park roles, and values that aren't mapped are ignored.
+	// Without a mapping, the values that are park roles are granted as they are.
+	RoleMapping map[string][]models.Role
+	// Leeway allows for clock skew between the single sign-on and the park when checking a token's times.
+	Leeway time.Duration
+}
+
+// JWTAuthenticator authenticates requests that send a JWT as a bearer token in the Authorization header. The
+// token's signature is checked against a KeySet, along with its issuer, audience and expiry, and it authenticates
+// the principal named by its sub claim.
+type JWTAuthenticator struct {
+	keys   *KeySet
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(keys *KeySet, config JWTConfig) *JWTAuthenticator {
+	if config.RolesClaim == "" {
+		config.RolesClaim = DefaultRolesClaim
+	}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(config.Issuer),
+		jwt.WithAudience(config.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+	)
+	return &JWTAuthenticator{keys: keys, config: config, parser: parser}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*models.Principal, error) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, models.MissingCredentials
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(token), claims, a.verificationKey)
+	if err != nil {
+		return nil, &models.CredentialsError{Reason: tokenErrorReason(err)}
+	}
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, &models.CredentialsError{Reason: "the bearer token has no subject"}
+	}
+	return &models.Principal{Name: subject, Roles: a.roles(claims)}, nil
+}
+
+// verificationKey returns the key from the KeySet that the token says it was signed with.
+func (a *JWTAuthenticator) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := a.keys.key(kid)
+	if !ok {
+		return nil, fmt.Errorf("there is no key with the id %q", kid)
+	}
+	return key, nil
+}
+
+func tokenErrorReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "the bearer token has expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return "the bearer token is not valid yet"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer), errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "the bearer token was not issued for this API"
+	default:
+		return "the bearer token is not valid"
+	}
+}
+
+// roles maps the values of the roles claim to park roles.
+func (a *JWTAuthenticator) roles(claims jwt.MapClaims) []models.Role {
+	roles := []models.Role{}
+	granted := map[models.Role]bool{}
+	for _, value := range claimValues(claims, a.config.RolesClaim) {
+		mapped := []models.Role{models.Role(value)}
+		if a.config.RoleMapping != nil {
+			mapped = a.config.RoleMapping[value]
+		}
+		for _, role := range mapped {
+			if models.IsValidRole(role) && !granted[role] {
+				granted[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+// claimValues reads the strings in a claim, which is named by its path through nested objects.
+func claimValues(claims jwt.MapClaims, name string) []string {
+	var claim any = map[string]any(claims)
+	for _, key := range strings.Split(name, ".") {
+		object, ok := claim.(map[string]any)
+		if !ok {
+			return nil
+		}
+		claim = object[key]
+	}
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []any:
+		values := []string{}
+		for _, value := range claim {
+			if value, ok := value.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+	return nil
+}
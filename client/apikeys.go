@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// CreateAPIKey issues a key with a name and roles. The key is only returned here.
+func (c *Client) CreateAPIKey(ctx context.Context, name string, roles ...models.Role) (*models.APIKey, error) {
+	created := &models.APIKey{}
+	body := models.APIKey{Name: name, Roles: roles}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/apikeys", body: body}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListAPIKeys lists every API key, including the revoked ones, without the keys themselves.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	apiKeys := []models.APIKey{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/apikeys"}, &apiKeys)
+	if err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// RevokeAPIKey stops an API key from authenticating.
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/apikeys/" + strconv.Itoa(id)}, nil)
+	return err
+}
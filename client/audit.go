@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// AuditListOptions filters the audit events that are listed. Fields that are empty or nil don't filter.
+type AuditListOptions struct {
+	// Entity lists only the changes to one entity, such as cage:C-1.
+	Entity string
+	// Since lists only the changes made at or after the time.
+	Since *time.Time
+	PageOptions
+}
+
+// ListAuditEvents lists a page of the changes made to the park, oldest first.
+func (c *Client) ListAuditEvents(ctx context.Context, options AuditListOptions) ([]models.AuditEvent, Page, error) {
+	query := url.Values{}
+	if options.Entity != "" {
+		query.Set("entity", options.Entity)
+	}
+	if options.Since != nil {
+		query.Set("since", options.Since.UTC().Format(time.RFC3339))
+	}
+	options.PageOptions.setQuery(query)
+
+	auditEvents := []models.AuditEvent{}
+	response, err := c.do(ctx, request{method: http.MethodGet, path: "/audit", query: query}, &auditEvents)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return auditEvents, pageOf(response), nil
+}
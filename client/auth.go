@@ -0,0 +1,40 @@
+package client
+
+import "net/http"
+
+// Authenticator adds credentials to the requests that the client sends. It is called for every attempt at a
+// request, so credentials that expire, such as bearer tokens, can be refreshed between attempts.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc is a function that adds credentials to a request.
+type AuthenticatorFunc func(req *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// APIKey authenticates requests with an API key, sent in the X-API-Key header.
+func APIKey(apiKey string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("X-API-Key", apiKey)
+		return nil
+	})
+}
+
+// BearerToken authenticates requests with a JWT from the park's single sign-on.
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// Actor names who is making the requests, for the audit log, to a server that doesn't authenticate requests.
+func Actor(name string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("X-Actor", name)
+		return nil
+	})
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// CageListOptions filters the cages that are listed. Fields that are empty or nil don't filter.
+type CageListOptions struct {
+	// HasPower lists only the cages with power, in any status other than DOWN, or only those without power.
+	HasPower    *bool
+	PowerStatus models.PowerStatus
+	// CanHouse ranks the cages that the named dinosaur could be added to. It can't be used with a sort.
+	CanHouse string
+	// Zone lists only the cages in the zone and in the zones nested in it.
+	Zone string
+	PageOptions
+}
+
+// CreateCage adds a cage. The power status defaults to ACTIVE.
+func (c *Client) CreateCage(ctx context.Context, cage models.CageV2) (*models.CageV2, error) {
+	created := &models.CageV2{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/cages", body: cage}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetCage reads a cage by its label.
+func (c *Client) GetCage(ctx context.Context, label string) (*models.CageV2, error) {
+	cage := &models.CageV2{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/cages/" + escape(label)}, cage)
+	if err != nil {
+		return nil, err
+	}
+	return cage, nil
+}
+
+// ListCages lists a page of the cages.
+func (c *Client) ListCages(ctx context.Context, options CageListOptions) ([]models.CageV2, Page, error) {
+	query := url.Values{}
+	if options.HasPower != nil {
+		query.Set("hasPower", strconv.FormatBool(*options.HasPower))
+	}
+	if options.PowerStatus != "" {
+		query.Set("powerStatus", string(options.PowerStatus))
+	}
+	if options.CanHouse != "" {
+		query.Set("canHouse", options.CanHouse)
+	}
+	if options.Zone != "" {
+		query.Set("zone", options.Zone)
+	}
+	options.PageOptions.setQuery(query)
+
+	cages := []models.CageV2{}
+	response, err := c.do(ctx, request{method: http.MethodGet, path: "/cages", query: query}, &cages)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return cages, pageOf(response), nil
+}
+
+// UpdateCage changes the fields of the cage that are set in the update.
+func (c *Client) UpdateCage(ctx context.Context, label string, update models.UpdateCageV2Request) (*models.CageV2, error) {
+	cage := &models.CageV2{}
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: "/cages/" + escape(label), body: update}, cage)
+	if err != nil {
+		return nil, err
+	}
+	return cage, nil
+}
+
+// SetPowerStatus moves the cage to the power status.
+func (c *Client) SetPowerStatus(ctx context.Context, label string, powerStatus models.PowerStatus) (*models.CageV2, error) {
+	return c.UpdateCage(ctx, label, models.UpdateCageV2Request{PowerStatus: &powerStatus})
+}
+
+// DeleteCage decommissions an empty cage.
+func (c *Client) DeleteCage(ctx context.Context, label string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/cages/" + escape(label)}, nil)
+	return err
+}
+
+// ListDinosaursInCage lists a page of the dinosaurs in the cage.
+func (c *Client) ListDinosaursInCage(ctx context.Context, label string, page PageOptions) ([]models.Dinosaur, Page, error) {
+	query := url.Values{}
+	page.setQuery(query)
+	dinosaurs := []models.Dinosaur{}
+	response, err := c.do(ctx, request{method: http.MethodGet, path: "/cages/" + escape(label) + "/dinosaurs", query: query}, &dinosaurs)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return dinosaurs, pageOf(response), nil
+}
+
+// AddDinosaurToCage puts a dinosaur that isn't in a cage into the cage.
+func (c *Client) AddDinosaurToCage(ctx context.Context, label, dinosaurName string) error {
+	body := models.AddDinosaurToCageRequest{Name: dinosaurName}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/cages/" + escape(label) + "/dinosaurs", body: body}, nil)
+	return err
+}
+
+// RemoveDinosaurFromCage takes a dinosaur out of the cage.
+func (c *Client) RemoveDinosaurFromCage(ctx context.Context, label, dinosaurName string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/cages/" + escape(label) + "/dinosaurs/" + escape(dinosaurName)}, nil)
+	return err
+}
@@ -0,0 +1,232 @@
+// Package client is a typed client for the Jurassic Park API. Every method takes a context, which cancels the
+// request, and sends and returns the types in models. Cages are sent and returned in their v2 representation.
+//
+// Error responses are returned as an *Error, which wraps the error in models that it reports, so that
+//
+//	errors.Is(err, models.CageCapacityExceeded)
+//
+// works as it does on the server. Requests that fail in a way that might not last are retried, as configured by
+// WithRetryPolicy, and credentials are added by an Authenticator.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// basePath is the path of the API version that the client uses. Every route is available under v2.
+const basePath = "/jurassicpark/v2"
+
+// Client sends requests to a Jurassic Park server. It is safe for concurrent use.
+type Client struct {
+	endpoint      string
+	httpClient    *http.Client
+	authenticator Authenticator
+	retryPolicy   RetryPolicy
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithHTTPClient sends requests with the HTTP client, rather than http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAuthenticator adds credentials to every request with the authenticator. Without one, requests are sent
+// without credentials, which only a server that doesn't authenticate requests accepts.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(c *Client) {
+		c.authenticator = authenticator
+	}
+}
+
+// WithAPIKey authenticates requests with an API key, sent in the X-API-Key header.
+func WithAPIKey(apiKey string) Option {
+	return WithAuthenticator(APIKey(apiKey))
+}
+
+// WithBearerToken authenticates requests with a JWT from the park's single sign-on.
+func WithBearerToken(token string) Option {
+	return WithAuthenticator(BearerToken(token))
+}
+
+// WithRetryPolicy retries requests with the policy, rather than DefaultRetryPolicy. A policy with a MaxAttempts of
+// 1 doesn't retry.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// New returns a client for the server at endpoint, such as http://localhost:8080.
+func New(endpoint string, opts ...Option) *Client {
+	c := &Client{
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		httpClient:  http.DefaultClient,
+		retryPolicy: DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// PageOptions selects a page of a list. The zero value selects the whole list in its default order.
+type PageOptions struct {
+	// Limit is the largest number of items to return. Zero means no limit.
+	Limit int
+	// Sort is a field to sort on, with a leading - to sort in descending order.
+	Sort string
+	// Cursor continues the list from the Next cursor of a previous page.
+	Cursor string
+	// IncludeTotal asks for the number of items across all pages.
+	IncludeTotal bool
+}
+
+func (p PageOptions) setQuery(query url.Values) {
+	if p.Limit > 0 {
+		query.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Sort != "" {
+		query.Set("sort", p.Sort)
+	}
+	if p.Cursor != "" {
+		query.Set("cursor", p.Cursor)
+	}
+	if p.IncludeTotal {
+		query.Set("includeTotal", "true")
+	}
+}
+
+// Page describes the page of a list that was returned.
+type Page struct {
+	// Next is the cursor of the next page, or empty on the last page.
+	Next string
+	// Total is the number of items across all pages, when it was asked for.
+	Total *int
+}
+
+func pageOf(response *http.Response) Page {
+	page := Page{Next: response.Header.Get("X-Next-Cursor")}
+	if total, err := strconv.Atoi(response.Header.Get("X-Total-Count")); err == nil {
+		page.Total = &total
+	}
+	return page
+}
+
+// request is a request to the API, relative to basePath.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	// body is sent as JSON, unless it is an io.Reader, which is sent as it is with contentType.
+	body        any
+	contentType string
+	headers     http.Header
+}
+
+// do sends the request and decodes the JSON response into result, unless result is nil. Responses with a status
+// of 400 or above are returned as an *Error.
+func (c *Client) do(ctx context.Context, req request, result any) (*http.Response, error) {
+	response, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if result != nil {
+		if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+			return nil, fmt.Errorf("decoding the response to %s %s: %w", req.method, req.path, err)
+		}
+	}
+	return response, nil
+}
+
+// send sends the request and returns the response, whose body the caller must close. Requests are retried as the
+// retry policy allows. Responses with a status of 400 or above are returned as an *Error.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	u := c.endpoint + basePath + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	var payload []byte
+	contentType := req.contentType
+	reader, streamed := req.body.(io.Reader)
+	if !streamed && req.body != nil {
+		var err error
+		if payload, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+		contentType = "application/json"
+	}
+
+	for attempt := 1; ; attempt++ {
+		var body io.Reader
+		if streamed {
+			body = reader
+		} else if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		httpRequest, err := http.NewRequestWithContext(ctx, req.method, u, body)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range req.headers {
+			httpRequest.Header[name] = values
+		}
+		if contentType != "" {
+			httpRequest.Header.Set("Content-Type", contentType)
+		}
+		if c.authenticator != nil {
+			if err := c.authenticator.Authenticate(httpRequest); err != nil {
+				return nil, err
+			}
+		}
+
+		response, err := c.httpClient.Do(httpRequest)
+		// a streamed body can't be sent again
+		if streamed || !c.retryPolicy.shouldRetry(ctx, req.method, attempt, response, err) {
+			if err != nil {
+				return nil, err
+			}
+			if err := checkStatus(response); err != nil {
+				return nil, err
+			}
+			return response, nil
+		}
+		if response != nil {
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		if err := sleep(ctx, c.retryPolicy.backoff(attempt, response)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// checkStatus returns an *Error for a response with a status of 400 or above, whose body it closes.
+func checkStatus(response *http.Response) error {
+	if response.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	defer response.Body.Close()
+	apiErr := &Error{StatusCode: response.StatusCode}
+	// a response that isn't a problem leaves the problem empty
+	json.NewDecoder(response.Body).Decode(&apiErr.Problem)
+	return apiErr
+}
+
+// escape escapes a label or name for use as a segment of a path.
+func escape(segment string) string {
+	return url.PathEscape(segment)
+}
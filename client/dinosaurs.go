@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// DinosaurListOptions filters the dinosaurs that are listed. Fields that are empty or nil don't filter.
+type DinosaurListOptions struct {
+	Species string
+	Sex     models.Sex
+	Diet    string
+	// NeedsCageAssignment lists only the dinosaurs that aren't in a cage, or only those that are.
+	NeedsCageAssignment *bool
+	Quarantined         *bool
+	PageOptions
+}
+
+// CreateDinosaur adds a dinosaur that isn't in a cage. Its diet is that of its species.
+func (c *Client) CreateDinosaur(ctx context.Context, dinosaur models.Dinosaur) (*models.Dinosaur, error) {
+	created := &models.Dinosaur{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/dinosaurs", body: dinosaur}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetDinosaur reads a dinosaur by its name.
+func (c *Client) GetDinosaur(ctx context.Context, name string) (*models.Dinosaur, error) {
+	dinosaur := &models.Dinosaur{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/dinosaurs/" + escape(name)}, dinosaur)
+	if err != nil {
+		return nil, err
+	}
+	return dinosaur, nil
+}
+
+// UpdateDinosaur changes the fields of the dinosaur that are set in the update, such as its sex after it has
+// changed sex.
+func (c *Client) UpdateDinosaur(ctx context.Context, name string, update models.UpdateDinosaurRequest) (*models.Dinosaur, error) {
+	dinosaur := &models.Dinosaur{}
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: "/dinosaurs/" + escape(name), body: update}, dinosaur)
+	if err != nil {
+		return nil, err
+	}
+	return dinosaur, nil
+}
+
+// ListDinosaurs lists a page of the dinosaurs.
+func (c *Client) ListDinosaurs(ctx context.Context, options DinosaurListOptions) ([]models.Dinosaur, Page, error) {
+	query := url.Values{}
+	if options.Species != "" {
+		query.Set("species", options.Species)
+	}
+	if options.Sex != "" {
+		query.Set("sex", string(options.Sex))
+	}
+	if options.Diet != "" {
+		query.Set("diet", options.Diet)
+	}
+	if options.NeedsCageAssignment != nil {
+		query.Set("needsCageAssignment", strconv.FormatBool(*options.NeedsCageAssignment))
+	}
+	if options.Quarantined != nil {
+		query.Set("quarantined", strconv.FormatBool(*options.Quarantined))
+	}
+	options.PageOptions.setQuery(query)
+
+	dinosaurs := []models.Dinosaur{}
+	response, err := c.do(ctx, request{method: http.MethodGet, path: "/dinosaurs", query: query}, &dinosaurs)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return dinosaurs, pageOf(response), nil
+}
+
+// TransferDinosaur moves a dinosaur that is in a cage to another cage.
+func (c *Client) TransferDinosaur(ctx context.Context, name, cageLabel string) error {
+	body := models.TransferDinosaurRequest{Cage: cageLabel}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/dinosaurs/" + escape(name) + "/transfer", body: body}, nil)
+	return err
+}
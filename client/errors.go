@@ -0,0 +1,42 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// Error is an error response from the server. Problem is the problem details that the server responded with, which
+// is empty when the response wasn't a problem, such as a 404 for a path that doesn't exist.
+//
+// An Error wraps the error in models that its code reports, so errors.Is(err, models.CageCapacityExceeded) works
+// as it does on the server. The compatibility rules that a cage assignment would break and the invalid fields of a
+// request can be read with errors.As, as a *models.CompatibilityError and a *models.ValidationError.
+type Error struct {
+	StatusCode int
+	Problem    models.ErrorResponse
+}
+
+func (e *Error) Error() string {
+	if e.Problem.Code == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.Problem.Detail == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, e.Problem.Code)
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Problem.Code, e.Problem.Detail)
+}
+
+// Unwrap returns the error in models that the response reports, or nil if its code isn't reported for an error in
+// models.
+func (e *Error) Unwrap() error {
+	err := models.ErrorForCode(e.Problem.Code)
+	switch {
+	case err == models.IncompatibleSpecies && len(e.Problem.Violations) > 0:
+		return &models.CompatibilityError{Violations: e.Problem.Violations}
+	case err == models.InvalidRequest && len(e.Problem.Fields) > 0:
+		return &models.ValidationError{Fields: e.Problem.Fields}
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxEventSize is the largest event that can be read from the event stream, in bytes.
+const maxEventSize = 1 << 20
+
+// Event is a change to the park, read from the event stream. Data is the JSON of the entity that changed, which
+// can be decoded into the model for the type of event.
+type Event struct {
+	ID   uint64          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// StreamEvents streams changes to the park, calling handle with each event until ctx is canceled, handle returns an
+// error or the server ends the stream. A lastEventID other than zero resumes the stream after that event. The id
+// of the last event handled can be used to resume the stream after it ends. Canceling ctx returns ctx.Err().
+func (c *Client) StreamEvents(ctx context.Context, lastEventID uint64, handle func(event Event) error) error {
+	req := request{method: http.MethodGet, path: "/events"}
+	headers := http.Header{"Accept": {"text/event-stream"}}
+	if lastEventID != 0 {
+		headers.Set("Last-Event-ID", strconv.FormatUint(lastEventID, 10))
+	}
+	req.headers = headers
+	response, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventSize)
+	event := Event{}
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// a blank line ends the event, and events without data, such as keep-alives, are skipped
+			if len(data) > 0 {
+				event.Data = json.RawMessage(strings.Join(data, "\n"))
+				if err := handle(event); err != nil {
+					return err
+				}
+			}
+			event = Event{}
+			data = nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.ID, _ = strconv.ParseUint(value, 10, 64)
+		case "event":
+			event.Type = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// CreateFeed adds a feed and its stock.
+func (c *Client) CreateFeed(ctx context.Context, feed models.Feed) (*models.Feed, error) {
+	created := &models.Feed{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/feeding/feeds", body: feed}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListFeeds lists the feeds and their stock. With lowStock only the feeds that have to be restocked are listed.
+func (c *Client) ListFeeds(ctx context.Context, lowStock bool) ([]models.Feed, error) {
+	query := url.Values{}
+	if lowStock {
+		query.Set("lowStock", "true")
+	}
+	feeds := []models.Feed{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/feeding/feeds", query: query}, &feeds)
+	if err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+// RestockFeed adds the quantity to the stock of a feed.
+func (c *Client) RestockFeed(ctx context.Context, name string, quantity int) (*models.Feed, error) {
+	feed := &models.Feed{}
+	body := models.RestockRequest{Quantity: quantity}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/feeding/feeds/" + escape(name) + "/restock", body: body}, feed)
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// CreateFeedingSchedule schedules a daily feeding of a cage.
+func (c *Client) CreateFeedingSchedule(ctx context.Context, schedule models.FeedingSchedule) (*models.FeedingSchedule, error) {
+	created := &models.FeedingSchedule{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/feeding/schedules", body: schedule}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListFeedingSchedules lists the feeding schedules, optionally only those of a cage.
+func (c *Client) ListFeedingSchedules(ctx context.Context, cageLabel string) ([]models.FeedingSchedule, error) {
+	query := url.Values{}
+	if cageLabel != "" {
+		query.Set("cage", cageLabel)
+	}
+	schedules := []models.FeedingSchedule{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/feeding/schedules", query: query}, &schedules)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// DeleteFeedingSchedule deletes a feeding schedule.
+func (c *Client) DeleteFeedingSchedule(ctx context.Context, id int) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/feeding/schedules/" + strconv.Itoa(id)}, nil)
+	return err
+}
+
+// GetFeedingRoster lists the feedings due on a date, such as 2023-06-01, and the dinosaurs they feed. The date
+// defaults to today, and the cage, when given, lists only that cage's feedings.
+func (c *Client) GetFeedingRoster(ctx context.Context, date, cageLabel string) ([]models.RosterEntry, error) {
+	roster := []models.RosterEntry{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/feeding/roster", query: feedingQuery(date, cageLabel)}, &roster)
+	if err != nil {
+		return nil, err
+	}
+	return roster, nil
+}
+
+// RecordFeeding records that a scheduled feeding was made on a date, which defaults to today.
+func (c *Client) RecordFeeding(ctx context.Context, scheduleID int, date string) (*models.Feeding, error) {
+	recorded := &models.Feeding{}
+	body := models.RecordFeedingRequest{ScheduleID: scheduleID, Date: date}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/feeding/feedings", body: body}, recorded)
+	if err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
+
+// ListFeedings lists the feedings made on a date, which defaults to today, optionally only those of a cage.
+func (c *Client) ListFeedings(ctx context.Context, date, cageLabel string) ([]models.Feeding, error) {
+	feedings := []models.Feeding{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/feeding/feedings", query: feedingQuery(date, cageLabel)}, &feedings)
+	if err != nil {
+		return nil, err
+	}
+	return feedings, nil
+}
+
+func feedingQuery(date, cageLabel string) url.Values {
+	query := url.Values{}
+	if date != "" {
+		query.Set("date", date)
+	}
+	if cageLabel != "" {
+		query.Set("cage", cageLabel)
+	}
+	return query
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// healthRecordsPath is the path of a dinosaur's health records.
+func healthRecordsPath(dinosaurName string) string {
+	return "/dinosaurs/" + escape(dinosaurName) + "/health-records"
+}
+
+// CreateHealthRecord adds a record to the medical history of the dinosaur named in the record. The time defaults
+// to now.
+func (c *Client) CreateHealthRecord(ctx context.Context, record models.HealthRecord) (*models.HealthRecord, error) {
+	created := &models.HealthRecord{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: healthRecordsPath(record.Dinosaur), body: record}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetHealthRecord reads one of a dinosaur's health records.
+func (c *Client) GetHealthRecord(ctx context.Context, dinosaurName string, id int) (*models.HealthRecord, error) {
+	record := &models.HealthRecord{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: healthRecordsPath(dinosaurName) + "/" + strconv.Itoa(id)}, record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ListHealthRecords lists a dinosaur's medical history, oldest first, optionally only the records of a type.
+func (c *Client) ListHealthRecords(ctx context.Context, dinosaurName string, recordType models.HealthRecordType) ([]models.HealthRecord, error) {
+	query := url.Values{}
+	if recordType != "" {
+		query.Set("type", string(recordType))
+	}
+	records := []models.HealthRecord{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: healthRecordsPath(dinosaurName), query: query}, &records)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// UpdateHealthRecord replaces the type, time and details of the health record with the record's id.
+func (c *Client) UpdateHealthRecord(ctx context.Context, record models.HealthRecord) (*models.HealthRecord, error) {
+	updated := &models.HealthRecord{}
+	path := healthRecordsPath(record.Dinosaur) + "/" + strconv.Itoa(record.ID)
+	_, err := c.do(ctx, request{method: http.MethodPut, path: path, body: record}, updated)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteHealthRecord deletes one of a dinosaur's health records.
+func (c *Client) DeleteHealthRecord(ctx context.Context, dinosaurName string, id int) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: healthRecordsPath(dinosaurName) + "/" + strconv.Itoa(id)}, nil)
+	return err
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// inventoryContentTypes are the content types of import and export files in each format.
+var inventoryContentTypes = map[models.InventoryFormat]string{
+	models.InventoryFormatCSV:    "text/csv",
+	models.InventoryFormatNDJSON: "application/x-ndjson",
+}
+
+// Import adds the zones, cages, dinosaurs and cage assignments in a CSV or NDJSON file, and reports the outcome of
+// each row. The mode defaults to atomic. The report is returned even when rows failed, so the caller should check
+// whether it was committed.
+func (c *Client) Import(ctx context.Context, format models.InventoryFormat, file io.Reader, options models.ImportOptions) (*models.ImportReport, error) {
+	query := url.Values{}
+	query.Set("format", string(format))
+	if options.Mode != "" {
+		query.Set("mode", string(options.Mode))
+	}
+	if options.DryRun {
+		query.Set("dryRun", "true")
+	}
+	report := &models.ImportReport{}
+	req := request{
+		method:      http.MethodPost,
+		path:        "/import",
+		query:       query,
+		body:        file,
+		contentType: inventoryContentTypes[format],
+	}
+	_, err := c.do(ctx, req, report)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Export writes every cage, dinosaur and cage assignment to w as a CSV or NDJSON file that can be imported. The
+// file is copied as it is streamed, so w may have been written to when an error is returned.
+func (c *Client) Export(ctx context.Context, format models.InventoryFormat, w io.Writer) error {
+	query := url.Values{}
+	query.Set("format", string(format))
+	response, err := c.send(ctx, request{method: http.MethodGet, path: "/export", query: query})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, err = io.Copy(w, response.Body)
+	return err
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy is how the client retries requests that fail in a way that might not last, which is when the server
+// can't be reached or responds with 429, 502, 503 or 504. Only requests with an idempotent method, such as GET,
+// PUT and DELETE, are retried, since a POST or PATCH that failed might still have been applied.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a request is sent, including the first. 1 or less doesn't retry.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry. The wait doubles after every retry, up to MaxBackoff, and a
+	// random part of it is taken off so that clients that failed together don't retry together. A Retry-After
+	// header overrides the wait, up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of a client that isn't given one.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  100 * time.Millisecond,
+	MaxBackoff:  2 * time.Second,
+}
+
+// retryStatuses are the statuses of responses that are retried.
+var retryStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// idempotentMethods are the methods of requests that are retried.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// shouldRetry reports whether an attempt at a request that got the response or error should be retried.
+func (p RetryPolicy) shouldRetry(ctx context.Context, method string, attempt int, response *http.Response, err error) bool {
+	if attempt >= p.MaxAttempts || !idempotentMethods[method] || ctx.Err() != nil {
+		return false
+	}
+	return err != nil || retryStatuses[response.StatusCode]
+}
+
+// backoff is the wait before retrying after the attempt.
+func (p RetryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, p.MaxBackoff)
+		}
+	}
+	wait := p.MinBackoff
+	for i := 1; i < attempt && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, p.MaxBackoff)
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// sleep waits for the duration, returning early with the error of the context if it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
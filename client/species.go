@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// CreateSpecies adds a species of dinosaur.
+func (c *Client) CreateSpecies(ctx context.Context, species models.Species) (*models.Species, error) {
+	created := &models.Species{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/species", body: species}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetSpecies reads a species by its name.
+func (c *Client) GetSpecies(ctx context.Context, name string) (*models.Species, error) {
+	species := &models.Species{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/species/" + escape(name)}, species)
+	if err != nil {
+		return nil, err
+	}
+	return species, nil
+}
+
+// ListSpecies lists every species.
+func (c *Client) ListSpecies(ctx context.Context) ([]models.Species, error) {
+	species := []models.Species{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/species"}, &species)
+	if err != nil {
+		return nil, err
+	}
+	return species, nil
+}
+
+// UpdateSpecies changes the diet of a species. The species is identified by its name.
+func (c *Client) UpdateSpecies(ctx context.Context, species models.Species) (*models.Species, error) {
+	updated := &models.Species{}
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/species/" + escape(species.Name), body: species}, updated)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteSpecies deletes a species that no dinosaur belongs to.
+func (c *Client) DeleteSpecies(ctx context.Context, name string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/species/" + escape(name)}, nil)
+	return err
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// CreateWebhook subscribes a URL to events. The secret, generated when none is given, is only returned here.
+func (c *Client) CreateWebhook(ctx context.Context, webhook models.Webhook) (*models.Webhook, error) {
+	created := &models.Webhook{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/webhooks", body: webhook}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetWebhook reads a webhook, without its secret.
+func (c *Client) GetWebhook(ctx context.Context, id int) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/webhooks/" + strconv.Itoa(id)}, webhook)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// ListWebhooks lists every webhook, without their secrets.
+func (c *Client) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/webhooks"}, &webhooks)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook unsubscribes a webhook.
+func (c *Client) DeleteWebhook(ctx context.Context, id int) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/webhooks/" + strconv.Itoa(id)}, nil)
+	return err
+}
+
+// ListWebhookDeliveries lists a page of the deliveries made to a webhook, oldest first, optionally only those with
+// a status.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id int, status models.DeliveryStatus, page PageOptions) ([]models.WebhookDelivery, Page, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", string(status))
+	}
+	page.setQuery(query)
+	deliveries := []models.WebhookDelivery{}
+	response, err := c.do(ctx, request{method: http.MethodGet, path: "/webhooks/" + strconv.Itoa(id) + "/deliveries", query: query}, &deliveries)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return deliveries, pageOf(response), nil
+}
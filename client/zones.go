@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// CreateZone adds a zone, nested in its parent if it has one.
+func (c *Client) CreateZone(ctx context.Context, zone models.Zone) (*models.Zone, error) {
+	created := &models.Zone{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/zones", body: zone}, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetZone reads a zone by its name, with the totals for the cages in it.
+func (c *Client) GetZone(ctx context.Context, name string) (*models.ZoneSummary, error) {
+	summary := &models.ZoneSummary{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/zones/" + escape(name)}, summary)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ListZones lists every zone, with the totals for the cages in each.
+func (c *Client) ListZones(ctx context.Context) ([]models.ZoneSummary, error) {
+	summaries := []models.ZoneSummary{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/zones"}, &summaries)
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// UpdateZone changes the fields of the zone that are set in the update.
+func (c *Client) UpdateZone(ctx context.Context, name string, update models.UpdateZoneRequest) (*models.Zone, error) {
+	zone := &models.Zone{}
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: "/zones/" + escape(name), body: update}, zone)
+	if err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// DeleteZone deletes a zone that has no cages or zones in it.
+func (c *Client) DeleteZone(ctx context.Context, name string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/zones/" + escape(name)}, nil)
+	return err
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/client"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func webhooksList(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	webhooks, err := a.client.ListWebhooks(a.ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(webhooks, func() table { return webhookTable(webhooks...) })
+}
+
+func webhookTable(webhooks ...models.Webhook) table {
+	t := table{header: []string{"ID", "URL", "EVENTS", "CREATED"}}
+	for _, webhook := range webhooks {
+		t.add(itoa(webhook.ID), webhook.URL, strings.Join(webhook.Events, ","), formatTime(&webhook.CreatedTime))
+	}
+	return t
+}
+
+func webhooksGet(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	webhook, err := a.client.GetWebhook(a.ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.print(webhook, func() table { return webhookTable(*webhook) })
+}
+
+func webhooksCreate(a *app, args []string) error {
+	fs := a.flags()
+	webhook := models.Webhook{}
+	fs.Func("events", "the comma separated `types` of event to subscribe to, such as cage.created (required)", func(value string) error {
+		webhook.Events = strings.Split(value, ",")
+		return nil
+	})
+	fs.StringVar(&webhook.Secret, "secret", "", "the `secret` that deliveries are signed with, generated by default")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	webhook.URL = positional[0]
+	created, err := a.client.CreateWebhook(a.ctx, webhook)
+	if err != nil {
+		return err
+	}
+	if err := a.print(created, func() table { return webhookTable(*created) }); err != nil {
+		return err
+	}
+	a.printMessage("deliveries are signed with the secret %s, which is only shown this once", created.Secret)
+	return nil
+}
+
+func webhooksDelete(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteWebhook(a.ctx, id); err != nil {
+		return err
+	}
+	a.printMessage("deleted webhook %d", id)
+	return nil
+}
+
+func webhooksDeliveries(a *app, args []string) error {
+	fs := a.flags()
+	status := fs.String("status", "", "lists only the deliveries with the `status`: PENDING, DELIVERED or DEAD_LETTERED")
+	page := pageFlags(fs)
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	deliveries, pageInfo, err := a.client.ListWebhookDeliveries(a.ctx, id, models.DeliveryStatus(*status), *page)
+	if err != nil {
+		return err
+	}
+	err = a.print(deliveries, func() table {
+		t := table{header: []string{"ID", "EVENT", "TYPE", "STATUS", "ATTEMPTS", "RESPONSE", "LAST ERROR", "CREATED"}}
+		for _, delivery := range deliveries {
+			response := "-"
+			if delivery.ResponseStatus != nil {
+				response = itoa(*delivery.ResponseStatus)
+			}
+			t.add(itoa(delivery.ID), fmt.Sprint(delivery.EventID), delivery.EventType, string(delivery.Status), itoa(delivery.Attempts),
+				response, orDash(delivery.LastError), formatTime(&delivery.CreatedTime))
+		}
+		return t
+	})
+	if err != nil {
+		return err
+	}
+	a.printPage(pageInfo)
+	return nil
+}
+
+func apiKeysList(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	apiKeys, err := a.client.ListAPIKeys(a.ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(apiKeys, func() table { return apiKeyTable(apiKeys...) })
+}
+
+func apiKeyTable(apiKeys ...models.APIKey) table {
+	t := table{header: []string{"ID", "NAME", "PREFIX", "ROLES", "CREATED", "REVOKED"}}
+	for _, apiKey := range apiKeys {
+		roles := []string{}
+		for _, role := range apiKey.Roles {
+			roles = append(roles, string(role))
+		}
+		t.add(itoa(apiKey.ID), apiKey.Name, apiKey.Prefix, strings.Join(roles, ","), formatTime(&apiKey.CreatedTime), formatTime(apiKey.RevokedTime))
+	}
+	return t
+}
+
+func apiKeysCreate(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, -1)
+	if err != nil {
+		return err
+	}
+	roles := []models.Role{}
+	for _, role := range positional[1:] {
+		roles = append(roles, models.Role(role))
+	}
+	created, err := a.client.CreateAPIKey(a.ctx, positional[0], roles...)
+	if err != nil {
+		return err
+	}
+	if err := a.print(created, func() table { return apiKeyTable(*created) }); err != nil {
+		return err
+	}
+	a.printMessage("the key is %s, which is only shown this once", created.Key)
+	return nil
+}
+
+func apiKeysRevoke(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := a.client.RevokeAPIKey(a.ctx, id); err != nil {
+		return err
+	}
+	a.printMessage("revoked API key %d", id)
+	return nil
+}
+
+func audit(a *app, args []string) error {
+	fs := a.flags()
+	options := client.AuditListOptions{}
+	fs.StringVar(&options.Entity, "entity", "", "lists only the changes to the `entity`, such as cage:C-1")
+	fs.Func("since", "lists only the changes made since the RFC 3339 `time`", func(value string) error {
+		since, err := time.Parse(time.RFC3339, value)
+		options.Since = &since
+		return err
+	})
+	page := pageFlags(fs)
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	options.PageOptions = *page
+
+	auditEvents, pageInfo, err := a.client.ListAuditEvents(a.ctx, options)
+	if err != nil {
+		return err
+	}
+	err = a.print(auditEvents, func() table {
+		t := table{header: []string{"ID", "TIME", "ACTOR", "ACTION", "ENTITY"}}
+		for _, auditEvent := range auditEvents {
+			t.add(itoa(auditEvent.ID), formatTime(&auditEvent.Time), auditEvent.Actor, string(auditEvent.Action), auditEvent.Entity)
+		}
+		return t
+	})
+	if err != nil {
+		return err
+	}
+	a.printPage(pageInfo)
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/client"
+)
+
+// The output formats of jpctl.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// app is a command being run, along with the flags that every command takes and the client it connects with.
+type app struct {
+	ctx     context.Context
+	command command
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+
+	configFile string
+	endpoint   string
+	apiKey     string
+	token      string
+	output     string
+	client     *client.Client
+}
+
+// flags returns the flag set of the command, with the flags that every command takes.
+func (a *app) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("jpctl "+a.command.name, flag.ContinueOnError)
+	// errors are returned rather than printed, and the usage is printed by parse when it is asked for
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	fs.StringVar(&a.configFile, "config", "", "the config `file`, rather than $JPCTL_CONFIG or jpctl/config.yaml in the user config directory")
+	fs.StringVar(&a.endpoint, "endpoint", "", "the `URL` of the server, such as http://localhost:8080")
+	fs.StringVar(&a.apiKey, "api-key", "", "the API `key` to authenticate with")
+	fs.StringVar(&a.token, "token", "", "the bearer `token` to authenticate with")
+	fs.StringVar(&a.output, "o", "", "the output `format`: table, json or yaml")
+	fs.StringVar(&a.output, "output", "", "the output `format`: table, json or yaml")
+	return fs
+}
+
+// parse parses args, in which the flags can come before, between or after the positional arguments, and connects
+// to the server. It returns the positional arguments, of which there must be between min and max, where a max of -1
+// is no limit.
+func (a *app) parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				a.printCommandUsage(fs)
+				return nil, err
+			}
+			return nil, usageErrorf("%v, run jpctl %s -h to list its flags", err, a.command.name)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		return nil, usageErrorf("usage: jpctl %s", strings.TrimSpace(a.command.name+" "+a.command.args))
+	}
+	return positional, a.connect()
+}
+
+func (a *app) printCommandUsage(fs *flag.FlagSet) {
+	fmt.Fprintf(a.stdout, "usage: jpctl %s [flags]\n", strings.TrimSpace(a.command.name+" "+a.command.args))
+	fmt.Fprintf(a.stdout, "%s\n\nflags:\n", a.command.summary)
+	fs.SetOutput(a.stdout)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
+}
+
+// connect creates the client from the config file, which the environment and then the flags override.
+func (a *app) connect() error {
+	cfg, err := loadConfig(a.configFile)
+	if err != nil {
+		return err
+	}
+	if a.endpoint != "" {
+		cfg.Endpoint = a.endpoint
+	}
+	if a.apiKey != "" || a.token != "" {
+		cfg.APIKey = a.apiKey
+		cfg.Token = a.token
+	}
+	if a.output == "" {
+		a.output = cfg.Output
+	}
+	if a.output == "" {
+		a.output = outputTable
+	}
+	if a.output != outputTable && a.output != outputJSON && a.output != outputYAML {
+		return usageErrorf("the output format must be %s, %s or %s", outputTable, outputJSON, outputYAML)
+	}
+
+	options := []client.Option{}
+	if cfg.APIKey != "" {
+		options = append(options, client.WithAPIKey(cfg.APIKey))
+	} else if cfg.Token != "" {
+		options = append(options, client.WithBearerToken(cfg.Token))
+	}
+	a.client = client.New(cfg.Endpoint, options...)
+	return nil
+}
+
+// pageFlags adds the flags that select a page of a list.
+func pageFlags(fs *flag.FlagSet) *client.PageOptions {
+	page := &client.PageOptions{}
+	fs.IntVar(&page.Limit, "limit", 0, "the largest `number` of items to list")
+	fs.StringVar(&page.Sort, "sort", "", "the `field` to sort on, with a leading - to sort in descending order")
+	fs.StringVar(&page.Cursor, "cursor", "", "continues the list from the `cursor` printed with the previous page")
+	fs.BoolVar(&page.IncludeTotal, "total", false, "prints the number of items across all pages")
+	return page
+}
+
+// printPage prints how to list the next page, and the total when it was asked for. They are printed to stderr, so
+// that they don't get mixed up with the list.
+func (a *app) printPage(page client.Page) {
+	if page.Total != nil {
+		fmt.Fprintf(a.stderr, "total: %d\n", *page.Total)
+	}
+	if page.Next != "" {
+		fmt.Fprintf(a.stderr, "more items can be listed with --cursor %s\n", page.Next)
+	}
+}
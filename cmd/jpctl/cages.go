@@ -0,0 +1,182 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/client"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func cagesList(a *app, args []string) error {
+	fs := a.flags()
+	options := client.CageListOptions{}
+	powered := fs.Bool("powered", false, "lists only the cages with power, in any status other than DOWN")
+	unpowered := fs.Bool("unpowered", false, "lists only the cages without power")
+	fs.Func("status", "lists only the cages in the power `status`", func(value string) error {
+		options.PowerStatus = models.PowerStatus(value)
+		return nil
+	})
+	fs.StringVar(&options.Zone, "zone", "", "lists only the cages in the `zone` and in the zones nested in it")
+	fs.StringVar(&options.CanHouse, "can-house", "", "ranks the cages that the `dinosaur` could be added to")
+	page := pageFlags(fs)
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *powered && *unpowered {
+		return usageErrorf("--powered and --unpowered can't both be given")
+	}
+	if *powered || *unpowered {
+		options.HasPower = powered
+	}
+	options.PageOptions = *page
+
+	cages, pageInfo, err := a.client.ListCages(a.ctx, options)
+	if err != nil {
+		return err
+	}
+	if err := a.print(cages, func() table { return cageTable(cages...) }); err != nil {
+		return err
+	}
+	a.printPage(pageInfo)
+	return nil
+}
+
+func cageTable(cages ...models.CageV2) table {
+	t := table{header: []string{"LABEL", "POWER", "OCCUPANCY", "ZONE"}}
+	for _, cage := range cages {
+		t.add(cage.Label, string(cage.PowerStatus), itoa(cage.Occupancy)+"/"+itoa(cage.MaxOccupancy), optional(cage.Zone))
+	}
+	return t
+}
+
+func cagesGet(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	cage, err := a.client.GetCage(a.ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return a.print(cage, func() table { return cageTable(*cage) })
+}
+
+func cagesCreate(a *app, args []string) error {
+	fs := a.flags()
+	cage := models.CageV2{PowerStatus: models.PowerStatusActive}
+	fs.IntVar(&cage.MaxOccupancy, "max-occupancy", 0, "the largest `number` of dinosaurs the cage can hold (required)")
+	fs.Func("status", "the power `status`, ACTIVE by default", func(value string) error {
+		cage.PowerStatus = models.PowerStatus(value)
+		return nil
+	})
+	fs.Func("zone", "the `zone` the cage is in", func(value string) error {
+		cage.Zone = &value
+		return nil
+	})
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if cage.MaxOccupancy <= 0 {
+		return usageErrorf("--max-occupancy must be greater than 0")
+	}
+	cage.Label = positional[0]
+
+	created, err := a.client.CreateCage(a.ctx, cage)
+	if err != nil {
+		return err
+	}
+	return a.print(created, func() table { return cageTable(*created) })
+}
+
+func cagesUpdate(a *app, args []string) error {
+	fs := a.flags()
+	update := models.UpdateCageV2Request{}
+	fs.Func("label", "the new `label` of the cage", func(value string) error {
+		update.Label = &value
+		return nil
+	})
+	fs.Func("max-occupancy", "the largest `number` of dinosaurs the cage can hold", func(value string) error {
+		maxOccupancy, err := strconv.Atoi(value)
+		update.MaxOccupancy = &maxOccupancy
+		return err
+	})
+	fs.Func("zone", "the `zone` the cage is moved to, or an empty zone to take it out of its zone", func(value string) error {
+		update.Zone = &value
+		return nil
+	})
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if update.Label == nil && update.MaxOccupancy == nil && update.Zone == nil {
+		return usageErrorf("at least one of --label, --max-occupancy and --zone must be given")
+	}
+	cage, err := a.client.UpdateCage(a.ctx, positional[0], update)
+	if err != nil {
+		return err
+	}
+	return a.print(cage, func() table { return cageTable(*cage) })
+}
+
+func cagesDelete(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteCage(a.ctx, positional[0]); err != nil {
+		return err
+	}
+	a.printMessage("deleted cage %s", positional[0])
+	return nil
+}
+
+func cagesDinosaurs(a *app, args []string) error {
+	fs := a.flags()
+	page := pageFlags(fs)
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	dinosaurs, pageInfo, err := a.client.ListDinosaursInCage(a.ctx, positional[0], *page)
+	if err != nil {
+		return err
+	}
+	if err := a.print(dinosaurs, func() table { return dinosaurTable(dinosaurs...) }); err != nil {
+		return err
+	}
+	a.printPage(pageInfo)
+	return nil
+}
+
+func powerOn(a *app, args []string) error {
+	return setPower(a, args, models.PowerStatusActive)
+}
+
+func powerOff(a *app, args []string) error {
+	return setPower(a, args, models.PowerStatusDown)
+}
+
+func powerSet(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return changePower(a, positional[0], models.PowerStatus(positional[1]))
+}
+
+func setPower(a *app, args []string, powerStatus models.PowerStatus) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	return changePower(a, positional[0], powerStatus)
+}
+
+func changePower(a *app, label string, powerStatus models.PowerStatus) error {
+	cage, err := a.client.SetPowerStatus(a.ctx, label, powerStatus)
+	if err != nil {
+		return err
+	}
+	return a.print(cage, func() table { return cageTable(*cage) })
+}
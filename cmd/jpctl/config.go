@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultEndpoint is the address that the server listens on when it is run locally.
+const defaultEndpoint = "http://localhost:8080"
+
+// config is the config file, which holds the endpoint of the server and the credentials to use with it, as in
+//
+//	endpoint: https://park.example.com
+//	apiKey: jpk_...
+//	output: table
+//
+// The API key is used when both an API key and a token are set.
+type config struct {
+	Endpoint string `yaml:"endpoint"`
+	APIKey   string `yaml:"apiKey"`
+	Token    string `yaml:"token"`
+	Output   string `yaml:"output"`
+}
+
+// configPath is the path of the config file, which is $JPCTL_CONFIG or jpctl/config.yaml in the user's config
+// directory, such as ~/.config on Linux.
+func configPath() (string, error) {
+	if path := os.Getenv("JPCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jpctl", "config.yaml"), nil
+}
+
+// loadConfig reads the config file at path, or at the default path when path is empty. The file at the default path
+// doesn't have to exist. The environment variables JPCTL_ENDPOINT, JPCTL_API_KEY and JPCTL_TOKEN override the file.
+func loadConfig(path string) (config, error) {
+	cfg := config{}
+	explicit := path != ""
+	if !explicit {
+		var err error
+		if path, err = configPath(); err != nil {
+			return config{}, err
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil && (explicit || !errors.Is(err, fs.ErrNotExist)) {
+		return config{}, err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(content, &cfg); err != nil {
+			return config{}, fmt.Errorf("reading the config file %s: %w", path, err)
+		}
+	}
+
+	if endpoint := os.Getenv("JPCTL_ENDPOINT"); endpoint != "" {
+		cfg.Endpoint = endpoint
+	}
+	if apiKey := os.Getenv("JPCTL_API_KEY"); apiKey != "" {
+		cfg.APIKey = apiKey
+	}
+	if token := os.Getenv("JPCTL_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaultEndpoint
+	}
+	return cfg, nil
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/client"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func dinoList(a *app, args []string) error {
+	fs := a.flags()
+	options := client.DinosaurListOptions{}
+	needsCage := fs.Bool("needs-cage", false, "lists only the dinosaurs that aren't in a cage")
+	caged := fs.Bool("caged", false, "lists only the dinosaurs that are in a cage")
+	quarantined := fs.Bool("quarantined", false, "lists only the dinosaurs in quarantine")
+	fs.StringVar(&options.Species, "species", "", "lists only the dinosaurs of the `species`")
+	fs.Func("sex", "lists only the dinosaurs of the `sex`, Female or Male", func(value string) error {
+		options.Sex = models.Sex(value)
+		return nil
+	})
+	fs.StringVar(&options.Diet, "diet", "", "lists only the dinosaurs with the `diet`")
+	page := pageFlags(fs)
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *needsCage && *caged {
+		return usageErrorf("--needs-cage and --caged can't both be given")
+	}
+	if *needsCage || *caged {
+		options.NeedsCageAssignment = needsCage
+	}
+	if *quarantined {
+		options.Quarantined = quarantined
+	}
+	options.PageOptions = *page
+
+	dinosaurs, pageInfo, err := a.client.ListDinosaurs(a.ctx, options)
+	if err != nil {
+		return err
+	}
+	if err := a.print(dinosaurs, func() table { return dinosaurTable(dinosaurs...) }); err != nil {
+		return err
+	}
+	a.printPage(pageInfo)
+	return nil
+}
+
+func dinosaurTable(dinosaurs ...models.Dinosaur) table {
+	t := table{header: []string{"NAME", "SPECIES", "SEX", "DIET", "CAGE", "QUARANTINED"}}
+	for _, dinosaur := range dinosaurs {
+		t.add(dinosaur.Name, dinosaur.Species, orDash(string(dinosaur.Sex)), dinosaur.Diet, optional(dinosaur.Cage), yesNo(dinosaur.Quarantined))
+	}
+	return t
+}
+
+func dinoGet(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	dinosaur, err := a.client.GetDinosaur(a.ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return a.print(dinosaur, func() table { return dinosaurTable(*dinosaur) })
+}
+
+func dinoAdd(a *app, args []string) error {
+	fs := a.flags()
+	dinosaur := models.Dinosaur{}
+	fs.StringVar(&dinosaur.Species, "species", "", "the `species` of the dinosaur (required)")
+	fs.Func("sex", "the `sex` of the dinosaur, Female by default", func(value string) error {
+		dinosaur.Sex = models.Sex(value)
+		return nil
+	})
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if dinosaur.Species == "" {
+		return usageErrorf("--species must be given")
+	}
+	dinosaur.Name = positional[0]
+
+	created, err := a.client.CreateDinosaur(a.ctx, dinosaur)
+	if err != nil {
+		return err
+	}
+	return a.print(created, func() table { return dinosaurTable(*created) })
+}
+
+func dinoAssign(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if err := a.client.AddDinosaurToCage(a.ctx, positional[1], positional[0]); err != nil {
+		return err
+	}
+	a.printMessage("put %s in cage %s", positional[0], positional[1])
+	return nil
+}
+
+func dinoUnassign(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	name := positional[0]
+	dinosaur, err := a.client.GetDinosaur(a.ctx, name)
+	if err != nil {
+		return err
+	}
+	if dinosaur.Cage == nil {
+		a.printMessage("%s is not in a cage", name)
+		return nil
+	}
+	if err := a.client.RemoveDinosaurFromCage(a.ctx, *dinosaur.Cage, name); err != nil {
+		return err
+	}
+	a.printMessage("took %s out of cage %s", name, *dinosaur.Cage)
+	return nil
+}
+
+func dinoTransfer(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if err := a.client.TransferDinosaur(a.ctx, positional[0], positional[1]); err != nil {
+		return err
+	}
+	a.printMessage("moved %s to cage %s", positional[0], positional[1])
+	return nil
+}
+
+func dinoSex(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	sex := models.Sex(positional[1])
+	dinosaur, err := a.client.UpdateDinosaur(a.ctx, positional[0], models.UpdateDinosaurRequest{Sex: &sex})
+	if err != nil {
+		return err
+	}
+	return a.print(dinosaur, func() table { return dinosaurTable(*dinosaur) })
+}
+
+func healthList(a *app, args []string) error {
+	fs := a.flags()
+	recordType := fs.String("type", "", "lists only the records of the `type`, such as weight")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	records, err := a.client.ListHealthRecords(a.ctx, positional[0], models.HealthRecordType(*recordType))
+	if err != nil {
+		return err
+	}
+	return a.print(records, func() table { return healthRecordTable(records...) })
+}
+
+func healthRecordTable(records ...models.HealthRecord) table {
+	t := table{header: []string{"ID", "TYPE", "TIME", "DETAILS", "NOTES", "RECORDED BY"}}
+	for _, record := range records {
+		details := "-"
+		if record.WeightKg != nil {
+			details = strconv.FormatFloat(*record.WeightKg, 'f', -1, 64) + " kg"
+		} else if record.Medication != "" {
+			details = record.Medication + " " + record.Dosage
+		}
+		t.add(itoa(record.ID), string(record.Type), formatTime(&record.Time), details, orDash(record.Notes), record.RecordedBy)
+	}
+	return t
+}
+
+func healthGet(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[1])
+	if err != nil {
+		return err
+	}
+	record, err := a.client.GetHealthRecord(a.ctx, positional[0], id)
+	if err != nil {
+		return err
+	}
+	return a.print(record, func() table { return healthRecordTable(*record) })
+}
+
+// healthRecordFlags adds the flags that set the fields of a health record.
+func healthRecordFlags(fs *flag.FlagSet, record *models.HealthRecord) {
+	fs.Func("type", "the `type` of record: weight, examination, treatment, medication, quarantine or release (required)", func(value string) error {
+		record.Type = models.HealthRecordType(value)
+		return nil
+	})
+	fs.Func("time", "when it happened, as an RFC 3339 `time`, now by default", func(value string) error {
+		var err error
+		record.Time, err = time.Parse(time.RFC3339, value)
+		return err
+	})
+	fs.Func("weight-kg", "the `weight` of the dinosaur, on weight records", func(value string) error {
+		weight, err := strconv.ParseFloat(value, 64)
+		record.WeightKg = &weight
+		return err
+	})
+	fs.StringVar(&record.Medication, "medication", "", "the `medication` given, on medication records")
+	fs.StringVar(&record.Dosage, "dosage", "", "the `dosage` of the medication")
+	fs.StringVar(&record.Notes, "notes", "", "`notes` on the record")
+}
+
+func healthAdd(a *app, args []string) error {
+	fs := a.flags()
+	record := models.HealthRecord{}
+	healthRecordFlags(fs, &record)
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	record.Dinosaur = positional[0]
+	created, err := a.client.CreateHealthRecord(a.ctx, record)
+	if err != nil {
+		return err
+	}
+	return a.print(created, func() table { return healthRecordTable(*created) })
+}
+
+func healthUpdate(a *app, args []string) error {
+	fs := a.flags()
+	record := models.HealthRecord{}
+	healthRecordFlags(fs, &record)
+	positional, err := a.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	record.Dinosaur = positional[0]
+	if record.ID, err = parseID(positional[1]); err != nil {
+		return err
+	}
+	updated, err := a.client.UpdateHealthRecord(a.ctx, record)
+	if err != nil {
+		return err
+	}
+	return a.print(updated, func() table { return healthRecordTable(*updated) })
+}
+
+func healthDelete(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[1])
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteHealthRecord(a.ctx, positional[0], id); err != nil {
+		return err
+	}
+	a.printMessage("deleted health record %d of %s", id, positional[0])
+	return nil
+}
+
+// parseID reads the id of a health record, feeding schedule, webhook or API key.
+func parseID(value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, usageErrorf("the id must be a number, not %s", value)
+	}
+	return id, nil
+}
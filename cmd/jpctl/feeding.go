@@ -0,0 +1,187 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func feedsList(a *app, args []string) error {
+	fs := a.flags()
+	lowStock := fs.Bool("low-stock", false, "lists only the feeds that have to be restocked")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	feeds, err := a.client.ListFeeds(a.ctx, *lowStock)
+	if err != nil {
+		return err
+	}
+	return a.print(feeds, func() table { return feedTable(feeds...) })
+}
+
+func feedTable(feeds ...models.Feed) table {
+	t := table{header: []string{"NAME", "DIET", "STOCK", "LOW STOCK THRESHOLD"}}
+	for _, feed := range feeds {
+		t.add(feed.Name, feed.Diet, itoa(feed.Stock)+" "+feed.Unit, itoa(feed.LowStockThreshold)+" "+feed.Unit)
+	}
+	return t
+}
+
+func feedsCreate(a *app, args []string) error {
+	fs := a.flags()
+	feed := models.Feed{}
+	fs.StringVar(&feed.Diet, "diet", "", "the `diet` that the feed is for, Carnivore or Herbivore (required)")
+	fs.StringVar(&feed.Unit, "unit", "", "the `unit` that the feed is measured in, such as kg (required)")
+	fs.IntVar(&feed.Stock, "stock", 0, "the `quantity` in stock")
+	fs.IntVar(&feed.LowStockThreshold, "low-stock-threshold", 0, "the `quantity` at which the feed has to be restocked")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	feed.Name = positional[0]
+	created, err := a.client.CreateFeed(a.ctx, feed)
+	if err != nil {
+		return err
+	}
+	return a.print(created, func() table { return feedTable(*created) })
+}
+
+func feedsRestock(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	quantity, err := strconv.Atoi(positional[1])
+	if err != nil {
+		return usageErrorf("the quantity must be a number, not %s", positional[1])
+	}
+	feed, err := a.client.RestockFeed(a.ctx, positional[0], quantity)
+	if err != nil {
+		return err
+	}
+	return a.print(feed, func() table { return feedTable(*feed) })
+}
+
+func schedulesList(a *app, args []string) error {
+	fs := a.flags()
+	cage := fs.String("cage", "", "lists only the schedules of the `cage`")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	schedules, err := a.client.ListFeedingSchedules(a.ctx, *cage)
+	if err != nil {
+		return err
+	}
+	return a.print(schedules, func() table { return scheduleTable(schedules...) })
+}
+
+func scheduleTable(schedules ...models.FeedingSchedule) table {
+	t := table{header: []string{"ID", "CAGE", "FEED", "TIME", "QUANTITY"}}
+	for _, schedule := range schedules {
+		t.add(itoa(schedule.ID), schedule.Cage, schedule.Feed, schedule.Time, itoa(schedule.Quantity))
+	}
+	return t
+}
+
+func schedulesCreate(a *app, args []string) error {
+	fs := a.flags()
+	schedule := models.FeedingSchedule{}
+	fs.StringVar(&schedule.Cage, "cage", "", "the `cage` that is fed (required)")
+	fs.StringVar(&schedule.Feed, "feed", "", "the `feed` it is fed (required)")
+	fs.StringVar(&schedule.Time, "time", "", "the 24 hour `time` it is fed at, such as 06:00 (required)")
+	fs.IntVar(&schedule.Quantity, "quantity", 0, "the `quantity` of feed for each dinosaur in the cage (required)")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if schedule.Cage == "" || schedule.Feed == "" {
+		return usageErrorf("--cage and --feed must be given")
+	}
+	created, err := a.client.CreateFeedingSchedule(a.ctx, schedule)
+	if err != nil {
+		return err
+	}
+	return a.print(created, func() table { return scheduleTable(*created) })
+}
+
+func schedulesDelete(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteFeedingSchedule(a.ctx, id); err != nil {
+		return err
+	}
+	a.printMessage("deleted feeding schedule %d", id)
+	return nil
+}
+
+func roster(a *app, args []string) error {
+	fs := a.flags()
+	date := fs.String("date", "", "the `date` of the roster, such as 2023-06-01, today by default")
+	cage := fs.String("cage", "", "lists only the feedings of the `cage`")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	entries, err := a.client.GetFeedingRoster(a.ctx, *date, *cage)
+	if err != nil {
+		return err
+	}
+	return a.print(entries, func() table {
+		t := table{header: []string{"SCHEDULE", "TIME", "CAGE", "FEED", "QUANTITY", "DINOSAURS", "FED"}}
+		for _, entry := range entries {
+			fed := "no"
+			if entry.Feeding != nil {
+				fed = formatTime(&entry.Feeding.FedTime)
+			}
+			t.add(itoa(entry.ScheduleID), entry.Time, entry.Cage, entry.Feed, itoa(entry.Quantity)+" "+entry.Unit,
+				orDash(strings.Join(entry.Dinosaurs, ",")), fed)
+		}
+		return t
+	})
+}
+
+func feedingsList(a *app, args []string) error {
+	fs := a.flags()
+	date := fs.String("date", "", "the `date` the feedings were made on, such as 2023-06-01, today by default")
+	cage := fs.String("cage", "", "lists only the feedings of the `cage`")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	feedings, err := a.client.ListFeedings(a.ctx, *date, *cage)
+	if err != nil {
+		return err
+	}
+	return a.print(feedings, func() table { return feedingTable(feedings...) })
+}
+
+func feedingTable(feedings ...models.Feeding) table {
+	t := table{header: []string{"ID", "SCHEDULE", "DATE", "CAGE", "FEED", "QUANTITY", "FED", "FED BY"}}
+	for _, feeding := range feedings {
+		t.add(itoa(feeding.ID), itoa(feeding.ScheduleID), feeding.Date, feeding.Cage, feeding.Feed, itoa(feeding.Quantity),
+			formatTime(&feeding.FedTime), feeding.FedBy)
+	}
+	return t
+}
+
+func feedingsRecord(a *app, args []string) error {
+	fs := a.flags()
+	date := fs.String("date", "", "the `date` the feeding was made on, such as 2023-06-01, today by default")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	scheduleID, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	recorded, err := a.client.RecordFeeding(a.ctx, scheduleID, *date)
+	if err != nil {
+		return err
+	}
+	return a.print(recorded, func() table { return feedingTable(*recorded) })
+}
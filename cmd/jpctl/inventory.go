@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/EdgarH78/jurassic-park/client"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// fileFormats maps the extensions of import and export files to their formats.
+var fileFormats = map[string]models.InventoryFormat{
+	".csv":    models.InventoryFormatCSV,
+	".ndjson": models.InventoryFormatNDJSON,
+	".jsonl":  models.InventoryFormatNDJSON,
+}
+
+func importInventory(a *app, args []string) error {
+	fs := a.flags()
+	format := fs.String("format", "", "the `format` of the file, csv or ndjson, which is otherwise read from its extension")
+	options := models.ImportOptions{}
+	fs.Func("mode", "atomic, to keep nothing unless every row succeeds, or per-row, to keep the rows that succeed", func(value string) error {
+		options.Mode = models.ImportMode(value)
+		return nil
+	})
+	fs.BoolVar(&options.DryRun, "dry-run", false, "reports what would happen without keeping anything")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	path := positional[0]
+	fileFormat, err := inventoryFormat(*format, path)
+	if err != nil {
+		return err
+	}
+
+	var file io.Reader = a.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		file = f
+	}
+	report, err := a.client.Import(a.ctx, fileFormat, file, options)
+	if err != nil {
+		return err
+	}
+	err = a.print(report, func() table {
+		t := table{header: []string{"LINE", "KIND", "KEY", "STATUS", "CODE", "DETAIL"}}
+		for _, result := range report.Rows {
+			t.add(itoa(result.Line), string(result.Row.Kind), rowKey(result.Row), string(result.Status), orDash(string(result.Code)), orDash(result.Detail))
+		}
+		return t
+	})
+	if err != nil {
+		return err
+	}
+	outcome := "committed"
+	if report.DryRun {
+		outcome = "dry run, nothing was kept"
+	} else if !report.Committed {
+		outcome = "nothing was kept"
+	}
+	a.printMessage("%d applied, %d failed, %s", report.Applied, report.Failed, outcome)
+	if report.Failed > 0 {
+		return &importFailedError{failed: report.Failed}
+	}
+	return nil
+}
+
+// rowKey identifies the zone, cage, dinosaur or cage assignment that an import row adds.
+func rowKey(row models.InventoryRow) string {
+	switch row.Kind {
+	case models.InventoryKindZone:
+		return row.Name
+	case models.InventoryKindCage:
+		return row.Label
+	case models.InventoryKindDinosaur:
+		return row.Name
+	case models.InventoryKindAssignment:
+		return row.Dinosaur + " -> " + row.Cage
+	}
+	return "-"
+}
+
+// inventoryFormat is the format given, or else the format of the file's extension.
+func inventoryFormat(format, path string) (models.InventoryFormat, error) {
+	if format != "" {
+		return models.InventoryFormat(format), nil
+	}
+	fileFormat, ok := fileFormats[filepath.Ext(path)]
+	if !ok {
+		return "", usageErrorf("--format must be given when the file doesn't end in .csv, .ndjson or .jsonl")
+	}
+	return fileFormat, nil
+}
+
+func exportInventory(a *app, args []string) error {
+	fs := a.flags()
+	format := fs.String("format", "", "the `format` of the file, csv or ndjson, which is otherwise read from its extension, or csv for stdout")
+	positional, err := a.parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		if *format == "" {
+			*format = string(models.InventoryFormatCSV)
+		}
+		return a.client.Export(a.ctx, models.InventoryFormat(*format), a.stdout)
+	}
+
+	path := positional[0]
+	fileFormat, err := inventoryFormat(*format, path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = a.client.Export(a.ctx, fileFormat, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// a partial export can't be told apart from a whole one, so it isn't kept
+		os.Remove(path)
+		return err
+	}
+	a.printMessage("exported the park to %s", path)
+	return nil
+}
+
+func streamEvents(a *app, args []string) error {
+	fs := a.flags()
+	lastEventID := fs.Uint64("last-event-id", 0, "resumes the stream after the event with the `id`")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	err := a.client.StreamEvents(a.ctx, *lastEventID, func(event client.Event) error {
+		switch a.output {
+		case outputJSON:
+			content, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(a.stdout, "%s\n", content)
+			return err
+		case outputYAML:
+			content, err := toYAML(event)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(a.stdout, "---\n%s", content)
+			return err
+		}
+		_, err := fmt.Fprintf(a.stdout, "%d\t%s\t%s\n", event.ID, event.Type, event.Data)
+		return err
+	})
+	if errors.Is(err, context.Canceled) {
+		// the stream runs until it is interrupted
+		return nil
+	}
+	return err
+}
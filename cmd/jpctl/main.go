@@ -0,0 +1,195 @@
+// Command jpctl manages the park from the command line, through the API of a Jurassic Park server. The endpoint and
+// credentials are read from a config file, and can be overridden with environment variables and flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/client"
+)
+
+// command is a command of jpctl, such as cages list. name is the words that run it.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(app *app, args []string) error
+}
+
+// commands lists every command, in the order they are listed in the usage.
+var commands = []command{
+	{"cages list", "", "lists the cages", cagesList},
+	{"cages get", "label", "shows a cage", cagesGet},
+	{"cages create", "label", "adds a cage", cagesCreate},
+	{"cages update", "label", "changes the label, capacity or zone of a cage", cagesUpdate},
+	{"cages delete", "label", "decommissions an empty cage", cagesDelete},
+	{"cages dinosaurs", "label", "lists the dinosaurs in a cage", cagesDinosaurs},
+	{"power on", "label", "turns the power of a cage on, which sets it to ACTIVE", powerOn},
+	{"power off", "label", "turns the power of an empty cage off, which sets it to DOWN", powerOff},
+	{"power set", "label status", "sets the power status of a cage", powerSet},
+	{"dino list", "", "lists the dinosaurs", dinoList},
+	{"dino get", "name", "shows a dinosaur", dinoGet},
+	{"dino add", "name", "adds a dinosaur that isn't in a cage", dinoAdd},
+	{"dino assign", "name cage", "puts a dinosaur that isn't in a cage into a cage", dinoAssign},
+	{"dino unassign", "name", "takes a dinosaur out of its cage", dinoUnassign},
+	{"dino transfer", "name cage", "moves a dinosaur to another cage", dinoTransfer},
+	{"dino sex", "name sex", "records that a dinosaur has changed sex", dinoSex},
+	{"health list", "dinosaur", "lists the health records of a dinosaur", healthList},
+	{"health get", "dinosaur id", "shows a health record", healthGet},
+	{"health add", "dinosaur", "adds a health record", healthAdd},
+	{"health update", "dinosaur id", "replaces a health record", healthUpdate},
+	{"health delete", "dinosaur id", "deletes a health record", healthDelete},
+	{"species list", "", "lists the species", speciesList},
+	{"species get", "name", "shows a species", speciesGet},
+	{"species create", "name", "adds a species", speciesCreate},
+	{"species update", "name", "changes the diet of a species", speciesUpdate},
+	{"species delete", "name", "deletes a species", speciesDelete},
+	{"zones list", "", "lists the zones", zonesList},
+	{"zones get", "name", "shows a zone", zonesGet},
+	{"zones create", "name", "adds a zone", zonesCreate},
+	{"zones update", "name", "moves a zone or changes its description", zonesUpdate},
+	{"zones delete", "name", "deletes an empty zone", zonesDelete},
+	{"feeds list", "", "lists the feeds and their stock", feedsList},
+	{"feeds create", "name", "adds a feed", feedsCreate},
+	{"feeds restock", "name quantity", "adds to the stock of a feed", feedsRestock},
+	{"schedules list", "", "lists the feeding schedules", schedulesList},
+	{"schedules create", "", "schedules a daily feeding of a cage", schedulesCreate},
+	{"schedules delete", "id", "deletes a feeding schedule", schedulesDelete},
+	{"roster", "", "lists the feedings due on a day", roster},
+	{"feedings list", "", "lists the feedings made on a day", feedingsList},
+	{"feedings record", "schedule", "records that a scheduled feeding was made", feedingsRecord},
+	{"webhooks list", "", "lists the webhooks", webhooksList},
+	{"webhooks get", "id", "shows a webhook", webhooksGet},
+	{"webhooks create", "url", "subscribes a URL to events", webhooksCreate},
+	{"webhooks delete", "id", "unsubscribes a webhook", webhooksDelete},
+	{"webhooks deliveries", "id", "lists the deliveries made to a webhook", webhooksDeliveries},
+	{"apikeys list", "", "lists the API keys", apiKeysList},
+	{"apikeys create", "name role...", "issues an API key, which is only shown this once", apiKeysCreate},
+	{"apikeys revoke", "id", "stops an API key from authenticating", apiKeysRevoke},
+	{"audit", "", "lists the changes made to the park", audit},
+	{"import", "file", "adds the zones, cages, dinosaurs and cage assignments in a CSV or NDJSON file, - for stdin", importInventory},
+	{"export", "[file]", "writes the whole park to a CSV or NDJSON file, or to stdout", exportInventory},
+	{"events", "", "streams changes to the park until interrupted", streamEvents},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "jpctl:", err)
+	}
+	os.Exit(exitCode(err))
+}
+
+// run runs the command named by the first words of args.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return nil
+	}
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			app := &app{ctx: ctx, command: cmd, stdin: stdin, stdout: stdout, stderr: stderr}
+			err := cmd.run(app, args[len(words):])
+			if errors.Is(err, flag.ErrHelp) {
+				return nil
+			}
+			return err
+		}
+	}
+	return usageErrorf("unknown command %q, run jpctl help to list the commands", strings.Join(args, " "))
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: jpctl command [args] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-36s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Every command takes the flags --config, --endpoint, --api-key, --token and -o, which is table, json or")
+	fmt.Fprintln(w, "yaml. Run jpctl command -h to list the flags of a command.")
+}
+
+// usageError is a command that was run with the wrong arguments or flags.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usageErrorf(format string, args ...any) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// importFailedError is an import that had rows that failed, whose report has already been printed.
+type importFailedError struct {
+	failed int
+}
+
+func (e *importFailedError) Error() string {
+	return fmt.Sprintf("%d rows failed to import", e.failed)
+}
+
+// The exit codes of jpctl, so that scripts can tell why a command failed.
+const (
+	exitOK           = 0
+	exitError        = 1
+	exitUsage        = 2
+	exitUnauthorized = 3
+	exitNotFound     = 4
+	exitConflict     = 5
+	exitInvalid      = 6
+	exitUnreachable  = 7
+	exitImportFailed = 8
+)
+
+func exitCode(err error) int {
+	var usageErr *usageError
+	var apiErr *client.Error
+	var importErr *importFailedError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.As(err, &importErr):
+		return exitImportFailed
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return exitUnauthorized
+		case apiErr.StatusCode == http.StatusNotFound:
+			return exitNotFound
+		case apiErr.StatusCode == http.StatusConflict:
+			return exitConflict
+		case apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusRequestEntityTooLarge ||
+			apiErr.StatusCode == http.StatusUnprocessableEntity:
+			return exitInvalid
+		}
+		return exitError
+	case isUnreachable(err):
+		return exitUnreachable
+	}
+	return exitError
+}
+
+// isUnreachable reports whether the request failed before the server responded, such as when it isn't running.
+func isUnreachable(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/api"
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/client"
+	"github.com/EdgarH78/jurassic-park/memory"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// clearConfig keeps the config file and environment of the machine running the tests from reaching jpctl.
+func clearConfig(t *testing.T) {
+	t.Setenv("JPCTL_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("JPCTL_ENDPOINT", "")
+	t.Setenv("JPCTL_API_KEY", "")
+	t.Setenv("JPCTL_TOKEN", "")
+}
+
+// newServer serves the API of the park, and returns the URL of the server. The server is closed when the test ends.
+func newServer(t *testing.T, dao *memory.ParkMemoryDao, opts ...api.Option) string {
+	r := gin.New()
+	api.NewAPI(dao, r, opts...)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// jpctl runs jpctl with the args against the server at endpoint, and returns what it printed to stdout and the code
+// it would exit with.
+func jpctl(endpoint string, args ...string) (string, int) {
+	var stdout, stderr bytes.Buffer
+	if endpoint != "" {
+		args = append(args, "--endpoint", endpoint)
+	}
+	err := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr)
+	return stdout.String(), exitCode(err)
+}
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		description      string
+		err              error
+		expectedExitCode int
+	}{
+		{"success", nil, exitOK},
+		{"usage", usageErrorf("usage: jpctl cages get label"), exitUsage},
+		{"import with failed rows", &importFailedError{failed: 2}, exitImportFailed},
+		{"unauthorized", &client.Error{StatusCode: http.StatusUnauthorized}, exitUnauthorized},
+		{"forbidden", &client.Error{StatusCode: http.StatusForbidden}, exitUnauthorized},
+		{"not found", &client.Error{StatusCode: http.StatusNotFound}, exitNotFound},
+		{"conflict", &client.Error{StatusCode: http.StatusConflict}, exitConflict},
+		{"bad request", &client.Error{StatusCode: http.StatusBadRequest}, exitInvalid},
+		{"too large", &client.Error{StatusCode: http.StatusRequestEntityTooLarge}, exitInvalid},
+		{"unprocessable", &client.Error{StatusCode: http.StatusUnprocessableEntity}, exitInvalid},
+		{"server error", &client.Error{StatusCode: http.StatusInternalServerError}, exitError},
+		{"wrapped API error", fmt.Errorf("reading the cage: %w", &client.Error{StatusCode: http.StatusNotFound}), exitNotFound},
+		{"unreachable", &url.Error{Op: "Get", URL: "http://localhost:1", Err: errors.New("connection refused")}, exitUnreachable},
+		{"interrupted", &url.Error{Op: "Get", URL: "http://localhost:1", Err: context.Canceled}, exitError},
+		{"other error", errors.New("disk full"), exitError},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if code := exitCode(c.err); code != c.expectedExitCode {
+				t.Errorf("expected exit code %d got %d", c.expectedExitCode, code)
+			}
+		})
+	}
+}
+
+func TestOutput(t *testing.T) {
+	cage := models.CageV2{Label: "C-1", MaxOccupancy: 2, Occupancy: 1, PowerStatus: models.PowerStatusActive, Zone: wrapString("Sector 4")}
+	cages := []models.CageV2{cage, {Label: "Paddock-12", MaxOccupancy: 10, PowerStatus: models.PowerStatusDown}}
+	cases := []struct {
+		output         string
+		value          any
+		expectedOutput string
+	}{
+		{
+			output: outputTable,
+			value:  cages,
+			expectedOutput: "LABEL       POWER   OCCUPANCY  ZONE\n" +
+				"C-1         ACTIVE  1/2        Sector 4\n" +
+				"Paddock-12  DOWN    0/10       -\n",
+		},
+		{
+			output: outputJSON,
+			value:  cage,
+			expectedOutput: `{
+  "label": "C-1",
+  "occupancy": 1,
+  "maxOccupancy": 2,
+  "powerStatus": "ACTIVE",
+  "zone": "Sector 4"
+}
+`,
+		},
+		{
+			output: outputYAML,
+			value:  cages,
+			expectedOutput: `- label: C-1
+  occupancy: 1
+  maxOccupancy: 2
+  powerStatus: ACTIVE
+  zone: Sector 4
+- label: Paddock-12
+  occupancy: 0
+  maxOccupancy: 10
+  powerStatus: DOWN
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.output, func(t *testing.T) {
+			var stdout bytes.Buffer
+			a := &app{stdout: &stdout, output: c.output}
+			if err := a.print(c.value, func() table { return cageTable(cages...) }); err != nil {
+				t.Fatalf("unexpected error printing: %s", err)
+			}
+			if stdout.String() != c.expectedOutput {
+				t.Errorf("expected output\n%s\ngot\n%s", c.expectedOutput, stdout.String())
+			}
+		})
+	}
+
+	t.Run("messages are only printed with tables", func(t *testing.T) {
+		for output, expectedOutput := range map[string]string{outputTable: "deleted cage C-1\n", outputJSON: "", outputYAML: ""} {
+			var stdout bytes.Buffer
+			a := &app{stdout: &stdout, output: output}
+			a.printMessage("deleted cage %s", "C-1")
+			if stdout.String() != expectedOutput {
+				t.Errorf("expected %s to print %q got %q", output, expectedOutput, stdout.String())
+			}
+		}
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	content := "endpoint: https://park.example.com\napiKey: jpk_file\noutput: yaml\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("unexpected error writing the config file: %s", err)
+	}
+	invalidFile := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalidFile, []byte("endpoint: [\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error writing the config file: %s", err)
+	}
+
+	cases := []struct {
+		description    string
+		path           string
+		env            map[string]string
+		expectedConfig config
+		expectError    bool
+	}{
+		{
+			description:    "the config file at the path",
+			path:           configFile,
+			expectedConfig: config{Endpoint: "https://park.example.com", APIKey: "jpk_file", Output: "yaml"},
+		},
+		{
+			description:    "the config file at $JPCTL_CONFIG",
+			env:            map[string]string{"JPCTL_CONFIG": configFile},
+			expectedConfig: config{Endpoint: "https://park.example.com", APIKey: "jpk_file", Output: "yaml"},
+		},
+		{
+			description:    "the environment overrides the config file",
+			path:           configFile,
+			env:            map[string]string{"JPCTL_ENDPOINT": "http://localhost:9090", "JPCTL_API_KEY": "jpk_env", "JPCTL_TOKEN": "token"},
+			expectedConfig: config{Endpoint: "http://localhost:9090", APIKey: "jpk_env", Token: "token", Output: "yaml"},
+		},
+		{
+			description:    "the environment without a config file",
+			env:            map[string]string{"JPCTL_TOKEN": "token"},
+			expectedConfig: config{Endpoint: defaultEndpoint, Token: "token"},
+		},
+		{
+			description: "a config file that was asked for must exist",
+			path:        filepath.Join(dir, "missing.yaml"),
+			expectError: true,
+		},
+		{
+			description: "a config file that isn't YAML",
+			path:        invalidFile,
+			expectError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			clearConfig(t)
+			for name, value := range c.env {
+				t.Setenv(name, value)
+			}
+			cfg, err := loadConfig(c.path)
+			if c.expectError {
+				if err == nil {
+					t.Errorf("expected an error got %+v", cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg != c.expectedConfig {
+				t.Errorf("expected %+v got %+v", c.expectedConfig, cfg)
+			}
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	clearConfig(t)
+	dao := memory.NewParkMemoryDao()
+	endpoint := newServer(t, dao)
+	ctx := context.Background()
+	for _, cage := range []models.Cage{
+		{Label: "C-1", MaxOccupancy: 2, HasPower: true},
+		{Label: "C-2", MaxOccupancy: 1, HasPower: true},
+		{Label: "C-3", MaxOccupancy: 1, HasPower: false},
+	} {
+		if err := dao.AddCage(ctx, cage); err != nil {
+			t.Fatalf("error when adding the cage: %s", err)
+		}
+	}
+	for _, name := range []string{"Blue", "Charlie"} {
+		if err := dao.AddDinosaur(ctx, models.Dinosaur{Name: name, Species: "Velociraptor"}); err != nil {
+			t.Fatalf("error when adding the dinosaur: %s", err)
+		}
+	}
+
+	// the cases are run in order, and later cases see the changes made by the earlier ones
+	cases := []struct {
+		description      string
+		args             []string
+		expectedOutput   string
+		expectedExitCode int
+	}{
+		{
+			description: "list the powered cages",
+			args:        []string{"cages", "list", "--powered"},
+			expectedOutput: "LABEL  POWER   OCCUPANCY  ZONE\n" +
+				"C-1    ACTIVE  0/2        -\n" +
+				"C-2    ACTIVE  0/1        -\n",
+		},
+		{
+			description:    "list the unpowered cages as json",
+			args:           []string{"cages", "list", "--unpowered", "-o", "json"},
+			expectedOutput: "[\n  {\n    \"label\": \"C-3\",\n    \"occupancy\": 0,\n    \"maxOccupancy\": 1,\n    \"powerStatus\": \"DOWN\"\n  }\n]\n",
+		},
+		{
+			description:      "list the cages with both --powered and --unpowered",
+			args:             []string{"cages", "list", "--powered", "--unpowered"},
+			expectedExitCode: exitUsage,
+		},
+		{
+			description:      "list the cages with an argument",
+			args:             []string{"cages", "list", "C-1"},
+			expectedExitCode: exitUsage,
+		},
+		{
+			description: "list the dinosaurs that need a cage",
+			args:        []string{"dino", "list", "--needs-cage"},
+			expectedOutput: "NAME     SPECIES       SEX     DIET       CAGE  QUARANTINED\n" +
+				"Blue     Velociraptor  Female  Carnivore  -     no\n" +
+				"Charlie  Velociraptor  Female  Carnivore  -     no\n",
+		},
+		{
+			description:    "assign a dinosaur to a cage",
+			args:           []string{"dino", "assign", "Blue", "C-1"},
+			expectedOutput: "put Blue in cage C-1\n",
+		},
+		{
+			description:    "assign a dinosaur to a cage with the flags first",
+			args:           []string{"dino", "assign", "-o", "yaml", "Charlie", "C-1"},
+			expectedOutput: "",
+		},
+		{
+			description:      "assign a dinosaur without a cage",
+			args:             []string{"dino", "assign", "Blue"},
+			expectedExitCode: exitUsage,
+		},
+		{
+			description:      "assign a dinosaur that doesn't exist",
+			args:             []string{"dino", "assign", "Rexy", "C-2"},
+			expectedExitCode: exitNotFound,
+		},
+		{
+			description:      "assign a dinosaur to a cage that is down",
+			args:             []string{"dino", "assign", "Blue", "C-3"},
+			expectedExitCode: exitConflict,
+		},
+		{
+			description:    "no dinosaurs need a cage once they are assigned",
+			args:           []string{"dino", "list", "--needs-cage", "-o", "yaml"},
+			expectedOutput: "[]\n",
+		},
+		{
+			description:      "list the dinosaurs that both need a cage and are caged",
+			args:             []string{"dino", "list", "--needs-cage", "--caged"},
+			expectedExitCode: exitUsage,
+		},
+		{
+			description: "turn the power off to an empty cage",
+			args:        []string{"power", "off", "C-2"},
+			expectedOutput: "LABEL  POWER  OCCUPANCY  ZONE\n" +
+				"C-2    DOWN   0/1        -\n",
+		},
+		{
+			description:      "turn the power off to an occupied cage",
+			args:             []string{"power", "off", "C-1"},
+			expectedExitCode: exitConflict,
+		},
+		{
+			description:      "turn the power off to a cage that doesn't exist",
+			args:             []string{"power", "off", "C-9"},
+			expectedExitCode: exitNotFound,
+		},
+		{
+			description:      "turn the power off without a cage",
+			args:             []string{"power", "off"},
+			expectedExitCode: exitUsage,
+		},
+		{
+			description:      "an unknown flag",
+			args:             []string{"power", "off", "C-2", "--force"},
+			expectedExitCode: exitUsage,
+		},
+		{
+			description:      "an unknown output format",
+			args:             []string{"cages", "list", "-o", "xml"},
+			expectedExitCode: exitUsage,
+		},
+		{
+			description:      "an unknown command",
+			args:             []string{"cages", "paint"},
+			expectedExitCode: exitUsage,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			output, code := jpctl(endpoint, c.args...)
+			if code != c.expectedExitCode {
+				t.Fatalf("expected exit code %d got %d: %s", c.expectedExitCode, code, output)
+			}
+			if output != c.expectedOutput {
+				t.Errorf("expected output\n%s\ngot\n%s", c.expectedOutput, output)
+			}
+		})
+	}
+
+	t.Run("the cages list reflects the changes", func(t *testing.T) {
+		output, code := jpctl(endpoint, "cages", "list", "--powered")
+		expectedOutput := "LABEL  POWER   OCCUPANCY  ZONE\n" +
+			"C-1    ACTIVE  2/2        -\n"
+		if code != exitOK || output != expectedOutput {
+			t.Errorf("expected exit code %d and output\n%s\ngot %d and\n%s", exitOK, expectedOutput, code, output)
+		}
+	})
+}
+
+func TestCommandCredentials(t *testing.T) {
+	clearConfig(t)
+	dao := memory.NewParkMemoryDao()
+	endpoint := newServer(t, dao, api.WithAuthenticators(auth.NewAPIKeyAuthenticator(dao)))
+	apiKey := models.APIKey{Name: "gate", Roles: []models.Role{models.RoleViewer}}
+	if err := auth.Issue(&apiKey); err != nil {
+		t.Fatalf("error when issuing the API key: %s", err)
+	}
+	if _, err := dao.AddAPIKey(context.Background(), apiKey); err != nil {
+		t.Fatalf("error when adding the API key: %s", err)
+	}
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("apiKey: "+apiKey.Key+"\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error writing the config file: %s", err)
+	}
+
+	cases := []struct {
+		description      string
+		env              map[string]string
+		args             []string
+		expectedExitCode int
+	}{
+		{
+			description:      "the API key in the config file",
+			args:             []string{"cages", "list", "--config", configFile},
+			expectedExitCode: exitOK,
+		},
+		{
+			description:      "the API key in the environment overrides the config file",
+			env:              map[string]string{"JPCTL_API_KEY": "jpk_unknown"},
+			args:             []string{"cages", "list", "--config", configFile},
+			expectedExitCode: exitUnauthorized,
+		},
+		{
+			description:      "the API key flag overrides the environment",
+			env:              map[string]string{"JPCTL_API_KEY": "jpk_unknown"},
+			args:             []string{"cages", "list", "--api-key", apiKey.Key},
+			expectedExitCode: exitOK,
+		},
+		{
+			description:      "a role that isn't allowed to run the command",
+			args:             []string{"power", "off", "C-1", "--config", configFile},
+			expectedExitCode: exitUnauthorized,
+		},
+		{
+			description:      "no credentials",
+			args:             []string{"cages", "list"},
+			expectedExitCode: exitUnauthorized,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			for name, value := range c.env {
+				t.Setenv(name, value)
+			}
+			if _, code := jpctl(endpoint, c.args...); code != c.expectedExitCode {
+				t.Errorf("expected exit code %d got %d", c.expectedExitCode, code)
+			}
+		})
+	}
+
+	t.Run("a server that isn't running", func(t *testing.T) {
+		stopped := httptest.NewServer(http.NotFoundHandler())
+		stopped.Close()
+		if _, code := jpctl(stopped.URL, "cages", "list"); code != exitUnreachable {
+			t.Errorf("expected exit code %d got %d", exitUnreachable, code)
+		}
+	})
+}
+
+func wrapString(s string) *string {
+	return &s
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// table is the table output of a command.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// print prints v in the output format. The table is only built when the output is a table.
+func (a *app) print(v any, toTable func() table) error {
+	switch a.output {
+	case outputJSON:
+		content, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(a.stdout, "%s\n", content)
+		return err
+	case outputYAML:
+		content, err := toYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = a.stdout.Write(content)
+		return err
+	}
+	t := toTable()
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printMessage prints the outcome of a command that has nothing else to print. Only tables print it, so that the
+// output of json and yaml can always be parsed.
+func (a *app) printMessage(format string, args ...any) {
+	if a.output == outputTable {
+		fmt.Fprintf(a.stdout, format+"\n", args...)
+	}
+}
+
+// toYAML converts v to YAML through its JSON, so that the fields have the same names and order as they do in JSON.
+func toYAML(v any) ([]byte, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is YAML, but in the flow style and with every string quoted
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(content, node); err != nil {
+		return nil, err
+	}
+	resetStyle(node)
+	return yaml.Marshal(node)
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// The cells of a table for values that might not be set.
+
+func optional(value *string) string {
+	if value == nil || *value == "" {
+		return "-"
+	}
+	return *value
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/client"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func speciesList(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	species, err := a.client.ListSpecies(a.ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(species, func() table { return speciesTable(species...) })
+}
+
+func speciesTable(species ...models.Species) table {
+	t := table{header: []string{"NAME", "DIET"}}
+	for _, s := range species {
+		t.add(s.Name, s.Diet)
+	}
+	return t
+}
+
+func speciesGet(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	species, err := a.client.GetSpecies(a.ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return a.print(species, func() table { return speciesTable(*species) })
+}
+
+func speciesCreate(a *app, args []string) error {
+	return saveSpecies(a, args, (*client.Client).CreateSpecies)
+}
+
+func speciesUpdate(a *app, args []string) error {
+	return saveSpecies(a, args, (*client.Client).UpdateSpecies)
+}
+
+// saveSpecies creates or updates the species named in args, whose diet is given by a flag.
+func saveSpecies(a *app, args []string, save func(c *client.Client, ctx context.Context, species models.Species) (*models.Species, error)) error {
+	fs := a.flags()
+	diet := fs.String("diet", "", "the `diet` of the species, Carnivore or Herbivore (required)")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *diet == "" {
+		return usageErrorf("--diet must be given")
+	}
+	saved, err := save(a.client, a.ctx, models.Species{Name: positional[0], Diet: *diet})
+	if err != nil {
+		return err
+	}
+	return a.print(saved, func() table { return speciesTable(*saved) })
+}
+
+func speciesDelete(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteSpecies(a.ctx, positional[0]); err != nil {
+		return err
+	}
+	a.printMessage("deleted species %s", positional[0])
+	return nil
+}
+
+func zonesList(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	summaries, err := a.client.ListZones(a.ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(summaries, func() table { return zoneTable(summaries...) })
+}
+
+func zoneTable(summaries ...models.ZoneSummary) table {
+	t := table{header: []string{"NAME", "PARENT", "ZONES", "CAGES", "OCCUPANCY", "POWER", "DESCRIPTION"}}
+	for _, summary := range summaries {
+		powerStatuses := []string{}
+		for powerStatus, cages := range summary.PowerStatuses {
+			powerStatuses = append(powerStatuses, string(powerStatus)+"="+itoa(cages))
+		}
+		sort.Strings(powerStatuses)
+		t.add(summary.Name, optional(summary.Parent), orDash(strings.Join(summary.Zones, ",")), itoa(summary.Cages),
+			itoa(summary.Occupancy)+"/"+itoa(summary.MaxOccupancy), orDash(strings.Join(powerStatuses, ",")), orDash(summary.Description))
+	}
+	return t
+}
+
+func zonesGet(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	summary, err := a.client.GetZone(a.ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return a.print(summary, func() table { return zoneTable(*summary) })
+}
+
+func zonesCreate(a *app, args []string) error {
+	fs := a.flags()
+	zone := models.Zone{}
+	fs.Func("parent", "the `zone` that the zone is nested in", func(value string) error {
+		zone.Parent = &value
+		return nil
+	})
+	fs.StringVar(&zone.Description, "description", "", "a `description` of the zone")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	zone.Name = positional[0]
+	created, err := a.client.CreateZone(a.ctx, zone)
+	if err != nil {
+		return err
+	}
+	return a.print(created, func() table { return zoneTable(models.ZoneSummary{Zone: *created}) })
+}
+
+func zonesUpdate(a *app, args []string) error {
+	fs := a.flags()
+	update := models.UpdateZoneRequest{}
+	fs.Func("parent", "the `zone` that the zone is moved to, or an empty zone to move it to the top of the park", func(value string) error {
+		update.Parent = &value
+		return nil
+	})
+	fs.Func("description", "the new `description` of the zone", func(value string) error {
+		update.Description = &value
+		return nil
+	})
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if update.Parent == nil && update.Description == nil {
+		return usageErrorf("at least one of --parent and --description must be given")
+	}
+	zone, err := a.client.UpdateZone(a.ctx, positional[0], update)
+	if err != nil {
+		return err
+	}
+	return a.print(zone, func() table { return zoneTable(models.ZoneSummary{Zone: *zone}) })
+}
+
+func zonesDelete(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteZone(a.ctx, positional[0]); err != nil {
+		return err
+	}
+	a.printMessage("deleted zone %s", positional[0])
+	return nil
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+const apiKeyColumns = `id, name, keyHash, keyPrefix, roles, createdTime, revokedTime`
+
+func scanAPIKey(rows *sql.Rows) (*models.APIKey, error) {
+	apiKey := models.APIKey{}
+	var roles string
+	err := rows.Scan(&apiKey.ID, &apiKey.Name, &apiKey.KeyHash, &apiKey.Prefix, &roles, &apiKey.CreatedTime, &apiKey.RevokedTime)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range strings.Split(roles, ",") {
+		apiKey.Roles = append(apiKey.Roles, models.Role(role))
+	}
+	return &apiKey, nil
+}
+
+func joinRoles(roles []models.Role) string {
+	names := []string{}
+	for _, role := range roles {
+		names = append(names, string(role))
+	}
+	return strings.Join(names, ",")
+}
+
+func (s *ParkSqlDao) AddAPIKey(ctx context.Context, apiKey models.APIKey) (*models.APIKey, error) {
+	var created *models.APIKey
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		qs := `INSERT INTO apiKey(name, keyHash, keyPrefix, roles, createdTime)
+				VALUES(?,?,?,?,?)`
+		params := []interface{}{apiKey.Name, apiKey.KeyHash, apiKey.Prefix, joinRoles(apiKey.Roles), time.Now().UTC()}
+		result, err := tx.Exec(qs, params...)
+		if err != nil {
+			if isDuplicateKeyError(err) {
+				return models.EntityAlreadyExists
+			}
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		created, err = s.getAPIKey(tx, `id=?`, id)
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityAPIKey, strconv.Itoa(created.ID)), models.AuditCreate, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *ParkSqlDao) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	return s.getAPIKey(s.db, `keyHash=?`, keyHash)
+}
+
+func (s *ParkSqlDao) getAPIKey(q querier, where string, arg any) (*models.APIKey, error) {
+	qs := `SELECT ` + apiKeyColumns + `
+		   FROM apiKey
+		   WHERE ` + where
+	rows, err := q.Query(qs, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, models.EntityNotFound
+	}
+	return scanAPIKey(rows)
+}
+
+// GetAPIKeys lists every API key, including the revoked ones.
+func (s *ParkSqlDao) GetAPIKeys() ([]models.APIKey, error) {
+	qs := `SELECT ` + apiKeyColumns + `
+		   FROM apiKey
+		   ORDER BY id`
+	rows, err := s.db.Query(qs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := []models.APIKey{}
+	for rows.Next() {
+		apiKey, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, *apiKey)
+	}
+	return apiKeys, rows.Err()
+}
+
+// RevokeAPIKey stops the API key from authenticating. Revoking a key that is already revoked changes nothing.
+func (s *ParkSqlDao) RevokeAPIKey(ctx context.Context, id int) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		apiKey, err := s.getAPIKey(tx, `id=? FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		if apiKey.RevokedTime != nil {
+			return nil
+		}
+		revoked := *apiKey
+		revokedTime := time.Now().UTC()
+		revoked.RevokedTime = &revokedTime
+		_, err = tx.Exec(`UPDATE apiKey SET revokedTime=? WHERE id=?`, revokedTime, id)
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityAPIKey, strconv.Itoa(id)), models.AuditRevoke, apiKey, revoked)
+	})
+}
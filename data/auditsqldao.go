@@ -0,0 +1,98 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// recordAudit appends a change made by the actor in ctx to the audit log. It is called with the transaction that
+// made the change, so that the change and its record are committed together or not at all.
+func (s *ParkSqlDao) recordAudit(ctx context.Context, q querier, entity string, action models.AuditAction, before, after any) error {
+	event, err := models.NewAuditEvent(ctx, entity, action, before, after)
+	if err != nil {
+		return err
+	}
+	insertStatement := `INSERT INTO audit_event(actor, eventTime, entity, action, beforeState, afterState)
+			VALUES(?,?,?,?,?,?)`
+	params := []interface{}{event.Actor, event.Time, event.Entity, event.Action, jsonColumn(event.Before), jsonColumn(event.After)}
+	_, err = q.Exec(insertStatement, params...)
+	return err
+}
+
+// jsonColumn converts a snapshot to the value of a JSON column, which is NULL when there is no snapshot.
+func jsonColumn(snapshot json.RawMessage) any {
+	if snapshot == nil {
+		return nil
+	}
+	return string(snapshot)
+}
+
+// GetAuditEvents reads the audit log, oldest first.
+func (s *ParkSqlDao) GetAuditEvents(filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error) {
+	if filter.Page.Sort.Field != "" {
+		return nil, models.PageInfo{}, models.InvalidSort
+	}
+
+	q := listQuery{
+		columns: `a.id, a.actor, a.eventTime, a.entity, a.action, a.beforeState, a.afterState`,
+		from:    `audit_event a`,
+	}
+	if filter.Entity != nil {
+		q.where = append(q.where, "a.entity=?")
+		q.whereArgs = append(q.whereArgs, *filter.Entity)
+	}
+	if filter.Since != nil {
+		q.where = append(q.where, "a.eventTime>=?")
+		q.whereArgs = append(q.whereArgs, *filter.Since)
+	}
+	keys := keyset{idColumn: "a.id"}
+
+	qs, args, err := keys.pageQuery(q, filter.Page)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	rows, err := s.db.Query(qs, args...)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	defer rows.Close()
+
+	auditEvents := []models.AuditEvent{}
+	sortValues := []string{}
+	ids := []int{}
+	for rows.Next() {
+		event := models.AuditEvent{}
+		var before, after []byte
+		var sortValue string
+		var id int
+		err := rows.Scan(&event.ID, &event.Actor, &event.Time, &event.Entity, &event.Action, &before, &after, &sortValue, &id)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		if before != nil {
+			event.Before = json.RawMessage(before)
+		}
+		if after != nil {
+			event.After = json.RawMessage(after)
+		}
+		auditEvents = append(auditEvents, event)
+		sortValues = append(sortValues, sortValue)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	count, pageInfo := keys.pageInfo(filter.Page, sortValues, ids)
+	auditEvents = auditEvents[:count]
+	if filter.Page.IncludeTotal {
+		total, err := s.countRows(q)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		pageInfo.Total = &total
+	}
+	return auditEvents, pageInfo, nil
+}
@@ -0,0 +1,306 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+const feedColumns = `name, diet, unit, stock, lowStockThreshold`
+
+func (s *ParkSqlDao) AddFeed(ctx context.Context, feed models.Feed) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		qs := `INSERT INTO feed(name, diet, unit, stock, lowStockThreshold)
+				VALUES(?,?,?,?,?)`
+		_, err := tx.Exec(qs, feed.Name, feed.Diet, feed.Unit, feed.Stock, feed.LowStockThreshold)
+		if err != nil {
+			if isDuplicateKeyError(err) {
+				return models.EntityAlreadyExists
+			}
+			if isMySQLError(err, mysqlNoReferencedRow) {
+				// the diet is not in speciesDiet
+				return models.InvalidSpeciesDiet
+			}
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityFeed, feed.Name), models.AuditCreate, nil, feed)
+	})
+}
+
+func (s *ParkSqlDao) GetFeed(name string) (*models.Feed, error) {
+	return s.queryFeed(s.db, `SELECT `+feedColumns+` FROM feed WHERE name=?`, name)
+}
+
+func (s *ParkSqlDao) lockFeed(tx *sql.Tx, name string) (*models.Feed, error) {
+	return s.queryFeed(tx, `SELECT `+feedColumns+` FROM feed WHERE name=? FOR UPDATE`, name)
+}
+
+func (s *ParkSqlDao) queryFeed(q querier, qs string, name string) (*models.Feed, error) {
+	rows, err := q.Query(qs, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, models.EntityNotFound
+	}
+	feed := models.Feed{}
+	if err := rows.Scan(&feed.Name, &feed.Diet, &feed.Unit, &feed.Stock, &feed.LowStockThreshold); err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// GetFeeds lists the feeds by name, optionally only those that are or aren't low on stock.
+func (s *ParkSqlDao) GetFeeds(filter models.FeedFilter) ([]models.Feed, error) {
+	qs := `SELECT ` + feedColumns + `
+		   FROM feed`
+	if filter.LowStock != nil {
+		if *filter.LowStock {
+			qs += ` WHERE stock <= lowStockThreshold`
+		} else {
+			qs += ` WHERE stock > lowStockThreshold`
+		}
+	}
+	qs += ` ORDER BY name`
+	rows, err := s.db.Query(qs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := []models.Feed{}
+	for rows.Next() {
+		feed := models.Feed{}
+		if err := rows.Scan(&feed.Name, &feed.Diet, &feed.Unit, &feed.Stock, &feed.LowStockThreshold); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, rows.Err()
+}
+
+// RestockFeed adds the quantity to the stock of the feed, and returns the restocked feed.
+func (s *ParkSqlDao) RestockFeed(ctx context.Context, name string, quantity int) (*models.Feed, error) {
+	var restocked models.Feed
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		feed, err := s.lockFeed(tx, name)
+		if err != nil {
+			return err
+		}
+		restocked = *feed
+		restocked.Stock += quantity
+		_, err = tx.Exec(`UPDATE feed SET stock=? WHERE name=?`, restocked.Stock, name)
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityFeed, name), models.AuditUpdate, feed, restocked)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &restocked, nil
+}
+
+// scheduleColumns selects a feeding schedule along with how its cage is referred to.
+func (s *ParkSqlDao) scheduleColumns() string {
+	return `fs.id, ` + s.cageRefColumn() + `, fs.feed, fs.feedingTime, fs.quantity`
+}
+
+func scanSchedule(rows *sql.Rows) (*models.FeedingSchedule, error) {
+	schedule := models.FeedingSchedule{}
+	if err := rows.Scan(&schedule.ID, &schedule.Cage, &schedule.Feed, &schedule.Time, &schedule.Quantity); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (s *ParkSqlDao) AddFeedingSchedule(ctx context.Context, schedule models.FeedingSchedule) (*models.FeedingSchedule, error) {
+	var created *models.FeedingSchedule
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		_, cageId, err := s.lockCage(tx, schedule.Cage)
+		if err != nil {
+			return err
+		}
+		qs := `INSERT INTO feedingSchedule(cageId, feed, feedingTime, quantity)
+				VALUES(?,?,?,?)`
+		result, err := tx.Exec(qs, cageId, schedule.Feed, schedule.Time, schedule.Quantity)
+		if err != nil {
+			if isMySQLError(err, mysqlNoReferencedRow) {
+				// the feed doesn't exist
+				return models.EntityNotFound
+			}
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		created, err = s.getFeedingSchedule(tx, int(id))
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntitySchedule, strconv.Itoa(created.ID)), models.AuditCreate, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *ParkSqlDao) GetFeedingSchedule(id int) (*models.FeedingSchedule, error) {
+	return s.getFeedingSchedule(s.db, id)
+}
+
+func (s *ParkSqlDao) getFeedingSchedule(q querier, id int) (*models.FeedingSchedule, error) {
+	qs := `SELECT ` + s.scheduleColumns() + `
+		   FROM feedingSchedule fs
+		   JOIN cage c ON c.id = fs.cageId
+		   WHERE fs.id=?`
+	rows, err := q.Query(qs, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, models.EntityNotFound
+	}
+	return scanSchedule(rows)
+}
+
+// GetFeedingSchedules lists the feeding schedules by time and then cage, optionally only those of one cage.
+func (s *ParkSqlDao) GetFeedingSchedules(filter models.FeedingScheduleFilter) ([]models.FeedingSchedule, error) {
+	qs := `SELECT ` + s.scheduleColumns() + `
+		   FROM feedingSchedule fs
+		   JOIN cage c ON c.id = fs.cageId`
+	args := []any{}
+	if filter.Cage != nil {
+		where, whereArgs := s.cageWhere(*filter.Cage)
+		qs += ` WHERE ` + where
+		args = append(args, whereArgs...)
+	}
+	qs += ` ORDER BY fs.feedingTime, ` + s.cageRefColumn() + `, fs.id`
+	rows, err := s.db.Query(qs, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.FeedingSchedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func (s *ParkSqlDao) DeleteFeedingSchedule(ctx context.Context, id int) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		schedule, err := s.getFeedingSchedule(tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM feedingSchedule WHERE id=?`, id)
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntitySchedule, strconv.Itoa(id)), models.AuditDelete, schedule, nil)
+	})
+}
+
+// RecordFeeding records a feeding made by the actor in ctx and takes the feed it used out of stock, returning the
+// feeding along with the feed's remaining stock. A schedule can only be fed once a day, and only with feed that
+// is in stock.
+func (s *ParkSqlDao) RecordFeeding(ctx context.Context, feeding models.Feeding) (*models.Feeding, *models.Feed, error) {
+	var remaining models.Feed
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		feed, err := s.lockFeed(tx, feeding.Feed)
+		if err != nil {
+			return err
+		}
+		if feed.Stock < feeding.Quantity {
+			return models.InsufficientFeedStock
+		}
+
+		feeding.FedTime = time.Now().UTC()
+		feeding.FedBy = models.ActorFromContext(ctx)
+		qs := `INSERT INTO feeding(scheduleId, cage, feed, quantity, feedingDate, fedTime, fedBy)
+				VALUES(?,?,?,?,?,?,?)`
+		params := []interface{}{feeding.ScheduleID, feeding.Cage, feeding.Feed, feeding.Quantity, feeding.Date, feeding.FedTime, feeding.FedBy}
+		result, err := tx.Exec(qs, params...)
+		if err != nil {
+			if isDuplicateKeyError(err) {
+				return models.EntityAlreadyExists
+			}
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		feeding.ID = int(id)
+
+		remaining = *feed
+		remaining.Stock -= feeding.Quantity
+		_, err = tx.Exec(`UPDATE feed SET stock=? WHERE name=?`, remaining.Stock, feed.Name)
+		if err != nil {
+			return err
+		}
+		err = s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityFeeding, strconv.Itoa(feeding.ID)), models.AuditCreate, nil, feeding)
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityFeed, feed.Name), models.AuditUpdate, feed, remaining)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &feeding, &remaining, nil
+}
+
+// GetFeedings lists the feedings in the order they were made, optionally only those on a date or in a cage.
+func (s *ParkSqlDao) GetFeedings(filter models.FeedingFilter) ([]models.Feeding, error) {
+	qs := `SELECT id, scheduleId, cage, feed, quantity, feedingDate, fedTime, fedBy
+		   FROM feeding`
+	where := []string{}
+	args := []any{}
+	if filter.Date != nil {
+		where = append(where, "feedingDate=?")
+		args = append(args, *filter.Date)
+	}
+	if filter.Cage != nil {
+		where = append(where, "cage=?")
+		args = append(args, *filter.Cage)
+	}
+	if len(where) > 0 {
+		qs += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	qs += ` ORDER BY fedTime, id`
+	rows, err := s.db.Query(qs, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feedings := []models.Feeding{}
+	for rows.Next() {
+		feeding := models.Feeding{}
+		var date time.Time
+		err := rows.Scan(&feeding.ID, &feeding.ScheduleID, &feeding.Cage, &feeding.Feed, &feeding.Quantity, &date, &feeding.FedTime, &feeding.FedBy)
+		if err != nil {
+			return nil, err
+		}
+		feeding.Date = date.Format(models.FeedingDateFormat)
+		feedings = append(feedings, feeding)
+	}
+	return feedings, rows.Err()
+}
@@ -0,0 +1,215 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+const healthRecordColumns = `h.id, d.name, h.recordType, h.recordTime, h.weightKg, h.medication, h.dosage, h.notes, h.recordedBy`
+
+const healthRecordFrom = `healthRecord h
+		   JOIN dinosaur d on d.id=h.dinosaurId`
+
+func scanHealthRecord(rows *sql.Rows) (*models.HealthRecord, error) {
+	record := models.HealthRecord{}
+	var medication, dosage sql.NullString
+	err := rows.Scan(&record.ID, &record.Dinosaur, &record.Type, &record.Time, &record.WeightKg, &medication, &dosage, &record.Notes, &record.RecordedBy)
+	if err != nil {
+		return nil, err
+	}
+	record.Medication = medication.String
+	record.Dosage = dosage.String
+	return &record, nil
+}
+
+// nullString stores empty strings as NULL.
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// AddHealthRecord adds a record to the dinosaur's medical history, recorded by the actor in ctx, and returns it.
+func (s *ParkSqlDao) AddHealthRecord(ctx context.Context, record models.HealthRecord) (*models.HealthRecord, error) {
+	var created *models.HealthRecord
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		dinosaurId, err := s.lockDinosaurId(tx, record.Dinosaur)
+		if err != nil {
+			return err
+		}
+		qs := `INSERT INTO healthRecord(dinosaurId, recordType, recordTime, weightKg, medication, dosage, notes, recordedBy)
+				VALUES(?,?,?,?,?,?,?,?)`
+		params := []interface{}{dinosaurId, record.Type, record.Time.UTC(), record.WeightKg, nullString(record.Medication),
+			nullString(record.Dosage), record.Notes, models.ActorFromContext(ctx)}
+		result, err := tx.Exec(qs, params...)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		created, err = s.getHealthRecord(tx, record.Dinosaur, int(id))
+		if err != nil {
+			return err
+		}
+		err = s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityHealth, strconv.Itoa(created.ID)), models.AuditCreate, nil, created)
+		if err != nil {
+			return err
+		}
+		return s.refreshQuarantine(ctx, tx, record.Dinosaur, dinosaurId, created.Type)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *ParkSqlDao) GetHealthRecord(dinosaurName string, id int) (*models.HealthRecord, error) {
+	return s.getHealthRecord(s.db, dinosaurName, id)
+}
+
+func (s *ParkSqlDao) getHealthRecord(q querier, dinosaurName string, id int) (*models.HealthRecord, error) {
+	qs := `SELECT ` + healthRecordColumns + `
+		   FROM ` + healthRecordFrom + `
+		   WHERE h.id=? AND d.name=?`
+	rows, err := q.Query(qs, id, dinosaurName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, models.EntityNotFound
+	}
+	return scanHealthRecord(rows)
+}
+
+// GetHealthRecords reads the dinosaur's medical history, oldest first.
+func (s *ParkSqlDao) GetHealthRecords(dinosaurName string, filter models.HealthRecordFilter) ([]models.HealthRecord, error) {
+	if _, err := s.GetDinosaur(dinosaurName); err != nil {
+		return nil, err
+	}
+	qs := `SELECT ` + healthRecordColumns + `
+		   FROM ` + healthRecordFrom + `
+		   WHERE d.name=?`
+	args := []any{dinosaurName}
+	if filter.Type != nil {
+		qs += ` AND h.recordType=?`
+		args = append(args, *filter.Type)
+	}
+	qs += ` ORDER BY h.recordTime, h.id`
+	rows, err := s.db.Query(qs, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []models.HealthRecord{}
+	for rows.Next() {
+		record, err := scanHealthRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+	return records, rows.Err()
+}
+
+// UpdateHealthRecord replaces the type, time and details of the record with those of the update, and returns the
+// updated record. Who recorded it is kept.
+func (s *ParkSqlDao) UpdateHealthRecord(ctx context.Context, update models.HealthRecord) (*models.HealthRecord, error) {
+	var updated *models.HealthRecord
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		dinosaurId, err := s.lockDinosaurId(tx, update.Dinosaur)
+		if err != nil {
+			return err
+		}
+		before, err := s.getHealthRecord(tx, update.Dinosaur, update.ID)
+		if err != nil {
+			return err
+		}
+		updateStatement := `UPDATE healthRecord
+					   SET recordType=?, recordTime=?, weightKg=?, medication=?, dosage=?, notes=?
+					   WHERE id=?`
+		params := []interface{}{update.Type, update.Time.UTC(), update.WeightKg, nullString(update.Medication),
+			nullString(update.Dosage), update.Notes, update.ID}
+		_, err = tx.Exec(updateStatement, params...)
+		if err != nil {
+			return err
+		}
+		updated, err = s.getHealthRecord(tx, update.Dinosaur, update.ID)
+		if err != nil {
+			return err
+		}
+		err = s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityHealth, strconv.Itoa(update.ID)), models.AuditUpdate, before, updated)
+		if err != nil {
+			return err
+		}
+		return s.refreshQuarantine(ctx, tx, update.Dinosaur, dinosaurId, before.Type, updated.Type)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *ParkSqlDao) DeleteHealthRecord(ctx context.Context, dinosaurName string, id int) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		dinosaurId, err := s.lockDinosaurId(tx, dinosaurName)
+		if err != nil {
+			return err
+		}
+		record, err := s.getHealthRecord(tx, dinosaurName, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM healthRecord WHERE id=?`, id)
+		if err != nil {
+			return err
+		}
+		err = s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityHealth, strconv.Itoa(id)), models.AuditDelete, record, nil)
+		if err != nil {
+			return err
+		}
+		return s.refreshQuarantine(ctx, tx, dinosaurName, dinosaurId, record.Type)
+	})
+}
+
+// refreshQuarantine sets the dinosaur's quarantine status from its latest quarantine or release record, when a
+// record of one of the changed types could have changed it, and records the change.
+func (s *ParkSqlDao) refreshQuarantine(ctx context.Context, tx *sql.Tx, dinosaurName string, dinosaurId int, changed ...models.HealthRecordType) error {
+	changesQuarantine := false
+	for _, recordType := range changed {
+		changesQuarantine = changesQuarantine || recordType.ChangesQuarantine()
+	}
+	if !changesQuarantine {
+		return nil
+	}
+
+	before, err := s.getDinosaur(tx, dinosaurName)
+	if err != nil {
+		return err
+	}
+	updateStatement := `UPDATE dinosaur
+		   SET quarantined=COALESCE((SELECT h.recordType=?
+									 FROM healthRecord h
+									 WHERE h.dinosaurId=? AND h.recordType IN (?,?)
+									 ORDER BY h.recordTime DESC, h.id DESC
+									 LIMIT 1), FALSE)
+		   WHERE id=?`
+	params := []interface{}{models.HealthRecordQuarantine, dinosaurId, models.HealthRecordQuarantine, models.HealthRecordRelease, dinosaurId}
+	_, err = tx.Exec(updateStatement, params...)
+	if err != nil {
+		return err
+	}
+	after, err := s.getDinosaur(tx, dinosaurName)
+	if err != nil {
+		return err
+	}
+	if before.Quarantined == after.Quarantined {
+		return nil
+	}
+	return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityDinosaur, dinosaurName), models.AuditUpdate, before, after)
+}
@@ -0,0 +1,60 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/EdgarH78/jurassic-park/inventory"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// ImportTransaction calls fn with a store whose changes are all made in one transaction. Each change is made under
+// a savepoint, so that a change that fails is rolled back without undoing the changes that were made before it.
+func (s *ParkSqlDao) ImportTransaction(ctx context.Context, fn func(store inventory.Store) error) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		return fn(&importStore{dao: s, tx: tx})
+	})
+}
+
+// importStore makes the changes of an import inside the import's transaction.
+type importStore struct {
+	dao *ParkSqlDao
+	tx  *sql.Tx
+}
+
+func (i *importStore) AddZone(ctx context.Context, zone models.Zone) error {
+	return i.inSavepoint(func() error {
+		return i.dao.addZone(ctx, i.tx, zone)
+	})
+}
+
+func (i *importStore) AddCage(ctx context.Context, cage models.Cage) error {
+	return i.inSavepoint(func() error {
+		return i.dao.addCage(ctx, i.tx, cage)
+	})
+}
+
+func (i *importStore) AddDinosaur(ctx context.Context, dinosaur models.Dinosaur) error {
+	return i.inSavepoint(func() error {
+		return i.dao.addDinosaur(ctx, i.tx, dinosaur)
+	})
+}
+
+func (i *importStore) AddDinosaurToCage(ctx context.Context, dinosaurName, targetCage string) error {
+	return i.inSavepoint(func() error {
+		return i.dao.addDinosaurToCage(ctx, i.tx, dinosaurName, targetCage)
+	})
+}
+
+// inSavepoint runs fn under a savepoint, which is rolled back to if fn returns an error and released otherwise.
+func (i *importStore) inSavepoint(fn func() error) error {
+	if _, err := i.tx.Exec("SAVEPOINT import_row"); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		i.tx.Exec("ROLLBACK TO SAVEPOINT import_row")
+		return err
+	}
+	_, err := i.tx.Exec("RELEASE SAVEPOINT import_row")
+	return err
+}
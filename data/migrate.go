@@ -0,0 +1,399 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// migrationFiles holds the schema migrations. Each version has an up and a down script, named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the name of the MySQL advisory lock that is held while migrating, so that when several server
+// instances start at the same time only one of them migrates and the others wait for it to finish.
+const (
+	migrationLock        = "jurassicpark.schema_migrations"
+	migrationLockTimeout = 5 * time.Minute
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// Optional migrations are only applied while the migrator is configured to want them, see optionalMigrations.
+	Optional bool
+}
+
+// MigrationStatus is a migration along with whether it has been applied to the database.
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// Migrations returns the embedded schema migrations in version order.
+func Migrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, file := range files {
+		base := path.Base(file)
+		versionAndName, direction, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s must end in .up.sql or .down.sql", base)
+		}
+		prefix, name, ok := strings.Cut(versionAndName, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s must start with its version number", base)
+		}
+		script, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d must have both an up and a down script", migration.Version)
+		}
+		_, migration.Optional = optionalMigrations[migration.Name]
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// optionalMigration is a migration that makes a part of the schema match the configuration, such as where cage
+// labels have to be unique. It is applied by Up while the migrator is configured to want it, and rolled back by Up
+// once it no longer is, so later migrations mustn't depend on it.
+type optionalMigration struct {
+	wanted func(m *Migrator) bool
+	// checkDown returns why the migration can't be rolled back, if it can't, so that it is refused before its down
+	// script leaves it dirty.
+	checkDown func(ctx context.Context, conn *sql.Conn) error
+}
+
+// optionalMigrations are the optional migrations by name.
+var optionalMigrations = map[string]optionalMigration{
+	"cage_labels_unique_in_zone": {
+		wanted: func(m *Migrator) bool {
+			return m.cageLabelScope == models.CageLabelsUniqueInZone
+		},
+		checkDown: func(ctx context.Context, conn *sql.Conn) error {
+			rows, err := conn.QueryContext(ctx, `SELECT externalId FROM cage GROUP BY externalId HAVING COUNT(*) > 1 LIMIT 1`)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			if rows.Next() {
+				var label string
+				if err := rows.Scan(&label); err != nil {
+					return err
+				}
+				return fmt.Errorf("cage labels can't be made unique across the park while cages in different zones are labeled %s: %w", label, models.EntityAlreadyExists)
+			}
+			return rows.Err()
+		},
+	},
+}
+
+// Migrator applies the embedded schema migrations and records the applied versions in the schema_migrations
+// table. It is safe to run from several processes at once.
+type Migrator struct {
+	db             *sql.DB
+	migrations     []Migration
+	cageLabelScope models.CageLabelScope
+}
+
+type MigratorOption func(*Migrator)
+
+// WithCageLabelScope sets where the schema makes cage labels unique, which is across the park by default.
+func WithCageLabelScope(scope models.CageLabelScope) MigratorOption {
+	return func(m *Migrator) {
+		m.cageLabelScope = scope
+	}
+}
+
+func NewMigrator(db *sql.DB, opts ...MigratorOption) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{
+		db:             db,
+		migrations:     migrations,
+		cageLabelScope: models.CageLabelsUniqueInPark,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// wanted reports whether the migration should be applied, which every migration other than an optional one should.
+func (m *Migrator) wanted(migration Migration) bool {
+	optional, ok := optionalMigrations[migration.Name]
+	return !ok || optional.wanted(m)
+}
+
+// Up applies every wanted migration that hasn't been applied yet, in version order, and rolls back the optional
+// migrations that are no longer wanted. It returns the migrations it applied, which is none if the database is
+// already up to date.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied := []Migration{}
+	err := m.withLock(ctx, func(conn *sql.Conn, versions map[int]bool) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if !versions[migration.Version] || m.wanted(migration) {
+				continue
+			}
+			if err := m.rollBack(ctx, conn, migration); err != nil {
+				return err
+			}
+		}
+		for _, migration := range m.migrations {
+			if versions[migration.Version] || !m.wanted(migration) {
+				continue
+			}
+			if err := m.run(ctx, conn, migration.Version, migration.Up); err != nil {
+				return fmt.Errorf("applying migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+			_, err := conn.ExecContext(ctx, `UPDATE schema_migrations SET name=?, dirty=FALSE WHERE version=?`, migration.Name, migration.Version)
+			if err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last steps migrations that were applied, newest first. It returns the migrations it rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	rolledBack := []Migration{}
+	err := m.withLock(ctx, func(conn *sql.Conn, versions map[int]bool) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			migration := m.migrations[i]
+			if !versions[migration.Version] {
+				continue
+			}
+			if err := m.rollBack(ctx, conn, migration); err != nil {
+				return err
+			}
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// rollBack runs the down script of the migration and forgets that it was applied.
+func (m *Migrator) rollBack(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if optional, ok := optionalMigrations[migration.Name]; ok {
+		if err := optional.checkDown(ctx, conn); err != nil {
+			return fmt.Errorf("rolling back migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	if err := m.run(ctx, conn, migration.Version, migration.Down); err != nil {
+		return fmt.Errorf("rolling back migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	_, err := conn.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version=?`, migration.Version)
+	return err
+}
+
+// Status lists every migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	statuses := []MigrationStatus{}
+	err := m.withLock(ctx, func(conn *sql.Conn, versions map[int]bool) error {
+		for _, migration := range m.migrations {
+			statuses = append(statuses, MigrationStatus{
+				Migration: migration,
+				Applied:   versions[migration.Version],
+			})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// run executes a migration script one statement at a time. MySQL commits schema changes as they are made, so a
+// script can't be rolled back if it fails part way. The version is marked dirty until the script has finished,
+// and a dirty version stops any further migrations until it has been fixed by hand.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, version int, script string) error {
+	_, err := conn.ExecContext(ctx, `INSERT INTO schema_migrations(version, dirty) VALUES(?, TRUE)
+		ON DUPLICATE KEY UPDATE dirty=TRUE`, version)
+	if err != nil {
+		return err
+	}
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withLock holds the migration lock while fn runs. fn is passed the versions that have been applied.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, versions map[int]bool) error) error {
+	// the advisory lock belongs to the session that took it, so every statement has to run on the same connection
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, migrationLock, int(migrationLockTimeout.Seconds())).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("timed out waiting for another instance to finish migrating")
+	}
+	defer conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, migrationLock)
+
+	versions, err := m.appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, versions)
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			version INT NOT NULL,
+			name VARCHAR(64) NOT NULL DEFAULT '',
+			dirty BOOL NOT NULL,
+			appliedTime DATETIME(6) DEFAULT NOW(6),
+			PRIMARY KEY(version)
+		)`)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.baseline(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, dirty FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int]bool{}
+	for rows.Next() {
+		var version int
+		var dirty bool
+		if err := rows.Scan(&version, &dirty); err != nil {
+			return nil, err
+		}
+		if dirty {
+			return nil, fmt.Errorf("migration %d failed part way through and has to be fixed by hand, then removed from schema_migrations", version)
+		}
+		versions[version] = true
+	}
+	return versions, rows.Err()
+}
+
+// baseline records the migrations that were already applied to databases created from the SQL script that came
+// before the migrations, so that they aren't applied a second time.
+func (m *Migrator) baseline(ctx context.Context, conn *sql.Conn) error {
+	var recorded int
+	if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&recorded); err != nil {
+		return err
+	}
+	if recorded > 0 {
+		return nil
+	}
+
+	columnExists := func(table, column string) (bool, error) {
+		var count int
+		err := conn.QueryRowContext(ctx, `SELECT COUNT(*)
+			FROM information_schema.columns
+			WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`, table, column).Scan(&count)
+		return count > 0, err
+	}
+	hasCages, err := columnExists("cage", "id")
+	if err != nil || !hasCages {
+		return err
+	}
+	hasPowerStatus, err := columnExists("cage", "powerStatus")
+	if err != nil {
+		return err
+	}
+
+	baseline := 1
+	if hasPowerStatus {
+		baseline = 2
+	}
+	for _, migration := range m.migrations[:baseline] {
+		_, err := conn.ExecContext(ctx, `INSERT INTO schema_migrations(version, name, dirty) VALUES(?, ?, FALSE)`,
+			migration.Version, migration.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a migration script into its statements, which end with a semicolon at the end of a
+// line. Comment lines are dropped.
+func splitStatements(script string) []string {
+	statements := []string{}
+	statement := []string{}
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		statement = append(statement, line)
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(strings.Join(statement, "\n")), ";"))
+			statement = []string{}
+		}
+	}
+	if len(statement) > 0 {
+		statements = append(statements, strings.TrimSpace(strings.Join(statement, "\n")))
+	}
+	return statements
+}
@@ -0,0 +1,5 @@
+DROP TABLE `dinosaur`;
+DROP TABLE `sex`;
+DROP TABLE `species`;
+DROP TABLE `speciesDiet`;
+DROP TABLE `cage`;
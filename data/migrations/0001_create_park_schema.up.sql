@@ -1,3 +1,5 @@
+-- The park schema as it was first released, with power tracked as the hasPower flag.
+
 CREATE TABLE `cage`
 (
     `id` INT NOT NULL AUTO_INCREMENT,
@@ -60,4 +62,4 @@ CREATE TABLE `dinosaur`
     CONSTRAINT `dinosaur_sex_fk` FOREIGN KEY(`sex`) REFERENCES `sex`(`name`),
     PRIMARY KEY(`id`)
 );
-CREATE UNIQUE INDEX `dinosaur_name` ON `dinosaur`(`name`);
\ No newline at end of file
+CREATE UNIQUE INDEX `dinosaur_name` ON `dinosaur`(`name`);
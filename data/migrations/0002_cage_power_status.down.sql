@@ -0,0 +1,8 @@
+-- Restores the hasPower flag. Every power status other than DOWN has power.
+
+ALTER TABLE `cage` ADD COLUMN `hasPower` TINYINT NOT NULL DEFAULT 0 AFTER `capacity`;
+UPDATE `cage` SET `hasPower` = IF(`powerStatus` = 'DOWN', 0, 1);
+ALTER TABLE `cage` ALTER COLUMN `hasPower` DROP DEFAULT;
+ALTER TABLE `cage` DROP FOREIGN KEY `cage_powerStatus_fk`;
+ALTER TABLE `cage` DROP COLUMN `powerStatus`;
+DROP TABLE `powerStatus`;
@@ -0,0 +1,19 @@
+-- Replaces the hasPower flag on cage with an enumerated power status.
+-- Existing cages with power become ACTIVE and cages without power become DOWN.
+
+CREATE TABLE `powerStatus`
+(
+    `name` VARCHAR(16) NOT NULL,
+    PRIMARY KEY(`name`)
+);
+INSERT INTO `powerStatus`(`name`)
+VALUES('ACTIVE'),
+      ('MAINTENANCE'),
+      ('FAILING'),
+      ('DOWN');
+
+ALTER TABLE `cage` ADD COLUMN `powerStatus` VARCHAR(16) NOT NULL DEFAULT 'DOWN' AFTER `capacity`;
+UPDATE `cage` SET `powerStatus` = IF(`hasPower` = 1, 'ACTIVE', 'DOWN');
+ALTER TABLE `cage` ALTER COLUMN `powerStatus` DROP DEFAULT;
+ALTER TABLE `cage` ADD CONSTRAINT `cage_powerStatus_fk` FOREIGN KEY(`powerStatus`) REFERENCES `powerStatus`(`name`);
+ALTER TABLE `cage` DROP COLUMN `hasPower`;
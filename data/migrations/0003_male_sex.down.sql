@@ -0,0 +1,4 @@
+-- Rolling back would have to record male dinosaurs as female, which loses their sex changes. The foreign key from
+-- dinosaur refuses to delete Male while any dinosaur is male, so those dinosaurs have to be changed or removed first.
+
+DELETE FROM `sex` WHERE `name` = 'Male';
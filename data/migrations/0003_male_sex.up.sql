@@ -0,0 +1,4 @@
+-- Dinosaurs were all recorded as female. Some have changed sex because of the frog DNA used to complete their genomes.
+
+INSERT INTO `sex`(`name`)
+VALUES('Male');
@@ -0,0 +1,4 @@
+-- Drops the species compatibility rules, leaving only the diet rules.
+
+DROP TABLE `speciesConstraint`;
+DROP TABLE `speciesCompatibility`;
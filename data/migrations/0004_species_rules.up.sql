@@ -0,0 +1,21 @@
+-- Species compatibility rules. Pairs of species without a row in speciesCompatibility fall back on their diets.
+
+CREATE TABLE `speciesCompatibility`
+(
+    `speciesA` VARCHAR(16) NOT NULL,
+    `speciesB` VARCHAR(16) NOT NULL,
+    `compatible` BOOL NOT NULL,
+    `reason` VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY(`speciesA`, `speciesB`),
+    CONSTRAINT `speciesCompatibility_speciesA_fk` FOREIGN KEY(`speciesA`) REFERENCES `species`(`name`) ON DELETE CASCADE,
+    CONSTRAINT `speciesCompatibility_speciesB_fk` FOREIGN KEY(`speciesB`) REFERENCES `species`(`name`) ON DELETE CASCADE
+);
+
+CREATE TABLE `speciesConstraint`
+(
+    `species` VARCHAR(16) NOT NULL,
+    `minPackSize` INT NOT NULL DEFAULT 0,
+    `maxPerCage` INT NOT NULL DEFAULT 0,
+    PRIMARY KEY(`species`),
+    CONSTRAINT `speciesConstraint_species_fk` FOREIGN KEY(`species`) REFERENCES `species`(`name`) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+-- Drops the webhook subscriptions along with their delivery log.
+
+DROP TABLE `webhookDelivery`;
+DROP TABLE `deliveryStatus`;
+DROP TABLE `webhook`;
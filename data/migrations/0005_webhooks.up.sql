@@ -0,0 +1,41 @@
+-- Webhook subscriptions and the log of their deliveries. events is a comma separated list of event types.
+
+CREATE TABLE `webhook`
+(
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `url` VARCHAR(2048) NOT NULL,
+    `secret` VARCHAR(128) NOT NULL,
+    `events` VARCHAR(1024) NOT NULL,
+    `createdTime` DATETIME(6) NOT NULL DEFAULT NOW(6),
+    PRIMARY KEY(`id`)
+);
+
+CREATE TABLE `deliveryStatus`
+(
+    `name` VARCHAR(16) NOT NULL,
+    PRIMARY KEY(`name`)
+);
+INSERT INTO `deliveryStatus`(`name`)
+VALUES('PENDING'),
+      ('DELIVERED'),
+      ('DEAD_LETTERED');
+
+CREATE TABLE `webhookDelivery`
+(
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `webhookId` INT NOT NULL,
+    `eventId` BIGINT UNSIGNED NOT NULL,
+    `eventType` VARCHAR(64) NOT NULL,
+    `payload` TEXT NOT NULL,
+    `status` VARCHAR(16) NOT NULL,
+    `attempts` INT NOT NULL DEFAULT 0,
+    `responseStatus` INT NULL,
+    `lastError` VARCHAR(1024) NOT NULL DEFAULT '',
+    `nextAttemptTime` DATETIME(6) NULL,
+    `createdTime` DATETIME(6) NOT NULL DEFAULT NOW(6),
+    `completedTime` DATETIME(6) NULL,
+    CONSTRAINT `webhookDelivery_webhookId_fk` FOREIGN KEY(`webhookId`) REFERENCES `webhook`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `webhookDelivery_status_fk` FOREIGN KEY(`status`) REFERENCES `deliveryStatus`(`name`),
+    PRIMARY KEY(`id`)
+);
+CREATE INDEX `webhookDelivery_due` ON `webhookDelivery`(`status`, `nextAttemptTime`);
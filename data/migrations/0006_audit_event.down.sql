@@ -0,0 +1,3 @@
+-- Drops the audit log.
+
+DROP TABLE `audit_event`;
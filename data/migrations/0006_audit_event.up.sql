@@ -0,0 +1,16 @@
+-- An append-only log of every change made to the park. Rows are only ever inserted, in the same transaction as the
+-- change they record. entity is the type and key of what changed, as in cage:C-1.
+
+CREATE TABLE `audit_event`
+(
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `actor` VARCHAR(255) NOT NULL,
+    `eventTime` DATETIME(6) NOT NULL,
+    `entity` VARCHAR(64) NOT NULL,
+    `action` VARCHAR(16) NOT NULL,
+    `beforeState` JSON NULL,
+    `afterState` JSON NULL,
+    PRIMARY KEY(`id`)
+);
+CREATE INDEX `audit_event_entity` ON `audit_event`(`entity`, `id`);
+CREATE INDEX `audit_event_eventTime` ON `audit_event`(`eventTime`);
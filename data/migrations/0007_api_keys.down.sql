@@ -0,0 +1,3 @@
+-- Drops the API keys.
+
+DROP TABLE `apiKey`;
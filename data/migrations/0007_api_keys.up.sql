@@ -0,0 +1,17 @@
+-- API keys that authenticate requests. Only the SHA-256 hash of a key is stored, along with its first few characters
+-- so that it can be recognized. roles is a comma separated list of roles. Revoked keys are kept, with the time they
+-- were revoked, so that their names in the audit log stay unique.
+
+CREATE TABLE `apiKey`
+(
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `name` VARCHAR(64) NOT NULL,
+    `keyHash` CHAR(64) NOT NULL,
+    `keyPrefix` VARCHAR(16) NOT NULL,
+    `roles` VARCHAR(256) NOT NULL,
+    `createdTime` DATETIME(6) NOT NULL DEFAULT NOW(6),
+    `revokedTime` DATETIME(6) NULL,
+    PRIMARY KEY(`id`)
+);
+CREATE UNIQUE INDEX `apiKey_name` ON `apiKey`(`name`);
+CREATE UNIQUE INDEX `apiKey_keyHash` ON `apiKey`(`keyHash`);
@@ -0,0 +1,5 @@
+-- Drops the feeding schedules, feedings and feed stock.
+
+DROP TABLE `feeding`;
+DROP TABLE `feedingSchedule`;
+DROP TABLE `feed`;
@@ -0,0 +1,41 @@
+-- Feed stock, the daily feeding schedule of each cage and the feedings that were made. quantity is the amount of
+-- feed for each dinosaur in the cage that eats it, in the feed's unit. Feedings record their cage and feed by name,
+-- so that they are kept after the cage and its schedules are deleted.
+
+CREATE TABLE `feed`
+(
+    `name` VARCHAR(32) NOT NULL,
+    `diet` VARCHAR(16) NOT NULL,
+    `unit` VARCHAR(16) NOT NULL,
+    `stock` INT NOT NULL DEFAULT 0,
+    `lowStockThreshold` INT NOT NULL DEFAULT 0,
+    CONSTRAINT `feed_diet_fk` FOREIGN KEY(`diet`) REFERENCES `speciesDiet`(`name`),
+    PRIMARY KEY(`name`)
+);
+
+CREATE TABLE `feedingSchedule`
+(
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `cageId` INT NOT NULL,
+    `feed` VARCHAR(32) NOT NULL,
+    `feedingTime` CHAR(5) NOT NULL,
+    `quantity` INT NOT NULL,
+    CONSTRAINT `feedingSchedule_cageId_fk` FOREIGN KEY(`cageId`) REFERENCES `cage`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `feedingSchedule_feed_fk` FOREIGN KEY(`feed`) REFERENCES `feed`(`name`),
+    PRIMARY KEY(`id`)
+);
+
+CREATE TABLE `feeding`
+(
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `scheduleId` INT NOT NULL,
+    `cage` VARCHAR(16) NOT NULL,
+    `feed` VARCHAR(32) NOT NULL,
+    `quantity` INT NOT NULL,
+    `feedingDate` DATE NOT NULL,
+    `fedTime` DATETIME(6) NOT NULL,
+    `fedBy` VARCHAR(255) NOT NULL,
+    PRIMARY KEY(`id`)
+);
+CREATE UNIQUE INDEX `feeding_scheduleId_feedingDate` ON `feeding`(`scheduleId`, `feedingDate`);
+CREATE INDEX `feeding_feedingDate` ON `feeding`(`feedingDate`);
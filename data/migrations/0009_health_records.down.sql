@@ -0,0 +1,4 @@
+-- Drops the health records and the quarantine status of dinosaurs.
+
+ALTER TABLE `dinosaur` DROP COLUMN `quarantined`;
+DROP TABLE `healthRecord`;
@@ -0,0 +1,21 @@
+-- The medical history of each dinosaur. weightKg is only set on weight records, and medication and dosage on
+-- medication records. dinosaur.quarantined follows the latest quarantine or release record of the dinosaur, so that
+-- dinosaurs can be filtered on it and cages checked against it without reading their history.
+
+CREATE TABLE `healthRecord`
+(
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `dinosaurId` INT NOT NULL,
+    `recordType` VARCHAR(16) NOT NULL,
+    `recordTime` DATETIME(6) NOT NULL,
+    `weightKg` DOUBLE NULL,
+    `medication` VARCHAR(64) NULL,
+    `dosage` VARCHAR(64) NULL,
+    `notes` VARCHAR(1024) NOT NULL DEFAULT '',
+    `recordedBy` VARCHAR(255) NOT NULL,
+    CONSTRAINT `healthRecord_dinosaurId_fk` FOREIGN KEY(`dinosaurId`) REFERENCES `dinosaur`(`id`) ON DELETE CASCADE,
+    PRIMARY KEY(`id`)
+);
+CREATE INDEX `healthRecord_dinosaurId_recordTime` ON `healthRecord`(`dinosaurId`, `recordTime`);
+
+ALTER TABLE `dinosaur` ADD COLUMN `quarantined` BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- Drops the zones and takes every cage out of its zone.
+
+ALTER TABLE `cage` DROP FOREIGN KEY `cage_zone_fk`;
+ALTER TABLE `cage` DROP COLUMN `zone`;
+DROP TABLE `zone`;
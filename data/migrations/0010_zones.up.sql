@@ -0,0 +1,14 @@
+-- The zones that the park is organized into, such as sectors and paddocks. A zone can be nested in a parent zone,
+-- and a cage can be placed in a zone. Zones are kept by name, and can't be deleted while cages or zones are in them.
+
+CREATE TABLE `zone`
+(
+    `name` VARCHAR(64) NOT NULL,
+    `parent` VARCHAR(64) NULL,
+    `description` VARCHAR(255) NOT NULL DEFAULT '',
+    CONSTRAINT `zone_parent_fk` FOREIGN KEY(`parent`) REFERENCES `zone`(`name`),
+    PRIMARY KEY(`name`)
+);
+
+ALTER TABLE `cage` ADD COLUMN `zone` VARCHAR(64) NULL;
+ALTER TABLE `cage` ADD CONSTRAINT `cage_zone_fk` FOREIGN KEY(`zone`) REFERENCES `zone`(`name`);
@@ -0,0 +1,9 @@
+-- Drops the index that keeps cage labels unique within their zone. The park-wide index on labels is back by now,
+-- since 0012 is rolled back first. Narrowing the columns fails while feedings or audit events refer to cages by zone
+-- and label.
+
+ALTER TABLE `audit_event` MODIFY COLUMN `entity` VARCHAR(64) NOT NULL;
+ALTER TABLE `feeding` MODIFY COLUMN `cage` VARCHAR(16) NOT NULL;
+
+DROP INDEX `cage_zoneKey_externalId` ON `cage`;
+ALTER TABLE `cage` DROP COLUMN `zoneKey`;
@@ -0,0 +1,10 @@
+-- Lets cage labels be unique within their zone instead of across the park. zoneKey is the zone of a cage, or an
+-- empty string for a cage that isn't in a zone, so that cages outside of zones are unique among themselves too. The
+-- park-wide index on labels is kept, and is dropped by 0012 when the park runs with CAGE_LABEL_SCOPE=zone.
+-- Cages are referred to by zone and label in that scope, so the columns that record cage references are widened.
+
+ALTER TABLE `cage` ADD COLUMN `zoneKey` VARCHAR(64) AS (COALESCE(`zone`, '')) STORED NOT NULL;
+CREATE UNIQUE INDEX `cage_zoneKey_externalId` ON `cage`(`zoneKey`, `externalId`);
+
+ALTER TABLE `feeding` MODIFY COLUMN `cage` VARCHAR(81) NOT NULL;
+ALTER TABLE `audit_event` MODIFY COLUMN `entity` VARCHAR(128) NOT NULL;
@@ -0,0 +1,4 @@
+-- Makes cage labels unique across the park again. The migrator refuses to run this while cages in different zones
+-- share a label.
+
+CREATE UNIQUE INDEX `cage_externalId` ON `cage`(`externalId`);
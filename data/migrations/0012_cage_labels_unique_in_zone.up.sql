@@ -0,0 +1,5 @@
+-- Drops the park-wide index on cage labels, leaving them unique within their zone through the index added by 0011.
+-- This migration is optional: it is only applied while the park runs with CAGE_LABEL_SCOPE=zone, and the migrator
+-- rolls it back once it runs with CAGE_LABEL_SCOPE=park again.
+
+DROP INDEX `cage_externalId` ON `cage`;
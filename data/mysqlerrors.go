@@ -0,0 +1,25 @@
+package data
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// mysqlDuplicateEntry is the MySQL error number for a unique constraint violation.
+	mysqlDuplicateEntry = 1062
+	// mysqlRowIsReferenced is the MySQL error number for deleting a row that a foreign key still references.
+	mysqlRowIsReferenced = 1451
+	// mysqlNoReferencedRow is the MySQL error number for inserting a row whose foreign key has no parent.
+	mysqlNoReferencedRow = 1452
+)
+
+func isMySQLError(err error, number uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == number
+}
+
+func isDuplicateKeyError(err error) bool {
+	return isMySQLError(err, mysqlDuplicateEntry)
+}
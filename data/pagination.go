@@ -0,0 +1,137 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// listQuery holds the parts of a query for a list, so that the same filters can be used both to read a page and
+// to count the rows across every page.
+type listQuery struct {
+	columns    string
+	from       string
+	where      []string
+	whereArgs  []any
+	groupBy    string
+	having     []string
+	havingArgs []any
+}
+
+func (q listQuery) sql(columns string) (string, []any) {
+	qs := "SELECT " + columns + " FROM " + q.from
+	args := append([]any{}, q.whereArgs...)
+	if len(q.where) > 0 {
+		qs += " WHERE " + strings.Join(q.where, " AND ")
+	}
+	qs += q.groupBy
+	if len(q.having) > 0 {
+		qs += " HAVING " + strings.Join(q.having, " AND ")
+	}
+	args = append(args, q.havingArgs...)
+	return qs, args
+}
+
+// sortColumn is an expression that a list can be sorted on.
+type sortColumn struct {
+	expression string
+	numeric    bool
+	// aggregate columns are compared to the cursor in the HAVING clause rather than the WHERE clause
+	aggregate bool
+}
+
+// keyset pages through a list in sort order. Rows with the same sort value are ordered by id, and a page carries
+// on from the sort value and id of the last row of the previous page, so rows added or removed in between don't
+// shift the pages.
+type keyset struct {
+	// sort is recorded in the cursors, so that a cursor can't be used with a different sort
+	sort       string
+	column     *sortColumn
+	descending bool
+	idColumn   string
+}
+
+// pageQuery returns the query for one page. The sort value of each row is selected after q's columns, followed
+// by its id. One row more than the limit is read, to find out whether there is another page.
+func (k keyset) pageQuery(q listQuery, page models.Pagination) (string, []any, error) {
+	if page.After != nil {
+		condition, args, err := k.after(*page.After)
+		if err != nil {
+			return "", nil, err
+		}
+		if k.column != nil && k.column.aggregate {
+			q.having = append(append([]string{}, q.having...), condition)
+			q.havingArgs = append(append([]any{}, q.havingArgs...), args...)
+		} else {
+			q.where = append(append([]string{}, q.where...), condition)
+			q.whereArgs = append(append([]any{}, q.whereArgs...), args...)
+		}
+	}
+
+	sortValue := "''"
+	orderBy := " ORDER BY " + k.idColumn
+	if k.column != nil {
+		sortValue = k.column.expression
+		direction := "ASC"
+		if k.descending {
+			direction = "DESC"
+		}
+		orderBy = fmt.Sprintf(" ORDER BY %s %s, %s", k.column.expression, direction, k.idColumn)
+	}
+	qs, args := q.sql(fmt.Sprintf("%s, %s AS sortValue, %s", q.columns, sortValue, k.idColumn))
+	qs += orderBy
+	if page.Limit > 0 {
+		qs += " LIMIT ?"
+		args = append(args, page.Limit+1)
+	}
+	return qs, args, nil
+}
+
+// after returns the condition that selects the rows after the cursor.
+func (k keyset) after(cursor models.Cursor) (string, []any, error) {
+	if cursor.Sort != k.sort {
+		return "", nil, models.InvalidCursor
+	}
+	if k.column == nil {
+		return fmt.Sprintf(" %s > ? ", k.idColumn), []any{cursor.ID}, nil
+	}
+
+	var value any = cursor.Value
+	if k.column.numeric {
+		number, err := strconv.Atoi(cursor.Value)
+		if err != nil {
+			return "", nil, models.InvalidCursor
+		}
+		value = number
+	}
+	comparison := ">"
+	if k.descending {
+		comparison = "<"
+	}
+	condition := fmt.Sprintf(" (%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s > ?)) ", k.column.expression, comparison, k.idColumn)
+	return condition, []any{value, value, cursor.ID}, nil
+}
+
+// pageInfo trims the extra row read by pageQuery and returns the number of rows on the page, along with the
+// cursor for the next page. sortValues and ids hold the sort value and id of each row that was read.
+func (k keyset) pageInfo(page models.Pagination, sortValues []string, ids []int) (int, models.PageInfo) {
+	pageInfo := models.PageInfo{}
+	if page.Limit <= 0 || len(ids) <= page.Limit {
+		return len(ids), pageInfo
+	}
+	pageInfo.Next = &models.Cursor{
+		Sort:  k.sort,
+		Value: sortValues[page.Limit-1],
+		ID:    ids[page.Limit-1],
+	}
+	return page.Limit, pageInfo
+}
+
+func (s *ParkSqlDao) countRows(q listQuery) (int, error) {
+	qs, args := q.sql(q.columns)
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM ("+qs+") counted", args...).Scan(&count)
+	return count, err
+}
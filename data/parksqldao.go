@@ -1,11 +1,15 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/rules"
+	"github.com/EdgarH78/jurassic-park/zones"
 	_ "github.com/go-sql-driver/mysql"
 )
 
@@ -25,11 +29,29 @@ func (sc *SQLConfig) ConnectionString() string {
 	return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", sc.User, sc.Password, sc.Host, sc.DatabaseName)
 }
 
+// querier is satisfied by both *sql.DB and *sql.Tx, so that read helpers can be used inside or outside of a transaction.
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
 type ParkSqlDao struct {
-	db *sql.DB
+	db             *sql.DB
+	breedingPolicy models.BreedingPolicy
+	compatibility  *rules.Engine
+	cageLabelScope models.CageLabelScope
 }
 
 func NewParkSqlDao(sqlConfig SQLConfig) (*ParkSqlDao, error) {
+	db, err := OpenDB(sqlConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewParkSqlDaoFromDB(db), nil
+}
+
+// OpenDB opens the connection pool for the database and checks that the database can be reached.
+func OpenDB(sqlConfig SQLConfig) (*sql.DB, error) {
 	db, err := sql.Open("mysql", sqlConfig.ConnectionString())
 	if err != nil {
 		return nil, err
@@ -37,24 +59,110 @@ func NewParkSqlDao(sqlConfig SQLConfig) (*ParkSqlDao, error) {
 	db.SetMaxOpenConns(maxOpenConns)
 	db.SetMaxIdleConns(maxIdleConns)
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
+	return db, nil
+}
+
+// NewParkSqlDaoFromDB creates a ParkSqlDao on top of an existing connection pool, for example one opened with an
+// instrumented driver.
+func NewParkSqlDaoFromDB(db *sql.DB) *ParkSqlDao {
 	return &ParkSqlDao{
-		db: db,
-	}, nil
+		db:             db,
+		breedingPolicy: models.DefaultBreedingPolicy,
+		compatibility:  rules.NewEngine(rules.Rules{}),
+		cageLabelScope: models.CageLabelsUniqueInPark,
+	}
+}
+
+// SetBreedingPolicy replaces the breeding policy that is enforced when dinosaurs are added to cages. It should be
+// called before the dao is used.
+func (s *ParkSqlDao) SetBreedingPolicy(policy models.BreedingPolicy) {
+	s.breedingPolicy = policy
+}
+
+// SetCompatibilityRules replaces the species compatibility rules that are enforced when dinosaurs are added to
+// cages. It should be called before the dao is used.
+func (s *ParkSqlDao) SetCompatibilityRules(engine *rules.Engine) {
+	s.compatibility = engine
 }
 
-func (s *ParkSqlDao) AddCage(cage models.Cage) error {
-	qs := `INSERT INTO cage(externalId, capacity, hasPower)
-			VALUES(?,?,?)`
-	params := []interface{}{cage.Label, cage.MaxOccupancy, cage.HasPower}
-	_, err := s.db.Exec(qs, params...)
+// SetCageLabelScope sets where cage labels have to be unique. The schema is changed to match by the migrations run
+// with the same scope, see WithCageLabelScope, and this fails if it doesn't match. It should be called before the dao
+// is used.
+func (s *ParkSqlDao) SetCageLabelScope(ctx context.Context, scope models.CageLabelScope) error {
+	var indexes int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*)
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = 'cage' AND index_name = 'cage_externalId'`).Scan(&indexes)
 	if err != nil {
 		return err
 	}
+	if scope == models.CageLabelsUniqueInZone && indexes > 0 {
+		return fmt.Errorf("CAGE_LABEL_SCOPE is %s but the database keeps cage labels unique across the park; run the migrations with CAGE_LABEL_SCOPE=%s", scope, scope)
+	}
+	if scope == models.CageLabelsUniqueInPark && indexes == 0 {
+		return fmt.Errorf("CAGE_LABEL_SCOPE is %s but the database only keeps cage labels unique within their zone; run the migrations with CAGE_LABEL_SCOPE=%s", scope, scope)
+	}
+	s.cageLabelScope = scope
 	return nil
 }
 
+// cageRef returns how the cage is referred to, as described by models.CageLabelScope.
+func (s *ParkSqlDao) cageRef(cage models.Cage) string {
+	return s.cageLabelScope.Ref(cage.Zone, cage.Label)
+}
+
+// cageRefColumn selects how the cage joined as c is referred to.
+func (s *ParkSqlDao) cageRefColumn() string {
+	if s.cageLabelScope != models.CageLabelsUniqueInZone {
+		return `c.externalId`
+	}
+	return `CASE WHEN c.zone IS NULL THEN c.externalId ELSE CONCAT(c.zone, '` + models.CageRefSeparator + `', c.externalId) END`
+}
+
+// cageWhere is the condition that finds the cage joined as c that ref refers to. zoneKey is the cage's zone, or an
+// empty string for a cage that isn't in a zone.
+func (s *ParkSqlDao) cageWhere(ref string) (string, []any) {
+	if s.cageLabelScope != models.CageLabelsUniqueInZone {
+		return `c.externalId = ?`, []any{ref}
+	}
+	zone, label := s.cageLabelScope.ParseRef(ref)
+	return `c.zoneKey = ? AND c.externalId = ?`, []any{zone, label}
+}
+
+func (s *ParkSqlDao) AddCage(ctx context.Context, cage models.Cage) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		return s.addCage(ctx, tx, cage)
+	})
+}
+
+func (s *ParkSqlDao) addCage(ctx context.Context, tx *sql.Tx, cage models.Cage) error {
+	powerStatus := cage.RequestedPowerStatus()
+	if !powerStatus.IsValid() {
+		return models.InvalidPowerStatus
+	}
+	if err := s.cageLabelScope.CheckLabel(cage.Label); err != nil {
+		return err
+	}
+	qs := `INSERT INTO cage(externalId, capacity, powerStatus, zone)
+			VALUES(?,?,?,?)`
+	params := []interface{}{cage.Label, cage.MaxOccupancy, powerStatus, cage.Zone}
+	_, err := tx.Exec(qs, params...)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return models.EntityAlreadyExists
+		}
+		if isMySQLError(err, mysqlNoReferencedRow) {
+			return models.InvalidCageZone
+		}
+		return err
+	}
+	created := models.CageV2{Label: cage.Label, MaxOccupancy: cage.MaxOccupancy, PowerStatus: powerStatus, Zone: cage.Zone}
+	return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityCage, s.cageRef(cage)), models.AuditCreate, nil, created)
+}
+
 func (s *ParkSqlDao) GetCage(cageLabel string) (*models.Cage, error) {
 	cage, _, err := s.getCageWithId(cageLabel)
 	if err != nil {
@@ -63,12 +171,34 @@ func (s *ParkSqlDao) GetCage(cageLabel string) (*models.Cage, error) {
 	return cage, nil
 }
 
+// cageColumns selects a cage along with its occupancy. Occupancy is counted by joining the dinosaurs in the cage
+// and grouping by cage, so any number of cages is read in a single round trip.
+const cageColumns = `c.id, c.externalId, c.capacity, c.powerStatus, c.zone, COUNT(d.id) AS occupancy`
+
+const cageGroupBy = ` GROUP BY c.id, c.externalId, c.capacity, c.powerStatus, c.zone`
+
+// cageSortColumns maps the fields that cages can be sorted on to their columns.
+var cageSortColumns = map[string]sortColumn{
+	models.SortByLabel:        {expression: "c.externalId"},
+	models.SortByOccupancy:    {expression: "COUNT(d.id)", numeric: true, aggregate: true},
+	models.SortByMaxOccupancy: {expression: "c.capacity", numeric: true},
+}
+
+// canHouseRank ranks the cages that can house a dinosaur. Cages that already hold the dinosaur's species are ranked
+// first, then other occupied cages, and empty cages come last so they stay free for species that can't share.
+const canHouseRank = `CASE
+			WHEN SUM(CASE WHEN d.species = t.species THEN 1 ELSE 0 END) > 0 THEN 0
+			WHEN COUNT(d.id) > 0 THEN 1
+			ELSE 2
+		END`
+
 func (s *ParkSqlDao) getCageWithId(cageLabel string) (*models.Cage, int, error) {
-	qs := `SELECT id, externalId, capacity, hasPower 
-			FROM cage			
-			WHERE externalId = ?
-			`
-	rows, err := s.db.Query(qs, cageLabel)
+	where, args := s.cageWhere(cageLabel)
+	qs := `SELECT ` + cageColumns + `
+			FROM cage c
+			LEFT OUTER JOIN dinosaur d on d.cageId=c.id
+			WHERE ` + where + cageGroupBy
+	rows, err := s.db.Query(qs, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -77,71 +207,195 @@ func (s *ParkSqlDao) getCageWithId(cageLabel string) (*models.Cage, int, error)
 	if !rows.Next() {
 		return nil, 0, models.EntityNotFound
 	}
-	var id int
-	cage := models.Cage{}
-	if err := rows.Scan(&id, &cage.Label, &cage.MaxOccupancy, &cage.HasPower); err != nil {
+	cage, id, err := scanCage(rows)
+	if err != nil {
 		return nil, 0, err
 	}
+	return cage, id, nil
+}
 
-	occupancy, err := s.getDinosaurCountInCage(id)
-	if err != nil {
+// scanCage reads a row selected with cageColumns. Any columns selected after them are read into extra.
+func scanCage(rows *sql.Rows, extra ...any) (*models.Cage, int, error) {
+	var id int
+	cage := models.Cage{}
+	dest := append([]any{&id, &cage.Label, &cage.MaxOccupancy, &cage.PowerStatus, &cage.Zone, &cage.Occupancy}, extra...)
+	if err := rows.Scan(dest...); err != nil {
 		return nil, 0, err
 	}
-	cage.Occupancy = occupancy
-
+	cage.HasPower = cage.PowerStatus.HasPower()
 	return &cage, id, nil
 }
 
-func (s *ParkSqlDao) GetCages(filter models.CageFilter) ([]models.Cage, error) {
-	qs := `SELECT id, externalId, capacity, hasPower 
-			FROM cage`
+func (s *ParkSqlDao) GetCages(filter models.CageFilter) ([]models.Cage, models.PageInfo, error) {
+	q := listQuery{
+		columns: cageColumns,
+		from:    `cage c`,
+		groupBy: cageGroupBy,
+	}
+	keys := keyset{
+		sort:       filter.Page.Sort.String(),
+		descending: filter.Page.Sort.Descending,
+		idColumn:   "c.id",
+	}
+	if filter.Page.Sort.Field != "" {
+		if filter.CanHouse != nil {
+			return nil, models.PageInfo{}, models.InvalidSort
+		}
+		column, ok := cageSortColumns[filter.Page.Sort.Field]
+		if !ok {
+			return nil, models.PageInfo{}, models.InvalidSort
+		}
+		keys.column = &column
+	}
 
-	whereParts := []string{}
+	var canHouse *models.Dinosaur
+	if filter.CanHouse != nil {
+		var err error
+		canHouse, err = s.GetDinosaur(*filter.CanHouse)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		// the dinosaur is also joined to every cage as t, so that the cages can be ranked against its species
+		q.from += ` JOIN (SELECT td.species, td.sex, td.cageId
+					  FROM dinosaur td
+					  WHERE td.name=?) t`
+		q.whereArgs = append(q.whereArgs, canHouse.Name)
+	}
+	q.from += ` LEFT OUTER JOIN dinosaur d on d.cageId=c.id`
 
 	// look for filter and apply
-	// Note: for this assignment I'm just supporting a filter on power, but I'm coding it as if I'm going to
-	// support more filtering.
 	if filter.HasPower != nil {
 		if *filter.HasPower {
-			whereParts = append(whereParts, " hasPower = 1 ")
+			q.where = append(q.where, " c.powerStatus <> 'DOWN' ")
 		} else {
-			whereParts = append(whereParts, " hasPower = 0 ")
+			q.where = append(q.where, " c.powerStatus = 'DOWN' ")
 		}
 	}
-
-	if len(whereParts) > 0 {
-		where := strings.Join(whereParts, " AND ")
-		qs += " WHERE " + where
+	if filter.PowerStatus != nil {
+		q.where = append(q.where, " c.powerStatus = ? ")
+		q.whereArgs = append(q.whereArgs, *filter.PowerStatus)
+	}
+	if filter.Zone != nil {
+		// MySQL 5.7 can't walk the zone hierarchy in a query, so the zones nested in the zone are found first
+		allZones, err := s.GetZones()
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		if !zones.Exists(allZones, *filter.Zone) {
+			return nil, models.PageInfo{}, models.EntityNotFound
+		}
+		subtree := zones.Subtree(allZones, *filter.Zone)
+		q.where = append(q.where, " c.zone IN ("+strings.TrimSuffix(strings.Repeat("?,", len(subtree)), ",")+") ")
+		for _, zone := range subtree {
+			q.whereArgs = append(q.whereArgs, zone)
+		}
+	}
+	if canHouse != nil {
+		// A cage can house the dinosaur if it accepts new dinosaurs, is below capacity and wouldn't break any
+		// compatibility rules. The dinosaur's current cage is left out.
+		q.where = append(q.where, " c.powerStatus = 'ACTIVE' ", " (t.cageId IS NULL OR t.cageId <> c.id) ")
+		q.having = append(q.having, " COUNT(d.id) < c.capacity ")
+		conditions, args, err := s.compatibilityConditions(*canHouse)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		q.having = append(q.having, conditions...)
+		q.havingArgs = append(q.havingArgs, args...)
+		if s.breedingPolicy.PreventBreeding {
+			q.having = append(q.having, " SUM(CASE WHEN d.species = t.species AND d.sex <> t.sex THEN 1 ELSE 0 END) = 0 ")
+		}
+		// quarantined dinosaurs don't share cages, either as the dinosaur being housed or as an occupant
+		q.having = append(q.having, " SUM(CASE WHEN d.quarantined THEN 1 ELSE 0 END) = 0 ")
+		if canHouse.Quarantined {
+			q.having = append(q.having, " COUNT(d.id) = 0 ")
+		}
+		keys.sort = "canHouse"
+		keys.column = &sortColumn{expression: canHouseRank, numeric: true, aggregate: true}
 	}
 
-	qs += " ORDER BY id "
-	rows, err := s.db.Query(qs)
+	qs, args, err := keys.pageQuery(q, filter.Page)
 	if err != nil {
-		return nil, err
+		return nil, models.PageInfo{}, err
+	}
+	rows, err := s.db.Query(qs, args...)
+	if err != nil {
+		return nil, models.PageInfo{}, err
 	}
 	defer rows.Close()
 
 	cages := []models.Cage{}
+	sortValues := []string{}
+	ids := []int{}
 	for rows.Next() {
+		var sortValue string
 		var id int
-		cage := models.Cage{}
-		if err := rows.Scan(&id, &cage.Label, &cage.MaxOccupancy, &cage.HasPower); err != nil {
-			return nil, err
+		cage, _, err := scanCage(rows, &sortValue, &id)
+		if err != nil {
+			return nil, models.PageInfo{}, err
 		}
-		occupancy, err := s.getDinosaurCountInCage(id)
+		cages = append(cages, *cage)
+		sortValues = append(sortValues, sortValue)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	count, pageInfo := keys.pageInfo(filter.Page, sortValues, ids)
+	cages = cages[:count]
+	if filter.Page.IncludeTotal {
+		total, err := s.countRows(q)
 		if err != nil {
-			return nil, err
+			return nil, models.PageInfo{}, err
 		}
-		cage.Occupancy = occupancy
-		cages = append(cages, cage)
+		pageInfo.Total = &total
+	}
+	return cages, pageInfo, nil
+}
+
+// compatibilityConditions translates the compatibility rules for adding the dinosaur to a cage into HAVING
+// conditions on the cages joined with their dinosaurs as d, so that they match Engine.Check.
+func (s *ParkSqlDao) compatibilityConditions(dinosaur models.Dinosaur) ([]string, []any, error) {
+	allSpecies, err := s.GetAllSpecies()
+	if err != nil {
+		return nil, nil, err
 	}
+	conditions := []string{}
+	args := []any{}
 
-	return cages, nil
+	if incompatible := s.compatibility.IncompatibleSpecies(dinosaur, allSpecies); len(incompatible) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(incompatible)), ",")
+		conditions = append(conditions, " SUM(CASE WHEN d.species IN ("+placeholders+") THEN 1 ELSE 0 END) = 0 ")
+		for _, species := range incompatible {
+			args = append(args, species)
+		}
+	}
+	if rule := s.compatibility.SpeciesRule(dinosaur.Species); rule.MaxPerCage > 0 {
+		conditions = append(conditions, " SUM(CASE WHEN d.species = ? THEN 1 ELSE 0 END) < ? ")
+		args = append(args, dinosaur.Species, rule.MaxPerCage)
+	}
+	// a cage holding a pack species, including the dinosaur itself, must have room for a full pack next to the
+	// other species once the dinosaur has been added
+	for _, species := range s.compatibility.Species() {
+		packSize := s.compatibility.SpeciesRule(species).MinPackSize
+		if packSize <= 1 {
+			continue
+		}
+		if species == dinosaur.Species {
+			conditions = append(conditions, " c.capacity - COUNT(d.id) + SUM(CASE WHEN d.species = ? THEN 1 ELSE 0 END) >= ? ")
+			args = append(args, species, packSize)
+		} else {
+			conditions = append(conditions, ` (SUM(CASE WHEN d.species = ? THEN 1 ELSE 0 END) = 0
+				OR c.capacity - COUNT(d.id) - 1 + SUM(CASE WHEN d.species = ? THEN 1 ELSE 0 END) >= ?) `)
+			args = append(args, species, species, packSize)
+		}
+	}
+	return conditions, args, nil
 }
 
-func (s *ParkSqlDao) getDinosaurCountInCage(cageId int) (int, error) {
+func (s *ParkSqlDao) getDinosaurCountInCage(q querier, cageId int) (int, error) {
 	qs := `SELECT COUNT(*) FROM dinosaur where cageId=?`
-	rows, err := s.db.Query(qs, cageId)
+	rows, err := q.Query(qs, cageId)
 	if err != nil {
 		return 0, err
 	}
@@ -158,9 +412,15 @@ func (s *ParkSqlDao) getDinosaurCountInCage(cageId int) (int, error) {
 	return occupancy, nil
 }
 
-func (s *ParkSqlDao) AddDinosaur(dinosaur models.Dinosaur) error {
+func (s *ParkSqlDao) AddDinosaur(ctx context.Context, dinosaur models.Dinosaur) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		return s.addDinosaur(ctx, tx, dinosaur)
+	})
+}
+
+func (s *ParkSqlDao) addDinosaur(ctx context.Context, tx *sql.Tx, dinosaur models.Dinosaur) error {
 	speciesQuery := `SELECT COUNT(*) FROM species where name=?`
-	speciesRows, err := s.db.Query(speciesQuery, dinosaur.Species)
+	speciesRows, err := tx.Query(speciesQuery, dinosaur.Species)
 	if err != nil {
 		return err
 	}
@@ -176,14 +436,19 @@ func (s *ParkSqlDao) AddDinosaur(dinosaur models.Dinosaur) error {
 	if err != nil {
 		return err
 	}
+	speciesRows.Close()
 	if speciesCount == 0 {
 		return models.InvalidDinosaurSpecies
 	}
+	sex := dinosaur.RequestedSex()
+	if !sex.IsValid() {
+		return models.InvalidDinosaurSex
+	}
 
 	insertStmt := `INSERT IGNORE INTO dinosaur(name, species, sex)
-					VALUES(?,?,'Female')`
-	params := []interface{}{dinosaur.Name, dinosaur.Species}
-	result, err := s.db.Exec(insertStmt, params...)
+					VALUES(?,?,?)`
+	params := []interface{}{dinosaur.Name, dinosaur.Species, sex}
+	result, err := tx.Exec(insertStmt, params...)
 	if err != nil {
 		return err
 	}
@@ -197,64 +462,127 @@ func (s *ParkSqlDao) AddDinosaur(dinosaur models.Dinosaur) error {
 		return models.EntityAlreadyExists
 	}
 
-	return nil
+	created, err := s.getDinosaur(tx, dinosaur.Name)
+	if err != nil {
+		return err
+	}
+	return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityDinosaur, dinosaur.Name), models.AuditCreate, nil, created)
+}
+
+// dinosaurColumns selects a dinosaur along with how its cage is referred to.
+func (s *ParkSqlDao) dinosaurColumns() string {
+	return `d.name, d.species, d.sex, s.diet, ` + s.cageRefColumn() + `, d.quarantined`
 }
 
-func (s *ParkSqlDao) GetDinosaurs(filter models.DinosaurFilter) ([]models.Dinosaur, error) {
-	qs := `SELECT d.name, d.species, s.diet, c.externalId
-		   FROM dinosaur d
-		   JOIN species s on s.name=d.species 
+const dinosaurFrom = `dinosaur d
+		   JOIN species s on s.name=d.species
 		   LEFT OUTER JOIN cage c on c.id=d.cageId`
 
+// dinosaurSortColumns maps the fields that dinosaurs can be sorted on to their columns.
+var dinosaurSortColumns = map[string]sortColumn{
+	models.SortByName:    {expression: "d.name"},
+	models.SortBySpecies: {expression: "d.species"},
+}
+
+func (s *ParkSqlDao) GetDinosaurs(filter models.DinosaurFilter) ([]models.Dinosaur, models.PageInfo, error) {
+	q := listQuery{
+		columns: s.dinosaurColumns(),
+		from:    dinosaurFrom,
+	}
+
 	//Use the filter to build the where clause
-	whereParts := []string{}
-	args := []any{}
 	if filter.Diet != nil {
-		whereParts = append(whereParts, `s.diet=?`)
-		args = append(args, *filter.Diet)
+		q.where = append(q.where, `s.diet=?`)
+		q.whereArgs = append(q.whereArgs, *filter.Diet)
 	}
 	if filter.NeedsCageAssignment != nil {
 		if *filter.NeedsCageAssignment {
-			whereParts = append(whereParts, "d.cageId IS NULL")
+			q.where = append(q.where, "d.cageId IS NULL")
 		} else {
-			whereParts = append(whereParts, "d.cageId IS NOT NULL")
+			q.where = append(q.where, "d.cageId IS NOT NULL")
 		}
 	}
 	if filter.Species != nil {
-		whereParts = append(whereParts, "s.name=?")
-		args = append(args, *filter.Species)
+		q.where = append(q.where, "s.name=?")
+		q.whereArgs = append(q.whereArgs, *filter.Species)
 	}
+	if filter.Sex != nil {
+		q.where = append(q.where, "d.sex=?")
+		q.whereArgs = append(q.whereArgs, *filter.Sex)
+	}
+	if filter.Quarantined != nil {
+		q.where = append(q.where, "d.quarantined=?")
+		q.whereArgs = append(q.whereArgs, *filter.Quarantined)
+	}
+
+	return s.getDinosaurPage(q, filter.Page)
+}
 
-	if len(whereParts) > 0 {
-		qs += " WHERE " + strings.Join(whereParts, " AND ")
+// getDinosaurPage reads a page of the dinosaurs selected by q.
+func (s *ParkSqlDao) getDinosaurPage(q listQuery, page models.Pagination) ([]models.Dinosaur, models.PageInfo, error) {
+	keys := keyset{
+		sort:       page.Sort.String(),
+		descending: page.Sort.Descending,
+		idColumn:   "d.id",
+	}
+	if page.Sort.Field != "" {
+		column, ok := dinosaurSortColumns[page.Sort.Field]
+		if !ok {
+			return nil, models.PageInfo{}, models.InvalidSort
+		}
+		keys.column = &column
 	}
 
-	qs += " ORDER BY d.id "
+	qs, args, err := keys.pageQuery(q, page)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
 	rows, err := s.db.Query(qs, args...)
 	if err != nil {
-		return nil, err
+		return nil, models.PageInfo{}, err
 	}
 	defer rows.Close()
 
 	dinosaurs := []models.Dinosaur{}
+	sortValues := []string{}
+	ids := []int{}
 	for rows.Next() {
 		dinosaur := models.Dinosaur{}
-		err = rows.Scan(&dinosaur.Name, &dinosaur.Species, &dinosaur.Diet, &dinosaur.Cage)
+		var sortValue string
+		var id int
+		err = rows.Scan(&dinosaur.Name, &dinosaur.Species, &dinosaur.Sex, &dinosaur.Diet, &dinosaur.Cage, &dinosaur.Quarantined, &sortValue, &id)
 		if err != nil {
-			return nil, err
+			return nil, models.PageInfo{}, err
 		}
 		dinosaurs = append(dinosaurs, dinosaur)
+		sortValues = append(sortValues, sortValue)
+		ids = append(ids, id)
 	}
-	return dinosaurs, nil
+	if err := rows.Err(); err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	count, pageInfo := keys.pageInfo(page, sortValues, ids)
+	dinosaurs = dinosaurs[:count]
+	if page.IncludeTotal {
+		total, err := s.countRows(q)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		pageInfo.Total = &total
+	}
+	return dinosaurs, pageInfo, nil
 }
 
 func (s *ParkSqlDao) GetDinosaur(name string) (*models.Dinosaur, error) {
-	qs := `SELECT d.name, d.species, s.diet, c.externalId
-		   FROM dinosaur d
-		   JOIN species s on s.name=d.species 
-		   LEFT OUTER JOIN cage c on c.id=d.cageId
+	return s.getDinosaur(s.db, name)
+}
+
+func (s *ParkSqlDao) getDinosaur(q querier, name string) (*models.Dinosaur, error) {
+	qs := `SELECT ` + s.dinosaurColumns() + `
+		   FROM ` + dinosaurFrom + `
 		   WHERE d.name=?`
-	rows, err := s.db.Query(qs, name)
+	rows, err := q.Query(qs, name)
 	if err != nil {
 		return nil, err
 	}
@@ -265,152 +593,422 @@ func (s *ParkSqlDao) GetDinosaur(name string) (*models.Dinosaur, error) {
 	}
 
 	dinosaur := models.Dinosaur{}
-	err = rows.Scan(&dinosaur.Name, &dinosaur.Species, &dinosaur.Diet, &dinosaur.Cage)
+	err = rows.Scan(&dinosaur.Name, &dinosaur.Species, &dinosaur.Sex, &dinosaur.Diet, &dinosaur.Cage, &dinosaur.Quarantined)
 	if err != nil {
 		return nil, err
 	}
 	return &dinosaur, nil
 }
 
-func (s *ParkSqlDao) AddDinosaurToCage(dinosaurName, targetCage string) error {
-	dinosaur, err := s.GetDinosaur(dinosaurName)
+// UpdateDinosaur changes the fields of the dinosaur that are set in the update. A dinosaur in a cage can only change
+// sex if the breeding policy still allows it to share the cage, so the cage is locked while the occupants are
+// checked, in the same order as addDinosaurToCage locks the cage and then the dinosaur.
+func (s *ParkSqlDao) UpdateDinosaur(ctx context.Context, name string, update models.UpdateDinosaurRequest) (*models.Dinosaur, error) {
+	var updated *models.Dinosaur
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		if update.Sex != nil && !update.Sex.IsValid() {
+			return models.InvalidDinosaurSex
+		}
+		current, err := s.getDinosaur(tx, name)
+		if err != nil {
+			return err
+		}
+		if current.Cage != nil {
+			if _, _, err := s.lockCage(tx, *current.Cage); err != nil {
+				return err
+			}
+		}
+		before, err := s.lockDinosaur(tx, name)
+		if err != nil {
+			return err
+		}
+		if before.Cage != nil && (current.Cage == nil || *before.Cage != *current.Cage) {
+			// the dinosaur was moved by another request before the locks were acquired
+			if _, _, err := s.lockCage(tx, *before.Cage); err != nil {
+				return err
+			}
+		}
+
+		after := *before
+		if update.Sex != nil {
+			after.Sex = *update.Sex
+		}
+		if after.Cage != nil {
+			occupants, err := s.getOccupants(tx, *after.Cage)
+			if err != nil {
+				return err
+			}
+			for _, occupant := range occupants {
+				if occupant.Name != name && !s.breedingPolicy.AllowsPair(after, occupant) {
+					return models.BreedingPairNotAllowed
+				}
+			}
+		}
+
+		_, err = tx.Exec(`UPDATE dinosaur SET sex=? WHERE name=?`, after.Sex, name)
+		if err != nil {
+			return err
+		}
+		updated, err = s.getDinosaur(tx, name)
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityDinosaur, name), models.AuditUpdate, before, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *ParkSqlDao) AddDinosaurToCage(ctx context.Context, dinosaurName, targetCage string) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		return s.addDinosaurToCage(ctx, tx, dinosaurName, targetCage)
+	})
+}
+
+func (s *ParkSqlDao) addDinosaurToCage(ctx context.Context, tx *sql.Tx, dinosaurName, targetCage string) error {
+	// Lock the cage row first so that every assignment or power change to the same cage is serialized.
+	cage, cageId, err := s.lockCage(tx, targetCage)
 	if err != nil {
 		return err
 	}
-	cage, cageId, err := s.getCageWithId(targetCage)
+	dinosaur, err := s.lockDinosaur(tx, dinosaurName)
 	if err != nil {
 		return err
 	}
-	if cage.Occupancy >= cage.MaxOccupancy {
-		return models.CageCapacityExceeded
-	}
-	if !cage.HasPower {
-		return models.IncompatibleCagePowerState
+	err = s.checkCageCanHouse(tx, *dinosaur, *cage)
+	if err != nil {
+		return err
 	}
-	if dinosaur.Diet == "Carnivore" {
-		cageHasOtherSpecies, err := s.cageHasOtherSpecies(*dinosaur, *cage)
+	return s.moveDinosaur(ctx, tx, *dinosaur, &cageId, models.AuditAssign)
+}
+
+func (s *ParkSqlDao) RemoveDinosaurFromCage(ctx context.Context, dinosaurName, cageLabel string) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		_, _, err := s.lockCage(tx, cageLabel)
+		if err != nil {
+			return err
+		}
+		dinosaur, err := s.lockDinosaur(tx, dinosaurName)
 		if err != nil {
 			return err
 		}
-		if cageHasOtherSpecies {
-			return models.IncompatibleSpecies
+		if dinosaur.Cage == nil || *dinosaur.Cage != cageLabel {
+			return models.DinosaurNotInCage
 		}
-	} else {
-		cageHasCarnivores, err := s.cageHasCarnivores(*cage)
+		return s.moveDinosaur(ctx, tx, *dinosaur, nil, models.AuditRemove)
+	})
+}
+
+// TransferDinosaur moves a dinosaur from its current cage to the target cage. Both cages are locked, and the
+// target cage is held to the same capacity, power and species rules as AddDinosaurToCage.
+func (s *ParkSqlDao) TransferDinosaur(ctx context.Context, dinosaurName, targetCage string) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		current, err := s.getDinosaur(tx, dinosaurName)
 		if err != nil {
 			return err
 		}
-		if cageHasCarnivores {
-			return models.IncompatibleSpecies
+		if current.Cage == nil {
+			return models.DinosaurNotCaged
+		}
+		if *current.Cage == targetCage {
+			return nil
 		}
-	}
 
-	updateStatement := `UPDATE dinosaur 
-		   SET cageId=?
-		   WHERE name=?`
-	params := []interface{}{cageId, dinosaur.Name}
-	_, err = s.db.Exec(updateStatement, params...)
+		// Cages are always locked in label order, so that two opposing transfers can't deadlock.
+		labels := []string{*current.Cage, targetCage}
+		sort.Strings(labels)
+		var cage *models.Cage
+		var cageId int
+		for _, label := range labels {
+			lockedCage, lockedCageId, err := s.lockCage(tx, label)
+			if err != nil {
+				return err
+			}
+			if label == targetCage {
+				cage, cageId = lockedCage, lockedCageId
+			}
+		}
+
+		dinosaur, err := s.lockDinosaur(tx, dinosaurName)
+		if err != nil {
+			return err
+		}
+		// the dinosaur was moved by another request before the locks were acquired
+		if dinosaur.Cage == nil {
+			return models.DinosaurNotCaged
+		}
+		if *dinosaur.Cage != *current.Cage {
+			return models.DinosaurNotInCage
+		}
+		err = s.checkCageCanHouse(tx, *dinosaur, *cage)
+		if err != nil {
+			return err
+		}
+		return s.moveDinosaur(ctx, tx, *dinosaur, &cageId, models.AuditTransfer)
+	})
+}
+
+// checkCageCanHouse returns an error if adding the dinosaur to the cage would exceed its capacity, if the cage
+// is powered off, if it would put a quarantined dinosaur in a shared cage, if it would break the compatibility rules
+// or if the breeding policy doesn't allow the dinosaur to share the cage.
+func (s *ParkSqlDao) checkCageCanHouse(q querier, dinosaur models.Dinosaur, cage models.Cage) error {
+	if cage.Occupancy >= cage.MaxOccupancy {
+		return models.CageCapacityExceeded
+	}
+	if !cage.PowerStatus.AcceptsNewDinosaurs() {
+		return models.IncompatibleCagePowerState
+	}
+	occupants, err := s.getOccupants(q, s.cageRef(cage))
 	if err != nil {
 		return err
 	}
+	if err := models.CheckQuarantine(dinosaur, occupants); err != nil {
+		return err
+	}
+	if violations := s.compatibility.Check(dinosaur, cage.MaxOccupancy, occupants); len(violations) > 0 {
+		return &models.CompatibilityError{Violations: violations}
+	}
+	for _, occupant := range occupants {
+		if !s.breedingPolicy.AllowsPair(dinosaur, occupant) {
+			return models.BreedingPairNotAllowed
+		}
+	}
 	return nil
 }
 
-func (s *ParkSqlDao) cageHasOtherSpecies(dinosaur models.Dinosaur, cage models.Cage) (bool, error) {
-	qs := `SELECT COUNT(*)
-		   FROM cage c 
-		   JOIN dinosaur d on d.cageId=c.id 
-		   WHERE c.externalId=? AND d.species<>?`
-	rows, err := s.db.Query(qs, cage.Label, dinosaur.Species)
+// getOccupants reads every dinosaur in the cage.
+func (s *ParkSqlDao) getOccupants(q querier, cageLabel string) ([]models.Dinosaur, error) {
+	where, args := s.cageWhere(cageLabel)
+	qs := `SELECT ` + s.dinosaurColumns() + `
+		   FROM ` + dinosaurFrom + `
+		   WHERE ` + where
+	rows, err := q.Query(qs, args...)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer rows.Close()
 
-	if !rows.Next() {
-		return false, nil
+	occupants := []models.Dinosaur{}
+	for rows.Next() {
+		occupant := models.Dinosaur{}
+		err = rows.Scan(&occupant.Name, &occupant.Species, &occupant.Sex, &occupant.Diet, &occupant.Cage, &occupant.Quarantined)
+		if err != nil {
+			return nil, err
+		}
+		occupants = append(occupants, occupant)
 	}
-	var otherSpeciesCount int
-	err = rows.Scan(&otherSpeciesCount)
+	return occupants, rows.Err()
+}
+
+// moveDinosaur puts the dinosaur in the cage, or takes it out of its cage when cageId is nil, and records the move.
+func (s *ParkSqlDao) moveDinosaur(ctx context.Context, tx *sql.Tx, dinosaur models.Dinosaur, cageId *int, action models.AuditAction) error {
+	updateStatement := `UPDATE dinosaur 
+		   SET cageId=?
+		   WHERE name=?`
+	params := []interface{}{cageId, dinosaur.Name}
+	_, err := tx.Exec(updateStatement, params...)
 	if err != nil {
-		return false, err
+		return err
 	}
+	moved, err := s.getDinosaur(tx, dinosaur.Name)
+	if err != nil {
+		return err
+	}
+	return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityDinosaur, dinosaur.Name), action, dinosaur, moved)
+}
 
-	return otherSpeciesCount > 0, nil
+// inTransaction runs fn inside a read committed transaction. The transaction is committed if fn returns nil
+// and rolled back otherwise. Read committed ensures that reads made after acquiring a row lock see the
+// latest committed state rather than a snapshot taken before the lock was granted.
+func (s *ParkSqlDao) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *ParkSqlDao) cageHasCarnivores(cage models.Cage) (bool, error) {
-	qs := `SELECT COUNT(*)
-		   FROM cage c 
-		   JOIN dinosaur d on d.cageId=c.id
-		   JOIN species s on s.name=d.species
-		   WHERE c.externalId=? AND s.diet='Carnivore'`
-	rows, err := s.db.Query(qs, cage.Label)
+// lockCage reads the cage with SELECT ... FOR UPDATE, holding the row lock until the transaction ends.
+func (s *ParkSqlDao) lockCage(tx *sql.Tx, cageLabel string) (*models.Cage, int, error) {
+	where, args := s.cageWhere(cageLabel)
+	qs := `SELECT c.id, c.externalId, c.capacity, c.powerStatus, c.zone
+			FROM cage c
+			WHERE ` + where + `
+			FOR UPDATE`
+	rows, err := tx.Query(qs, args...)
 	if err != nil {
-		return false, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
-		return false, nil
+		return nil, 0, models.EntityNotFound
+	}
+	var id int
+	cage := models.Cage{}
+	if err := rows.Scan(&id, &cage.Label, &cage.MaxOccupancy, &cage.PowerStatus, &cage.Zone); err != nil {
+		return nil, 0, err
 	}
-	var carnivoreCount int
-	err = rows.Scan(&carnivoreCount)
+	cage.HasPower = cage.PowerStatus.HasPower()
+	rows.Close()
+
+	occupancy, err := s.getDinosaurCountInCage(tx, id)
 	if err != nil {
-		return false, err
+		return nil, 0, err
 	}
+	cage.Occupancy = occupancy
 
-	return carnivoreCount > 0, nil
+	return &cage, id, nil
 }
 
-func (s *ParkSqlDao) GetDinosaursInCage(cageLabel string) ([]models.Dinosaur, error) {
-	cage, cageId, err := s.getCageWithId(cageLabel)
-	if err != nil {
+// lockDinosaur locks the dinosaur row with SELECT ... FOR UPDATE, holding the lock until the transaction ends.
+// Only the dinosaur table is locked, because a locking read over a join would also lock the species and cage rows.
+func (s *ParkSqlDao) lockDinosaur(tx *sql.Tx, name string) (*models.Dinosaur, error) {
+	if _, err := s.lockDinosaurId(tx, name); err != nil {
 		return nil, err
 	}
+	return s.getDinosaur(tx, name)
+}
 
-	qs := `SELECT d.name, d.species, s.diet
-		   FROM dinosaur d
-		   JOIN species s on s.name=d.species 
-		   WHERE d.cageId=?
-		   ORDER BY d.id`
-	rows, err := s.db.Query(qs, cageId)
+// lockDinosaurId locks the dinosaur row in the same way as lockDinosaur, and returns its id.
+func (s *ParkSqlDao) lockDinosaurId(tx *sql.Tx, name string) (int, error) {
+	qs := `SELECT id
+		   FROM dinosaur
+		   WHERE name=?
+		   FOR UPDATE`
+	rows, err := tx.Query(qs, name)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 	defer rows.Close()
 
-	dinosaurs := []models.Dinosaur{}
-	for rows.Next() {
-		dinosaur := models.Dinosaur{
-			Cage: &cage.Label,
-		}
-		err = rows.Scan(&dinosaur.Name, &dinosaur.Species, &dinosaur.Diet)
-		if err != nil {
-			return nil, err
-		}
-		dinosaurs = append(dinosaurs, dinosaur)
+	if !rows.Next() {
+		return 0, models.EntityNotFound
 	}
-	return dinosaurs, nil
+	var id int
+	if err := rows.Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
 }
 
-func (s *ParkSqlDao) UpdateCagePowerStatus(cageLabel string, powerOn bool) error {
-	cage, cageId, err := s.getCageWithId(cageLabel)
+func (s *ParkSqlDao) GetDinosaursInCage(cageLabel string, page models.Pagination) ([]models.Dinosaur, models.PageInfo, error) {
+	_, cageId, err := s.getCageWithId(cageLabel)
 	if err != nil {
-		return err
+		return nil, models.PageInfo{}, err
 	}
-	if !powerOn && cage.Occupancy > 0 {
-		return models.IncompatibleCagePowerState
+
+	q := listQuery{
+		columns:   s.dinosaurColumns(),
+		from:      dinosaurFrom,
+		where:     []string{"d.cageId=?"},
+		whereArgs: []any{cageId},
 	}
+	return s.getDinosaurPage(q, page)
+}
+
+func (s *ParkSqlDao) UpdateCagePowerStatus(ctx context.Context, cageLabel string, powerOn bool) error {
+	_, err := s.UpdateCage(ctx, cageLabel, models.UpdateCageRequest{HasPower: &powerOn})
+	return err
+}
+
+// UpdateCage applies the changes in the update request to the cage and returns the updated cage. The capacity
+// can't be lowered below the current occupancy or the room its species need for their packs, and power can't be cut
+// to an occupied cage.
+func (s *ParkSqlDao) UpdateCage(ctx context.Context, cageLabel string, update models.UpdateCageRequest) (*models.Cage, error) {
+	var cage *models.Cage
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		var cageId int
+		var err error
+		cage, cageId, err = s.lockCage(tx, cageLabel)
+		if err != nil {
+			return err
+		}
+		before := models.NewCageV2(*cage)
+		ref := s.cageRef(*cage)
+		if update.MaxOccupancy != nil {
+			if *update.MaxOccupancy < cage.Occupancy {
+				return models.CageCapacityBelowOccupancy
+			}
+			if *update.MaxOccupancy < cage.MaxOccupancy {
+				occupants, err := s.getOccupants(tx, ref)
+				if err != nil {
+					return err
+				}
+				if violations := s.compatibility.CheckCapacity(*update.MaxOccupancy, occupants); len(violations) > 0 {
+					return &models.CompatibilityError{Violations: violations}
+				}
+			}
+			cage.MaxOccupancy = *update.MaxOccupancy
+		}
+		if powerStatus := update.RequestedPowerStatus(); powerStatus != nil {
+			if err := cage.PowerStatus.CheckTransition(*powerStatus, cage.Occupancy); err != nil {
+				return err
+			}
+			cage.PowerStatus = *powerStatus
+			cage.HasPower = powerStatus.HasPower()
+		}
+		if update.Label != nil {
+			if err := s.cageLabelScope.CheckLabel(*update.Label); err != nil {
+				return err
+			}
+			cage.Label = *update.Label
+		}
+		if update.Zone != nil {
+			cage.Zone = update.Zone
+			if *update.Zone == "" {
+				cage.Zone = nil
+			}
+		}
 
-	updateStatement := `UPDATE cage
-				   SET hasPower=?
-				   WHERE id=?`
-	params := []interface{}{powerOn, cageId}
-	_, err = s.db.Exec(updateStatement, params...)
+		updateStatement := `UPDATE cage
+					   SET externalId=?, capacity=?, powerStatus=?, zone=?
+					   WHERE id=?`
+		params := []interface{}{cage.Label, cage.MaxOccupancy, cage.PowerStatus, cage.Zone, cageId}
+		_, err = tx.Exec(updateStatement, params...)
+		if err != nil {
+			if isDuplicateKeyError(err) {
+				return models.EntityAlreadyExists
+			}
+			if isMySQLError(err, mysqlNoReferencedRow) {
+				return models.InvalidCageZone
+			}
+			return err
+		}
+		// the change is recorded against the cage as it was referred to, so a renamed cage is found under its old label
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityCage, ref), models.AuditUpdate, before, models.NewCageV2(*cage))
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return cage, nil
+}
 
+// DeleteCage decommissions the cage. Only empty cages can be deleted.
+func (s *ParkSqlDao) DeleteCage(ctx context.Context, cageLabel string) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		cage, cageId, err := s.lockCage(tx, cageLabel)
+		if err != nil {
+			return err
+		}
+		if cage.Occupancy > 0 {
+			return models.CageNotEmpty
+		}
+
+		deleteStatement := `DELETE FROM cage WHERE id=?`
+		_, err = tx.Exec(deleteStatement, cageId)
+		if err != nil {
+			if isMySQLError(err, mysqlRowIsReferenced) {
+				return models.CageNotEmpty
+			}
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityCage, s.cageRef(*cage)), models.AuditDelete, models.NewCageV2(*cage), nil)
+	})
 }
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"github.com/EdgarH78/jurassic-park/rules"
+)
+
+// GetCompatibilityRules reads the species compatibility rules from the speciesCompatibility and speciesConstraint
+// tables.
+func (s *ParkSqlDao) GetCompatibilityRules() (rules.Rules, error) {
+	compatibility := rules.Rules{
+		Pairs:   []rules.PairRule{},
+		Species: map[string]rules.SpeciesRule{},
+	}
+
+	pairRows, err := s.db.Query(`SELECT speciesA, speciesB, compatible, reason
+		   FROM speciesCompatibility
+		   ORDER BY speciesA, speciesB`)
+	if err != nil {
+		return rules.Rules{}, err
+	}
+	defer pairRows.Close()
+
+	for pairRows.Next() {
+		pair := rules.PairRule{}
+		if err := pairRows.Scan(&pair.Species[0], &pair.Species[1], &pair.Compatible, &pair.Reason); err != nil {
+			return rules.Rules{}, err
+		}
+		compatibility.Pairs = append(compatibility.Pairs, pair)
+	}
+	if err := pairRows.Err(); err != nil {
+		return rules.Rules{}, err
+	}
+
+	speciesRows, err := s.db.Query(`SELECT species, minPackSize, maxPerCage
+		   FROM speciesConstraint`)
+	if err != nil {
+		return rules.Rules{}, err
+	}
+	defer speciesRows.Close()
+
+	for speciesRows.Next() {
+		var species string
+		rule := rules.SpeciesRule{}
+		if err := speciesRows.Scan(&species, &rule.MinPackSize, &rule.MaxPerCage); err != nil {
+			return rules.Rules{}, err
+		}
+		compatibility.Species[species] = rule
+	}
+	if err := speciesRows.Err(); err != nil {
+		return rules.Rules{}, err
+	}
+
+	if err := compatibility.Validate(); err != nil {
+		return rules.Rules{}, err
+	}
+	return compatibility, nil
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func (s *ParkSqlDao) AddSpecies(ctx context.Context, species models.Species) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		qs := `INSERT INTO species(name, diet)
+				VALUES(?,?)`
+		_, err := tx.Exec(qs, species.Name, species.Diet)
+		if err != nil {
+			if isDuplicateKeyError(err) {
+				return models.EntityAlreadyExists
+			}
+			if isMySQLError(err, mysqlNoReferencedRow) {
+				// the diet is not in speciesDiet
+				return models.InvalidSpeciesDiet
+			}
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntitySpecies, species.Name), models.AuditCreate, nil, species)
+	})
+}
+
+func (s *ParkSqlDao) GetSpecies(name string) (*models.Species, error) {
+	qs := `SELECT name, diet
+		   FROM species
+		   WHERE name=?`
+	rows, err := s.db.Query(qs, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, models.EntityNotFound
+	}
+	species := models.Species{}
+	if err := rows.Scan(&species.Name, &species.Diet); err != nil {
+		return nil, err
+	}
+	return &species, nil
+}
+
+func (s *ParkSqlDao) GetAllSpecies() ([]models.Species, error) {
+	qs := `SELECT name, diet
+		   FROM species
+		   ORDER BY name`
+	rows, err := s.db.Query(qs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allSpecies := []models.Species{}
+	for rows.Next() {
+		species := models.Species{}
+		if err := rows.Scan(&species.Name, &species.Diet); err != nil {
+			return nil, err
+		}
+		allSpecies = append(allSpecies, species)
+	}
+	return allSpecies, nil
+}
+
+// UpdateSpecies changes the diet of a species. The diet of a species can't be changed while there are dinosaurs
+// of that species in the park, because that could leave carnivores and herbivores sharing a cage.
+func (s *ParkSqlDao) UpdateSpecies(ctx context.Context, species models.Species) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		current, err := s.lockSpecies(tx, species.Name)
+		if err != nil {
+			return err
+		}
+		if current.Diet == species.Diet {
+			return nil
+		}
+
+		dinosaurCount, err := s.getDinosaurCountForSpecies(tx, species.Name)
+		if err != nil {
+			return err
+		}
+		if dinosaurCount > 0 {
+			return models.EntityInUse
+		}
+
+		updateStatement := `UPDATE species
+			   SET diet=?
+			   WHERE name=?`
+		_, err = tx.Exec(updateStatement, species.Diet, species.Name)
+		if err != nil {
+			if isMySQLError(err, mysqlNoReferencedRow) {
+				return models.InvalidSpeciesDiet
+			}
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntitySpecies, species.Name), models.AuditUpdate, current, species)
+	})
+}
+
+// DeleteSpecies removes a species. Species that are referenced by dinosaurs can't be removed.
+func (s *ParkSqlDao) DeleteSpecies(ctx context.Context, name string) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		current, err := s.lockSpecies(tx, name)
+		if err != nil {
+			return err
+		}
+		deleteStatement := `DELETE FROM species WHERE name=?`
+		_, err = tx.Exec(deleteStatement, name)
+		if err != nil {
+			if isMySQLError(err, mysqlRowIsReferenced) {
+				return models.EntityInUse
+			}
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntitySpecies, name), models.AuditDelete, current, nil)
+	})
+}
+
+// lockSpecies reads the species with SELECT ... FOR UPDATE, holding the row lock until the transaction ends.
+func (s *ParkSqlDao) lockSpecies(tx *sql.Tx, name string) (*models.Species, error) {
+	qs := `SELECT name, diet
+		   FROM species
+		   WHERE name=?
+		   FOR UPDATE`
+	rows, err := tx.Query(qs, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, models.EntityNotFound
+	}
+	species := models.Species{}
+	if err := rows.Scan(&species.Name, &species.Diet); err != nil {
+		return nil, err
+	}
+	return &species, nil
+}
+
+func (s *ParkSqlDao) getDinosaurCountForSpecies(q querier, species string) (int, error) {
+	qs := `SELECT COUNT(*) FROM dinosaur where species=?`
+	rows, err := q.Query(qs, species)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var count int
+	if err := rows.Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,266 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+const webhookColumns = `id, url, secret, events, createdTime`
+
+func scanWebhook(rows *sql.Rows) (*models.Webhook, error) {
+	webhook := models.Webhook{}
+	var events string
+	if err := rows.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &events, &webhook.CreatedTime); err != nil {
+		return nil, err
+	}
+	webhook.Events = strings.Split(events, ",")
+	return &webhook, nil
+}
+
+func (s *ParkSqlDao) AddWebhook(ctx context.Context, webhook models.Webhook) (*models.Webhook, error) {
+	var created *models.Webhook
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		qs := `INSERT INTO webhook(url, secret, events, createdTime)
+				VALUES(?,?,?,?)`
+		params := []interface{}{webhook.URL, webhook.Secret, strings.Join(webhook.Events, ","), time.Now().UTC()}
+		result, err := tx.Exec(qs, params...)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		created, err = s.getWebhook(tx, int(id))
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityWebhook, strconv.Itoa(created.ID)), models.AuditCreate, nil, withoutSecret(*created))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *ParkSqlDao) GetWebhook(id int) (*models.Webhook, error) {
+	return s.getWebhook(s.db, id)
+}
+
+func (s *ParkSqlDao) getWebhook(q querier, id int) (*models.Webhook, error) {
+	qs := `SELECT ` + webhookColumns + `
+		   FROM webhook
+		   WHERE id=?`
+	rows, err := q.Query(qs, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, models.EntityNotFound
+	}
+	return scanWebhook(rows)
+}
+
+func (s *ParkSqlDao) GetWebhooks() ([]models.Webhook, error) {
+	qs := `SELECT ` + webhookColumns + `
+		   FROM webhook
+		   ORDER BY id`
+	rows, err := s.db.Query(qs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook deletes the webhook along with its delivery log.
+func (s *ParkSqlDao) DeleteWebhook(ctx context.Context, id int) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		webhook, err := s.getWebhook(tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM webhook WHERE id=?`, id)
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityWebhook, strconv.Itoa(id)), models.AuditDelete, withoutSecret(*webhook), nil)
+	})
+}
+
+// withoutSecret is the snapshot of a webhook in the audit log, which leaves out the secret.
+func withoutSecret(webhook models.Webhook) models.Webhook {
+	webhook.Secret = ""
+	return webhook
+}
+
+const deliveryColumns = `wd.id, wd.webhookId, wd.eventId, wd.eventType, wd.payload, wd.status, wd.attempts,
+		wd.responseStatus, wd.lastError, wd.nextAttemptTime, wd.createdTime, wd.completedTime`
+
+// scanDelivery reads a row selected with deliveryColumns. Any columns selected after them are read into extra.
+func scanDelivery(rows *sql.Rows, extra ...any) (*models.WebhookDelivery, error) {
+	d := models.WebhookDelivery{}
+	dest := append([]any{&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.ResponseStatus, &d.LastError, &d.NextAttemptTime, &d.CreatedTime, &d.CompletedTime}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *ParkSqlDao) AddWebhookDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	qs := `INSERT INTO webhookDelivery(webhookId, eventId, eventType, payload, status, nextAttemptTime, createdTime)
+			VALUES`
+	params := []interface{}{}
+	for i, d := range deliveries {
+		if i > 0 {
+			qs += ","
+		}
+		qs += "(?,?,?,?,?,?,?)"
+		params = append(params, d.WebhookID, d.EventID, d.EventType, d.Payload, d.Status, d.NextAttemptTime, d.CreatedTime)
+	}
+	_, err := s.db.Exec(qs, params...)
+	if err != nil {
+		if isMySQLError(err, mysqlNoReferencedRow) {
+			// the webhook was deleted after the event was published
+			return models.EntityNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ClaimWebhookDeliveries locks the pending deliveries that are due, so that two servers can't claim the same
+// delivery, and pushes their next attempt back by the lease.
+func (s *ParkSqlDao) ClaimWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	err := s.inTransaction(context.Background(), func(tx *sql.Tx) error {
+		qs := `SELECT ` + deliveryColumns + `
+			   FROM webhookDelivery wd
+			   WHERE wd.status=? AND wd.nextAttemptTime <= ?
+			   ORDER BY wd.nextAttemptTime, wd.id
+			   LIMIT ?
+			   FOR UPDATE`
+		rows, err := tx.Query(qs, models.DeliveryPending, now, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			delivery, err := scanDelivery(rows)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, *delivery)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+
+		leaseExpiry := now.Add(lease)
+		for _, delivery := range deliveries {
+			_, err := tx.Exec(`UPDATE webhookDelivery SET nextAttemptTime=? WHERE id=?`, leaseExpiry, delivery.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery records the outcome of an attempt to deliver.
+func (s *ParkSqlDao) UpdateWebhookDelivery(delivery models.WebhookDelivery) error {
+	updateStatement := `UPDATE webhookDelivery
+		   SET status=?, attempts=?, responseStatus=?, lastError=?, nextAttemptTime=?, completedTime=?
+		   WHERE id=?`
+	params := []interface{}{delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.LastError,
+		delivery.NextAttemptTime, delivery.CompletedTime, delivery.ID}
+	_, err := s.db.Exec(updateStatement, params...)
+	return err
+}
+
+// GetWebhookDeliveries reads the delivery log of a webhook, oldest first.
+func (s *ParkSqlDao) GetWebhookDeliveries(webhookId int, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, models.PageInfo, error) {
+	if _, err := s.GetWebhook(webhookId); err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	if filter.Page.Sort.Field != "" {
+		return nil, models.PageInfo{}, models.InvalidSort
+	}
+
+	q := listQuery{
+		columns:   deliveryColumns,
+		from:      `webhookDelivery wd`,
+		where:     []string{"wd.webhookId=?"},
+		whereArgs: []any{webhookId},
+	}
+	if filter.Status != nil {
+		q.where = append(q.where, "wd.status=?")
+		q.whereArgs = append(q.whereArgs, *filter.Status)
+	}
+	keys := keyset{idColumn: "wd.id"}
+
+	qs, args, err := keys.pageQuery(q, filter.Page)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	rows, err := s.db.Query(qs, args...)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	sortValues := []string{}
+	ids := []int{}
+	for rows.Next() {
+		var sortValue string
+		var id int
+		delivery, err := scanDelivery(rows, &sortValue, &id)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		deliveries = append(deliveries, *delivery)
+		sortValues = append(sortValues, sortValue)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	count, pageInfo := keys.pageInfo(filter.Page, sortValues, ids)
+	deliveries = deliveries[:count]
+	if filter.Page.IncludeTotal {
+		total, err := s.countRows(q)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		pageInfo.Total = &total
+	}
+	return deliveries, pageInfo, nil
+}
@@ -0,0 +1,156 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/zones"
+)
+
+const zoneColumns = `name, parent, description`
+
+// AddZone adds the zone, nested in its parent if it has one.
+func (s *ParkSqlDao) AddZone(ctx context.Context, zone models.Zone) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		return s.addZone(ctx, tx, zone)
+	})
+}
+
+func (s *ParkSqlDao) addZone(ctx context.Context, tx *sql.Tx, zone models.Zone) error {
+	all, err := s.lockZones(tx)
+	if err != nil {
+		return err
+	}
+	if zones.Exists(all, zone.Name) {
+		return models.EntityAlreadyExists
+	}
+	if zone.Parent != nil {
+		if err := zones.CheckParent(all, zone.Name, *zone.Parent); err != nil {
+			return err
+		}
+	}
+	qs := `INSERT INTO zone(name, parent, description)
+			VALUES(?,?,?)`
+	_, err = tx.Exec(qs, zone.Name, zone.Parent, zone.Description)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return models.EntityAlreadyExists
+		}
+		return err
+	}
+	return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityZone, zone.Name), models.AuditCreate, nil, zone)
+}
+
+func (s *ParkSqlDao) GetZone(name string) (*models.Zone, error) {
+	return s.getZone(s.db, name)
+}
+
+func (s *ParkSqlDao) getZone(q querier, name string) (*models.Zone, error) {
+	found, err := s.queryZones(q, `SELECT `+zoneColumns+` FROM zone WHERE name=?`, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, models.EntityNotFound
+	}
+	return &found[0], nil
+}
+
+// GetZones lists every zone by name.
+func (s *ParkSqlDao) GetZones() ([]models.Zone, error) {
+	return s.queryZones(s.db, `SELECT `+zoneColumns+` FROM zone ORDER BY name`)
+}
+
+// lockZones reads every zone with SELECT ... FOR UPDATE, so that the hierarchy can't change until the transaction
+// ends.
+func (s *ParkSqlDao) lockZones(tx *sql.Tx) ([]models.Zone, error) {
+	return s.queryZones(tx, `SELECT `+zoneColumns+` FROM zone ORDER BY name FOR UPDATE`)
+}
+
+func (s *ParkSqlDao) queryZones(q querier, qs string, args ...any) ([]models.Zone, error) {
+	rows, err := q.Query(qs, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Zone{}
+	for rows.Next() {
+		zone := models.Zone{}
+		if err := rows.Scan(&zone.Name, &zone.Parent, &zone.Description); err != nil {
+			return nil, err
+		}
+		result = append(result, zone)
+	}
+	return result, rows.Err()
+}
+
+// UpdateZone applies the changes in the update request to the zone and returns the updated zone. A zone can't be
+// nested in itself or in a zone nested in it.
+func (s *ParkSqlDao) UpdateZone(ctx context.Context, name string, update models.UpdateZoneRequest) (*models.Zone, error) {
+	var zone *models.Zone
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		all, err := s.lockZones(tx)
+		if err != nil {
+			return err
+		}
+		zone, err = s.getZone(tx, name)
+		if err != nil {
+			return err
+		}
+		before := *zone
+		if update.Parent != nil {
+			zone.Parent = nil
+			if *update.Parent != "" {
+				if err := zones.CheckParent(all, name, *update.Parent); err != nil {
+					return err
+				}
+				zone.Parent = update.Parent
+			}
+		}
+		if update.Description != nil {
+			zone.Description = *update.Description
+		}
+
+		updateStatement := `UPDATE zone
+					   SET parent=?, description=?
+					   WHERE name=?`
+		_, err = tx.Exec(updateStatement, zone.Parent, zone.Description, name)
+		if err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityZone, name), models.AuditUpdate, before, *zone)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// DeleteZone deletes the zone. Only zones without cages or zones in them can be deleted.
+func (s *ParkSqlDao) DeleteZone(ctx context.Context, name string) error {
+	return s.inTransaction(ctx, func(tx *sql.Tx) error {
+		all, err := s.lockZones(tx)
+		if err != nil {
+			return err
+		}
+		zone, err := s.getZone(tx, name)
+		if err != nil {
+			return err
+		}
+		if len(zones.Subtree(all, name)) > 1 {
+			return models.ZoneNotEmpty
+		}
+
+		_, err = tx.Exec(`DELETE FROM zone WHERE name=?`, name)
+		if err != nil {
+			if isMySQLError(err, mysqlRowIsReferenced) {
+				// a cage is still in the zone
+				return models.ZoneNotEmpty
+			}
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditEntity(models.AuditEntityZone, name), models.AuditDelete, *zone, nil)
+	})
+}
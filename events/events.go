@@ -0,0 +1,134 @@
+// Package events publishes changes to the park as they happen, so that clients such as the control room dashboard
+// don't have to poll for them.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// The types of event that are published.
+const (
+	CageCreated         = "cage.created"
+	CageUpdated         = "cage.updated"
+	CagePowerChanged    = "cage.powerChanged"
+	CagePowerCutRefused = "cage.powerCutRefused"
+	CageDeleted         = "cage.deleted"
+	DinosaurAdded       = "dinosaur.added"
+	DinosaurAssigned    = "dinosaur.assigned"
+	DinosaurRemoved     = "dinosaur.removed"
+	DinosaurTransferred = "dinosaur.transferred"
+	DinosaurUpdated     = "dinosaur.updated"
+	FeedingRecorded     = "feeding.recorded"
+	FeedLowStock        = "feed.lowStock"
+)
+
+// Types lists every type of event.
+var Types = []string{
+	CageCreated,
+	CageUpdated,
+	CagePowerChanged,
+	CagePowerCutRefused,
+	CageDeleted,
+	DinosaurAdded,
+	DinosaurAssigned,
+	DinosaurRemoved,
+	DinosaurTransferred,
+	DinosaurUpdated,
+	FeedingRecorded,
+	FeedLowStock,
+}
+
+const (
+	// DefaultReplaySize is the number of events kept for clients that reconnect.
+	DefaultReplaySize = 1000
+	// subscriberBuffer is the number of events a subscriber can fall behind by before it is dropped.
+	subscriberBuffer = 64
+)
+
+// Event is a change to the park. IDs increase by one with every event, starting from 1 each time the server starts.
+type Event struct {
+	ID   uint64    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+// Broker hands each published event to every subscriber, and keeps the most recent events so that subscribers
+// that lost their connection can resume where they left off. It is safe for concurrent use.
+type Broker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	replay      []Event
+	replaySize  int
+	subscribers map[chan Event]struct{}
+}
+
+func NewBroker(replaySize int) *Broker {
+	return &Broker{
+		replaySize:  replaySize,
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+// Publish records an event and sends it to the subscribers. Subscribers that have fallen too far behind to take
+// the event are dropped by closing their channel, so a slow client can't hold up the park. They can resume from
+// the replay buffer.
+func (b *Broker) Publish(eventType string, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{
+		ID:   b.lastID,
+		Type: eventType,
+		Time: time.Now().UTC(),
+		Data: data,
+	}
+	b.replay = append(b.replay, event)
+	if len(b.replay) > b.replaySize {
+		b.replay = b.replay[len(b.replay)-b.replaySize:]
+	}
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			delete(b.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+	return event
+}
+
+// Subscribe returns a channel of the events published from now on, and a function that ends the subscription.
+// When lastEventID is given, the events after it that are still in the replay buffer are returned as well, in
+// order, so that none are missed or repeated between the replay and the channel. Events that have already left
+// the replay buffer can't be recovered.
+func (b *Broker) Subscribe(lastEventID *uint64) ([]Event, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay := []Event{}
+	if lastEventID != nil {
+		for _, event := range b.replay {
+			// an id from before the server restarted is ahead of the current ids, so everything is replayed
+			if event.ID > *lastEventID || *lastEventID > b.lastID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	subscriber := make(chan Event, subscriberBuffer)
+	b.subscribers[subscriber] = struct{}{}
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[subscriber]; ok {
+			delete(b.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+	return replay, subscriber, unsubscribe
+}
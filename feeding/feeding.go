@@ -0,0 +1,90 @@
+// Package feeding works out the daily feeding roster. Each cage's feeding schedules are repeated every day, and the
+// amount of feed is worked out from the dinosaurs that are in the cage when the roster is made.
+package feeding
+
+import (
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// Store reads the schedules, feed and dinosaurs that the roster is made from.
+type Store interface {
+	GetFeed(name string) (*models.Feed, error)
+	GetFeedingSchedules(filter models.FeedingScheduleFilter) ([]models.FeedingSchedule, error)
+	GetFeedings(filter models.FeedingFilter) ([]models.Feeding, error)
+	GetDinosaursInCage(cageLabel string, page models.Pagination) ([]models.Dinosaur, models.PageInfo, error)
+}
+
+// Today is the date of the roster when no date is asked for.
+func Today() string {
+	return time.Now().UTC().Format(models.FeedingDateFormat)
+}
+
+// ParseDate checks a date that feedings are made on, which can't be in the future.
+func ParseDate(date string) (string, error) {
+	parsed, err := time.Parse(models.FeedingDateFormat, date)
+	if err != nil || parsed.Format(models.FeedingDateFormat) > Today() {
+		return "", models.InvalidFeedingDate
+	}
+	return parsed.Format(models.FeedingDateFormat), nil
+}
+
+// Roster lists the feedings due on the date, by time and then cage, along with the feedings that were already
+// made. Schedules for cages that hold no dinosaurs that eat the feed are left out, unless they were fed.
+func Roster(store Store, date string, filter models.FeedingScheduleFilter) ([]models.RosterEntry, error) {
+	schedules, err := store.GetFeedingSchedules(filter)
+	if err != nil {
+		return nil, err
+	}
+	feedings, err := store.GetFeedings(models.FeedingFilter{Date: &date, Cage: filter.Cage})
+	if err != nil {
+		return nil, err
+	}
+	fed := map[int]models.Feeding{}
+	for _, feeding := range feedings {
+		fed[feeding.ScheduleID] = feeding
+	}
+
+	roster := []models.RosterEntry{}
+	for _, schedule := range schedules {
+		entry, err := Entry(store, schedule)
+		if err != nil {
+			return nil, err
+		}
+		if feeding, ok := fed[schedule.ID]; ok {
+			entry.Feeding = &feeding
+		} else if entry.Quantity == 0 {
+			continue
+		}
+		roster = append(roster, entry)
+	}
+	return roster, nil
+}
+
+// Entry works out the dinosaurs a schedule feeds right now, and how much feed they need.
+func Entry(store Store, schedule models.FeedingSchedule) (models.RosterEntry, error) {
+	feed, err := store.GetFeed(schedule.Feed)
+	if err != nil {
+		return models.RosterEntry{}, err
+	}
+	dinosaurs, _, err := store.GetDinosaursInCage(schedule.Cage, models.Pagination{})
+	if err != nil {
+		return models.RosterEntry{}, err
+	}
+	entry := models.RosterEntry{
+		ScheduleID: schedule.ID,
+		Cage:       schedule.Cage,
+		Time:       schedule.Time,
+		Feed:       feed.Name,
+		Unit:       feed.Unit,
+		Dinosaurs:  []string{},
+	}
+	for _, dinosaur := range dinosaurs {
+		if dinosaur.Diet == feed.Diet {
+			entry.Dinosaurs = append(entry.Dinosaurs, dinosaur.Name)
+			entry.Quantity += schedule.Quantity
+		}
+	}
+	return entry, nil
+}
@@ -2,28 +2,22 @@ package integration_test
 
 import (
 	"bytes"
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
-	"github.com/EdgarH78/jurassic-park/api"
-	"github.com/EdgarH78/jurassic-park/data"
 	"github.com/EdgarH78/jurassic-park/models"
 	"github.com/gin-gonic/gin"
 )
 
-// We purposefully configure to a local database on a different port, because we don't interfere with a real database.
-var config = data.SQLConfig{
-	Host:         "localhost:3307",
-	User:         "admin",
-	Password:     "password",
-	DatabaseName: "jurassicpark",
+func TestCreateCage(t *testing.T) {
+	forEachBackend(t, testCreateCage)
 }
 
-func TestCreateCage(t *testing.T) {
+func testCreateCage(t *testing.T, backend parkBackend) {
 	cases := []struct {
 		description        string
 		cage               models.Cage
@@ -42,17 +36,13 @@ func TestCreateCage(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
-			err := clearOutTestDatabase()
+			err := backend.Reset()
 			if err != nil {
 				t.Errorf("error when clearing out test database: %s", err)
 				return
 			}
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			cageBody, err := json.Marshal(c.cage)
 			if err != nil {
@@ -73,17 +63,18 @@ func TestCreateCage(t *testing.T) {
 }
 
 func TestGetCage(t *testing.T) {
-	err := clearOutTestDatabase()
+	forEachBackend(t, testGetCage)
+}
+
+func testGetCage(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-	}
-	err = dao.AddCage(models.Cage{
+	dao := backend.Park()
+	err = dao.AddCage(context.Background(), models.Cage{
 		Label:        "test-cage-1",
 		MaxOccupancy: 10,
 		HasPower:     true,
@@ -121,11 +112,7 @@ func TestGetCage(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 
@@ -150,17 +137,18 @@ func TestGetCage(t *testing.T) {
 }
 
 func TestGetCages(t *testing.T) {
-	err := clearOutTestDatabase()
+	forEachBackend(t, testGetCages)
+}
+
+func testGetCages(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-	}
-	err = dao.AddCage(models.Cage{
+	dao := backend.Park()
+	err = dao.AddCage(context.Background(), models.Cage{
 		Label:        "test-cage-1",
 		MaxOccupancy: 10,
 		HasPower:     true,
@@ -168,7 +156,7 @@ func TestGetCages(t *testing.T) {
 	if err != nil {
 		t.Errorf("error when creating test cage: %s", err)
 	}
-	err = dao.AddCage(models.Cage{
+	err = dao.AddCage(context.Background(), models.Cage{
 		Label:        "test-cage-2",
 		MaxOccupancy: 7,
 		HasPower:     false,
@@ -205,11 +193,7 @@ func TestGetCages(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 
@@ -242,17 +226,18 @@ func TestGetCages(t *testing.T) {
 }
 
 func TestGetCagesWithQueryParameter(t *testing.T) {
-	err := clearOutTestDatabase()
+	forEachBackend(t, testGetCagesWithQueryParameter)
+}
+
+func testGetCagesWithQueryParameter(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-	}
-	err = dao.AddCage(models.Cage{
+	dao := backend.Park()
+	err = dao.AddCage(context.Background(), models.Cage{
 		Label:        "test-cage-1",
 		MaxOccupancy: 10,
 		HasPower:     true,
@@ -260,7 +245,7 @@ func TestGetCagesWithQueryParameter(t *testing.T) {
 	if err != nil {
 		t.Errorf("error when creating test cage: %s", err)
 	}
-	err = dao.AddCage(models.Cage{
+	err = dao.AddCage(context.Background(), models.Cage{
 		Label:        "test-cage-2",
 		MaxOccupancy: 7,
 		HasPower:     false,
@@ -303,11 +288,7 @@ func TestGetCagesWithQueryParameter(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 
@@ -356,8 +337,12 @@ func assertCagesMatch(expectedCage, actualCage models.Cage, t *testing.T) {
 }
 
 func TestAddDinosaur(t *testing.T) {
+	forEachBackend(t, testAddDinosaur)
+}
+
+func testAddDinosaur(t *testing.T, backend parkBackend) {
 
-	err := clearOutTestDatabase()
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
@@ -405,11 +390,7 @@ func TestAddDinosaur(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err := createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			dinoBody, err := json.Marshal(c.dinosaur)
 			if err != nil {
@@ -430,19 +411,19 @@ func TestAddDinosaur(t *testing.T) {
 }
 
 func TestGetDinosaurs(t *testing.T) {
-	err := clearOutTestDatabase()
+	forEachBackend(t, testGetDinosaurs)
+}
+
+func testGetDinosaurs(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-		return
-	}
+	dao := backend.Park()
 
-	err = dao.AddDinosaur(models.Dinosaur{
+	err = dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Vela",
 		Species: "Velociraptor",
 	})
@@ -450,7 +431,7 @@ func TestGetDinosaurs(t *testing.T) {
 		t.Errorf("error when adding dinosaur")
 		return
 	}
-	err = dao.AddDinosaur(models.Dinosaur{
+	err = dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Brachen",
 		Species: "Brachiosaurus",
 	})
@@ -487,11 +468,7 @@ func TestGetDinosaurs(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 
@@ -524,19 +501,19 @@ func TestGetDinosaurs(t *testing.T) {
 }
 
 func TestGetDinosaur(t *testing.T) {
-	err := clearOutTestDatabase()
+	forEachBackend(t, testGetDinosaur)
+}
+
+func testGetDinosaur(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-		return
-	}
+	dao := backend.Park()
 
-	err = dao.AddDinosaur(models.Dinosaur{
+	err = dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Vela",
 		Species: "Velociraptor",
 	})
@@ -568,11 +545,7 @@ func TestGetDinosaur(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 
@@ -597,72 +570,72 @@ func TestGetDinosaur(t *testing.T) {
 }
 
 func TestAddDinosaurToCage(t *testing.T) {
-	err := clearOutTestDatabase()
+	forEachBackend(t, testAddDinosaurToCage)
+}
+
+func testAddDinosaurToCage(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-		return
-	}
+	dao := backend.Park()
 
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "T-Rex-Pen",
 		MaxOccupancy: 2,
 		HasPower:     true,
 	})
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "Raptor-Pen-1",
 		MaxOccupancy: 10,
 		HasPower:     true,
 	})
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "Raptor-Pen-2",
 		MaxOccupancy: 5,
 		HasPower:     false,
 	})
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "Herbivore-Pen",
 		MaxOccupancy: 10,
 		HasPower:     true,
 	})
 
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "TerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "MerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "JerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Vela",
 		Species: "Velociraptor",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Verona",
 		Species: "Velociraptor",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Talon",
 		Species: "Verlociraptor",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "LittleFoot",
 		Species: "Brachiosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Cera",
 		Species: "Triceratops",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Rooter",
 		Species: "Stegosaurus",
 	})
@@ -744,11 +717,7 @@ func TestAddDinosaurToCage(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 
@@ -773,37 +742,37 @@ func TestAddDinosaurToCage(t *testing.T) {
 }
 
 func TestGetDinosaursForCage(t *testing.T) {
-	err := clearOutTestDatabase()
+	forEachBackend(t, testGetDinosaursForCage)
+}
+
+func testGetDinosaursForCage(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-		return
-	}
+	dao := backend.Park()
 
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "T-Rex-Pen",
 		MaxOccupancy: 2,
 		HasPower:     true,
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "TerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "MerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "LittleFoot",
 		Species: "Brachiosaurus",
 	})
-	dao.AddDinosaurToCage("TerryRex", "T-Rex-Pen")
-	dao.AddDinosaurToCage("MerryRex", "T-Rex-Pen")
+	dao.AddDinosaurToCage(context.Background(), "TerryRex", "T-Rex-Pen")
+	dao.AddDinosaurToCage(context.Background(), "MerryRex", "T-Rex-Pen")
 
 	cases := []struct {
 		description        string
@@ -840,11 +809,7 @@ func TestGetDinosaursForCage(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 
@@ -877,39 +842,39 @@ func TestGetDinosaursForCage(t *testing.T) {
 }
 
 func TestSwitchPowerStatusOnCages(t *testing.T) {
-	err := clearOutTestDatabase()
+	forEachBackend(t, testSwitchPowerStatusOnCages)
+}
+
+func testSwitchPowerStatusOnCages(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-		return
-	}
+	dao := backend.Park()
 
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "T-Rex-Pen",
 		MaxOccupancy: 2,
 		HasPower:     true,
 	})
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "Raptor-Pen-1",
 		MaxOccupancy: 5,
 		HasPower:     true,
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "TerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "MerryRex",
 		Species: "Tyrannosaurus",
 	})
 
-	dao.AddDinosaurToCage("TerryRex", "T-Rex-Pen")
-	dao.AddDinosaurToCage("MerryRex", "T-Rex-Pen")
+	dao.AddDinosaurToCage(context.Background(), "TerryRex", "T-Rex-Pen")
+	dao.AddDinosaurToCage(context.Background(), "MerryRex", "T-Rex-Pen")
 
 	cases := []struct {
 		description        string
@@ -946,11 +911,7 @@ func TestSwitchPowerStatusOnCages(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 
@@ -975,58 +936,58 @@ func TestSwitchPowerStatusOnCages(t *testing.T) {
 }
 
 func TestGetDinosaursWithQueryParameters(t *testing.T) {
+	forEachBackend(t, testGetDinosaursWithQueryParameters)
+}
+
+func testGetDinosaursWithQueryParameters(t *testing.T, backend parkBackend) {
 	// supported query parameters are: species, diet, needsCageAssignment
 
-	err := clearOutTestDatabase()
+	err := backend.Reset()
 	if err != nil {
 		t.Errorf("error when clearing out test database: %s", err)
 		return
 	}
 
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		t.Errorf("error when creating test dao: %s", err)
-		return
-	}
+	dao := backend.Park()
 
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "T-Rex-Pen",
 		MaxOccupancy: 2,
 		HasPower:     true,
 	})
-	dao.AddCage(models.Cage{
+	dao.AddCage(context.Background(), models.Cage{
 		Label:        "Herbivore-Pen",
 		MaxOccupancy: 12,
 		HasPower:     true,
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "TerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "MerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "JerryRex",
 		Species: "Tyrannosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Vela",
 		Species: "Velociraptor",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "LittleFoot",
 		Species: "Brachiosaurus",
 	})
-	dao.AddDinosaur(models.Dinosaur{
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
 		Name:    "Cera",
 		Species: "Triceratops",
 	})
 
-	dao.AddDinosaurToCage("TerryRex", "T-Rex-Pen")
-	dao.AddDinosaurToCage("MerryRex", "T-Rex-Pen")
-	dao.AddDinosaurToCage("LittleFoot", "Herbivore-Pen")
+	dao.AddDinosaurToCage(context.Background(), "TerryRex", "T-Rex-Pen")
+	dao.AddDinosaurToCage(context.Background(), "MerryRex", "T-Rex-Pen")
+	dao.AddDinosaurToCage(context.Background(), "LittleFoot", "Herbivore-Pen")
 
 	cases := []struct {
 		description         string
@@ -1125,11 +1086,7 @@ func TestGetDinosaursWithQueryParameters(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
 			r := gin.Default()
-			_, err = createTestApi(r)
-			if err != nil {
-				t.Errorf("error when creating test api: %s", err)
-				return
-			}
+			backend.NewAPI(r)
 
 			w := httptest.NewRecorder()
 			url := "/jurassicpark/v1/dinosaurs"
@@ -1176,6 +1133,10 @@ func wrapBool(value bool) *bool {
 	return &value
 }
 
+func wrapInt(value int) *int {
+	return &value
+}
+
 func assertDinosaursMatch(expectedDinosaur, actualDinosaur models.Dinosaur, t *testing.T) {
 	if expectedDinosaur.Name != actualDinosaur.Name {
 		t.Errorf("expected dinosaur Name to be %s got %s", expectedDinosaur.Name, actualDinosaur.Name)
@@ -1183,6 +1144,9 @@ func assertDinosaursMatch(expectedDinosaur, actualDinosaur models.Dinosaur, t *t
 	if expectedDinosaur.Species != actualDinosaur.Species {
 		t.Errorf("expected dinosaur Species to be %s got %s", expectedDinosaur.Species, actualDinosaur.Species)
 	}
+	if expectedDinosaur.Sex != "" && expectedDinosaur.Sex != actualDinosaur.Sex {
+		t.Errorf("expected dinosaur Sex to be %s got %s", expectedDinosaur.Sex, actualDinosaur.Sex)
+	}
 	if expectedDinosaur.Diet != actualDinosaur.Diet {
 		t.Errorf("expected dinosaur Diet to be %s got %s", expectedDinosaur.Diet, actualDinosaur.Diet)
 	}
@@ -1196,31 +1160,3 @@ func assertDinosaursMatch(expectedDinosaur, actualDinosaur models.Dinosaur, t *t
 		}
 	}
 }
-
-func createTestApi(engine *gin.Engine) (*api.API, error) {
-	dao, err := data.NewParkSqlDao(config)
-	if err != nil {
-		return nil, err
-	}
-
-	api := api.NewAPI(dao, engine)
-	return api, nil
-}
-
-func clearOutTestDatabase() error {
-	db, err := sql.Open("mysql", config.ConnectionString())
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec("TRUNCATE dinosaur")
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec("DELETE FROM cage")
-	if err != nil {
-		return err
-	}
-	return nil
-}
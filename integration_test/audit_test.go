@@ -0,0 +1,240 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// expectedAuditEvent is an audit event with its snapshots as JSON, which are compared regardless of formatting.
+type expectedAuditEvent struct {
+	actor  string
+	action models.AuditAction
+	before string
+	after  string
+}
+
+func TestAuditLog(t *testing.T) {
+	forEachBackend(t, testAuditLog)
+}
+
+func testAuditLog(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	start := time.Now().UTC().Add(-time.Second)
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path, actor string, body any, expectedStatusCode int) {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(payload))
+		if actor != "" {
+			req.Header.Set("X-Actor", actor)
+		}
+		r.ServeHTTP(w, req)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d", method, path, expectedStatusCode, w.Code)
+		}
+	}
+	maintenance := models.PowerStatusMaintenance
+	down := models.PowerStatusDown
+	send("POST", "/jurassicpark/v2/cages", "muldoon", models.CageV2{Label: "C-1", MaxOccupancy: 2, PowerStatus: models.PowerStatusActive}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/dinosaurs", "wu", models.Dinosaur{Name: "Blue", Species: "Velociraptor"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages/C-1/dinosaurs", "muldoon", models.AddDinosaurToCageRequest{Name: "Blue"}, http.StatusCreated)
+	send("PATCH", "/jurassicpark/v2/cages/C-1", "nedry", models.UpdateCageV2Request{PowerStatus: &down}, http.StatusConflict)
+	send("PATCH", "/jurassicpark/v2/cages/C-1", "arnold", models.UpdateCageV2Request{PowerStatus: &maintenance}, http.StatusOK)
+	send("DELETE", "/jurassicpark/v1/cages/C-1/dinosaurs/Blue", "", nil, http.StatusOK)
+	send("DELETE", "/jurassicpark/v1/cages/C-1", "arnold", nil, http.StatusOK)
+	err = backend.Park().AddCage(context.Background(), models.Cage{Label: "C-2", MaxOccupancy: 1, HasPower: true})
+	if err != nil {
+		t.Errorf("error when adding the cage: %s", err)
+		return
+	}
+
+	cases := []struct {
+		description string
+		query       url.Values
+		expected    []expectedAuditEvent
+	}{
+		{
+			description: "changes to a cage",
+			query:       url.Values{"entity": {"cage:C-1"}},
+			expected: []expectedAuditEvent{
+				{
+					actor:  "muldoon",
+					action: models.AuditCreate,
+					after:  `{"label":"C-1","occupancy":0,"maxOccupancy":2,"powerStatus":"ACTIVE"}`,
+				},
+				{
+					// the refused power cut isn't recorded, because nothing changed
+					actor:  "arnold",
+					action: models.AuditUpdate,
+					before: `{"label":"C-1","occupancy":1,"maxOccupancy":2,"powerStatus":"ACTIVE"}`,
+					after:  `{"label":"C-1","occupancy":1,"maxOccupancy":2,"powerStatus":"MAINTENANCE"}`,
+				},
+				{
+					actor:  "arnold",
+					action: models.AuditDelete,
+					before: `{"label":"C-1","occupancy":0,"maxOccupancy":2,"powerStatus":"MAINTENANCE"}`,
+				},
+			},
+		},
+		{
+			description: "changes to a dinosaur",
+			query:       url.Values{"entity": {"dinosaur:Blue"}},
+			expected: []expectedAuditEvent{
+				{
+					actor:  "wu",
+					action: models.AuditCreate,
+					after:  `{"name":"Blue","species":"Velociraptor","sex":"Female","diet":"Carnivore"}`,
+				},
+				{
+					actor:  "muldoon",
+					action: models.AuditAssign,
+					before: `{"name":"Blue","species":"Velociraptor","sex":"Female","diet":"Carnivore"}`,
+					after:  `{"name":"Blue","species":"Velociraptor","sex":"Female","diet":"Carnivore","cage":"C-1"}`,
+				},
+				{
+					actor:  "anonymous",
+					action: models.AuditRemove,
+					before: `{"name":"Blue","species":"Velociraptor","sex":"Female","diet":"Carnivore","cage":"C-1"}`,
+					after:  `{"name":"Blue","species":"Velociraptor","sex":"Female","diet":"Carnivore"}`,
+				},
+			},
+		},
+		{
+			description: "changes made without a request",
+			query:       url.Values{"entity": {"cage:C-2"}},
+			expected: []expectedAuditEvent{
+				{
+					actor:  models.SystemActor,
+					action: models.AuditCreate,
+					after:  `{"label":"C-2","occupancy":0,"maxOccupancy":1,"powerStatus":"ACTIVE"}`,
+				},
+			},
+		},
+		{
+			description: "changes since before the test started",
+			query:       url.Values{"entity": {"cage:C-2"}, "since": {start.Format(time.RFC3339)}},
+			expected: []expectedAuditEvent{
+				{
+					actor:  models.SystemActor,
+					action: models.AuditCreate,
+					after:  `{"label":"C-2","occupancy":0,"maxOccupancy":1,"powerStatus":"ACTIVE"}`,
+				},
+			},
+		},
+		{
+			description: "changes since a time in the future",
+			query:       url.Values{"since": {time.Now().Add(time.Hour).Format(time.RFC3339)}},
+			expected:    []expectedAuditEvent{},
+		},
+		{
+			description: "entity without changes",
+			query:       url.Values{"entity": {"species:Velociraptor"}},
+			expected:    []expectedAuditEvent{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/jurassicpark/v1/audit?"+c.query.Encode(), nil)
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("expected status code %d got %d", http.StatusOK, w.Code)
+				return
+			}
+			auditEvents := []models.AuditEvent{}
+			if err := json.Unmarshal(w.Body.Bytes(), &auditEvents); err != nil {
+				t.Errorf("error when reading the audit log: %s", err)
+				return
+			}
+			if len(auditEvents) != len(c.expected) {
+				t.Errorf("expected %d audit events got %d: %s", len(c.expected), len(auditEvents), w.Body.String())
+				return
+			}
+			for i, expected := range c.expected {
+				actual := auditEvents[i]
+				if actual.Actor != expected.actor || actual.Action != expected.action || actual.Time.Before(start) {
+					t.Errorf("expected %s by %s got %s by %s at %s", expected.action, expected.actor, actual.Action, actual.Actor, actual.Time)
+				}
+				assertSnapshotsMatch(expected.before, actual.Before, t)
+				assertSnapshotsMatch(expected.after, actual.After, t)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jurassicpark/v1/audit?limit=2&includeTotal=true", nil)
+	r.ServeHTTP(w, req)
+	if w.Header().Get("X-Next-Cursor") == "" || w.Header().Get("X-Total-Count") != "7" {
+		t.Errorf("expected the first page of 7 audit events got X-Next-Cursor %q and X-Total-Count %q",
+			w.Header().Get("X-Next-Cursor"), w.Header().Get("X-Total-Count"))
+	}
+}
+
+func assertSnapshotsMatch(expected string, actual json.RawMessage, t *testing.T) {
+	if expected == "" || actual == nil {
+		if expected != "" || actual != nil {
+			t.Errorf("expected snapshot %q got %q", expected, actual)
+		}
+		return
+	}
+	var expectedValue, actualValue any
+	json.Unmarshal([]byte(expected), &expectedValue)
+	json.Unmarshal(actual, &actualValue)
+	if !reflect.DeepEqual(expectedValue, actualValue) {
+		t.Errorf("expected snapshot %s got %s", expected, actual)
+	}
+}
+
+func TestInvalidAuditQuery(t *testing.T) {
+	forEachBackend(t, testInvalidAuditQuery)
+}
+
+func testInvalidAuditQuery(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	cases := []struct {
+		description string
+		query       string
+	}{
+		{"entity without a key", "entity=cage"},
+		{"entity with an empty key", "entity=cage:"},
+		{"unknown entity type", "entity=visitor:Gennaro"},
+		{"since that isn't a time", "since=yesterday"},
+		{"sort", "sort=actor"},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/jurassicpark/v1/audit?"+c.query, nil)
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("expected status code %d got %d", http.StatusUnprocessableEntity, w.Code)
+			}
+		})
+	}
+}
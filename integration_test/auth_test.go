@@ -0,0 +1,285 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/api"
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// issueAPIKey adds an API key with the roles straight to the park, and returns the key.
+func issueAPIKey(t *testing.T, park testPark, name string, roles ...models.Role) string {
+	apiKey := models.APIKey{Name: name, Roles: roles}
+	if err := auth.Issue(&apiKey); err != nil {
+		t.Fatalf("error when issuing the API key: %s", err)
+	}
+	if _, err := park.AddAPIKey(context.Background(), apiKey); err != nil {
+		t.Fatalf("error when adding the API key: %s", err)
+	}
+	return apiKey.Key
+}
+
+// newAuthenticatedAPI is an API that authenticates requests with the API keys in the park.
+func newAuthenticatedAPI(backend parkBackend) *gin.Engine {
+	r := gin.New()
+	backend.NewAPI(r, api.WithAuthenticators(auth.NewAPIKeyAuthenticator(backend.Park())))
+	return r
+}
+
+func sendWithAPIKey(r *gin.Engine, method, path, key string, body any) *httptest.ResponseRecorder {
+	return sendWithCredentials(r, method, path, auth.APIKeyHeader, key, body)
+}
+
+// sendWithCredentials sends a request with the credentials in the header, or without credentials if they're empty.
+func sendWithCredentials(r *gin.Engine, method, path, header, credentials string, body any) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewReader(payload))
+	if credentials != "" {
+		req.Header.Set(header, credentials)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	forEachBackend(t, testAPIKeyAuthentication)
+}
+
+func testAPIKeyAuthentication(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := newAuthenticatedAPI(backend)
+	viewerKey := issueAPIKey(t, backend.Park(), "visitor-center", models.RoleViewer)
+	revokedKey := issueAPIKey(t, backend.Park(), "nedry", models.RoleAdmin)
+	revoked, err := backend.Park().GetAPIKeyByHash(auth.HashKey(revokedKey))
+	if err != nil {
+		t.Errorf("error when looking up the API key: %s", err)
+		return
+	}
+	if err := backend.Park().RevokeAPIKey(context.Background(), revoked.ID); err != nil {
+		t.Errorf("error when revoking the API key: %s", err)
+		return
+	}
+
+	cases := []struct {
+		description        string
+		key                string
+		expectedStatusCode int
+	}{
+		{"no key", "", http.StatusUnauthorized},
+		{"unknown key", "jpk_0000000000000000", http.StatusUnauthorized},
+		{"revoked key", revokedKey, http.StatusUnauthorized},
+		{"valid key", viewerKey, http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := sendWithAPIKey(r, "GET", "/jurassicpark/v1/cages", c.key, nil)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+				return
+			}
+			if w.Code == http.StatusUnauthorized {
+				errorResponse := models.ErrorResponse{}
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResponse); err != nil || errorResponse.ErrorMessage == "" {
+					t.Errorf("expected an error response got %s", w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRoleAuthorization(t *testing.T) {
+	forEachBackend(t, testRoleAuthorization)
+}
+
+func testRoleAuthorization(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := newAuthenticatedAPI(backend)
+	keys := map[models.Role]string{}
+	for _, role := range models.Roles {
+		keys[role] = issueAPIKey(t, backend.Park(), string(role), role)
+	}
+	err = backend.Park().AddCage(context.Background(), models.Cage{Label: "C-1", MaxOccupancy: 2, HasPower: true})
+	if err != nil {
+		t.Errorf("error when adding the cage: %s", err)
+		return
+	}
+
+	down := models.PowerStatusDown
+	active := models.PowerStatusActive
+	noPower := false
+	capacity := 3
+	cases := []struct {
+		description        string
+		role               models.Role
+		method             string
+		path               string
+		body               any
+		expectedStatusCode int
+	}{
+		{"viewer reads cages", models.RoleViewer, "GET", "/jurassicpark/v1/cages", nil, http.StatusOK},
+		{"power operator reads cages", models.RolePowerOperator, "GET", "/jurassicpark/v2/cages/C-1", nil, http.StatusOK},
+		{"viewer can't add a dinosaur", models.RoleViewer, "POST", "/jurassicpark/v1/dinosaurs", models.Dinosaur{Name: "Blue", Species: "Velociraptor"}, http.StatusForbidden},
+		{"keeper adds a dinosaur", models.RoleKeeper, "POST", "/jurassicpark/v1/dinosaurs", models.Dinosaur{Name: "Blue", Species: "Velociraptor"}, http.StatusCreated},
+		{"power operator can't add a cage", models.RolePowerOperator, "POST", "/jurassicpark/v2/cages", models.CageV2{Label: "C-2", MaxOccupancy: 1, PowerStatus: models.PowerStatusActive}, http.StatusForbidden},
+		{"keeper can't cut power", models.RoleKeeper, "PATCH", "/jurassicpark/v2/cages/C-1", models.UpdateCageV2Request{PowerStatus: &down}, http.StatusForbidden},
+		{"keeper can't cut power with hasPower", models.RoleKeeper, "PATCH", "/jurassicpark/v1/cages/C-1", models.UpdateCageRequest{HasPower: &noPower}, http.StatusForbidden},
+		{"keeper can't cut power along with the capacity", models.RoleKeeper, "PATCH", "/jurassicpark/v2/cages/C-1", models.UpdateCageV2Request{MaxOccupancy: &capacity, PowerStatus: &down}, http.StatusForbidden},
+		{"power operator cuts power", models.RolePowerOperator, "PATCH", "/jurassicpark/v2/cages/C-1", models.UpdateCageV2Request{PowerStatus: &down}, http.StatusOK},
+		{"power operator can't change the capacity", models.RolePowerOperator, "PATCH", "/jurassicpark/v2/cages/C-1", models.UpdateCageV2Request{MaxOccupancy: &capacity}, http.StatusForbidden},
+		{"keeper changes the capacity", models.RoleKeeper, "PATCH", "/jurassicpark/v2/cages/C-1", models.UpdateCageV2Request{MaxOccupancy: &capacity}, http.StatusOK},
+		{"admin restores power", models.RoleAdmin, "PATCH", "/jurassicpark/v2/cages/C-1", models.UpdateCageV2Request{PowerStatus: &active}, http.StatusOK},
+		{"keeper can't read the audit log", models.RoleKeeper, "GET", "/jurassicpark/v1/audit", nil, http.StatusForbidden},
+		{"admin reads the audit log", models.RoleAdmin, "GET", "/jurassicpark/v1/audit", nil, http.StatusOK},
+		{"power operator can't list webhooks", models.RolePowerOperator, "GET", "/jurassicpark/v1/webhooks", nil, http.StatusForbidden},
+		{"keeper can't add a health record", models.RoleKeeper, "POST", "/jurassicpark/v1/dinosaurs/Blue/health-records", models.HealthRecord{Type: models.HealthRecordQuarantine}, http.StatusForbidden},
+		{"vet quarantines a dinosaur", models.RoleVet, "POST", "/jurassicpark/v1/dinosaurs/Blue/health-records", models.HealthRecord{Type: models.HealthRecordQuarantine}, http.StatusCreated},
+		{"viewer reads the health records", models.RoleViewer, "GET", "/jurassicpark/v1/dinosaurs/Blue/health-records", nil, http.StatusOK},
+		{"keeper can't issue API keys", models.RoleKeeper, "POST", "/jurassicpark/v1/apikeys", models.APIKey{Name: "new", Roles: []models.Role{models.RoleAdmin}}, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := sendWithAPIKey(r, c.method, c.path, keys[c.role], c.body)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d: %s", c.expectedStatusCode, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	cages, _, err := backend.Park().GetCages(models.CageFilter{})
+	if err != nil {
+		t.Errorf("error when getting the cages: %s", err)
+		return
+	}
+	if len(cages) != 1 || cages[0].MaxOccupancy != capacity || cages[0].PowerStatus != models.PowerStatusActive {
+		t.Errorf("expected only the allowed changes to be made to the cage got %+v", cages)
+	}
+}
+
+func TestAPIKeyManagement(t *testing.T) {
+	forEachBackend(t, testAPIKeyManagement)
+}
+
+func testAPIKeyManagement(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := newAuthenticatedAPI(backend)
+	adminKey := issueAPIKey(t, backend.Park(), "hammond", models.RoleAdmin)
+	admin, err := backend.Park().GetAPIKeyByHash(auth.HashKey(adminKey))
+	if err != nil {
+		t.Errorf("error when looking up the API key: %s", err)
+		return
+	}
+
+	createCases := []struct {
+		description        string
+		apiKey             models.APIKey
+		expectedStatusCode int
+	}{
+		{"valid key", models.APIKey{Name: "control-room", Roles: []models.Role{models.RoleKeeper, models.RolePowerOperator}}, http.StatusCreated},
+		{"name in use", models.APIKey{Name: "hammond", Roles: []models.Role{models.RoleViewer}}, http.StatusConflict},
+		{"no name", models.APIKey{Roles: []models.Role{models.RoleViewer}}, http.StatusUnprocessableEntity},
+		{"name too long", models.APIKey{Name: strings.Repeat("k", 65), Roles: []models.Role{models.RoleViewer}}, http.StatusUnprocessableEntity},
+		{"no roles", models.APIKey{Name: "gift-shop"}, http.StatusUnprocessableEntity},
+		{"unknown role", models.APIKey{Name: "gift-shop", Roles: []models.Role{"cashier"}}, http.StatusUnprocessableEntity},
+	}
+	var created models.APIKey
+	for _, c := range createCases {
+		t.Run(c.description, func(t *testing.T) {
+			w := sendWithAPIKey(r, "POST", "/jurassicpark/v1/apikeys", adminKey, c.apiKey)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+				return
+			}
+			if w.Code == http.StatusCreated {
+				json.Unmarshal(w.Body.Bytes(), &created)
+			}
+		})
+	}
+	if !strings.HasPrefix(created.Key, created.Prefix) || created.Prefix == "" || created.ID == 0 {
+		t.Errorf("expected the created API key to be returned with its key got %+v", created)
+		return
+	}
+
+	w := sendWithAPIKey(r, "PATCH", "/jurassicpark/v1/cages/C-1", created.Key, models.UpdateCageRequest{Label: &created.Name})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected the created API key to authenticate got status code %d", w.Code)
+	}
+
+	w = sendWithAPIKey(r, "GET", "/jurassicpark/v1/apikeys", adminKey, nil)
+	if strings.Contains(w.Body.String(), created.Key) || strings.Contains(w.Body.String(), auth.HashKey(created.Key)) {
+		t.Errorf("expected the API keys to be listed without the keys or their hashes")
+	}
+	apiKeys := []models.APIKey{}
+	json.Unmarshal(w.Body.Bytes(), &apiKeys)
+	if len(apiKeys) != 2 || apiKeys[1].Name != "control-room" || len(apiKeys[1].Roles) != 2 {
+		t.Errorf("expected the admin and control room keys got %s", w.Body.String())
+	}
+
+	revokeCases := []struct {
+		description        string
+		id                 string
+		expectedStatusCode int
+	}{
+		{"revoke the key", strconv.Itoa(created.ID), http.StatusOK},
+		{"revoke it again", strconv.Itoa(created.ID), http.StatusOK},
+		{"unknown id", "999999", http.StatusNotFound},
+		{"id that isn't a number", "control-room", http.StatusNotFound},
+	}
+	for _, c := range revokeCases {
+		t.Run(c.description, func(t *testing.T) {
+			w := sendWithAPIKey(r, "DELETE", "/jurassicpark/v1/apikeys/"+c.id, adminKey, nil)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+			}
+		})
+	}
+	w = sendWithAPIKey(r, "GET", "/jurassicpark/v1/cages", created.Key, nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the revoked API key to be refused got status code %d", w.Code)
+	}
+
+	auditEvents, _, err := backend.Park().GetAuditEvents(models.AuditFilter{})
+	if err != nil {
+		t.Errorf("error when reading the audit log: %s", err)
+		return
+	}
+	actions := []string{}
+	for _, event := range auditEvents {
+		actions = append(actions, event.Actor+" "+string(event.Action)+" "+event.Entity)
+		if strings.Contains(string(event.Before)+string(event.After), auth.HashKey(created.Key)) {
+			t.Errorf("expected the audit log to leave out the key hash got %s", event.After)
+		}
+	}
+	expected := []string{
+		models.SystemActor + " create apikey:" + strconv.Itoa(admin.ID),
+		"hammond create apikey:" + strconv.Itoa(created.ID),
+		"hammond revoke apikey:" + strconv.Itoa(created.ID),
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the audit log %q got %q", expected, actions)
+	}
+}
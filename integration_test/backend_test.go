@@ -0,0 +1,219 @@
+package integration_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/api"
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/data"
+	"github.com/EdgarH78/jurassic-park/memory"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/rules"
+	"github.com/EdgarH78/jurassic-park/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// We purposefully configure to a local database on a different port, because we don't interfere with a real database.
+var config = data.SQLConfig{
+	Host:         "localhost:3307",
+	User:         "admin",
+	Password:     "password",
+	DatabaseName: "jurassicpark",
+}
+
+// seededSpecies are the species that every park starts with.
+var seededSpecies = []string{
+	"Tyrannosaurus",
+	"Velociraptor",
+	"Spinosaurus",
+	"Megalosaurus",
+	"Brachiosaurus",
+	"Stegosaurus",
+	"Ankylosaurus",
+	"Triceratops",
+}
+
+// testPark is the part of the park manager that the tests use to seed and inspect data directly.
+type testPark interface {
+	AddCage(ctx context.Context, cage models.Cage) error
+	GetCages(filter models.CageFilter) ([]models.Cage, models.PageInfo, error)
+	AddDinosaur(ctx context.Context, dinosaur models.Dinosaur) error
+	AddDinosaurToCage(ctx context.Context, dinosaurName, targetCage string) error
+	GetDinosaursInCage(cageLabel string, page models.Pagination) ([]models.Dinosaur, models.PageInfo, error)
+	SetBreedingPolicy(policy models.BreedingPolicy)
+	SetCompatibilityRules(engine *rules.Engine)
+	webhooks.Store
+	AddWebhook(ctx context.Context, webhook models.Webhook) (*models.Webhook, error)
+	GetWebhookDeliveries(webhookId int, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, models.PageInfo, error)
+	GetAuditEvents(filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error)
+	auth.KeyStore
+	AddAPIKey(ctx context.Context, apiKey models.APIKey) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+// parkBackend is a park manager implementation that the conformance tests are run against.
+type parkBackend interface {
+	Name() string
+	// Available reports whether the backend can be used in this environment. If it can't, the reason is returned.
+	Available() (bool, string)
+	// Reset empties the park.
+	Reset() error
+	Park() testPark
+	// SetCageLabelScope sets where cage labels have to be unique, migrating the database to match.
+	SetCageLabelScope(scope models.CageLabelScope) error
+	NewAPI(engine *gin.Engine, opts ...api.Option) *api.API
+}
+
+var backends = []parkBackend{
+	&memoryBackend{},
+	&sqlBackend{},
+}
+
+// forEachBackend runs the test once against every park backend, so that both implementations are held to the
+// same behavior.
+func forEachBackend(t *testing.T, test func(t *testing.T, backend parkBackend)) {
+	for _, backend := range backends {
+		t.Run(backend.Name(), func(t *testing.T) {
+			if available, reason := backend.Available(); !available {
+				t.Skipf("%s backend is unavailable: %s", backend.Name(), reason)
+			}
+			test(t, backend)
+		})
+	}
+}
+
+type memoryBackend struct {
+	dao *memory.ParkMemoryDao
+}
+
+func (b *memoryBackend) Name() string {
+	return "memory"
+}
+
+func (b *memoryBackend) Available() (bool, string) {
+	return true, ""
+}
+
+func (b *memoryBackend) Reset() error {
+	b.dao = memory.NewParkMemoryDao()
+	return nil
+}
+
+func (b *memoryBackend) Park() testPark {
+	return b.dao
+}
+
+func (b *memoryBackend) SetCageLabelScope(scope models.CageLabelScope) error {
+	return b.dao.SetCageLabelScope(context.Background(), scope)
+}
+
+func (b *memoryBackend) NewAPI(engine *gin.Engine, opts ...api.Option) *api.API {
+	return api.NewAPI(b.dao, engine, opts...)
+}
+
+// sqlBackend runs against the MySQL database started by scripts/run-tests-db.sh. The tests are skipped if the
+// database can't be reached. Any pending migrations are applied before the first test.
+type sqlBackend struct {
+	db  *sql.DB
+	dao *data.ParkSqlDao
+}
+
+func (b *sqlBackend) Name() string {
+	return "mysql"
+}
+
+func (b *sqlBackend) Available() (bool, string) {
+	if b.dao != nil {
+		return true, ""
+	}
+	db, err := data.OpenDB(config)
+	if err != nil {
+		return false, err.Error()
+	}
+	migrator, err := data.NewMigrator(db)
+	if err != nil {
+		return false, err.Error()
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		return false, err.Error()
+	}
+	b.db = db
+	b.dao = data.NewParkSqlDaoFromDB(db)
+	return true, ""
+}
+
+func (b *sqlBackend) Reset() error {
+	db, err := sql.Open("mysql", config.ConnectionString())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// dinosaur can't be truncated while healthRecord references it
+	_, err = db.Exec("DELETE FROM healthRecord")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DELETE FROM dinosaur")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("TRUNCATE audit_event")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DELETE FROM cage")
+	if err != nil {
+		return err
+	}
+
+	// zones are unnested first, so that they can be deleted in any order
+	_, err = db.Exec("UPDATE zone SET parent=NULL")
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{"speciesCompatibility", "speciesConstraint", "webhook", "apiKey", "feeding", "feedingSchedule", "feed", "zone"} {
+		if _, err := db.Exec("DELETE FROM " + table); err != nil {
+			return err
+		}
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(seededSpecies)), ",")
+	args := []any{}
+	for _, species := range seededSpecies {
+		args = append(args, species)
+	}
+	_, err = db.Exec(fmt.Sprintf("DELETE FROM species WHERE name NOT IN (%s)", placeholders), args...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (b *sqlBackend) Park() testPark {
+	return b.dao
+}
+
+func (b *sqlBackend) SetCageLabelScope(scope models.CageLabelScope) error {
+	ctx := context.Background()
+	migrator, err := data.NewMigrator(b.db, data.WithCageLabelScope(scope))
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return err
+	}
+	return b.dao.SetCageLabelScope(ctx, scope)
+}
+
+func (b *sqlBackend) NewAPI(engine *gin.Engine, opts ...api.Option) *api.API {
+	return api.NewAPI(b.dao, engine, opts...)
+}
@@ -0,0 +1,209 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateAndDeleteCages(t *testing.T) {
+	forEachBackend(t, testUpdateAndDeleteCages)
+}
+
+func testUpdateAndDeleteCages(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	dao.AddCage(context.Background(), models.Cage{
+		Label:        "T-Rex-Pen",
+		MaxOccupancy: 3,
+		HasPower:     true,
+	})
+	dao.AddCage(context.Background(), models.Cage{
+		Label:        "Raptor-Pen",
+		MaxOccupancy: 5,
+		HasPower:     true,
+	})
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "TerryRex",
+		Species: "Tyrannosaurus",
+	})
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "MerryRex",
+		Species: "Tyrannosaurus",
+	})
+	dao.AddDinosaurToCage(context.Background(), "TerryRex", "T-Rex-Pen")
+	dao.AddDinosaurToCage(context.Background(), "MerryRex", "T-Rex-Pen")
+
+	cases := []struct {
+		description        string
+		method             string
+		url                string
+		body               any
+		expectedStatusCode int
+		expectedCage       *models.Cage
+		expectedMessage    string
+	}{
+		{
+			description:        "lower the capacity of T-Rex-Pen to its occupancy",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/T-Rex-Pen",
+			body:               models.UpdateCageRequest{MaxOccupancy: wrapInt(2)},
+			expectedStatusCode: http.StatusOK,
+			expectedCage: &models.Cage{
+				Label:        "T-Rex-Pen",
+				MaxOccupancy: 2,
+				HasPower:     true,
+				Occupancy:    2,
+			},
+		},
+		{
+			description:        "lower the capacity of T-Rex-Pen below its occupancy",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/T-Rex-Pen",
+			body:               models.UpdateCageRequest{MaxOccupancy: wrapInt(1)},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "rename T-Rex-Pen to the label of another cage",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/T-Rex-Pen",
+			body:               models.UpdateCageRequest{Label: wrapString("Raptor-Pen")},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "rename T-Rex-Pen and raise its capacity",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/T-Rex-Pen",
+			body:               models.UpdateCageRequest{Label: wrapString("Rex-Paddock"), MaxOccupancy: wrapInt(4)},
+			expectedStatusCode: http.StatusOK,
+			expectedCage: &models.Cage{
+				Label:        "Rex-Paddock",
+				MaxOccupancy: 4,
+				HasPower:     true,
+				Occupancy:    2,
+			},
+		},
+		{
+			description:        "get the cage by its old label",
+			method:             "GET",
+			url:                "/jurassicpark/v1/cages/T-Rex-Pen",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "get the dinosaurs in the renamed cage",
+			method:             "GET",
+			url:                "/jurassicpark/v1/cages/Rex-Paddock/dinosaurs",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "turn off Raptor-Pen with a body without hasPower, as v1 always has",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen",
+			body:               models.UpdateCageRequest{},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "power status updated",
+		},
+		{
+			description:        "turn on Raptor-Pen with hasPower",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen",
+			body:               models.UpdateCageRequest{HasPower: wrapBool(true)},
+			expectedStatusCode: http.StatusOK,
+			expectedMessage:    "power status updated",
+		},
+		{
+			description:        "get Raptor-Pen after turning it back on",
+			method:             "GET",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen",
+			expectedStatusCode: http.StatusOK,
+			expectedCage: &models.Cage{
+				Label:        "Raptor-Pen",
+				MaxOccupancy: 5,
+				HasPower:     true,
+			},
+		},
+		{
+			description:        "update a cage that does not exist",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/NotHere",
+			body:               models.UpdateCageRequest{MaxOccupancy: wrapInt(4)},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "delete the occupied Rex-Paddock",
+			method:             "DELETE",
+			url:                "/jurassicpark/v1/cages/Rex-Paddock",
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "delete the empty Raptor-Pen",
+			method:             "DELETE",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "delete Raptor-Pen a second time",
+			method:             "DELETE",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.Default()
+			backend.NewAPI(r)
+
+			w := httptest.NewRecorder()
+
+			var body []byte
+			if c.body != nil {
+				body, err = json.Marshal(c.body)
+				if err != nil {
+					t.Errorf("failed to marshal request: %s", err)
+					return
+				}
+			}
+			req, _ := http.NewRequest(c.method, c.url, bytes.NewReader(body))
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+			}
+			if c.expectedCage != nil {
+				var actualCage models.Cage
+				err = json.NewDecoder(w.Result().Body).Decode(&actualCage)
+				if err != nil {
+					t.Errorf("error while decoding result body: %s", err)
+					return
+				}
+				assertCagesMatch(*c.expectedCage, actualCage, t)
+			}
+			if c.expectedMessage != "" {
+				var actualMessage struct {
+					Message string `json:"message"`
+				}
+				err = json.NewDecoder(w.Result().Body).Decode(&actualMessage)
+				if err != nil {
+					t.Errorf("error while decoding result body: %s", err)
+					return
+				}
+				if actualMessage.Message != c.expectedMessage {
+					t.Errorf("expected message %q got %q", c.expectedMessage, actualMessage.Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetCagesThatCanHouseDinosaur(t *testing.T) {
+	forEachBackend(t, testGetCagesThatCanHouseDinosaur)
+}
+
+func testGetCagesThatCanHouseDinosaur(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	for _, cage := range []models.CageV2{
+		{Label: "Rex-Pen-Full", MaxOccupancy: 1, PowerStatus: models.PowerStatusActive},
+		{Label: "Rex-Pen", MaxOccupancy: 3, PowerStatus: models.PowerStatusActive},
+		{Label: "Empty-Pen", MaxOccupancy: 2, PowerStatus: models.PowerStatusActive},
+		{Label: "Raptor-Pen", MaxOccupancy: 3, PowerStatus: models.PowerStatusActive},
+		{Label: "Herbivore-Pen", MaxOccupancy: 5, PowerStatus: models.PowerStatusActive},
+		{Label: "Trike-Pen", MaxOccupancy: 5, PowerStatus: models.PowerStatusActive},
+		{Label: "Dark-Pen", MaxOccupancy: 5, PowerStatus: models.PowerStatusDown},
+		{Label: "Serviced-Pen", MaxOccupancy: 5, PowerStatus: models.PowerStatusMaintenance},
+	} {
+		dao.AddCage(context.Background(), cage.Cage())
+	}
+	for _, dinosaur := range []models.Dinosaur{
+		{Name: "TerryRex", Species: "Tyrannosaurus"},
+		{Name: "MerryRex", Species: "Tyrannosaurus"},
+		{Name: "JerryRex", Species: "Tyrannosaurus"},
+		{Name: "Vela", Species: "Velociraptor"},
+		{Name: "LittleFoot", Species: "Brachiosaurus"},
+		{Name: "Tank", Species: "Triceratops"},
+		{Name: "Cera", Species: "Triceratops"},
+	} {
+		dao.AddDinosaur(context.Background(), dinosaur)
+	}
+	dao.AddDinosaurToCage(context.Background(), "TerryRex", "Rex-Pen-Full")
+	dao.AddDinosaurToCage(context.Background(), "MerryRex", "Rex-Pen")
+	dao.AddDinosaurToCage(context.Background(), "Vela", "Raptor-Pen")
+	dao.AddDinosaurToCage(context.Background(), "LittleFoot", "Herbivore-Pen")
+	dao.AddDinosaurToCage(context.Background(), "Tank", "Trike-Pen")
+
+	cases := []struct {
+		description        string
+		url                string
+		expectedStatusCode int
+		expectedLabels     []string
+	}{
+		{
+			description:        "cages for JerryRex",
+			url:                "/jurassicpark/v1/cages?canHouse=JerryRex",
+			expectedStatusCode: http.StatusOK,
+			expectedLabels:     []string{"Rex-Pen", "Empty-Pen"},
+		},
+		{
+			description:        "cages for Cera",
+			url:                "/jurassicpark/v1/cages?canHouse=Cera",
+			expectedStatusCode: http.StatusOK,
+			expectedLabels:     []string{"Trike-Pen", "Herbivore-Pen", "Empty-Pen"},
+		},
+		{
+			description:        "cages for MerryRex leave out her own cage",
+			url:                "/jurassicpark/v2/cages?canHouse=MerryRex",
+			expectedStatusCode: http.StatusOK,
+			expectedLabels:     []string{"Empty-Pen"},
+		},
+		{
+			description:        "cages for a dinosaur that is not at the park",
+			url:                "/jurassicpark/v1/cages?canHouse=NotHere",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.Default()
+			backend.NewAPI(r)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", c.url, nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+			}
+			if c.expectedLabels == nil {
+				return
+			}
+			var actualCages []models.Cage
+			err = json.NewDecoder(w.Result().Body).Decode(&actualCages)
+			if err != nil {
+				t.Errorf("error while decoding result body: %s", err)
+				return
+			}
+			actualLabels := []string{}
+			for _, cage := range actualCages {
+				actualLabels = append(actualLabels, cage.Label)
+			}
+			if fmt.Sprint(actualLabels) != fmt.Sprint(c.expectedLabels) {
+				t.Errorf("expected cages %v got %v", c.expectedLabels, actualLabels)
+			}
+		})
+	}
+}
@@ -0,0 +1,510 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/api"
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/client"
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// newClient serves the API of the backend over HTTP, and returns a client of it. The server is closed when the test
+// ends.
+func newClient(t *testing.T, backend parkBackend, opts ...api.Option) *client.Client {
+	r := gin.New()
+	backend.NewAPI(r, opts...)
+	return serve(t, r)
+}
+
+// serve serves the handler over HTTP, and returns a client of it with the options. The server is closed when the
+// test ends.
+func serve(t *testing.T, handler http.Handler, opts ...client.Option) *client.Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return client.New(server.URL, opts...)
+}
+
+func TestClientCages(t *testing.T) {
+	forEachBackend(t, testClientCages)
+}
+
+func testClientCages(t *testing.T, backend parkBackend) {
+	if err := backend.Reset(); err != nil {
+		t.Fatalf("error when clearing out test database: %s", err)
+	}
+	c := newClient(t, backend)
+	ctx := context.Background()
+
+	for _, cage := range []models.CageV2{
+		{Label: "C-1", MaxOccupancy: 1},
+		{Label: "C-2", MaxOccupancy: 2, PowerStatus: models.PowerStatusDown},
+	} {
+		if _, err := c.CreateCage(ctx, cage); err != nil {
+			t.Fatalf("unexpected error creating cage %s: %s", cage.Label, err)
+		}
+	}
+	for _, name := range []string{"Blue", "Delta"} {
+		if _, err := c.CreateDinosaur(ctx, models.Dinosaur{Name: name, Species: "Velociraptor"}); err != nil {
+			t.Fatalf("unexpected error creating dinosaur %s: %s", name, err)
+		}
+	}
+
+	hasPower := true
+	powered, _, err := c.ListCages(ctx, client.CageListOptions{HasPower: &hasPower})
+	if err != nil {
+		t.Fatalf("unexpected error listing cages: %s", err)
+	}
+	if len(powered) != 1 || powered[0].Label != "C-1" || powered[0].PowerStatus != models.PowerStatusActive {
+		t.Errorf("expected only the cage C-1 to have power, got %+v", powered)
+	}
+
+	if err := c.AddDinosaurToCage(ctx, "C-1", "Blue"); err != nil {
+		t.Fatalf("unexpected error adding Blue to C-1: %s", err)
+	}
+	err = c.AddDinosaurToCage(ctx, "C-1", "Delta")
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an *client.Error adding Delta to the full cage, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusConflict || apiErr.Problem.Code != models.CodeCageCapacityExceeded {
+		t.Errorf("expected a 409 %s, got %d %s", models.CodeCageCapacityExceeded, apiErr.StatusCode, apiErr.Problem.Code)
+	}
+
+	_, err = c.SetPowerStatus(ctx, "C-1", models.PowerStatusDown)
+	if !errors.As(err, &apiErr) || apiErr.Problem.Code != models.CodeIncompatibleCagePowerState {
+		t.Errorf("expected %s powering off an occupied cage, got %v", models.CodeIncompatibleCagePowerState, err)
+	}
+	cage, err := c.SetPowerStatus(ctx, "C-2", models.PowerStatusActive)
+	if err != nil {
+		t.Fatalf("unexpected error powering on C-2: %s", err)
+	}
+	if cage.PowerStatus != models.PowerStatusActive {
+		t.Errorf("expected C-2 to be %s, got %s", models.PowerStatusActive, cage.PowerStatus)
+	}
+	if err := c.TransferDinosaur(ctx, "Blue", "C-2"); err != nil {
+		t.Fatalf("unexpected error transferring Blue to C-2: %s", err)
+	}
+
+	dinosaurs, _, err := c.ListDinosaursInCage(ctx, "C-2", client.PageOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error listing the dinosaurs in C-2: %s", err)
+	}
+	if len(dinosaurs) != 1 || dinosaurs[0].Name != "Blue" {
+		t.Errorf("expected Blue to be in C-2, got %+v", dinosaurs)
+	}
+	needsCage := true
+	uncaged, _, err := c.ListDinosaurs(ctx, client.DinosaurListOptions{NeedsCageAssignment: &needsCage})
+	if err != nil {
+		t.Fatalf("unexpected error listing dinosaurs: %s", err)
+	}
+	if len(uncaged) != 1 || uncaged[0].Name != "Delta" {
+		t.Errorf("expected only Delta to need a cage, got %+v", uncaged)
+	}
+
+	_, err = c.GetCage(ctx, "C-3")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 getting a cage that doesn't exist, got %v", err)
+	}
+}
+
+func TestClientPagination(t *testing.T) {
+	forEachBackend(t, testClientPagination)
+}
+
+func testClientPagination(t *testing.T, backend parkBackend) {
+	if err := backend.Reset(); err != nil {
+		t.Fatalf("error when clearing out test database: %s", err)
+	}
+	c := newClient(t, backend)
+	ctx := context.Background()
+	expected := []string{"C-1", "C-2", "C-3"}
+	for _, label := range expected {
+		if _, err := c.CreateCage(ctx, models.CageV2{Label: label, MaxOccupancy: 1}); err != nil {
+			t.Fatalf("unexpected error creating cage %s: %s", label, err)
+		}
+	}
+
+	labels := []string{}
+	options := client.CageListOptions{PageOptions: client.PageOptions{Limit: 2, Sort: "label", IncludeTotal: true}}
+	for {
+		cages, page, err := c.ListCages(ctx, options)
+		if err != nil {
+			t.Fatalf("unexpected error listing cages: %s", err)
+		}
+		if page.Total == nil || *page.Total != len(expected) {
+			t.Errorf("expected a total of %d, got %v", len(expected), page.Total)
+		}
+		for _, cage := range cages {
+			labels = append(labels, cage.Label)
+		}
+		if page.Next == "" {
+			break
+		}
+		options.Cursor = page.Next
+	}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected the pages to list %v, got %v", expected, labels)
+	}
+}
+
+func TestClientInventory(t *testing.T) {
+	forEachBackend(t, testClientInventory)
+}
+
+func testClientInventory(t *testing.T, backend parkBackend) {
+	if err := backend.Reset(); err != nil {
+		t.Fatalf("error when clearing out test database: %s", err)
+	}
+	c := newClient(t, backend)
+	ctx := context.Background()
+
+	report, err := c.Import(ctx, models.InventoryFormatCSV, bytes.NewBufferString(islandCSV), models.ImportOptions{Mode: models.ImportModePerRow})
+	if err != nil {
+		t.Fatalf("unexpected error importing: %s", err)
+	}
+	if !report.Committed || report.Applied != 5 || report.Failed != 1 {
+		t.Errorf("expected 5 rows to be applied and 1 to fail, got %+v", report)
+	}
+
+	exported := &bytes.Buffer{}
+	if err := c.Export(ctx, models.InventoryFormatNDJSON, exported); err != nil {
+		t.Fatalf("unexpected error exporting: %s", err)
+	}
+	if err := backend.Reset(); err != nil {
+		t.Fatalf("error when clearing out test database: %s", err)
+	}
+	c = newClient(t, backend)
+	report, err = c.Import(ctx, models.InventoryFormatNDJSON, exported, models.ImportOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error importing the export: %s", err)
+	}
+	if !report.Committed || report.Applied != 5 {
+		t.Errorf("expected every row of the export to be applied, got %+v", report)
+	}
+
+	_, err = c.Import(ctx, models.InventoryFormatCSV, bytes.NewBufferString("kind,label\n\"cage"), models.ImportOptions{})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Problem.Code != models.CodeInvalidImportFile {
+		t.Errorf("expected %s importing a file that isn't CSV, got %v", models.CodeInvalidImportFile, err)
+	}
+}
+
+func TestClientEvents(t *testing.T) {
+	forEachBackend(t, testClientEvents)
+}
+
+func testClientEvents(t *testing.T, backend parkBackend) {
+	if err := backend.Reset(); err != nil {
+		t.Fatalf("error when clearing out test database: %s", err)
+	}
+	broker := events.NewBroker(events.DefaultReplaySize)
+	c := newClient(t, backend, api.WithEvents(broker))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, label := range []string{"C-1", "C-2"} {
+		if _, err := c.CreateCage(ctx, models.CageV2{Label: label, MaxOccupancy: 1}); err != nil {
+			t.Fatalf("unexpected error creating cage %s: %s", label, err)
+		}
+	}
+
+	// the stream resumes after the first event, so only the second is replayed
+	received := []client.Event{}
+	err := c.StreamEvents(ctx, 1, func(event client.Event) error {
+		received = append(received, event)
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the stream to end when it is canceled, got %v", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(received))
+	}
+	if received[0].ID != 2 || received[0].Type != events.CageCreated || !bytes.Contains(received[0].Data, []byte(`"label":"C-2"`)) {
+		t.Errorf("expected the cage.created event of C-2, got %d %s %s", received[0].ID, received[0].Type, received[0].Data)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	forEachBackend(t, testClientErrors)
+}
+
+func testClientErrors(t *testing.T, backend parkBackend) {
+	if err := backend.Reset(); err != nil {
+		t.Fatalf("error when clearing out test database: %s", err)
+	}
+	c := newClient(t, backend)
+	ctx := context.Background()
+	if _, err := c.CreateCage(ctx, models.CageV2{Label: "C-1", MaxOccupancy: 1}); err != nil {
+		t.Fatalf("unexpected error creating cage C-1: %s", err)
+	}
+	if _, err := c.CreateCage(ctx, models.CageV2{Label: "C-2", MaxOccupancy: 2}); err != nil {
+		t.Fatalf("unexpected error creating cage C-2: %s", err)
+	}
+	for _, dinosaur := range []models.Dinosaur{
+		{Name: "Blue", Species: "Velociraptor"},
+		{Name: "Delta", Species: "Velociraptor"},
+		{Name: "Rexy", Species: "Tyrannosaurus"},
+	} {
+		if _, err := c.CreateDinosaur(ctx, dinosaur); err != nil {
+			t.Fatalf("unexpected error creating dinosaur %s: %s", dinosaur.Name, err)
+		}
+	}
+	for _, assignment := range [][2]string{{"C-1", "Blue"}, {"C-2", "Delta"}} {
+		if err := c.AddDinosaurToCage(ctx, assignment[0], assignment[1]); err != nil {
+			t.Fatalf("unexpected error adding %s to %s: %s", assignment[1], assignment[0], err)
+		}
+	}
+
+	cases := []struct {
+		description   string
+		call          func() error
+		expectedErr   error
+		unexpectedErr error
+	}{
+		{
+			description:   "a full cage",
+			call:          func() error { return c.AddDinosaurToCage(ctx, "C-1", "Rexy") },
+			expectedErr:   models.CageCapacityExceeded,
+			unexpectedErr: models.EntityNotFound,
+		},
+		{
+			description: "a dinosaur that doesn't exist",
+			call: func() error {
+				_, err := c.GetDinosaur(ctx, "Nedry")
+				return err
+			},
+			expectedErr: models.EntityNotFound,
+		},
+		{
+			description: "a cage that already exists",
+			call: func() error {
+				_, err := c.CreateCage(ctx, models.CageV2{Label: "C-1", MaxOccupancy: 1})
+				return err
+			},
+			expectedErr: models.EntityAlreadyExists,
+		},
+		{
+			description: "an occupied cage that is powered off",
+			call: func() error {
+				_, err := c.SetPowerStatus(ctx, "C-1", models.PowerStatusDown)
+				return err
+			},
+			expectedErr: models.IncompatibleCagePowerState,
+		},
+		{
+			description: "incompatible species",
+			call:        func() error { return c.AddDinosaurToCage(ctx, "C-2", "Rexy") },
+			expectedErr: models.IncompatibleSpecies,
+		},
+		{
+			description: "invalid fields",
+			call: func() error {
+				_, err := c.CreateCage(ctx, models.CageV2{})
+				return err
+			},
+			expectedErr: models.InvalidRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := tc.call()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected an error that is %q, got %v", tc.expectedErr, err)
+			}
+			if tc.unexpectedErr != nil && errors.Is(err, tc.unexpectedErr) {
+				t.Errorf("expected an error that isn't %q, got %v", tc.unexpectedErr, err)
+			}
+		})
+	}
+
+	var compatibilityErr *models.CompatibilityError
+	err := c.AddDinosaurToCage(ctx, "C-2", "Rexy")
+	if !errors.As(err, &compatibilityErr) || len(compatibilityErr.Violations) == 0 {
+		t.Errorf("expected the broken compatibility rules, got %v", err)
+	}
+	var validationErr *models.ValidationError
+	_, err = c.CreateCage(ctx, models.CageV2{MaxOccupancy: 1})
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "label" {
+		t.Errorf("expected label to be the invalid field, got %v", err)
+	}
+
+	// a code that isn't reported for an error in models wraps nothing
+	_, _, err = c.ListWebhookDeliveries(ctx, 1, "BOGUS", client.PageOptions{})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Problem.Code != models.CodeInvalidParameter || errors.Unwrap(err) != nil {
+		t.Errorf("expected an %s that wraps no error, got %v", models.CodeInvalidParameter, err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	forEachBackend(t, testClientRetries)
+}
+
+func testClientRetries(t *testing.T, backend parkBackend) {
+	policy := client.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	cases := []struct {
+		description        string
+		policy             client.RetryPolicy
+		failures           int32
+		call               func(c *client.Client) error
+		expectedAttempts   int32
+		expectedStatusCode int
+	}{
+		{
+			description: "a GET is retried until it succeeds",
+			policy:      policy,
+			failures:    2,
+			call: func(c *client.Client) error {
+				_, _, err := c.ListCages(context.Background(), client.CageListOptions{})
+				return err
+			},
+			expectedAttempts: 3,
+		},
+		{
+			description: "a GET fails when every attempt fails",
+			policy:      policy,
+			failures:    3,
+			call: func(c *client.Client) error {
+				_, err := c.GetCage(context.Background(), "C-1")
+				return err
+			},
+			expectedAttempts:   3,
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			description: "a DELETE is retried",
+			policy:      policy,
+			failures:    1,
+			call: func(c *client.Client) error {
+				return c.DeleteCage(context.Background(), "C-1")
+			},
+			expectedAttempts: 2,
+		},
+		{
+			description: "a POST isn't retried",
+			policy:      policy,
+			failures:    1,
+			call: func(c *client.Client) error {
+				_, err := c.CreateCage(context.Background(), models.CageV2{Label: "C-2", MaxOccupancy: 1})
+				return err
+			},
+			expectedAttempts:   1,
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			description: "a policy of one attempt doesn't retry",
+			policy:      client.RetryPolicy{MaxAttempts: 1},
+			failures:    1,
+			call: func(c *client.Client) error {
+				_, _, err := c.ListCages(context.Background(), client.CageListOptions{})
+				return err
+			},
+			expectedAttempts:   1,
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			if err := backend.Reset(); err != nil {
+				t.Fatalf("error when clearing out test database: %s", err)
+			}
+			if err := backend.Park().AddCage(context.Background(), models.Cage{Label: "C-1", MaxOccupancy: 1, HasPower: true}); err != nil {
+				t.Fatalf("error when adding cage: %s", err)
+			}
+			r := gin.New()
+			backend.NewAPI(r)
+			// the first requests fail as they would behind a proxy while the server restarts
+			var attempts atomic.Int32
+			failures := tc.failures
+			flaky := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if attempts.Add(1) <= failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				r.ServeHTTP(w, req)
+			})
+			c := serve(t, flaky, client.WithRetryPolicy(tc.policy))
+
+			err := tc.call(c)
+			if tc.expectedStatusCode == 0 && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			var apiErr *client.Error
+			if tc.expectedStatusCode != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tc.expectedStatusCode) {
+				t.Errorf("expected a %d, got %v", tc.expectedStatusCode, err)
+			}
+			if attempts.Load() != tc.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tc.expectedAttempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestClientAuthenticators(t *testing.T) {
+	forEachBackend(t, testClientAuthenticators)
+}
+
+func testClientAuthenticators(t *testing.T, backend parkBackend) {
+	if err := backend.Reset(); err != nil {
+		t.Fatalf("error when clearing out test database: %s", err)
+	}
+	r := newAuthenticatedAPI(backend)
+	viewerKey := issueAPIKey(t, backend.Park(), "visitor-center", models.RoleViewer)
+	keeperKey := issueAPIKey(t, backend.Park(), "muldoon", models.RoleKeeper)
+	ctx := context.Background()
+
+	_, _, err := serve(t, r).ListCages(ctx, client.CageListOptions{})
+	if !errors.Is(err, models.MissingCredentials) {
+		t.Errorf("expected the credentials to be missing, got %v", err)
+	}
+	_, _, err = serve(t, r, client.WithAPIKey("jpk_not-a-key")).ListCages(ctx, client.CageListOptions{})
+	if !errors.Is(err, models.InvalidCredentials) {
+		t.Errorf("expected the credentials to be invalid, got %v", err)
+	}
+
+	viewer := serve(t, r, client.WithAPIKey(viewerKey))
+	if _, _, err := viewer.ListCages(ctx, client.CageListOptions{}); err != nil {
+		t.Errorf("unexpected error listing cages as a viewer: %s", err)
+	}
+	_, err = viewer.CreateCage(ctx, models.CageV2{Label: "C-1", MaxOccupancy: 1})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a viewer to be forbidden from creating a cage, got %v", err)
+	}
+
+	// a custom authenticator is called for every request
+	var calls atomic.Int32
+	keeper := serve(t, r, client.WithAuthenticator(client.AuthenticatorFunc(func(req *http.Request) error {
+		calls.Add(1)
+		req.Header.Set(auth.APIKeyHeader, keeperKey)
+		return nil
+	})))
+	if _, err := keeper.CreateCage(ctx, models.CageV2{Label: "C-1", MaxOccupancy: 1}); err != nil {
+		t.Errorf("unexpected error creating a cage as a keeper: %s", err)
+	}
+	if _, err := keeper.GetCage(ctx, "C-1"); err != nil {
+		t.Errorf("unexpected error getting a cage as a keeper: %s", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected the authenticator to be called for both requests, got %d calls", calls.Load())
+	}
+	events, _, err := keeper.ListAuditEvents(ctx, client.AuditListOptions{})
+	if err == nil {
+		t.Errorf("expected a keeper to be forbidden from reading the audit log, got %d events", len(events))
+	}
+}
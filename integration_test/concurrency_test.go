@@ -0,0 +1,146 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestConcurrentCageAssignments(t *testing.T) {
+	forEachBackend(t, testConcurrentCageAssignments)
+}
+
+func testConcurrentCageAssignments(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+
+	const (
+		cageCount        = 8
+		cageCapacity     = 5
+		dinosaurCount    = 120
+		assignmentCount  = 400
+		powerChangeCount = 100
+	)
+
+	species := []string{"Tyrannosaurus", "Velociraptor", "Brachiosaurus", "Triceratops"}
+	cageLabels := []string{}
+	for i := 0; i < cageCount; i++ {
+		label := fmt.Sprintf("Race-Pen-%d", i)
+		cageLabels = append(cageLabels, label)
+		err = dao.AddCage(context.Background(), models.Cage{
+			Label:        label,
+			MaxOccupancy: cageCapacity,
+			HasPower:     true,
+		})
+		if err != nil {
+			t.Errorf("error when creating test cage: %s", err)
+			return
+		}
+	}
+	dinosaurNames := []string{}
+	for i := 0; i < dinosaurCount; i++ {
+		name := fmt.Sprintf("Racer-%d", i)
+		dinosaurNames = append(dinosaurNames, name)
+		err = dao.AddDinosaur(context.Background(), models.Dinosaur{
+			Name:    name,
+			Species: species[i%len(species)],
+		})
+		if err != nil {
+			t.Errorf("error when adding dinosaur: %s", err)
+			return
+		}
+	}
+
+	r := gin.New()
+	backend.NewAPI(r)
+
+	random := rand.New(rand.NewSource(42))
+	requests := []*http.Request{}
+	for i := 0; i < assignmentCount; i++ {
+		addBody, _ := json.Marshal(models.AddDinosaurToCageRequest{
+			Name: dinosaurNames[random.Intn(len(dinosaurNames))],
+		})
+		url := fmt.Sprintf("/jurassicpark/v1/cages/%s/dinosaurs", cageLabels[random.Intn(len(cageLabels))])
+		req, _ := http.NewRequest("POST", url, bytes.NewReader(addBody))
+		requests = append(requests, req)
+	}
+	for i := 0; i < powerChangeCount; i++ {
+		powerBody, _ := json.Marshal(models.UpdateCagePowerStatusRequest{
+			HasPower: i%2 == 0,
+		})
+		url := fmt.Sprintf("/jurassicpark/v1/cages/%s", cageLabels[random.Intn(len(cageLabels))])
+		req, _ := http.NewRequest("PATCH", url, bytes.NewReader(powerBody))
+		requests = append(requests, req)
+	}
+	random.Shuffle(len(requests), func(i, j int) {
+		requests[i], requests[j] = requests[j], requests[i]
+	})
+
+	var wg sync.WaitGroup
+	for _, req := range requests {
+		wg.Add(1)
+		go func(req *http.Request) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code == http.StatusInternalServerError {
+				t.Errorf("unexpected internal server error for %s %s: %s", req.Method, req.URL, w.Body.String())
+			}
+		}(req)
+	}
+	wg.Wait()
+
+	assertCageInvariantsHold(dao, t)
+}
+
+// assertCageInvariantsHold verifies that no cage is over capacity, that no cage without power is occupied
+// and that carnivores only share a cage with their own species.
+func assertCageInvariantsHold(dao testPark, t *testing.T) {
+	cages, _, err := dao.GetCages(models.CageFilter{})
+	if err != nil {
+		t.Errorf("error when getting cages: %s", err)
+		return
+	}
+	for _, cage := range cages {
+		if cage.Occupancy > cage.MaxOccupancy {
+			t.Errorf("cage %s holds %d dinosaurs but has a capacity of %d", cage.Label, cage.Occupancy, cage.MaxOccupancy)
+		}
+		if !cage.HasPower && cage.Occupancy > 0 {
+			t.Errorf("cage %s has no power but holds %d dinosaurs", cage.Label, cage.Occupancy)
+		}
+
+		dinosaurs, _, err := dao.GetDinosaursInCage(cage.Label, models.Pagination{})
+		if err != nil {
+			t.Errorf("error when getting dinosaurs in cage %s: %s", cage.Label, err)
+			return
+		}
+		if len(dinosaurs) != cage.Occupancy {
+			t.Errorf("cage %s reports an occupancy of %d but holds %d dinosaurs", cage.Label, cage.Occupancy, len(dinosaurs))
+		}
+		speciesInCage := map[string]bool{}
+		hasCarnivore := false
+		for _, dinosaur := range dinosaurs {
+			speciesInCage[dinosaur.Species] = true
+			if dinosaur.Diet == "Carnivore" {
+				hasCarnivore = true
+			}
+		}
+		if hasCarnivore && len(speciesInCage) > 1 {
+			t.Errorf("cage %s mixes a carnivore with other species: %v", cage.Label, speciesInCage)
+		}
+	}
+}
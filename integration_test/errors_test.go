@@ -0,0 +1,172 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblemResponses(t *testing.T) {
+	forEachBackend(t, testProblemResponses)
+}
+
+func testProblemResponses(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) {
+		w := sendWithCredentials(r, method, path, "X-Actor", "muldoon", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+	}
+	send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-1", MaxOccupancy: 2, HasPower: true}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/dinosaurs", models.Dinosaur{Name: "Blue", Species: "Velociraptor"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/dinosaurs", models.Dinosaur{Name: "Delta", Species: "Velociraptor"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/dinosaurs", models.Dinosaur{Name: "Charlie", Species: "Velociraptor"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages/C-1/dinosaurs", models.AddDinosaurToCageRequest{Name: "Blue"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages/C-1/dinosaurs", models.AddDinosaurToCageRequest{Name: "Delta"}, http.StatusCreated)
+
+	cases := []struct {
+		description        string
+		method             string
+		path               string
+		body               any
+		expectedStatusCode int
+		expectedCode       models.ErrorCode
+		expectedContext    map[string]any
+	}{
+		{
+			description:        "add a dinosaur to a full cage",
+			method:             "POST",
+			path:               "/jurassicpark/v1/cages/C-1/dinosaurs",
+			body:               models.AddDinosaurToCageRequest{Name: "Charlie"},
+			expectedStatusCode: http.StatusConflict,
+			expectedCode:       models.CodeCageCapacityExceeded,
+			expectedContext: map[string]any{
+				"cage":         "C-1",
+				"occupancy":    float64(2),
+				"maxOccupancy": float64(2),
+				"powerStatus":  "ACTIVE",
+			},
+		},
+		{
+			description:        "create a cage with a label that is taken",
+			method:             "POST",
+			path:               "/jurassicpark/v1/cages",
+			body:               models.Cage{Label: "C-1", MaxOccupancy: 4, HasPower: true},
+			expectedStatusCode: http.StatusConflict,
+			expectedCode:       models.CodeAlreadyExists,
+			expectedContext:    map[string]any{"cage": "C-1"},
+		},
+		{
+			description:        "add a dinosaur with a name that is taken",
+			method:             "POST",
+			path:               "/jurassicpark/v1/dinosaurs",
+			body:               models.Dinosaur{Name: "Blue", Species: "Velociraptor"},
+			expectedStatusCode: http.StatusConflict,
+			expectedCode:       models.CodeAlreadyExists,
+			expectedContext:    map[string]any{"dinosaur": "Blue"},
+		},
+		{
+			description:        "lower the capacity of a cage below its occupancy",
+			method:             "PATCH",
+			path:               "/jurassicpark/v2/cages/C-1",
+			body:               models.UpdateCageV2Request{MaxOccupancy: wrapInt(1)},
+			expectedStatusCode: http.StatusConflict,
+			expectedCode:       models.CodeCageCapacityBelowOccupancy,
+			expectedContext: map[string]any{
+				"cage":                  "C-1",
+				"occupancy":             float64(2),
+				"maxOccupancy":          float64(2),
+				"powerStatus":           "ACTIVE",
+				"requestedMaxOccupancy": float64(1),
+			},
+		},
+		{
+			description:        "transfer a dinosaur that is not in a cage",
+			method:             "POST",
+			path:               "/jurassicpark/v1/dinosaurs/Charlie/transfer",
+			body:               models.TransferDinosaurRequest{Cage: "C-1"},
+			expectedStatusCode: http.StatusConflict,
+			expectedCode:       models.CodeDinosaurNotCaged,
+			expectedContext:    map[string]any{"dinosaur": "Charlie"},
+		},
+		{
+			description:        "remove a dinosaur from a cage it is not in",
+			method:             "DELETE",
+			path:               "/jurassicpark/v1/cages/C-1/dinosaurs/Charlie",
+			expectedStatusCode: http.StatusNotFound,
+			expectedCode:       models.CodeDinosaurNotInCage,
+			expectedContext:    map[string]any{"cage": "C-1", "dinosaur": "Charlie"},
+		},
+		{
+			description:        "get a cage that does not exist",
+			method:             "GET",
+			path:               "/jurassicpark/v1/cages/C-2",
+			expectedStatusCode: http.StatusNotFound,
+			expectedCode:       models.CodeNotFound,
+			expectedContext:    map[string]any{"cage": "C-2"},
+		},
+		{
+			description:        "list cages with a limit that is too large",
+			method:             "GET",
+			path:               "/jurassicpark/v1/cages?limit=1001",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedCode:       models.CodeInvalidParameter,
+			expectedContext:    map[string]any{"parameter": "limit", "min": float64(1), "max": float64(1000)},
+		},
+		{
+			description:        "create a cage with a body that is not json",
+			method:             "POST",
+			path:               "/jurassicpark/v1/cages",
+			body:               "C-3",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedCode:       models.CodeInvalidRequestBody,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := sendWithCredentials(r, c.method, c.path, "X-Actor", "muldoon", c.body)
+			if w.Code != c.expectedStatusCode {
+				t.Fatalf("expected status code %d got %d: %s", c.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), models.ProblemContentType) {
+				t.Errorf("expected content type %s got %s", models.ProblemContentType, w.Header().Get("Content-Type"))
+			}
+			var problem models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("unexpected error decoding the problem: %s", err)
+			}
+			if problem.Code != c.expectedCode {
+				t.Errorf("expected code %s got %s", c.expectedCode, problem.Code)
+			}
+			if problem.Status != c.expectedStatusCode || problem.Title != http.StatusText(c.expectedStatusCode) {
+				t.Errorf("expected status %d %s got %d %s", c.expectedStatusCode, http.StatusText(c.expectedStatusCode), problem.Status, problem.Title)
+			}
+			if problem.Type != "about:blank" {
+				t.Errorf("expected type about:blank got %s", problem.Type)
+			}
+			if problem.Detail == "" || problem.ErrorMessage != problem.Detail {
+				t.Errorf("expected errorMessage to repeat the detail, got %q and %q", problem.ErrorMessage, problem.Detail)
+			}
+			if problem.Instance != strings.Split(c.path, "?")[0] {
+				t.Errorf("expected instance %s got %s", c.path, problem.Instance)
+			}
+			if c.expectedContext != nil && !reflect.DeepEqual(problem.Context, c.expectedContext) {
+				t.Errorf("expected context %v got %v", c.expectedContext, problem.Context)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package integration_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// streamedEvent is an event read from the event stream.
+type streamedEvent struct {
+	id        string
+	eventType string
+	data      string
+}
+
+func TestEventStream(t *testing.T) {
+	forEachBackend(t, testEventStream)
+}
+
+func testEventStream(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	r := gin.New()
+	backend.NewAPI(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	post := func(path string, body any) {
+		payload, _ := json.Marshal(body)
+		resp, err := http.Post(server.URL+path, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("error when calling %s: %s", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			t.Fatalf("expected %s to succeed got status code %d", path, resp.StatusCode)
+		}
+	}
+	post("/jurassicpark/v2/cages", models.CageV2{Label: "Raptor-Pen", MaxOccupancy: 4, PowerStatus: models.PowerStatusActive})
+	post("/jurassicpark/v1/dinosaurs", models.Dinosaur{Name: "Blue", Species: "Velociraptor"})
+
+	// resume after the first event, so only the dinosaur is replayed
+	stream, cancel := openEventStream(t, server.URL, "1")
+	defer cancel()
+
+	post("/jurassicpark/v1/cages/Raptor-Pen/dinosaurs", models.AddDinosaurToCageRequest{Name: "Blue"})
+
+	expectedEvents := []streamedEvent{
+		{id: "2", eventType: events.DinosaurAdded, data: `{"name":"Blue","species":"Velociraptor","sex":"Female","diet":""}`},
+		{id: "3", eventType: events.DinosaurAssigned, data: `{"dinosaur":"Blue","cage":"Raptor-Pen"}`},
+	}
+	actualEvents := readEvents(t, stream, len(expectedEvents))
+	if !reflect.DeepEqual(expectedEvents, actualEvents) {
+		t.Errorf("expected events %v got %v", expectedEvents, actualEvents)
+	}
+
+	// a new client without Last-Event-ID only sees new events
+	live, cancelLive := openEventStream(t, server.URL, "")
+	defer cancelLive()
+
+	maintenance := models.PowerStatusMaintenance
+	body, _ := json.Marshal(models.UpdateCageV2Request{PowerStatus: &maintenance})
+	req, _ := http.NewRequest("PATCH", server.URL+"/jurassicpark/v2/cages/Raptor-Pen", bytes.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("error when updating the cage: %s", err)
+		return
+	}
+	resp.Body.Close()
+
+	expectedEvent := streamedEvent{
+		id:        "4",
+		eventType: events.CagePowerChanged,
+		data:      `{"label":"Raptor-Pen","occupancy":1,"maxOccupancy":4,"powerStatus":"MAINTENANCE"}`,
+	}
+	for _, s := range []*bufio.Reader{stream, live} {
+		actualEvents := readEvents(t, s, 1)
+		if !reflect.DeepEqual([]streamedEvent{expectedEvent}, actualEvents) {
+			t.Errorf("expected event %v got %v", expectedEvent, actualEvents)
+		}
+	}
+}
+
+func TestCageEventZone(t *testing.T) {
+	forEachBackend(t, testCageEventZone)
+}
+
+func testCageEventZone(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	r := gin.New()
+	backend.NewAPI(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	post := func(path string, body any) {
+		payload, _ := json.Marshal(body)
+		resp, err := http.Post(server.URL+path, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("error when calling %s: %s", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			t.Fatalf("expected %s to succeed got status code %d", path, resp.StatusCode)
+		}
+	}
+	post("/jurassicpark/v1/zones", models.Zone{Name: "North"})
+
+	stream, cancel := openEventStream(t, server.URL, "")
+	defer cancel()
+
+	post("/jurassicpark/v1/cages", models.Cage{Label: "Raptor-Pen", MaxOccupancy: 4, HasPower: true, Zone: wrapString("North")})
+	post("/jurassicpark/v2/cages", models.CageV2{Label: "Rex-Pen", MaxOccupancy: 1, PowerStatus: models.PowerStatusActive, Zone: wrapString("North")})
+
+	expectedEvents := []streamedEvent{
+		{eventType: events.CageCreated, data: `{"label":"Raptor-Pen","occupancy":0,"maxOccupancy":4,"powerStatus":"ACTIVE","zone":"North"}`},
+		{eventType: events.CageCreated, data: `{"label":"Rex-Pen","occupancy":0,"maxOccupancy":1,"powerStatus":"ACTIVE","zone":"North"}`},
+	}
+	actualEvents := readEvents(t, stream, len(expectedEvents))
+	for i := range actualEvents {
+		actualEvents[i].id = ""
+	}
+	if !reflect.DeepEqual(expectedEvents, actualEvents) {
+		t.Errorf("expected events %v got %v", expectedEvents, actualEvents)
+	}
+}
+
+func openEventStream(t *testing.T, serverURL, lastEventID string) (*bufio.Reader, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	req, _ := http.NewRequestWithContext(ctx, "GET", serverURL+"/jurassicpark/v1/events", nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatalf("error when opening the event stream: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		cancel()
+		t.Fatalf("expected an event stream got status code %d and %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body), func() {
+		cancel()
+		resp.Body.Close()
+	}
+}
+
+// readEvents reads count events from the stream, skipping comments.
+func readEvents(t *testing.T, stream *bufio.Reader, count int) []streamedEvent {
+	streamed := []streamedEvent{}
+	event := streamedEvent{}
+	for len(streamed) < count {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("error when reading the event stream: %s", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		field, value, _ := strings.Cut(line, ":")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.eventType = value
+		case "data":
+			event.data = value
+		case "":
+			if line == "" && event.id != "" {
+				streamed = append(streamed, event)
+				event = streamedEvent{}
+			}
+		}
+	}
+	return streamed
+}
+
+func TestEventReplayBuffer(t *testing.T) {
+	broker := events.NewBroker(2)
+	for _, label := range []string{"Alpha-Pen", "Bravo-Pen", "Charlie-Pen"} {
+		broker.Publish(events.CageDeleted, models.DeletedCage{Label: label})
+	}
+
+	cases := []struct {
+		description string
+		lastEventID *uint64
+		expectedIDs []uint64
+	}{
+		{
+			description: "events that are no longer buffered are skipped",
+			lastEventID: wrapUint64(0),
+			expectedIDs: []uint64{2, 3},
+		},
+		{
+			description: "events after the last event id",
+			lastEventID: wrapUint64(2),
+			expectedIDs: []uint64{3},
+		},
+		{
+			description: "client that is up to date",
+			lastEventID: wrapUint64(3),
+			expectedIDs: []uint64{},
+		},
+		{
+			description: "client from before the server restarted",
+			lastEventID: wrapUint64(50),
+			expectedIDs: []uint64{2, 3},
+		},
+		{
+			description: "client that isn't resuming",
+			expectedIDs: []uint64{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			replay, _, unsubscribe := broker.Subscribe(c.lastEventID)
+			defer unsubscribe()
+
+			actualIDs := []uint64{}
+			for _, event := range replay {
+				actualIDs = append(actualIDs, event.ID)
+			}
+			if !reflect.DeepEqual(c.expectedIDs, actualIDs) {
+				t.Errorf("expected events %v to be replayed got %v", c.expectedIDs, actualIDs)
+			}
+		})
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	broker := events.NewBroker(events.DefaultReplaySize)
+	_, stream, unsubscribe := broker.Subscribe(nil)
+	defer unsubscribe()
+
+	published := 0
+	for {
+		broker.Publish(events.CageDeleted, models.DeletedCage{Label: "Alpha-Pen"})
+		published++
+		if published > 1000 {
+			t.Errorf("expected a subscriber that doesn't read to be dropped")
+			return
+		}
+		if len(stream) < cap(stream) {
+			continue
+		}
+		// the buffer is full, so the next event drops the subscriber
+		broker.Publish(events.CageDeleted, models.DeletedCage{Label: "Alpha-Pen"})
+		break
+	}
+
+	received := 0
+	for range stream {
+		received++
+	}
+	if received != published {
+		t.Errorf("expected the %d buffered events before the stream closed got %d", published, received)
+	}
+}
+
+func wrapUint64(value uint64) *uint64 {
+	return &value
+}
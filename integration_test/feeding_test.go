@@ -0,0 +1,268 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/api"
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// seedFeedingPark adds a cage of two raptors, a cage of one triceratops and an empty cage.
+func seedFeedingPark(park testPark) error {
+	ctx := context.Background()
+	for _, cage := range []models.Cage{
+		{Label: "C-1", MaxOccupancy: 2, HasPower: true},
+		{Label: "C-2", MaxOccupancy: 2, HasPower: true},
+		{Label: "C-3", MaxOccupancy: 2, HasPower: true},
+	} {
+		if err := park.AddCage(ctx, cage); err != nil {
+			return err
+		}
+	}
+	for _, assignment := range []struct {
+		dinosaur models.Dinosaur
+		cage     string
+	}{
+		{dinosaur: models.Dinosaur{Name: "Blue", Species: "Velociraptor", Sex: models.SexFemale}, cage: "C-1"},
+		{dinosaur: models.Dinosaur{Name: "Charlie", Species: "Velociraptor", Sex: models.SexFemale}, cage: "C-1"},
+		{dinosaur: models.Dinosaur{Name: "Sarah", Species: "Triceratops", Sex: models.SexFemale}, cage: "C-2"},
+	} {
+		if err := park.AddDinosaur(ctx, assignment.dinosaur); err != nil {
+			return err
+		}
+		if err := park.AddDinosaurToCage(ctx, assignment.dinosaur.Name, assignment.cage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFeedingRoster(t *testing.T) {
+	forEachBackend(t, testFeedingRoster)
+}
+
+func testFeedingRoster(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	if err := seedFeedingPark(backend.Park()); err != nil {
+		t.Errorf("error when seeding the park: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) []byte {
+		w := sendWithCredentials(r, method, path, "X-Actor", "muldoon", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	schedule := func(cage, feed, time string, quantity int) int {
+		var created models.FeedingSchedule
+		body := send("POST", "/jurassicpark/v1/feeding/schedules", models.FeedingSchedule{Cage: cage, Feed: feed, Time: time, Quantity: quantity}, http.StatusCreated)
+		if err := json.Unmarshal(body, &created); err != nil {
+			t.Fatalf("failed to read the schedule: %s", err)
+		}
+		return created.ID
+	}
+	send("POST", "/jurassicpark/v1/feeding/feeds", models.Feed{Name: "goat", Diet: "Carnivore", Unit: "head", Stock: 10, LowStockThreshold: 3}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/feeding/feeds", models.Feed{Name: "hay", Diet: "Herbivore", Unit: "kg", Stock: 100, LowStockThreshold: 20}, http.StatusCreated)
+	raptors := schedule("C-1", "goat", "06:00", 2)
+	triceratops := schedule("C-2", "hay", "07:30", 25)
+	empty := schedule("C-3", "goat", "06:00", 1)
+	// the triceratops doesn't eat goats, so this schedule feeds no one
+	schedule("C-2", "goat", "05:00", 1)
+
+	send("POST", "/jurassicpark/v1/feeding/feedings", models.RecordFeedingRequest{ScheduleID: raptors}, http.StatusCreated)
+	today := time.Now().UTC().Format(models.FeedingDateFormat)
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(models.FeedingDateFormat)
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(models.FeedingDateFormat)
+
+	t.Run("roster", func(t *testing.T) {
+		var roster []models.RosterEntry
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/feeding/roster", nil, http.StatusOK), &roster); err != nil {
+			t.Fatalf("failed to read the roster: %s", err)
+		}
+		if len(roster) != 2 {
+			t.Fatalf("expected 2 feedings on the roster got %d", len(roster))
+		}
+		fed, due := roster[0], roster[1]
+		if fed.ScheduleID != raptors || fed.Quantity != 4 || !reflect.DeepEqual(fed.Dinosaurs, []string{"Blue", "Charlie"}) {
+			t.Errorf("expected the raptors to be fed 4 goats got %+v", fed)
+		}
+		if fed.Feeding == nil || fed.Feeding.FedBy != "muldoon" || fed.Feeding.Date != today {
+			t.Errorf("expected the raptors' feeding to be recorded against muldoon today got %+v", fed.Feeding)
+		}
+		if due.ScheduleID != triceratops || due.Quantity != 25 || due.Unit != "kg" || due.Feeding != nil {
+			t.Errorf("expected the triceratops to be due 25 kg of hay got %+v", due)
+		}
+	})
+
+	cases := []struct {
+		description        string
+		request            models.RecordFeedingRequest
+		expectedStatusCode int
+	}{
+		{
+			description:        "feed a schedule a second time on the same day",
+			request:            models.RecordFeedingRequest{ScheduleID: raptors},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "feed a schedule on an earlier day",
+			request:            models.RecordFeedingRequest{ScheduleID: raptors, Date: yesterday},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "feed a schedule in the future",
+			request:            models.RecordFeedingRequest{ScheduleID: triceratops, Date: tomorrow},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "feed an empty cage",
+			request:            models.RecordFeedingRequest{ScheduleID: empty},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "feed a schedule that does not exist",
+			request:            models.RecordFeedingRequest{ScheduleID: empty + 1000},
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			send("POST", "/jurassicpark/v1/feeding/feedings", c.request, c.expectedStatusCode)
+		})
+	}
+
+	t.Run("feedings", func(t *testing.T) {
+		var feedings []models.Feeding
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/feeding/feedings?date="+yesterday, nil, http.StatusOK), &feedings); err != nil {
+			t.Fatalf("failed to read the feedings: %s", err)
+		}
+		if len(feedings) != 1 || feedings[0].ScheduleID != raptors || feedings[0].Quantity != 4 {
+			t.Errorf("expected yesterday's feeding of the raptors got %+v", feedings)
+		}
+	})
+
+	t.Run("schedules of a deleted cage", func(t *testing.T) {
+		send("DELETE", "/jurassicpark/v1/cages/C-3", nil, http.StatusOK)
+		var schedules []models.FeedingSchedule
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/feeding/schedules?cage=C-3", nil, http.StatusOK), &schedules); err != nil {
+			t.Fatalf("failed to read the schedules: %s", err)
+		}
+		if len(schedules) != 0 {
+			t.Errorf("expected the schedules of the deleted cage to be deleted got %+v", schedules)
+		}
+		send("DELETE", fmt.Sprintf("/jurassicpark/v1/feeding/schedules/%d", empty), nil, http.StatusNotFound)
+	})
+}
+
+func TestFeedStock(t *testing.T) {
+	forEachBackend(t, testFeedStock)
+}
+
+func testFeedStock(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	if err := seedFeedingPark(backend.Park()); err != nil {
+		t.Errorf("error when seeding the park: %s", err)
+		return
+	}
+	broker := events.NewBroker(events.DefaultReplaySize)
+	r := gin.New()
+	backend.NewAPI(r, api.WithEvents(broker))
+
+	send := func(method, path string, body any, expectedStatusCode int) []byte {
+		w := sendWithCredentials(r, method, path, "X-Actor", "muldoon", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	lowStockFeeds := func() []string {
+		var feeds []models.Feed
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/feeding/feeds?lowStock=true", nil, http.StatusOK), &feeds); err != nil {
+			t.Fatalf("failed to read the feeds: %s", err)
+		}
+		names := []string{}
+		for _, feed := range feeds {
+			names = append(names, feed.Name)
+		}
+		return names
+	}
+
+	send("POST", "/jurassicpark/v1/feeding/feeds", models.Feed{Name: "goat", Diet: "Omnivore", Unit: "head"}, http.StatusUnprocessableEntity)
+	send("POST", "/jurassicpark/v1/feeding/feeds", models.Feed{Name: "goat", Diet: "Carnivore", Unit: "head", Stock: -1}, http.StatusUnprocessableEntity)
+	send("POST", "/jurassicpark/v1/feeding/feeds", models.Feed{Name: "goat", Diet: "Carnivore", Unit: "head", Stock: 6, LowStockThreshold: 2}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/feeding/feeds", models.Feed{Name: "goat", Diet: "Carnivore", Unit: "head"}, http.StatusConflict)
+	send("POST", "/jurassicpark/v1/feeding/schedules", models.FeedingSchedule{Cage: "C-1", Feed: "goat", Time: "6am", Quantity: 1}, http.StatusUnprocessableEntity)
+	send("POST", "/jurassicpark/v1/feeding/schedules", models.FeedingSchedule{Cage: "C-9", Feed: "goat", Time: "06:00", Quantity: 1}, http.StatusNotFound)
+	send("POST", "/jurassicpark/v1/feeding/schedules", models.FeedingSchedule{Cage: "C-1", Feed: "boar", Time: "06:00", Quantity: 1}, http.StatusNotFound)
+
+	var schedule models.FeedingSchedule
+	body := send("POST", "/jurassicpark/v1/feeding/schedules", models.FeedingSchedule{Cage: "C-1", Feed: "goat", Time: "06:00", Quantity: 2}, http.StatusCreated)
+	if err := json.Unmarshal(body, &schedule); err != nil {
+		t.Fatalf("failed to read the schedule: %s", err)
+	}
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(models.FeedingDateFormat)
+
+	if names := lowStockFeeds(); len(names) != 0 {
+		t.Errorf("expected no feeds to be low on stock got %v", names)
+	}
+	// the two raptors eat 4 of the 6 goats, which leaves the goats low on stock
+	send("POST", "/jurassicpark/v1/feeding/feedings", models.RecordFeedingRequest{ScheduleID: schedule.ID, Date: yesterday}, http.StatusCreated)
+	if names := lowStockFeeds(); !reflect.DeepEqual(names, []string{"goat"}) {
+		t.Errorf("expected the goats to be low on stock got %v", names)
+	}
+	send("POST", "/jurassicpark/v1/feeding/feedings", models.RecordFeedingRequest{ScheduleID: schedule.ID}, http.StatusConflict)
+
+	send("POST", "/jurassicpark/v1/feeding/feeds/goat/restock", models.RestockRequest{Quantity: 0}, http.StatusUnprocessableEntity)
+	send("POST", "/jurassicpark/v1/feeding/feeds/boar/restock", models.RestockRequest{Quantity: 5}, http.StatusNotFound)
+	var restocked models.Feed
+	if err := json.Unmarshal(send("POST", "/jurassicpark/v1/feeding/feeds/goat/restock", models.RestockRequest{Quantity: 10}, http.StatusOK), &restocked); err != nil {
+		t.Fatalf("failed to read the feed: %s", err)
+	}
+	if restocked.Stock != 12 {
+		t.Errorf("expected 12 goats in stock got %d", restocked.Stock)
+	}
+	send("POST", "/jurassicpark/v1/feeding/feedings", models.RecordFeedingRequest{ScheduleID: schedule.ID}, http.StatusCreated)
+	if names := lowStockFeeds(); len(names) != 0 {
+		t.Errorf("expected no feeds to be low on stock got %v", names)
+	}
+
+	replay, _, unsubscribe := broker.Subscribe(wrapUint64(0))
+	defer unsubscribe()
+	types := []string{}
+	for _, event := range replay {
+		types = append(types, event.Type)
+	}
+	expectedTypes := []string{events.FeedingRecorded, events.FeedLowStock, events.FeedingRecorded}
+	if !reflect.DeepEqual(expectedTypes, types) {
+		t.Errorf("expected the events %v got %v", expectedTypes, types)
+	}
+
+	auditEvents, _, err := backend.Park().GetAuditEvents(models.AuditFilter{Entity: wrapString("feed:goat")})
+	if err != nil {
+		t.Errorf("error when reading the audit log: %s", err)
+		return
+	}
+	if len(auditEvents) != 4 {
+		t.Errorf("expected the goats to be created, fed twice and restocked got %d audit events", len(auditEvents))
+	}
+}
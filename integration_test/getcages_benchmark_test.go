@@ -0,0 +1,173 @@
+package integration_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/data"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/go-sql-driver/mysql"
+)
+
+// The GetCages benchmarks compare the original implementation, which counted the dinosaurs in each cage with a
+// separate query, against the single aggregated query. Each benchmark reports the number of queries sent to
+// MySQL per call alongside the latency. They need the test database started by scripts/run-tests-db.sh:
+//
+//	go test -run XXX -bench GetCages ./integration_test/
+const benchmarkCageCount = 2000
+
+func BenchmarkGetCagesPerCageOccupancy(b *testing.B) {
+	db, queries := openCountingDB(b)
+	benchmarkGetCages(b, queries, func() (int, error) {
+		cages, err := getCagesWithPerCageOccupancy(db)
+		return len(cages), err
+	})
+}
+
+func BenchmarkGetCagesAggregatedOccupancy(b *testing.B) {
+	db, queries := openCountingDB(b)
+	dao := data.NewParkSqlDaoFromDB(db)
+	benchmarkGetCages(b, queries, func() (int, error) {
+		cages, _, err := dao.GetCages(models.CageFilter{})
+		return len(cages), err
+	})
+}
+
+func benchmarkGetCages(b *testing.B, queries *atomic.Int64, getCages func() (int, error)) {
+	seedBenchmarkCages(b)
+
+	queries.Store(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cageCount, err := getCages()
+		if err != nil {
+			b.Fatalf("error when getting cages: %s", err)
+		}
+		if cageCount != benchmarkCageCount {
+			b.Fatalf("expected %d cages got %d", benchmarkCageCount, cageCount)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(queries.Load())/float64(b.N), "queries/op")
+}
+
+// getCagesWithPerCageOccupancy is the original GetCages implementation: one query for the cages, then one query
+// per cage for its occupancy.
+func getCagesWithPerCageOccupancy(db *sql.DB) ([]models.Cage, error) {
+	rows, err := db.Query(`SELECT id, externalId, capacity, powerStatus FROM cage ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cages := []models.Cage{}
+	for rows.Next() {
+		var id int
+		cage := models.Cage{}
+		if err := rows.Scan(&id, &cage.Label, &cage.MaxOccupancy, &cage.PowerStatus); err != nil {
+			return nil, err
+		}
+		err = db.QueryRow(`SELECT COUNT(*) FROM dinosaur where cageId=?`, id).Scan(&cage.Occupancy)
+		if err != nil {
+			return nil, err
+		}
+		cages = append(cages, cage)
+	}
+	return cages, rows.Err()
+}
+
+var seedBenchmarkCagesOnce sync.Once
+
+func seedBenchmarkCages(b *testing.B) {
+	b.Helper()
+	var seedErr error
+	seedBenchmarkCagesOnce.Do(func() {
+		backend := &sqlBackend{}
+		if available, reason := backend.Available(); !available {
+			seedErr = fmt.Errorf("mysql backend is unavailable: %s", reason)
+			return
+		}
+		if seedErr = backend.Reset(); seedErr != nil {
+			return
+		}
+		dao := backend.Park()
+		for i := 0; i < benchmarkCageCount; i++ {
+			label := fmt.Sprintf("Bench-%d", i)
+			if seedErr = dao.AddCage(context.Background(), models.Cage{Label: label, MaxOccupancy: 4, HasPower: true}); seedErr != nil {
+				return
+			}
+			// put a dinosaur in every tenth cage so the occupancy counts aren't all zero
+			if i%10 == 0 {
+				name := fmt.Sprintf("Bencher-%d", i)
+				if seedErr = dao.AddDinosaur(context.Background(), models.Dinosaur{Name: name, Species: "Triceratops"}); seedErr != nil {
+					return
+				}
+				if seedErr = dao.AddDinosaurToCage(context.Background(), name, label); seedErr != nil {
+					return
+				}
+			}
+		}
+	})
+	if seedErr != nil {
+		b.Skip(seedErr)
+	}
+}
+
+var registerCountingDriverOnce sync.Once
+
+// benchmarkQueries counts every statement sent through the mysql-counting driver.
+var benchmarkQueries atomic.Int64
+
+// openCountingDB opens the test database through a driver that counts the statements it sends to MySQL.
+// Parameters are interpolated client side, so every query is a single round trip.
+func openCountingDB(b *testing.B) (*sql.DB, *atomic.Int64) {
+	registerCountingDriverOnce.Do(func() {
+		sql.Register("mysql-counting", &countingDriver{driver: &mysql.MySQLDriver{}, queries: &benchmarkQueries})
+	})
+	db, err := sql.Open("mysql-counting", config.ConnectionString()+"&interpolateParams=true")
+	if err != nil {
+		b.Skip(err)
+	}
+	if err := db.Ping(); err != nil {
+		b.Skipf("mysql backend is unavailable: %s", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return db, &benchmarkQueries
+}
+
+type countingDriver struct {
+	driver  driver.Driver
+	queries *atomic.Int64
+}
+
+func (d *countingDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, queries: d.queries}, nil
+}
+
+type countingConn struct {
+	driver.Conn
+	queries *atomic.Int64
+}
+
+func (c *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.queries.Add(1)
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.queries.Add(1)
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *countingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
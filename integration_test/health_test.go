@@ -0,0 +1,318 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func wrapFloat(value float64) *float64 {
+	return &value
+}
+
+func TestHealthRecords(t *testing.T) {
+	forEachBackend(t, testHealthRecords)
+}
+
+func testHealthRecords(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	err = backend.Park().AddDinosaur(context.Background(), models.Dinosaur{Name: "Blue", Species: "Velociraptor", Sex: models.SexFemale})
+	if err != nil {
+		t.Errorf("error when adding the dinosaur: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) []byte {
+		w := sendWithCredentials(r, method, path, "X-Actor", "harding", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	examined := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	var weight models.HealthRecord
+	body := send("POST", "/jurassicpark/v1/dinosaurs/Blue/health-records", models.HealthRecord{Type: models.HealthRecordWeight, WeightKg: wrapFloat(142.5)}, http.StatusCreated)
+	if err := json.Unmarshal(body, &weight); err != nil {
+		t.Fatalf("failed to read the health record: %s", err)
+	}
+	if weight.Dinosaur != "Blue" || weight.RecordedBy != "harding" || weight.Time.IsZero() {
+		t.Errorf("expected the weight to be recorded for Blue by harding now got %+v", weight)
+	}
+	var examination models.HealthRecord
+	body = send("POST", "/jurassicpark/v1/dinosaurs/Blue/health-records", models.HealthRecord{Type: models.HealthRecordExamination, Time: examined, Notes: "healthy"}, http.StatusCreated)
+	if err := json.Unmarshal(body, &examination); err != nil {
+		t.Fatalf("failed to read the health record: %s", err)
+	}
+
+	cases := []struct {
+		description        string
+		method             string
+		path               string
+		body               *models.HealthRecord
+		expectedStatusCode int
+	}{
+		{
+			description:        "add a record of an unknown type",
+			method:             "POST",
+			path:               "/jurassicpark/v1/dinosaurs/Blue/health-records",
+			body:               &models.HealthRecord{Type: "surgery"},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "add a weight without a weight",
+			method:             "POST",
+			path:               "/jurassicpark/v1/dinosaurs/Blue/health-records",
+			body:               &models.HealthRecord{Type: models.HealthRecordWeight},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "add a medication without a medication",
+			method:             "POST",
+			path:               "/jurassicpark/v1/dinosaurs/Blue/health-records",
+			body:               &models.HealthRecord{Type: models.HealthRecordMedication, Dosage: "10ml"},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "add a record in the future",
+			method:             "POST",
+			path:               "/jurassicpark/v1/dinosaurs/Blue/health-records",
+			body:               &models.HealthRecord{Type: models.HealthRecordExamination, Time: time.Now().Add(time.Hour)},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "add a record for a dinosaur that does not exist",
+			method:             "POST",
+			path:               "/jurassicpark/v1/dinosaurs/Rexy/health-records",
+			body:               &models.HealthRecord{Type: models.HealthRecordExamination},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "add a medication",
+			method:             "POST",
+			path:               "/jurassicpark/v1/dinosaurs/Blue/health-records",
+			body:               &models.HealthRecord{Type: models.HealthRecordMedication, Medication: "Amoxicillin", Dosage: "10ml"},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "get the records of a dinosaur that does not exist",
+			method:             "GET",
+			path:               "/jurassicpark/v1/dinosaurs/Rexy/health-records",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "get a record of another dinosaur",
+			method:             "GET",
+			path:               fmt.Sprintf("/jurassicpark/v1/dinosaurs/Rexy/health-records/%d", weight.ID),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "update the weight",
+			method:             "PUT",
+			path:               fmt.Sprintf("/jurassicpark/v1/dinosaurs/Blue/health-records/%d", weight.ID),
+			body:               &models.HealthRecord{Type: models.HealthRecordWeight, Time: examined, WeightKg: wrapFloat(145)},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "update a record that does not exist",
+			method:             "PUT",
+			path:               "/jurassicpark/v1/dinosaurs/Blue/health-records/abc",
+			body:               &models.HealthRecord{Type: models.HealthRecordExamination},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "delete the examination",
+			method:             "DELETE",
+			path:               fmt.Sprintf("/jurassicpark/v1/dinosaurs/Blue/health-records/%d", examination.ID),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "delete the examination a second time",
+			method:             "DELETE",
+			path:               fmt.Sprintf("/jurassicpark/v1/dinosaurs/Blue/health-records/%d", examination.ID),
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			var body any
+			if c.body != nil {
+				body = c.body
+			}
+			send(c.method, c.path, body, c.expectedStatusCode)
+		})
+	}
+
+	t.Run("medical history", func(t *testing.T) {
+		var records []models.HealthRecord
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/dinosaurs/Blue/health-records", nil, http.StatusOK), &records); err != nil {
+			t.Fatalf("failed to read the health records: %s", err)
+		}
+		types := []models.HealthRecordType{}
+		for _, record := range records {
+			types = append(types, record.Type)
+		}
+		// the weight was moved back to the time of the examination, so it comes first
+		expectedTypes := []models.HealthRecordType{models.HealthRecordWeight, models.HealthRecordMedication}
+		if !reflect.DeepEqual(expectedTypes, types) {
+			t.Fatalf("expected the records %v got %v", expectedTypes, types)
+		}
+		if *records[0].WeightKg != 145 || !records[0].Time.Equal(examined) || records[0].RecordedBy != "harding" {
+			t.Errorf("expected the updated weight got %+v", records[0])
+		}
+		if records[1].Medication != "Amoxicillin" || records[1].Dosage != "10ml" {
+			t.Errorf("expected the medication got %+v", records[1])
+		}
+
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/dinosaurs/Blue/health-records?type=weight", nil, http.StatusOK), &records); err != nil {
+			t.Fatalf("failed to read the health records: %s", err)
+		}
+		if len(records) != 1 || records[0].ID != weight.ID {
+			t.Errorf("expected only the weight got %+v", records)
+		}
+	})
+
+	t.Run("audit log", func(t *testing.T) {
+		events, _, err := backend.Park().GetAuditEvents(models.AuditFilter{Entity: wrapString(models.AuditEntity(models.AuditEntityHealth, fmt.Sprint(weight.ID)))})
+		if err != nil {
+			t.Fatalf("error when reading the audit log: %s", err)
+		}
+		actions := []models.AuditAction{}
+		for _, event := range events {
+			actions = append(actions, event.Action)
+		}
+		if !reflect.DeepEqual([]models.AuditAction{models.AuditCreate, models.AuditUpdate}, actions) {
+			t.Errorf("expected the weight to be created and updated got %v", actions)
+		}
+	})
+}
+
+func TestQuarantine(t *testing.T) {
+	forEachBackend(t, testQuarantine)
+}
+
+func testQuarantine(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	ctx := context.Background()
+	dao := backend.Park()
+	for _, cage := range []models.Cage{
+		{Label: "C-1", MaxOccupancy: 4, HasPower: true},
+		{Label: "C-2", MaxOccupancy: 4, HasPower: true},
+		{Label: "C-3", MaxOccupancy: 4, HasPower: true},
+	} {
+		if err := dao.AddCage(ctx, cage); err != nil {
+			t.Errorf("error when adding the cage: %s", err)
+			return
+		}
+	}
+	for _, name := range []string{"Blue", "Charlie", "Delta"} {
+		if err := dao.AddDinosaur(ctx, models.Dinosaur{Name: name, Species: "Velociraptor", Sex: models.SexFemale}); err != nil {
+			t.Errorf("error when adding the dinosaur: %s", err)
+			return
+		}
+	}
+	if err := dao.AddDinosaurToCage(ctx, "Delta", "C-1"); err != nil {
+		t.Errorf("error when adding the dinosaur to the cage: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) []byte {
+		w := sendWithCredentials(r, method, path, "X-Actor", "harding", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	quarantined := func() []string {
+		names, err := readNames(sendWithCredentials(r, "GET", "/jurassicpark/v1/dinosaurs?quarantined=true", "X-Actor", "harding", nil))
+		if err != nil {
+			t.Fatalf("failed to read the dinosaurs: %s", err)
+		}
+		return names
+	}
+	canHouse := func(name string) []string {
+		var cages []models.Cage
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/cages?canHouse="+name, nil, http.StatusOK), &cages); err != nil {
+			t.Fatalf("failed to read the cages: %s", err)
+		}
+		labels := []string{}
+		for _, cage := range cages {
+			labels = append(labels, cage.Label)
+		}
+		return labels
+	}
+
+	send("POST", "/jurassicpark/v1/dinosaurs/Blue/health-records", models.HealthRecord{Type: models.HealthRecordQuarantine, Notes: "coughing"}, http.StatusCreated)
+	if names := quarantined(); !reflect.DeepEqual(names, []string{"Blue"}) {
+		t.Errorf("expected Blue to be quarantined got %v", names)
+	}
+	if labels := canHouse("Blue"); !reflect.DeepEqual(labels, []string{"C-2", "C-3"}) {
+		t.Errorf("expected Blue to only fit in the empty cages got %v", labels)
+	}
+	send("POST", "/jurassicpark/v1/cages/C-1/dinosaurs", models.AddDinosaurToCageRequest{Name: "Blue"}, http.StatusConflict)
+	send("POST", "/jurassicpark/v1/cages/C-2/dinosaurs", models.AddDinosaurToCageRequest{Name: "Blue"}, http.StatusCreated)
+	if labels := canHouse("Charlie"); !reflect.DeepEqual(labels, []string{"C-1", "C-3"}) {
+		t.Errorf("expected Charlie to fit in every cage but Blue's got %v", labels)
+	}
+	send("POST", "/jurassicpark/v1/cages/C-2/dinosaurs", models.AddDinosaurToCageRequest{Name: "Charlie"}, http.StatusConflict)
+	send("POST", "/jurassicpark/v1/cages/C-3/dinosaurs", models.AddDinosaurToCageRequest{Name: "Charlie"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/dinosaurs/Charlie/transfer", models.TransferDinosaurRequest{Cage: "C-2"}, http.StatusConflict)
+
+	var release models.HealthRecord
+	body := send("POST", "/jurassicpark/v1/dinosaurs/Blue/health-records", models.HealthRecord{Type: models.HealthRecordRelease}, http.StatusCreated)
+	if err := json.Unmarshal(body, &release); err != nil {
+		t.Fatalf("failed to read the health record: %s", err)
+	}
+	if names := quarantined(); len(names) != 0 {
+		t.Errorf("expected no dinosaurs to be quarantined got %v", names)
+	}
+	// taking the release back puts Blue back in quarantine
+	send("DELETE", fmt.Sprintf("/jurassicpark/v1/dinosaurs/Blue/health-records/%d", release.ID), nil, http.StatusOK)
+	var blue models.Dinosaur
+	if err := json.Unmarshal(send("GET", "/jurassicpark/v1/dinosaurs/Blue", nil, http.StatusOK), &blue); err != nil {
+		t.Fatalf("failed to read the dinosaur: %s", err)
+	}
+	if !blue.Quarantined {
+		t.Errorf("expected Blue to be quarantined again")
+	}
+	send("POST", "/jurassicpark/v1/dinosaurs/Blue/health-records", models.HealthRecord{Type: models.HealthRecordRelease}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/dinosaurs/Charlie/transfer", models.TransferDinosaurRequest{Cage: "C-2"}, http.StatusOK)
+
+	events, _, err := dao.GetAuditEvents(models.AuditFilter{Entity: wrapString("dinosaur:Blue")})
+	if err != nil {
+		t.Errorf("error when reading the audit log: %s", err)
+		return
+	}
+	changes := []string{}
+	for _, event := range events {
+		if event.Action != models.AuditUpdate {
+			continue
+		}
+		var after models.Dinosaur
+		if err := json.Unmarshal(event.After, &after); err != nil {
+			t.Fatalf("failed to read the audit event: %s", err)
+		}
+		changes = append(changes, fmt.Sprint(after.Quarantined))
+	}
+	if !reflect.DeepEqual([]string{"true", "false", "true", "false"}, changes) {
+		t.Errorf("expected Blue to be quarantined and released twice got %v", changes)
+	}
+}
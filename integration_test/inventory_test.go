@@ -0,0 +1,292 @@
+package integration_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+// sendFile sends a file as the body of a request, with the content type of the file.
+func sendFile(r *gin.Engine, method, path, contentType, file string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(file))
+	req.Header.Set("X-Actor", "muldoon")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+const islandCSV = `kind,label,maxOccupancy,powerStatus,zone,name,species,sex,dinosaur,cage
+cage,C-1,2,,,,,,,
+cage,C-2,1,DOWN,,,,,,
+dinosaur,,,,,Blue,Velociraptor,,,
+dinosaur,,,,,Rexy,Tyrannosaurus,Female,,
+assignment,,,,,,,,Blue,C-1
+assignment,,,,,,,,Rexy,C-2
+`
+
+func TestImportInventory(t *testing.T) {
+	forEachBackend(t, testImportInventory)
+}
+
+func testImportInventory(t *testing.T, backend parkBackend) {
+	cases := []struct {
+		description       string
+		path              string
+		contentType       string
+		file              string
+		expectedCommitted bool
+		expectedStatuses  []models.ImportStatus
+		expectedCodes     []models.ErrorCode
+		expectedCages     []string
+	}{
+		{
+			description:       "an atomic import with a failing row keeps nothing",
+			path:              "/jurassicpark/v1/import",
+			contentType:       "text/csv",
+			file:              islandCSV,
+			expectedCommitted: false,
+			expectedStatuses:  []models.ImportStatus{"applied", "applied", "applied", "applied", "applied", "failed"},
+			expectedCodes:     []models.ErrorCode{"", "", "", "", "", models.CodeIncompatibleCagePowerState},
+			expectedCages:     []string{},
+		},
+		{
+			description:       "a per-row import keeps the rows that succeed",
+			path:              "/jurassicpark/v1/import?mode=per-row",
+			contentType:       "text/csv",
+			file:              islandCSV,
+			expectedCommitted: true,
+			expectedStatuses:  []models.ImportStatus{"applied", "applied", "applied", "applied", "applied", "failed"},
+			expectedCodes:     []models.ErrorCode{"", "", "", "", "", models.CodeIncompatibleCagePowerState},
+			expectedCages:     []string{"C-1", "C-2"},
+		},
+		{
+			description:       "a dry run keeps nothing",
+			path:              "/jurassicpark/v2/import?format=ndjson&dryRun=true",
+			file:              "{\"kind\": \"cage\", \"label\": \"C-1\", \"maxOccupancy\": 1}\n\n{\"kind\": \"dinosaur\", \"name\": \"Blue\", \"species\": \"Velociraptor\"}\n",
+			expectedCommitted: false,
+			expectedStatuses:  []models.ImportStatus{"applied", "applied"},
+			expectedCodes:     []models.ErrorCode{"", ""},
+			expectedCages:     []string{},
+		},
+		{
+			description:       "rows are held to the same rules as the endpoints that add them",
+			path:              "/jurassicpark/v1/import?mode=per-row",
+			contentType:       "application/x-ndjson",
+			file:              "{\"kind\": \"cage\", \"label\": \"C-1\", \"maxOccupancy\": 1}\n{\"kind\": \"cage\", \"label\": \"C-1\", \"maxOccupancy\": 1}\n{\"kind\": \"cage\", \"maxOccupancy\": \"one\"}\n{\"kind\": \"dinosaur\", \"name\": \"Blue\", \"species\": \"Dodo\"}\n{\"kind\": \"fence\"}\n{\"kind\": \"assignment\", \"dinosaur\": \"Blue\", \"cage\": \"C-1\"}\n",
+			expectedCommitted: true,
+			expectedStatuses:  []models.ImportStatus{"applied", "failed", "failed", "failed", "failed", "failed"},
+			expectedCodes: []models.ErrorCode{"", models.CodeAlreadyExists, models.CodeValidationFailed, models.CodeInvalidDinosaurSpecies,
+				models.CodeValidationFailed, models.CodeNotFound},
+			expectedCages: []string{"C-1"},
+		},
+		{
+			description:       "zones are added before the zones and cages in them",
+			path:              "/jurassicpark/v1/import?mode=per-row",
+			contentType:       "text/csv",
+			file:              "kind,name,parent,label,maxOccupancy,zone\nzone,Paddock 9,Sector 4,,,\nzone,Sector 4,,,,\nzone,Paddock 9,Sector 4,,,\nzone,Sector 4,,,,\nzone,,,,,\ncage,,,C-1,2,Paddock 9\n",
+			expectedCommitted: true,
+			expectedStatuses:  []models.ImportStatus{"failed", "applied", "applied", "failed", "failed", "applied"},
+			expectedCodes:     []models.ErrorCode{models.CodeInvalidZoneParent, "", "", models.CodeAlreadyExists, models.CodeValidationFailed, ""},
+			expectedCages:     []string{"C-1"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := backend.Reset()
+			if err != nil {
+				t.Fatalf("error when clearing out test database: %s", err)
+			}
+			r := gin.New()
+			backend.NewAPI(r)
+
+			w := sendFile(r, "POST", c.path, c.contentType, c.file)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status code %d got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var report models.ImportReport
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("unexpected error decoding the report: %s", err)
+			}
+			if report.Committed != c.expectedCommitted {
+				t.Errorf("expected committed to be %t got %t", c.expectedCommitted, report.Committed)
+			}
+			statuses := []models.ImportStatus{}
+			codes := []models.ErrorCode{}
+			for _, row := range report.Rows {
+				statuses = append(statuses, row.Status)
+				codes = append(codes, row.Code)
+				if row.Status == models.ImportStatusFailed && row.Detail == "" {
+					t.Errorf("expected the failed row on line %d to have a detail", row.Line)
+				}
+			}
+			if !reflect.DeepEqual(statuses, c.expectedStatuses) {
+				t.Errorf("expected statuses %v got %v", c.expectedStatuses, statuses)
+			}
+			if !reflect.DeepEqual(codes, c.expectedCodes) {
+				t.Errorf("expected codes %v got %v", c.expectedCodes, codes)
+			}
+
+			cages, _, err := backend.Park().GetCages(models.CageFilter{})
+			if err != nil {
+				t.Fatalf("unexpected error getting the cages: %s", err)
+			}
+			labels := []string{}
+			for _, cage := range cages {
+				labels = append(labels, cage.Label)
+			}
+			if !reflect.DeepEqual(labels, c.expectedCages) {
+				t.Errorf("expected cages %v got %v", c.expectedCages, labels)
+			}
+		})
+	}
+
+	t.Run("the report gives the line of each row", func(t *testing.T) {
+		backend.Reset()
+		r := gin.New()
+		backend.NewAPI(r)
+		w := sendFile(r, "POST", "/jurassicpark/v1/import?mode=per-row", "text/csv", "label,kind,maxOccupancy\nC-1,cage,ten\n")
+		var report models.ImportReport
+		json.Unmarshal(w.Body.Bytes(), &report)
+		if len(report.Rows) != 1 || report.Rows[0].Line != 2 {
+			t.Fatalf("expected one row on line 2, got %v", report.Rows)
+		}
+		expectedFields := []models.FieldError{{Field: "maxOccupancy", Reason: "must be a whole number"}}
+		if !reflect.DeepEqual(report.Rows[0].Fields, expectedFields) {
+			t.Errorf("expected fields %v got %v", expectedFields, report.Rows[0].Fields)
+		}
+	})
+
+	t.Run("a file that can't be read is refused", func(t *testing.T) {
+		backend.Reset()
+		r := gin.New()
+		backend.NewAPI(r)
+		cases := []struct {
+			path         string
+			contentType  string
+			file         string
+			expectedCode models.ErrorCode
+		}{
+			{"/jurassicpark/v1/import", "text/csv", "kind,color\ncage,blue\n", models.CodeInvalidImportFile},
+			{"/jurassicpark/v1/import", "text/csv", "label\nC-1\n", models.CodeInvalidImportFile},
+			{"/jurassicpark/v1/import", "text/csv", "kind,label\ncage,C-1,extra\n", models.CodeInvalidImportFile},
+			{"/jurassicpark/v1/import", "application/x-ndjson", "{\"kind\": \"cage\"}\nnot json\n", models.CodeInvalidImportFile},
+			{"/jurassicpark/v1/import", "application/json", "{}", models.CodeInvalidParameter},
+			{"/jurassicpark/v1/import?format=csv&mode=some", "", "kind\n", models.CodeInvalidParameter},
+		}
+		for _, c := range cases {
+			w := sendFile(r, "POST", c.path, c.contentType, c.file)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("expected %q to be refused with %d got %d: %s", c.file, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+				continue
+			}
+			var problem models.ErrorResponse
+			json.Unmarshal(w.Body.Bytes(), &problem)
+			if problem.Code != c.expectedCode {
+				t.Errorf("expected %q to be refused with code %s got %s", c.file, c.expectedCode, problem.Code)
+			}
+		}
+		cages, _, _ := backend.Park().GetCages(models.CageFilter{})
+		if len(cages) != 0 {
+			t.Errorf("expected the refused files to add no cages, got %v", cages)
+		}
+	})
+}
+
+func TestExportInventory(t *testing.T) {
+	forEachBackend(t, testExportInventory)
+}
+
+func testExportInventory(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) {
+		w := sendWithCredentials(r, method, path, "X-Actor", "muldoon", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+	}
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Sector 4", Description: "the raptor sector"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Aviary"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Paddock 9", Parent: wrapString("Sector 4")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Raptor Pen", Parent: wrapString("Paddock 9")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v2/cages", models.CageV2{Label: "C-1", MaxOccupancy: 2, PowerStatus: models.PowerStatusActive, Zone: wrapString("Raptor Pen")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v2/cages", models.CageV2{Label: "C-2", MaxOccupancy: 1, PowerStatus: models.PowerStatusDown}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/dinosaurs", models.Dinosaur{Name: "Blue", Species: "Velociraptor"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/dinosaurs", models.Dinosaur{Name: "Delta", Species: "Velociraptor", Sex: models.SexMale}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages/C-1/dinosaurs", models.AddDinosaurToCageRequest{Name: "Blue"}, http.StatusCreated)
+
+	w := sendWithCredentials(r, "GET", "/jurassicpark/v1/export?format=ndjson", "X-Actor", "muldoon", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("expected content type application/x-ndjson got %s", w.Header().Get("Content-Type"))
+	}
+	rows := []models.InventoryRow{}
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var row models.InventoryRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("unexpected error decoding %s: %s", scanner.Text(), err)
+		}
+		rows = append(rows, row)
+	}
+	// zones come before the zones nested in them, and before the cages in them
+	expectedRows := []models.InventoryRow{
+		{Kind: models.InventoryKindZone, Name: "Aviary"},
+		{Kind: models.InventoryKindZone, Name: "Sector 4", Description: "the raptor sector"},
+		{Kind: models.InventoryKindZone, Name: "Paddock 9", Parent: wrapString("Sector 4")},
+		{Kind: models.InventoryKindZone, Name: "Raptor Pen", Parent: wrapString("Paddock 9")},
+		{Kind: models.InventoryKindCage, Label: "C-1", MaxOccupancy: 2, PowerStatus: models.PowerStatusActive, Zone: wrapString("Raptor Pen")},
+		{Kind: models.InventoryKindCage, Label: "C-2", MaxOccupancy: 1, PowerStatus: models.PowerStatusDown},
+		{Kind: models.InventoryKindDinosaur, Name: "Blue", Species: "Velociraptor", Sex: models.SexFemale},
+		{Kind: models.InventoryKindDinosaur, Name: "Delta", Species: "Velociraptor", Sex: models.SexMale},
+		{Kind: models.InventoryKindAssignment, Dinosaur: "Blue", Cage: "C-1"},
+	}
+	if !reflect.DeepEqual(rows, expectedRows) {
+		t.Errorf("expected rows %v got %v", expectedRows, rows)
+	}
+
+	w = sendWithCredentials(r, "GET", "/jurassicpark/v1/export?format=csv", "X-Actor", "muldoon", nil)
+	exported := w.Body.String()
+	if !strings.HasPrefix(exported, "kind,label,maxOccupancy,powerStatus,zone,name,species,sex,dinosaur,cage,parent,description\nzone,,,,,Aviary,,,,,,\nzone,,,,,Sector 4,,,,,,the raptor sector\n") {
+		t.Errorf("expected the csv export to start with its header and the Aviary, got %s", exported)
+	}
+
+	// the export can be imported into an empty park, zones and all
+	backend.Reset()
+	r = gin.New()
+	backend.NewAPI(r)
+	w = sendFile(r, "POST", "/jurassicpark/v1/import", "text/csv", exported)
+	var report models.ImportReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if !report.Committed || report.Applied != len(expectedRows) {
+		t.Fatalf("expected the export to be imported, got %s", w.Body.String())
+	}
+	w = sendWithCredentials(r, "GET", "/jurassicpark/v1/export?format=csv", "X-Actor", "muldoon", nil)
+	if w.Body.String() != exported {
+		t.Errorf("expected the imported park to export the same file, got %s", w.Body.String())
+	}
+
+	w = sendWithCredentials(r, "GET", "/jurassicpark/v1/export", "X-Actor", "muldoon", nil)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected an export without a format to be refused with %d got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
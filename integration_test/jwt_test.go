@@ -0,0 +1,285 @@
+package integration_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/api"
+	"github.com/EdgarH78/jurassic-park/auth"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://sso.jurassicpark.example.com"
+	testAudience = "jurassic-park-api"
+)
+
+// jwksFor builds a JSON Web Key Set of public keys by their key id.
+func jwksFor(t *testing.T, keys map[string]crypto.PublicKey) []byte {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks := []map[string]string{}
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			jwks = append(jwks, map[string]string{
+				"kty": "EC", "kid": kid, "use": "sig", "crv": key.Curve.Params().Name,
+				"x": encode(key.X.FillBytes(make([]byte, size))), "y": encode(key.Y.FillBytes(make([]byte, size))),
+			})
+		default:
+			t.Fatalf("unsupported key type %T", key)
+		}
+	}
+	data, _ := json.Marshal(map[string]any{"keys": jwks})
+	return data
+}
+
+// mintToken signs a token with the claims, using the key id kid when it isn't empty.
+func mintToken(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("error when signing the token: %s", err)
+	}
+	return signed
+}
+
+// validClaims are the claims of a token that the test API accepts, with the roles claim set to roles.
+func validClaims(subject string, roles ...string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   subject,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}
+}
+
+func withClaim(claims jwt.MapClaims, name string, value any) jwt.MapClaims {
+	changed := jwt.MapClaims{}
+	for k, v := range claims {
+		changed[k] = v
+	}
+	if value == nil {
+		delete(changed, name)
+	} else {
+		changed[name] = value
+	}
+	return changed
+}
+
+func sendWithBearerToken(r *gin.Engine, method, path, token string, body any) *httptest.ResponseRecorder {
+	if token == "" {
+		return sendWithCredentials(r, method, path, "Authorization", "", body)
+	}
+	return sendWithCredentials(r, method, path, "Authorization", "Bearer "+token, body)
+}
+
+func TestBearerTokenAuthentication(t *testing.T) {
+	forEachBackend(t, testBearerTokenAuthentication)
+}
+
+func testBearerTokenAuthentication(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys, err := auth.ParseJWKS(jwksFor(t, map[string]crypto.PublicKey{"rsa-1": &rsaKey.PublicKey, "ec-1": &ecKey.PublicKey}))
+	if err != nil {
+		t.Errorf("error when parsing the JWKS: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r, api.WithAuthenticators(
+		auth.NewAPIKeyAuthenticator(backend.Park()),
+		auth.NewJWTAuthenticator(keys, auth.JWTConfig{Issuer: testIssuer, Audience: testAudience}),
+	))
+	publicKeyBytes, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	valid := validClaims("grant", "viewer")
+
+	cases := []struct {
+		description        string
+		token              string
+		expectedStatusCode int
+	}{
+		{"RSA token", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", valid), http.StatusOK},
+		{"EC token", mintToken(t, jwt.SigningMethodES256, ecKey, "ec-1", valid), http.StatusOK},
+		{"RSA-PSS token", mintToken(t, jwt.SigningMethodPS256, rsaKey, "rsa-1", valid), http.StatusOK},
+		{"no token", "", http.StatusUnauthorized},
+		{"malformed token", "not.a.token", http.StatusUnauthorized},
+		{"expired", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "exp", time.Now().Add(-time.Hour).Unix())), http.StatusUnauthorized},
+		{"without expiry", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "exp", nil)), http.StatusUnauthorized},
+		{"not valid yet", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "nbf", time.Now().Add(time.Hour).Unix())), http.StatusUnauthorized},
+		{"other issuer", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "iss", "https://biosyn.example.com")), http.StatusUnauthorized},
+		{"other audience", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "aud", []string{"gift-shop"})), http.StatusUnauthorized},
+		{"one of several audiences", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "aud", []string{"gift-shop", testAudience})), http.StatusOK},
+		{"without subject", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "sub", nil)), http.StatusUnauthorized},
+		{"unknown key id", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-2", valid), http.StatusUnauthorized},
+		{"without key id", mintToken(t, jwt.SigningMethodRS256, rsaKey, "", valid), http.StatusUnauthorized},
+		{"signed by another key", mintToken(t, jwt.SigningMethodRS256, otherKey, "rsa-1", valid), http.StatusUnauthorized},
+		{"key of the wrong type", mintToken(t, jwt.SigningMethodRS256, rsaKey, "ec-1", valid), http.StatusUnauthorized},
+		{"HMAC with the public key", mintToken(t, jwt.SigningMethodHS256, publicKeyBytes, "rsa-1", valid), http.StatusUnauthorized},
+		{"unsigned", mintToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "rsa-1", valid), http.StatusUnauthorized},
+		{"without roles", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "roles", nil)), http.StatusForbidden},
+		{"unknown roles", mintToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", withClaim(valid, "roles", []string{"dentist"})), http.StatusForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := sendWithBearerToken(r, "GET", "/jurassicpark/v1/cages", c.token, nil)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d: %s", c.expectedStatusCode, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	apiKey := issueAPIKey(t, backend.Park(), "visitor-center", models.RoleViewer)
+	w := sendWithAPIKey(r, "GET", "/jurassicpark/v1/cages", apiKey, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected API keys to be accepted alongside bearer tokens got status code %d", w.Code)
+	}
+}
+
+func TestBearerTokenRoles(t *testing.T) {
+	forEachBackend(t, testBearerTokenRoles)
+}
+
+func testBearerTokenRoles(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	keys, err := auth.ParseJWKS(jwksFor(t, map[string]crypto.PublicKey{"ec-1": &key.PublicKey}))
+	if err != nil {
+		t.Errorf("error when parsing the JWKS: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r, api.WithAuthenticators(auth.NewJWTAuthenticator(keys, auth.JWTConfig{
+		Issuer:     testIssuer,
+		Audience:   testAudience,
+		RolesClaim: "realm_access.roles",
+		RoleMapping: map[string][]models.Role{
+			"control-room": {models.RoleKeeper, models.RolePowerOperator},
+			"visitors":     {models.RoleViewer},
+		},
+	})))
+	err = backend.Park().AddCage(context.Background(), models.Cage{Label: "C-1", MaxOccupancy: 2, HasPower: true})
+	if err != nil {
+		t.Errorf("error when adding the cage: %s", err)
+		return
+	}
+	tokenFor := func(subject string, groups ...string) string {
+		claims := withClaim(validClaims(subject), "roles", nil)
+		claims["realm_access"] = map[string]any{"roles": groups}
+		return mintToken(t, jwt.SigningMethodES384, key, "ec-1", claims)
+	}
+
+	maintenance := models.PowerStatusMaintenance
+	cases := []struct {
+		description        string
+		token              string
+		expectedStatusCode int
+	}{
+		{"visitor can't change power", tokenFor("gennaro", "visitors"), http.StatusForbidden},
+		{"park role names aren't granted when there is a mapping", tokenFor("nedry", "admin"), http.StatusForbidden},
+		{"control room changes power", tokenFor("arnold", "visitors", "control-room"), http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := sendWithBearerToken(r, "PATCH", "/jurassicpark/v2/cages/C-1", c.token, models.UpdateCageV2Request{PowerStatus: &maintenance})
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d: %s", c.expectedStatusCode, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	entity := models.AuditEntity(models.AuditEntityCage, "C-1")
+	auditEvents, _, err := backend.Park().GetAuditEvents(models.AuditFilter{Entity: &entity})
+	if err != nil {
+		t.Errorf("error when reading the audit log: %s", err)
+		return
+	}
+	if len(auditEvents) != 2 || auditEvents[1].Actor != "arnold" {
+		t.Errorf("expected the power change to be recorded against the token's subject got %+v", auditEvents)
+	}
+}
+
+func TestLoadJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := jwksFor(t, map[string]crypto.PublicKey{"rsa-1": &rsaKey.PublicKey})
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatalf("error when writing the JWKS: %s", err)
+	}
+	if _, err := auth.LoadJWKSFile(path); err != nil {
+		t.Errorf("expected the JWKS file to load got %s", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/jwks.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(jwks)
+	}))
+	defer server.Close()
+	if _, err := auth.FetchJWKS(context.Background(), server.Client(), server.URL+"/.well-known/jwks.json"); err != nil {
+		t.Errorf("expected the JWKS to be fetched got %s", err)
+	}
+	if _, err := auth.FetchJWKS(context.Background(), server.Client(), server.URL+"/missing"); err == nil {
+		t.Errorf("expected fetching a missing JWKS to fail")
+	}
+
+	smallKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	invalidCases := []struct {
+		description string
+		jwks        string
+	}{
+		{"not JSON", `keys`},
+		{"no keys", `{"keys": []}`},
+		{"only encryption keys", `{"keys": [{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"}]}`},
+		{"RSA key that is too small", string(jwksFor(t, map[string]crypto.PublicKey{"small": &smallKey.PublicKey}))},
+		{"point that isn't on the curve", `{"keys": [{"kty": "EC", "kid": "ec", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`},
+		{"unsupported curve", `{"keys": [{"kty": "EC", "kid": "ec", "crv": "P-192", "x": "AQ", "y": "AQ"}]}`},
+		{"missing parameters", `{"keys": [{"kty": "RSA", "kid": "rsa"}]}`},
+	}
+	for _, c := range invalidCases {
+		t.Run(c.description, func(t *testing.T) {
+			if _, err := auth.ParseJWKS([]byte(c.jwks)); err == nil {
+				t.Errorf("expected the JWKS to be refused")
+			}
+		})
+	}
+}
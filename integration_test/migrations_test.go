@@ -0,0 +1,122 @@
+package integration_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/data"
+)
+
+func TestMigrationsAreEmbedded(t *testing.T) {
+	migrations, err := data.Migrations()
+	if err != nil {
+		t.Errorf("error when loading migrations: %s", err)
+		return
+	}
+	if len(migrations) == 0 {
+		t.Errorf("expected the migrations to be embedded")
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("expected migration %d to have version %d", migration.Version, i+1)
+		}
+		if migration.Name == "" {
+			t.Errorf("expected migration %d to have a name", migration.Version)
+		}
+	}
+}
+
+func TestConcurrentMigrations(t *testing.T) {
+	backend := &sqlBackend{}
+	if available, reason := backend.Available(); !available {
+		t.Skipf("%s backend is unavailable: %s", backend.Name(), reason)
+	}
+	db, err := data.OpenDB(config)
+	if err != nil {
+		t.Errorf("error when opening the database: %s", err)
+		return
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	migrator, err := data.NewMigrator(db)
+	if err != nil {
+		t.Errorf("error when creating the migrator: %s", err)
+		return
+	}
+	migrations, err := data.Migrations()
+	if err != nil {
+		t.Errorf("error when loading migrations: %s", err)
+		return
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Errorf("error when getting the migration status: %s", err)
+		return
+	}
+	applied := 0
+	for _, status := range statuses {
+		if status.Applied {
+			applied++
+		}
+	}
+	rolledBack, err := migrator.Down(ctx, len(migrations))
+	if err != nil {
+		t.Errorf("error when rolling back the migrations: %s", err)
+		return
+	}
+	if len(rolledBack) != applied {
+		t.Errorf("expected %d migrations to be rolled back got %d", applied, len(rolledBack))
+	}
+
+	// every instance races to migrate, but each migration should only be applied once
+	const instances = 5
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	migrated := map[int]int{}
+	for i := 0; i < instances; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instance, err := data.NewMigrator(db)
+			if err != nil {
+				t.Errorf("error when creating the migrator: %s", err)
+				return
+			}
+			applied, err := instance.Up(ctx)
+			if err != nil {
+				t.Errorf("error when migrating: %s", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, migration := range applied {
+				migrated[migration.Version]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	// optional migrations aren't wanted with the default configuration
+	for _, migration := range migrations {
+		expected := 1
+		if migration.Optional {
+			expected = 0
+		}
+		if migrated[migration.Version] != expected {
+			t.Errorf("expected migration %d to be applied %d times got %d", migration.Version, expected, migrated[migration.Version])
+		}
+	}
+	statuses, err = migrator.Status(ctx)
+	if err != nil {
+		t.Errorf("error when getting the migration status: %s", err)
+		return
+	}
+	for _, status := range statuses {
+		if status.Applied == status.Optional {
+			t.Errorf("expected migration %d to be applied %t got %t", status.Version, !status.Optional, status.Applied)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestPagination(t *testing.T) {
+	forEachBackend(t, testPagination)
+}
+
+func testPagination(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	for _, cage := range []models.Cage{
+		{Label: "Delta-Pen", MaxOccupancy: 4, HasPower: true},
+		{Label: "Alpha-Pen", MaxOccupancy: 2, HasPower: true},
+		{Label: "Echo-Pen", MaxOccupancy: 4, HasPower: false},
+		{Label: "Charlie-Pen", MaxOccupancy: 6, HasPower: true},
+		{Label: "Bravo-Pen", MaxOccupancy: 3, HasPower: true},
+	} {
+		if err := dao.AddCage(context.Background(), cage); err != nil {
+			t.Errorf("error when creating test cage: %s", err)
+			return
+		}
+	}
+	for _, dinosaur := range []models.Dinosaur{
+		{Name: "Tank", Species: "Triceratops"},
+		{Name: "Cera", Species: "Triceratops"},
+		{Name: "LittleFoot", Species: "Brachiosaurus"},
+		{Name: "Spike", Species: "Stegosaurus"},
+		{Name: "Bumpy", Species: "Ankylosaurus"},
+	} {
+		if err := dao.AddDinosaur(context.Background(), dinosaur); err != nil {
+			t.Errorf("error when adding dinosaur: %s", err)
+			return
+		}
+	}
+	for name, cage := range map[string]string{
+		"Tank":       "Charlie-Pen",
+		"Cera":       "Charlie-Pen",
+		"LittleFoot": "Charlie-Pen",
+		"Spike":      "Delta-Pen",
+	} {
+		if err := dao.AddDinosaurToCage(context.Background(), name, cage); err != nil {
+			t.Errorf("error when adding dinosaur to cage: %s", err)
+			return
+		}
+	}
+
+	cases := []struct {
+		description   string
+		url           string
+		expectedPages [][]string
+		expectedTotal *int
+	}{
+		{
+			description:   "cages are listed in the order they were added when no sort is given",
+			url:           "/jurassicpark/v1/cages?limit=2",
+			expectedPages: [][]string{{"Delta-Pen", "Alpha-Pen"}, {"Echo-Pen", "Charlie-Pen"}, {"Bravo-Pen"}},
+		},
+		{
+			description:   "cages sorted by label",
+			url:           "/jurassicpark/v1/cages?limit=3&sort=label",
+			expectedPages: [][]string{{"Alpha-Pen", "Bravo-Pen", "Charlie-Pen"}, {"Delta-Pen", "Echo-Pen"}},
+		},
+		{
+			description:   "cages sorted by descending occupancy break ties in the order they were added",
+			url:           "/jurassicpark/v2/cages?limit=2&sort=-occupancy",
+			expectedPages: [][]string{{"Charlie-Pen", "Delta-Pen"}, {"Alpha-Pen", "Echo-Pen"}, {"Bravo-Pen"}},
+		},
+		{
+			description:   "powered cages sorted by max occupancy with a total",
+			url:           "/jurassicpark/v1/cages?limit=2&sort=maxOccupancy&hasPower=true&includeTotal=true",
+			expectedPages: [][]string{{"Alpha-Pen", "Bravo-Pen"}, {"Delta-Pen", "Charlie-Pen"}},
+			expectedTotal: wrapInt(4),
+		},
+		{
+			description:   "cages without a limit are returned in a single page",
+			url:           "/jurassicpark/v1/cages?sort=-label&includeTotal=true",
+			expectedPages: [][]string{{"Echo-Pen", "Delta-Pen", "Charlie-Pen", "Bravo-Pen", "Alpha-Pen"}},
+			expectedTotal: wrapInt(5),
+		},
+		{
+			description:   "cages that can house a dinosaur are paged in rank order",
+			url:           "/jurassicpark/v1/cages?canHouse=Bumpy&limit=1",
+			expectedPages: [][]string{{"Delta-Pen"}, {"Charlie-Pen"}, {"Alpha-Pen"}, {"Bravo-Pen"}},
+		},
+		{
+			description:   "dinosaurs sorted by species",
+			url:           "/jurassicpark/v1/dinosaurs?limit=2&sort=species&includeTotal=true",
+			expectedPages: [][]string{{"Bumpy", "LittleFoot"}, {"Spike", "Tank"}, {"Cera"}},
+			expectedTotal: wrapInt(5),
+		},
+		{
+			description:   "dinosaurs with a filter sorted by descending name",
+			url:           "/jurassicpark/v2/dinosaurs?limit=2&sort=-name&species=Triceratops",
+			expectedPages: [][]string{{"Tank", "Cera"}},
+		},
+		{
+			description:   "dinosaurs in a cage",
+			url:           "/jurassicpark/v1/cages/Charlie-Pen/dinosaurs?limit=2&sort=name&includeTotal=true",
+			expectedPages: [][]string{{"Cera", "LittleFoot"}, {"Tank"}},
+			expectedTotal: wrapInt(3),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.New()
+			backend.NewAPI(r)
+
+			url := c.url
+			for i, expectedPage := range c.expectedPages {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", url, nil)
+				r.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					t.Errorf("expected status code %d got %d for page %d", http.StatusOK, w.Code, i)
+					return
+				}
+
+				actualPage, err := readNames(w)
+				if err != nil {
+					t.Errorf("error while decoding result body: %s", err)
+					return
+				}
+				if !reflect.DeepEqual(expectedPage, actualPage) {
+					t.Errorf("expected page %d to be %v got %v", i, expectedPage, actualPage)
+				}
+				if c.expectedTotal != nil && w.Header().Get("X-Total-Count") != strconv.Itoa(*c.expectedTotal) {
+					t.Errorf("expected a total of %d got %q", *c.expectedTotal, w.Header().Get("X-Total-Count"))
+				}
+				if c.expectedTotal == nil && w.Header().Get("X-Total-Count") != "" {
+					t.Errorf("expected no total got %q", w.Header().Get("X-Total-Count"))
+				}
+
+				link := w.Header().Get("Link")
+				isLastPage := i == len(c.expectedPages)-1
+				if isLastPage {
+					if link != "" || w.Header().Get("X-Next-Cursor") != "" {
+						t.Errorf("expected no next page after page %d got %s", i, link)
+					}
+					return
+				}
+				if !strings.Contains(link, "cursor="+w.Header().Get("X-Next-Cursor")) {
+					t.Errorf("expected the link %s to carry the next cursor %s", link, w.Header().Get("X-Next-Cursor"))
+				}
+				url = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+			}
+		})
+	}
+}
+
+// readNames reads the label of each cage or the name of each dinosaur in the response.
+func readNames(w *httptest.ResponseRecorder) ([]string, error) {
+	var items []struct {
+		Label string `json:"label"`
+		Name  string `json:"name"`
+	}
+	if err := json.NewDecoder(w.Result().Body).Decode(&items); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.Label+item.Name)
+	}
+	return names, nil
+}
+
+func TestInvalidPagination(t *testing.T) {
+	forEachBackend(t, testInvalidPagination)
+}
+
+func testInvalidPagination(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	if err := dao.AddDinosaur(context.Background(), models.Dinosaur{Name: "Tank", Species: "Triceratops"}); err != nil {
+		t.Errorf("error when adding dinosaur: %s", err)
+		return
+	}
+	labelCursor := models.Cursor{Sort: "label", Value: "Alpha-Pen", ID: 1}.Encode()
+	occupancyCursor := models.Cursor{Sort: "occupancy", Value: "many", ID: 1}.Encode()
+
+	cases := []struct {
+		description string
+		url         string
+	}{
+		{
+			description: "limit below one",
+			url:         "/jurassicpark/v1/cages?limit=0",
+		},
+		{
+			description: "limit above the maximum",
+			url:         "/jurassicpark/v1/dinosaurs?limit=1001",
+		},
+		{
+			description: "limit that is not a number",
+			url:         "/jurassicpark/v1/cages?limit=ten",
+		},
+		{
+			description: "sort on a field that the list can't be sorted on",
+			url:         "/jurassicpark/v1/dinosaurs?sort=occupancy",
+		},
+		{
+			description: "cursor that can't be decoded",
+			url:         "/jurassicpark/v1/cages?cursor=not-a-cursor",
+		},
+		{
+			description: "cursor from a different sort",
+			url:         "/jurassicpark/v2/cages?sort=-label&cursor=" + labelCursor,
+		},
+		{
+			description: "cursor with a value that doesn't match the sort",
+			url:         "/jurassicpark/v1/cages?sort=occupancy&cursor=" + occupancyCursor,
+		},
+		{
+			description: "sort with canHouse",
+			url:         "/jurassicpark/v1/cages?canHouse=Tank&sort=label",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.New()
+			backend.NewAPI(r)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", c.url, nil)
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("expected status code %d got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+			}
+		})
+	}
+}
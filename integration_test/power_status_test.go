@@ -0,0 +1,282 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestPowerStatusV2(t *testing.T) {
+	forEachBackend(t, testPowerStatusV2)
+}
+
+func testPowerStatusV2(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	dao.AddCage(context.Background(), models.Cage{
+		Label:        "Empty-Pen",
+		MaxOccupancy: 2,
+		HasPower:     false,
+	})
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "TerryRex",
+		Species: "Tyrannosaurus",
+	})
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "MerryRex",
+		Species: "Tyrannosaurus",
+	})
+
+	maintenance := models.PowerStatusMaintenance
+	active := models.PowerStatusActive
+	failing := models.PowerStatusFailing
+	down := models.PowerStatusDown
+	unknown := models.PowerStatus("FLICKERING")
+
+	cases := []struct {
+		description        string
+		method             string
+		url                string
+		body               any
+		expectedStatusCode int
+		expectedCage       *models.CageV2
+		expectedV1Cage     *models.Cage
+	}{
+		{
+			description:        "create T-Rex-Pen in maintenance",
+			method:             "POST",
+			url:                "/jurassicpark/v2/cages",
+			body:               models.CageV2{Label: "T-Rex-Pen", MaxOccupancy: 2, PowerStatus: maintenance},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "create a cage with an unknown power status",
+			method:             "POST",
+			url:                "/jurassicpark/v2/cages",
+			body:               models.CageV2{Label: "Odd-Pen", MaxOccupancy: 2, PowerStatus: unknown},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "get T-Rex-Pen from v2",
+			method:             "GET",
+			url:                "/jurassicpark/v2/cages/T-Rex-Pen",
+			expectedStatusCode: http.StatusOK,
+			expectedCage:       &models.CageV2{Label: "T-Rex-Pen", MaxOccupancy: 2, PowerStatus: maintenance},
+		},
+		{
+			description:        "get T-Rex-Pen from v1",
+			method:             "GET",
+			url:                "/jurassicpark/v1/cages/T-Rex-Pen",
+			expectedStatusCode: http.StatusOK,
+			expectedV1Cage:     &models.Cage{Label: "T-Rex-Pen", MaxOccupancy: 2, HasPower: true},
+		},
+		{
+			description:        "add TerryRex to T-Rex-Pen while it is in maintenance",
+			method:             "POST",
+			url:                "/jurassicpark/v2/cages/T-Rex-Pen/dinosaurs",
+			body:               models.AddDinosaurToCageRequest{Name: "TerryRex"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "activate T-Rex-Pen",
+			method:             "PATCH",
+			url:                "/jurassicpark/v2/cages/T-Rex-Pen",
+			body:               models.UpdateCageV2Request{PowerStatus: &active},
+			expectedStatusCode: http.StatusOK,
+			expectedCage:       &models.CageV2{Label: "T-Rex-Pen", MaxOccupancy: 2, PowerStatus: active},
+		},
+		{
+			description:        "add TerryRex to the active T-Rex-Pen",
+			method:             "POST",
+			url:                "/jurassicpark/v2/cages/T-Rex-Pen/dinosaurs",
+			body:               models.AddDinosaurToCageRequest{Name: "TerryRex"},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "take the occupied T-Rex-Pen down",
+			method:             "PATCH",
+			url:                "/jurassicpark/v2/cages/T-Rex-Pen",
+			body:               models.UpdateCageV2Request{PowerStatus: &down},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "mark the occupied T-Rex-Pen as failing",
+			method:             "PATCH",
+			url:                "/jurassicpark/v2/cages/T-Rex-Pen",
+			body:               models.UpdateCageV2Request{PowerStatus: &failing},
+			expectedStatusCode: http.StatusOK,
+			expectedCage:       &models.CageV2{Label: "T-Rex-Pen", MaxOccupancy: 2, Occupancy: 1, PowerStatus: failing},
+		},
+		{
+			description:        "add MerryRex to the failing T-Rex-Pen",
+			method:             "POST",
+			url:                "/jurassicpark/v1/cages/T-Rex-Pen/dinosaurs",
+			body:               models.AddDinosaurToCageRequest{Name: "MerryRex"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "turn off the occupied T-Rex-Pen through v1",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/T-Rex-Pen",
+			body:               models.UpdateCagePowerStatusRequest{HasPower: false},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "mark the down Empty-Pen as failing",
+			method:             "PATCH",
+			url:                "/jurassicpark/v2/cages/Empty-Pen",
+			body:               models.UpdateCageV2Request{PowerStatus: &failing},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "move Empty-Pen to an unknown power status",
+			method:             "PATCH",
+			url:                "/jurassicpark/v2/cages/Empty-Pen",
+			body:               models.UpdateCageV2Request{PowerStatus: &unknown},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "turn on Empty-Pen through v1",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/Empty-Pen",
+			body:               models.UpdateCagePowerStatusRequest{HasPower: true},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "get Empty-Pen from v2 after turning it on through v1",
+			method:             "GET",
+			url:                "/jurassicpark/v2/cages/Empty-Pen",
+			expectedStatusCode: http.StatusOK,
+			expectedCage:       &models.CageV2{Label: "Empty-Pen", MaxOccupancy: 2, PowerStatus: active},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.Default()
+			backend.NewAPI(r)
+
+			w := httptest.NewRecorder()
+
+			var body []byte
+			if c.body != nil {
+				body, err = json.Marshal(c.body)
+				if err != nil {
+					t.Errorf("failed to marshal request: %s", err)
+					return
+				}
+			}
+			req, _ := http.NewRequest(c.method, c.url, bytes.NewReader(body))
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+			}
+			if c.expectedCage != nil {
+				var actualCage models.CageV2
+				err = json.NewDecoder(w.Result().Body).Decode(&actualCage)
+				if err != nil {
+					t.Errorf("error while decoding result body: %s", err)
+					return
+				}
+				if *c.expectedCage != actualCage {
+					t.Errorf("expected cage to be %+v got %+v", *c.expectedCage, actualCage)
+				}
+			}
+			if c.expectedV1Cage != nil {
+				var actualCage models.Cage
+				err = json.NewDecoder(w.Result().Body).Decode(&actualCage)
+				if err != nil {
+					t.Errorf("error while decoding result body: %s", err)
+					return
+				}
+				assertCagesMatch(*c.expectedV1Cage, actualCage, t)
+			}
+		})
+	}
+}
+
+func TestGetCagesV2WithPowerStatus(t *testing.T) {
+	forEachBackend(t, testGetCagesV2WithPowerStatus)
+}
+
+func testGetCagesV2WithPowerStatus(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	for _, cage := range []models.CageV2{
+		{Label: "Active-Pen", MaxOccupancy: 2, PowerStatus: models.PowerStatusActive},
+		{Label: "Serviced-Pen", MaxOccupancy: 2, PowerStatus: models.PowerStatusMaintenance},
+		{Label: "Dark-Pen", MaxOccupancy: 2, PowerStatus: models.PowerStatusDown},
+	} {
+		err = dao.AddCage(context.Background(), cage.Cage())
+		if err != nil {
+			t.Errorf("error when creating test cage: %s", err)
+			return
+		}
+	}
+
+	cases := []struct {
+		description    string
+		url            string
+		expectedLabels []string
+	}{
+		{
+			description:    "only cages in maintenance",
+			url:            "/jurassicpark/v2/cages?powerStatus=MAINTENANCE",
+			expectedLabels: []string{"Serviced-Pen"},
+		},
+		{
+			description:    "all cages",
+			url:            "/jurassicpark/v2/cages",
+			expectedLabels: []string{"Active-Pen", "Serviced-Pen", "Dark-Pen"},
+		},
+		{
+			description:    "v1 cages with power",
+			url:            "/jurassicpark/v1/cages?hasPower=true",
+			expectedLabels: []string{"Active-Pen", "Serviced-Pen"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.Default()
+			backend.NewAPI(r)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", c.url, nil)
+			r.ServeHTTP(w, req)
+
+			var actualCages []models.CageV2
+			err = json.NewDecoder(w.Result().Body).Decode(&actualCages)
+			if err != nil {
+				t.Errorf("error while decoding result body: %s", err)
+				return
+			}
+			if len(actualCages) != len(c.expectedLabels) {
+				t.Errorf("expected %d cages to be returned got %d", len(c.expectedLabels), len(actualCages))
+				return
+			}
+			for i, label := range c.expectedLabels {
+				if actualCages[i].Label != label {
+					t.Errorf("expected cage label to be %s got %s", label, actualCages[i].Label)
+				}
+			}
+		})
+	}
+}
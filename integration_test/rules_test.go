@@ -0,0 +1,442 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/data"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/rules"
+	"github.com/gin-gonic/gin"
+)
+
+const testRules = `
+pairs:
+  - species: [Ankylosaurus, Stegosaurus]
+    compatible: false
+    reason: they fight over territory
+  - species: [Velociraptor, Megalosaurus]
+    compatible: true
+species:
+  Velociraptor:
+    minPackSize: 4
+  Triceratops:
+    maxPerCage: 2
+`
+
+func TestLoadRules(t *testing.T) {
+	cases := []struct {
+		description   string
+		yaml          string
+		expectedRules *rules.Rules
+	}{
+		{
+			description: "pairs and species rules",
+			yaml:        testRules,
+			expectedRules: &rules.Rules{
+				Pairs: []rules.PairRule{
+					{Species: [2]string{"Ankylosaurus", "Stegosaurus"}, Compatible: false, Reason: "they fight over territory"},
+					{Species: [2]string{"Velociraptor", "Megalosaurus"}, Compatible: true},
+				},
+				Species: map[string]rules.SpeciesRule{
+					"Velociraptor": {MinPackSize: 4},
+					"Triceratops":  {MaxPerCage: 2},
+				},
+			},
+		},
+		{
+			description:   "no rules",
+			yaml:          "",
+			expectedRules: &rules.Rules{},
+		},
+		{
+			description: "the same pair twice in a different order",
+			yaml: `
+pairs:
+  - species: [Ankylosaurus, Stegosaurus]
+  - species: [Stegosaurus, Ankylosaurus]
+    compatible: true
+`,
+		},
+		{
+			description: "a pack that is larger than fits in a cage",
+			yaml: `
+species:
+  Velociraptor:
+    minPackSize: 4
+    maxPerCage: 3
+`,
+		},
+		{
+			description: "a field that is not recognized",
+			yaml: `
+species:
+  Velociraptor:
+    packSize: 4
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			actualRules, err := rules.Load(strings.NewReader(c.yaml))
+			if c.expectedRules == nil {
+				if err == nil {
+					t.Errorf("expected the rules to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("error when loading rules: %s", err)
+				return
+			}
+			if !reflect.DeepEqual(*c.expectedRules, actualRules) {
+				t.Errorf("expected rules %+v got %+v", *c.expectedRules, actualRules)
+			}
+		})
+	}
+}
+
+func TestCompatibilityRules(t *testing.T) {
+	forEachBackend(t, testCompatibilityRules)
+}
+
+func testCompatibilityRules(t *testing.T, backend parkBackend) {
+	cases := []struct {
+		description        string
+		dinosaur           models.Dinosaur
+		cage               string
+		expectedStatusCode int
+		expectedRules      []string
+	}{
+		{
+			description:        "species that are incompatible by a pair rule",
+			dinosaur:           models.Dinosaur{Name: "Bumpy", Species: "Ankylosaurus"},
+			cage:               "Herbivore-Pen",
+			expectedStatusCode: http.StatusConflict,
+			expectedRules:      []string{"pair:Ankylosaurus/Stegosaurus"},
+		},
+		{
+			description:        "more of a species than can share a cage",
+			dinosaur:           models.Dinosaur{Name: "Horns", Species: "Triceratops"},
+			cage:               "Trike-Pen",
+			expectedStatusCode: http.StatusConflict,
+			expectedRules:      []string{"maxPerCage:Triceratops"},
+		},
+		{
+			description:        "a cage without room for a pack",
+			dinosaur:           models.Dinosaur{Name: "Charlie", Species: "Velociraptor"},
+			cage:               "Small-Pen",
+			expectedStatusCode: http.StatusConflict,
+			expectedRules:      []string{"minPackSize:Velociraptor"},
+		},
+		{
+			description:        "another species that would leave no room for the pack in the cage",
+			dinosaur:           models.Dinosaur{Name: "Tiny", Species: "Megalosaurus"},
+			cage:               "Raptor-Pen",
+			expectedStatusCode: http.StatusConflict,
+			expectedRules:      []string{"minPackSize:Velociraptor"},
+		},
+		{
+			description:        "carnivores of different species fall back on the diet rules",
+			dinosaur:           models.Dinosaur{Name: "Rex", Species: "Tyrannosaurus"},
+			cage:               "Mixed-Pen",
+			expectedStatusCode: http.StatusConflict,
+			expectedRules:      []string{"diet:carnivore"},
+		},
+		{
+			description:        "a cage with room for the pack",
+			dinosaur:           models.Dinosaur{Name: "Echo", Species: "Velociraptor"},
+			cage:               "Raptor-Pen",
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "carnivores that are compatible by a pair rule",
+			dinosaur:           models.Dinosaur{Name: "Delta", Species: "Velociraptor"},
+			cage:               "Mixed-Pen",
+			expectedStatusCode: http.StatusCreated,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if err := seedCompatibilityPark(backend); err != nil {
+				t.Errorf("error when seeding the park: %s", err)
+				return
+			}
+			defer backend.Park().SetCompatibilityRules(rules.NewEngine(rules.Rules{}))
+			if err := backend.Park().AddDinosaur(context.Background(), c.dinosaur); err != nil {
+				t.Errorf("error when adding dinosaur: %s", err)
+				return
+			}
+
+			r := gin.New()
+			backend.NewAPI(r)
+			body, _ := json.Marshal(models.AddDinosaurToCageRequest{Name: c.dinosaur.Name})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/jurassicpark/v1/cages/"+c.cage+"/dinosaurs", bytes.NewReader(body))
+			r.ServeHTTP(w, req)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+				return
+			}
+			if c.expectedStatusCode != http.StatusConflict {
+				return
+			}
+
+			var errorResponse models.ErrorResponse
+			if err := json.NewDecoder(w.Result().Body).Decode(&errorResponse); err != nil {
+				t.Errorf("error while decoding result body: %s", err)
+				return
+			}
+			actualRules := []string{}
+			for _, violation := range errorResponse.Violations {
+				if violation.Message == "" {
+					t.Errorf("expected the violation of %s to explain itself", violation.Rule)
+				}
+				actualRules = append(actualRules, violation.Rule)
+			}
+			if !reflect.DeepEqual(c.expectedRules, actualRules) {
+				t.Errorf("expected violations of %v got %v", c.expectedRules, actualRules)
+			}
+		})
+	}
+}
+
+func TestCageCapacityRules(t *testing.T) {
+	forEachBackend(t, testCageCapacityRules)
+}
+
+func testCageCapacityRules(t *testing.T, backend parkBackend) {
+	cases := []struct {
+		description        string
+		cage               string
+		maxOccupancy       int
+		expectedStatusCode int
+		expectedRules      []string
+	}{
+		{
+			description:        "a capacity that leaves no room for the pack in the cage",
+			cage:               "Raptor-Pen",
+			maxOccupancy:       3,
+			expectedStatusCode: http.StatusConflict,
+			expectedRules:      []string{"minPackSize:Velociraptor"},
+		},
+		{
+			description:        "a capacity that leaves room for the pack in the cage",
+			cage:               "Raptor-Pen",
+			maxOccupancy:       5,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "a lower capacity for a cage of species that don't live in packs",
+			cage:               "Mixed-Pen",
+			maxOccupancy:       1,
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if err := seedCompatibilityPark(backend); err != nil {
+				t.Errorf("error when seeding the park: %s", err)
+				return
+			}
+			defer backend.Park().SetCompatibilityRules(rules.NewEngine(rules.Rules{}))
+
+			r := gin.New()
+			backend.NewAPI(r)
+			body, _ := json.Marshal(models.UpdateCageV2Request{MaxOccupancy: wrapInt(c.maxOccupancy)})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/jurassicpark/v2/cages/"+c.cage, bytes.NewReader(body))
+			r.ServeHTTP(w, req)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d: %s", c.expectedStatusCode, w.Code, w.Body.String())
+				return
+			}
+
+			cages, _, err := backend.Park().GetCages(models.CageFilter{})
+			if err != nil {
+				t.Errorf("error when reading the cages: %s", err)
+				return
+			}
+			for _, cage := range cages {
+				if cage.Label == c.cage && (cage.MaxOccupancy == c.maxOccupancy) != (c.expectedStatusCode == http.StatusOK) {
+					t.Errorf("expected the capacity of %s to be changed only if the request succeeded, got %d", c.cage, cage.MaxOccupancy)
+				}
+			}
+			if c.expectedStatusCode != http.StatusConflict {
+				return
+			}
+
+			var errorResponse models.ErrorResponse
+			if err := json.NewDecoder(w.Result().Body).Decode(&errorResponse); err != nil {
+				t.Errorf("error while decoding result body: %s", err)
+				return
+			}
+			if errorResponse.Code != models.CodeIncompatibleSpecies {
+				t.Errorf("expected code %s got %s", models.CodeIncompatibleSpecies, errorResponse.Code)
+			}
+			actualRules := []string{}
+			for _, violation := range errorResponse.Violations {
+				actualRules = append(actualRules, violation.Rule)
+			}
+			if !reflect.DeepEqual(c.expectedRules, actualRules) {
+				t.Errorf("expected violations of %v got %v", c.expectedRules, actualRules)
+			}
+		})
+	}
+}
+
+func TestGetCagesThatCanHouseDinosaurWithRules(t *testing.T) {
+	forEachBackend(t, testGetCagesThatCanHouseDinosaurWithRules)
+}
+
+func testGetCagesThatCanHouseDinosaurWithRules(t *testing.T, backend parkBackend) {
+	if err := seedCompatibilityPark(backend); err != nil {
+		t.Errorf("error when seeding the park: %s", err)
+		return
+	}
+	defer backend.Park().SetCompatibilityRules(rules.NewEngine(rules.Rules{}))
+	for _, dinosaur := range []models.Dinosaur{
+		{Name: "Bumpy", Species: "Ankylosaurus"},
+		{Name: "Horns", Species: "Triceratops"},
+		{Name: "Charlie", Species: "Velociraptor"},
+		{Name: "Tiny", Species: "Megalosaurus"},
+	} {
+		if err := backend.Park().AddDinosaur(context.Background(), dinosaur); err != nil {
+			t.Errorf("error when adding dinosaur: %s", err)
+			return
+		}
+	}
+
+	cases := []struct {
+		dinosaur       string
+		expectedLabels []string
+	}{
+		{dinosaur: "Bumpy", expectedLabels: []string{"Trike-Pen", "Small-Pen"}},
+		{dinosaur: "Horns", expectedLabels: []string{"Herbivore-Pen", "Small-Pen"}},
+		{dinosaur: "Charlie", expectedLabels: []string{"Raptor-Pen", "Mixed-Pen"}},
+		{dinosaur: "Tiny", expectedLabels: []string{"Mixed-Pen", "Small-Pen"}},
+	}
+	for _, c := range cases {
+		t.Run(c.dinosaur, func(t *testing.T) {
+			r := gin.New()
+			backend.NewAPI(r)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/jurassicpark/v1/cages?canHouse="+c.dinosaur, nil)
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("expected status code %d got %d", http.StatusOK, w.Code)
+				return
+			}
+			actualLabels, err := readNames(w)
+			if err != nil {
+				t.Errorf("error while decoding result body: %s", err)
+				return
+			}
+			if !reflect.DeepEqual(c.expectedLabels, actualLabels) {
+				t.Errorf("expected cages %v got %v", c.expectedLabels, actualLabels)
+			}
+		})
+	}
+}
+
+// seedCompatibilityPark resets the park and fills it with cages for the testRules.
+func seedCompatibilityPark(backend parkBackend) error {
+	if err := backend.Reset(); err != nil {
+		return err
+	}
+	compatibilityRules, err := rules.Load(strings.NewReader(testRules))
+	if err != nil {
+		return err
+	}
+	dao := backend.Park()
+	dao.SetCompatibilityRules(rules.NewEngine(compatibilityRules))
+
+	for _, cage := range []models.Cage{
+		{Label: "Herbivore-Pen", MaxOccupancy: 5, HasPower: true},
+		{Label: "Trike-Pen", MaxOccupancy: 5, HasPower: true},
+		{Label: "Raptor-Pen", MaxOccupancy: 4, HasPower: true},
+		{Label: "Small-Pen", MaxOccupancy: 3, HasPower: true},
+		{Label: "Mixed-Pen", MaxOccupancy: 6, HasPower: true},
+	} {
+		if err := dao.AddCage(context.Background(), cage); err != nil {
+			return err
+		}
+	}
+	occupants := []struct {
+		dinosaur models.Dinosaur
+		cage     string
+	}{
+		{dinosaur: models.Dinosaur{Name: "Spike", Species: "Stegosaurus"}, cage: "Herbivore-Pen"},
+		{dinosaur: models.Dinosaur{Name: "Tank", Species: "Triceratops"}, cage: "Trike-Pen"},
+		{dinosaur: models.Dinosaur{Name: "Cera", Species: "Triceratops"}, cage: "Trike-Pen"},
+		{dinosaur: models.Dinosaur{Name: "Blue", Species: "Velociraptor"}, cage: "Raptor-Pen"},
+		{dinosaur: models.Dinosaur{Name: "Big", Species: "Megalosaurus"}, cage: "Mixed-Pen"},
+	}
+	for _, occupant := range occupants {
+		if err := dao.AddDinosaur(context.Background(), occupant.dinosaur); err != nil {
+			return err
+		}
+		if err := dao.AddDinosaurToCage(context.Background(), occupant.dinosaur.Name, occupant.cage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCompatibilityRulesFromDatabase(t *testing.T) {
+	backend := &sqlBackend{}
+	if available, reason := backend.Available(); !available {
+		t.Skipf("%s backend is unavailable: %s", backend.Name(), reason)
+	}
+	if err := backend.Reset(); err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	db, err := data.OpenDB(config)
+	if err != nil {
+		t.Errorf("error when connecting to the test database: %s", err)
+		return
+	}
+	defer db.Close()
+	defer backend.Reset()
+
+	_, err = db.Exec(`INSERT INTO speciesCompatibility(speciesA, speciesB, compatible, reason)
+		VALUES('Ankylosaurus', 'Stegosaurus', FALSE, 'they fight over territory'),
+			  ('Velociraptor', 'Megalosaurus', TRUE, '')`)
+	if err != nil {
+		t.Errorf("error when adding pair rules: %s", err)
+		return
+	}
+	_, err = db.Exec(`INSERT INTO speciesConstraint(species, minPackSize, maxPerCage)
+		VALUES('Velociraptor', 4, 0),
+			  ('Triceratops', 0, 2)`)
+	if err != nil {
+		t.Errorf("error when adding species rules: %s", err)
+		return
+	}
+
+	actualRules, err := backend.dao.GetCompatibilityRules()
+	if err != nil {
+		t.Errorf("error when reading rules: %s", err)
+		return
+	}
+	expectedRules := rules.Rules{
+		Pairs: []rules.PairRule{
+			{Species: [2]string{"Ankylosaurus", "Stegosaurus"}, Compatible: false, Reason: "they fight over territory"},
+			{Species: [2]string{"Velociraptor", "Megalosaurus"}, Compatible: true},
+		},
+		Species: map[string]rules.SpeciesRule{
+			"Velociraptor": {MinPackSize: 4},
+			"Triceratops":  {MaxPerCage: 2},
+		},
+	}
+	if !reflect.DeepEqual(expectedRules, actualRules) {
+		t.Errorf("expected rules %+v got %+v", expectedRules, actualRules)
+	}
+}
@@ -0,0 +1,362 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAddDinosaurWithSex(t *testing.T) {
+	forEachBackend(t, testAddDinosaurWithSex)
+}
+
+func testAddDinosaurWithSex(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	cases := []struct {
+		description        string
+		dinosaur           models.Dinosaur
+		expectedStatusCode int
+		expectedSex        models.Sex
+	}{
+		{
+			description:        "dinosaurs are female unless told otherwise",
+			dinosaur:           models.Dinosaur{Name: "Nedry", Species: "Spinosaurus"},
+			expectedStatusCode: http.StatusCreated,
+			expectedSex:        models.SexFemale,
+		},
+		{
+			description:        "male dinosaur",
+			dinosaur:           models.Dinosaur{Name: "Grant", Species: "Spinosaurus", Sex: models.SexMale},
+			expectedStatusCode: http.StatusCreated,
+			expectedSex:        models.SexMale,
+		},
+		{
+			description:        "sex that is not recognized",
+			dinosaur:           models.Dinosaur{Name: "Malcolm", Species: "Spinosaurus", Sex: "Chaotic"},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.New()
+			backend.NewAPI(r)
+
+			body, _ := json.Marshal(c.dinosaur)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/jurassicpark/v1/dinosaurs", bytes.NewReader(body))
+			r.ServeHTTP(w, req)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+				return
+			}
+			if c.expectedSex == "" {
+				return
+			}
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/jurassicpark/v1/dinosaurs/%s", c.dinosaur.Name), nil)
+			r.ServeHTTP(w, req)
+			var actualDinosaur models.Dinosaur
+			if err := json.NewDecoder(w.Result().Body).Decode(&actualDinosaur); err != nil {
+				t.Errorf("error while decoding result body: %s", err)
+				return
+			}
+			if actualDinosaur.Sex != c.expectedSex {
+				t.Errorf("expected the dinosaur to be %s got %s", c.expectedSex, actualDinosaur.Sex)
+			}
+		})
+	}
+}
+
+func TestGetDinosaursBySex(t *testing.T) {
+	forEachBackend(t, testGetDinosaursBySex)
+}
+
+func testGetDinosaursBySex(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	for _, dinosaur := range []models.Dinosaur{
+		{Name: "Tank", Species: "Triceratops", Sex: models.SexMale},
+		{Name: "Cera", Species: "Triceratops", Sex: models.SexFemale},
+		{Name: "Spike", Species: "Stegosaurus", Sex: models.SexMale},
+	} {
+		if err := dao.AddDinosaur(context.Background(), dinosaur); err != nil {
+			t.Errorf("error when adding dinosaur: %s", err)
+			return
+		}
+	}
+
+	r := gin.New()
+	backend.NewAPI(r)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jurassicpark/v1/dinosaurs?sex=Male", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d got %d", http.StatusOK, w.Code)
+		return
+	}
+	var actualDinosaurs []models.Dinosaur
+	if err := json.NewDecoder(w.Result().Body).Decode(&actualDinosaurs); err != nil {
+		t.Errorf("error while decoding result body: %s", err)
+		return
+	}
+	expectedDinosaurs := []models.Dinosaur{
+		{Name: "Tank", Species: "Triceratops", Sex: models.SexMale, Diet: "Herbivore"},
+		{Name: "Spike", Species: "Stegosaurus", Sex: models.SexMale, Diet: "Herbivore"},
+	}
+	if len(actualDinosaurs) != len(expectedDinosaurs) {
+		t.Errorf("expected %d dinosaurs to be returned got %d", len(expectedDinosaurs), len(actualDinosaurs))
+		return
+	}
+	for i, expectedDinosaur := range expectedDinosaurs {
+		assertDinosaursMatch(expectedDinosaur, actualDinosaurs[i], t)
+	}
+}
+
+func TestBreedingPolicy(t *testing.T) {
+	forEachBackend(t, testBreedingPolicy)
+}
+
+func testBreedingPolicy(t *testing.T, backend parkBackend) {
+	cases := []struct {
+		description        string
+		policy             models.BreedingPolicy
+		dinosaur           models.Dinosaur
+		expectedStatusCode int
+	}{
+		{
+			description:        "a male can't join a female of his species",
+			policy:             models.DefaultBreedingPolicy,
+			dinosaur:           models.Dinosaur{Name: "Tank", Species: "Triceratops", Sex: models.SexMale},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "a female can join a female of her species",
+			policy:             models.DefaultBreedingPolicy,
+			dinosaur:           models.Dinosaur{Name: "Sara", Species: "Triceratops", Sex: models.SexFemale},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "a male can join a female of another herbivore species",
+			policy:             models.DefaultBreedingPolicy,
+			dinosaur:           models.Dinosaur{Name: "LittleFoot", Species: "Brachiosaurus", Sex: models.SexMale},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "a male can join a female of his species when breeding is allowed",
+			policy:             models.BreedingPolicy{PreventBreeding: false},
+			dinosaur:           models.Dinosaur{Name: "Tank", Species: "Triceratops", Sex: models.SexMale},
+			expectedStatusCode: http.StatusCreated,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := backend.Reset()
+			if err != nil {
+				t.Errorf("error when clearing out test database: %s", err)
+				return
+			}
+			dao := backend.Park()
+			dao.SetBreedingPolicy(c.policy)
+			defer dao.SetBreedingPolicy(models.DefaultBreedingPolicy)
+
+			if err := dao.AddCage(context.Background(), models.Cage{Label: "Trike-Pen", MaxOccupancy: 5, HasPower: true}); err != nil {
+				t.Errorf("error when creating test cage: %s", err)
+				return
+			}
+			for _, dinosaur := range []models.Dinosaur{{Name: "Cera", Species: "Triceratops", Sex: models.SexFemale}, c.dinosaur} {
+				if err := dao.AddDinosaur(context.Background(), dinosaur); err != nil {
+					t.Errorf("error when adding dinosaur: %s", err)
+					return
+				}
+			}
+			if err := dao.AddDinosaurToCage(context.Background(), "Cera", "Trike-Pen"); err != nil {
+				t.Errorf("error when adding dinosaur to cage: %s", err)
+				return
+			}
+
+			r := gin.New()
+			backend.NewAPI(r)
+
+			// the cage should only be suggested for the dinosaur if it can be added to it
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/jurassicpark/v1/cages?canHouse="+c.dinosaur.Name, nil)
+			r.ServeHTTP(w, req)
+			var cages []models.Cage
+			if err := json.NewDecoder(w.Result().Body).Decode(&cages); err != nil {
+				t.Errorf("error while decoding result body: %s", err)
+				return
+			}
+			expectedCages := 0
+			if c.expectedStatusCode == http.StatusCreated {
+				expectedCages = 1
+			}
+			if len(cages) != expectedCages {
+				t.Errorf("expected canHouse to return %d cages got %d", expectedCages, len(cages))
+			}
+
+			body, _ := json.Marshal(models.AddDinosaurToCageRequest{Name: c.dinosaur.Name})
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("POST", "/jurassicpark/v1/cages/Trike-Pen/dinosaurs", bytes.NewReader(body))
+			r.ServeHTTP(w, req)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestChangeDinosaurSex(t *testing.T) {
+	forEachBackend(t, testChangeDinosaurSex)
+}
+
+func testChangeDinosaurSex(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	if err := dao.AddCage(context.Background(), models.Cage{Label: "Trike-Pen", MaxOccupancy: 5, HasPower: true}); err != nil {
+		t.Errorf("error when creating test cage: %s", err)
+		return
+	}
+	for _, dinosaur := range []models.Dinosaur{
+		{Name: "Cera", Species: "Triceratops", Sex: models.SexFemale},
+		{Name: "Sara", Species: "Triceratops", Sex: models.SexFemale},
+		{Name: "Tank", Species: "Triceratops", Sex: models.SexMale},
+	} {
+		if err := dao.AddDinosaur(context.Background(), dinosaur); err != nil {
+			t.Errorf("error when adding dinosaur: %s", err)
+			return
+		}
+	}
+	for _, name := range []string{"Cera", "Sara"} {
+		if err := dao.AddDinosaurToCage(context.Background(), name, "Trike-Pen"); err != nil {
+			t.Errorf("error when adding dinosaur to cage: %s", err)
+			return
+		}
+	}
+
+	male := models.SexMale
+	female := models.SexFemale
+	chaotic := models.Sex("Chaotic")
+	cases := []struct {
+		description        string
+		name               string
+		body               any
+		expectedStatusCode int
+		expectedSex        models.Sex
+	}{
+		{
+			description:        "a dinosaur without a cage changes sex",
+			name:               "Tank",
+			body:               models.UpdateDinosaurRequest{Sex: &female},
+			expectedStatusCode: http.StatusOK,
+			expectedSex:        models.SexFemale,
+		},
+		{
+			description:        "a dinosaur can't change sex while it shares a cage with the opposite sex of its species",
+			name:               "Sara",
+			body:               models.UpdateDinosaurRequest{Sex: &male},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "sex that is not recognized",
+			name:               "Sara",
+			body:               models.UpdateDinosaurRequest{Sex: &chaotic},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "body without sex",
+			name:               "Sara",
+			body:               models.UpdateDinosaurRequest{},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "dinosaur that does not exist",
+			name:               "Nedry",
+			body:               models.UpdateDinosaurRequest{Sex: &male},
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			body, _ := json.Marshal(c.body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/jurassicpark/v1/dinosaurs/"+c.name, bytes.NewReader(body))
+			r.ServeHTTP(w, req)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+				return
+			}
+			if c.expectedSex == "" {
+				return
+			}
+			var actualDinosaur models.Dinosaur
+			if err := json.NewDecoder(w.Result().Body).Decode(&actualDinosaur); err != nil {
+				t.Errorf("error while decoding result body: %s", err)
+				return
+			}
+			if actualDinosaur.Sex != c.expectedSex {
+				t.Errorf("expected the dinosaur to be %s got %s", c.expectedSex, actualDinosaur.Sex)
+			}
+		})
+	}
+
+	t.Run("the change is in the audit log", func(t *testing.T) {
+		events, _, err := dao.GetAuditEvents(models.AuditFilter{Entity: wrapString(models.AuditEntity(models.AuditEntityDinosaur, "Tank"))})
+		if err != nil {
+			t.Errorf("error when reading the audit log: %s", err)
+			return
+		}
+		last := events[len(events)-1]
+		var before, after models.Dinosaur
+		json.Unmarshal(last.Before, &before)
+		json.Unmarshal(last.After, &after)
+		if last.Action != models.AuditUpdate || before.Sex != models.SexMale || after.Sex != models.SexFemale {
+			t.Errorf("expected the last event to change Tank from Male to Female, got %s from %s to %s", last.Action, before.Sex, after.Sex)
+		}
+	})
+
+	t.Run("a dinosaur can change sex in a shared cage when breeding is allowed", func(t *testing.T) {
+		dao.SetBreedingPolicy(models.BreedingPolicy{PreventBreeding: false})
+		defer dao.SetBreedingPolicy(models.DefaultBreedingPolicy)
+		body, _ := json.Marshal(models.UpdateDinosaurRequest{Sex: &male})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", "/jurassicpark/v1/dinosaurs/Sara", bytes.NewReader(body))
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status code %d got %d", http.StatusOK, w.Code)
+			return
+		}
+		var updated models.Dinosaur
+		if err := json.NewDecoder(w.Result().Body).Decode(&updated); err != nil {
+			t.Errorf("error while decoding result body: %s", err)
+			return
+		}
+		if updated.Sex != models.SexMale || updated.Cage == nil || *updated.Cage != "Trike-Pen" {
+			t.Errorf("expected Sara to be a male in Trike-Pen, got %+v", updated)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestManageSpecies(t *testing.T) {
+	forEachBackend(t, testManageSpecies)
+}
+
+func testManageSpecies(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "TerryRex",
+		Species: "Tyrannosaurus",
+	})
+
+	cases := []struct {
+		description        string
+		method             string
+		speciesName        string
+		body               *models.Species
+		expectedStatusCode int
+	}{
+		{
+			description:        "add Dilophosaurus",
+			method:             "POST",
+			body:               &models.Species{Name: "Dilophosaurus", Diet: "Carnivore"},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "add Dilophosaurus a second time",
+			method:             "POST",
+			body:               &models.Species{Name: "Dilophosaurus", Diet: "Carnivore"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "add species with an unknown diet",
+			method:             "POST",
+			body:               &models.Species{Name: "Gallimimus", Diet: "Omnivore"},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "get Dilophosaurus",
+			method:             "GET",
+			speciesName:        "Dilophosaurus",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "get species that does not exist",
+			method:             "GET",
+			speciesName:        "Mythosaurus",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "change the diet of Dilophosaurus",
+			method:             "PUT",
+			speciesName:        "Dilophosaurus",
+			body:               &models.Species{Diet: "Herbivore"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "change Dilophosaurus to an unknown diet",
+			method:             "PUT",
+			speciesName:        "Dilophosaurus",
+			body:               &models.Species{Diet: "Omnivore"},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "change the diet of a species that does not exist",
+			method:             "PUT",
+			speciesName:        "Mythosaurus",
+			body:               &models.Species{Diet: "Herbivore"},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "change the diet of a species with living dinosaurs",
+			method:             "PUT",
+			speciesName:        "Tyrannosaurus",
+			body:               &models.Species{Diet: "Herbivore"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "delete a species with living dinosaurs",
+			method:             "DELETE",
+			speciesName:        "Tyrannosaurus",
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "delete Dilophosaurus",
+			method:             "DELETE",
+			speciesName:        "Dilophosaurus",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "delete Dilophosaurus a second time",
+			method:             "DELETE",
+			speciesName:        "Dilophosaurus",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.Default()
+			backend.NewAPI(r)
+
+			w := httptest.NewRecorder()
+
+			url := "/jurassicpark/v1/species"
+			if c.speciesName != "" {
+				url += fmt.Sprintf("/%s", c.speciesName)
+			}
+			var body []byte
+			if c.body != nil {
+				body, err = json.Marshal(c.body)
+				if err != nil {
+					t.Errorf("failed to marshal species: %s", err)
+					return
+				}
+			}
+			req, _ := http.NewRequest(c.method, url, bytes.NewReader(body))
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestGetAllSpecies(t *testing.T) {
+	forEachBackend(t, testGetAllSpecies)
+}
+
+func testGetAllSpecies(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	r := gin.Default()
+	backend.NewAPI(r)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jurassicpark/v1/species", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d got %d", http.StatusOK, w.Code)
+	}
+	var actualSpecies []models.Species
+	err = json.NewDecoder(w.Result().Body).Decode(&actualSpecies)
+	if err != nil {
+		t.Errorf("error while decoding result body: %s", err)
+		return
+	}
+	if len(actualSpecies) != len(seededSpecies) {
+		t.Errorf("expected %d species to be returned got %d", len(seededSpecies), len(actualSpecies))
+		return
+	}
+	for i, species := range actualSpecies {
+		if i > 0 && actualSpecies[i-1].Name > species.Name {
+			t.Errorf("expected species to be ordered by name, %s came before %s", actualSpecies[i-1].Name, species.Name)
+		}
+	}
+}
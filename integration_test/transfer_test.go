@@ -0,0 +1,207 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTransferAndRemoveDinosaurs(t *testing.T) {
+	forEachBackend(t, testTransferAndRemoveDinosaurs)
+}
+
+func testTransferAndRemoveDinosaurs(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+
+	dao := backend.Park()
+	dao.AddCage(context.Background(), models.Cage{
+		Label:        "Raptor-Pen-1",
+		MaxOccupancy: 2,
+		HasPower:     true,
+	})
+	dao.AddCage(context.Background(), models.Cage{
+		Label:        "Raptor-Pen-2",
+		MaxOccupancy: 1,
+		HasPower:     true,
+	})
+	dao.AddCage(context.Background(), models.Cage{
+		Label:        "T-Rex-Pen",
+		MaxOccupancy: 2,
+		HasPower:     true,
+	})
+	dao.AddCage(context.Background(), models.Cage{
+		Label:        "Unpowered-Pen",
+		MaxOccupancy: 2,
+		HasPower:     false,
+	})
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "Vela",
+		Species: "Velociraptor",
+	})
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "Verona",
+		Species: "Velociraptor",
+	})
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "TerryRex",
+		Species: "Tyrannosaurus",
+	})
+	dao.AddDinosaur(context.Background(), models.Dinosaur{
+		Name:    "Cera",
+		Species: "Triceratops",
+	})
+	dao.AddDinosaurToCage(context.Background(), "Vela", "Raptor-Pen-1")
+	dao.AddDinosaurToCage(context.Background(), "Verona", "Raptor-Pen-1")
+	dao.AddDinosaurToCage(context.Background(), "TerryRex", "T-Rex-Pen")
+
+	cases := []struct {
+		description        string
+		method             string
+		url                string
+		body               any
+		expectedStatusCode int
+	}{
+		{
+			description:        "transfer Vela to T-Rex-Pen",
+			method:             "POST",
+			url:                "/jurassicpark/v1/dinosaurs/Vela/transfer",
+			body:               models.TransferDinosaurRequest{Cage: "T-Rex-Pen"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "transfer Vela to Unpowered-Pen",
+			method:             "POST",
+			url:                "/jurassicpark/v1/dinosaurs/Vela/transfer",
+			body:               models.TransferDinosaurRequest{Cage: "Unpowered-Pen"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "transfer Vela to a missing cage",
+			method:             "POST",
+			url:                "/jurassicpark/v1/dinosaurs/Vela/transfer",
+			body:               models.TransferDinosaurRequest{Cage: "MissingPen"},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "transfer Cera who is not in a cage",
+			method:             "POST",
+			url:                "/jurassicpark/v1/dinosaurs/Cera/transfer",
+			body:               models.TransferDinosaurRequest{Cage: "Raptor-Pen-2"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "transfer a dinosaur that is not at the park",
+			method:             "POST",
+			url:                "/jurassicpark/v1/dinosaurs/NotHere/transfer",
+			body:               models.TransferDinosaurRequest{Cage: "Raptor-Pen-2"},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "transfer Vela to Raptor-Pen-2",
+			method:             "POST",
+			url:                "/jurassicpark/v1/dinosaurs/Vela/transfer",
+			body:               models.TransferDinosaurRequest{Cage: "Raptor-Pen-2"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "transfer Verona to the full Raptor-Pen-2",
+			method:             "POST",
+			url:                "/jurassicpark/v1/dinosaurs/Verona/transfer",
+			body:               models.TransferDinosaurRequest{Cage: "Raptor-Pen-2"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "remove Verona from a cage she is not in",
+			method:             "DELETE",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen-2/dinosaurs/Verona",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "remove Verona from Raptor-Pen-1",
+			method:             "DELETE",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen-1/dinosaurs/Verona",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			description:        "remove Verona from Raptor-Pen-1 a second time",
+			method:             "DELETE",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen-1/dinosaurs/Verona",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "remove a dinosaur from a missing cage",
+			method:             "DELETE",
+			url:                "/jurassicpark/v1/cages/MissingPen/dinosaurs/Vela",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "turn off power to the now empty Raptor-Pen-1",
+			method:             "PATCH",
+			url:                "/jurassicpark/v1/cages/Raptor-Pen-1",
+			body:               models.UpdateCagePowerStatusRequest{HasPower: false},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := gin.Default()
+			backend.NewAPI(r)
+
+			w := httptest.NewRecorder()
+
+			var body []byte
+			if c.body != nil {
+				body, err = json.Marshal(c.body)
+				if err != nil {
+					t.Errorf("failed to marshal request: %s", err)
+					return
+				}
+			}
+			req, _ := http.NewRequest(c.method, c.url, bytes.NewReader(body))
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+			}
+		})
+	}
+
+	expectedDinosaursInCages := map[string][]models.Dinosaur{
+		"Raptor-Pen-1": {},
+		"Raptor-Pen-2": {
+			{Name: "Vela", Species: "Velociraptor", Diet: "Carnivore", Cage: wrapString("Raptor-Pen-2")},
+		},
+		"T-Rex-Pen": {
+			{Name: "TerryRex", Species: "Tyrannosaurus", Diet: "Carnivore", Cage: wrapString("T-Rex-Pen")},
+		},
+	}
+	for cageLabel, expectedDinosaurs := range expectedDinosaursInCages {
+		t.Run(fmt.Sprintf("dinosaurs in %s", cageLabel), func(t *testing.T) {
+			actualDinosaurs, _, err := dao.GetDinosaursInCage(cageLabel, models.Pagination{})
+			if err != nil {
+				t.Errorf("error when getting dinosaurs in cage: %s", err)
+				return
+			}
+			if len(actualDinosaurs) != len(expectedDinosaurs) {
+				t.Errorf("expected %d dinosaurs to be in the cage got %d", len(expectedDinosaurs), len(actualDinosaurs))
+				return
+			}
+			for i := range expectedDinosaurs {
+				assertDinosaursMatch(expectedDinosaurs[i], actualDinosaurs[i], t)
+			}
+		})
+	}
+}
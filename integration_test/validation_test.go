@@ -0,0 +1,245 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestValidation(t *testing.T) {
+	forEachBackend(t, testRequestValidation)
+}
+
+func testRequestValidation(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) {
+		w := sendWithCredentials(r, method, path, "X-Actor", "muldoon", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+	}
+	send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-1", MaxOccupancy: 2, HasPower: true}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "North"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/feeding/feeds", models.Feed{Name: "Goat", Diet: "Carnivore", Unit: "head", Stock: 10}, http.StatusCreated)
+
+	cases := []struct {
+		description    string
+		method         string
+		path           string
+		body           string
+		expectedFields []models.FieldError
+	}{
+		{
+			description: "create a cage without a label or capacity",
+			method:      "POST",
+			path:        "/jurassicpark/v1/cages",
+			body:        `{"hasPower": true}`,
+			expectedFields: []models.FieldError{
+				{Field: "label", Reason: "is required"},
+				{Field: "maxOccupancy", Reason: "must be greater than 0"},
+			},
+		},
+		{
+			description: "create a cage with a label that is too long, a negative capacity and an occupancy",
+			method:      "POST",
+			path:        "/jurassicpark/v2/cages",
+			body:        `{"label": "Raptor-Paddock-North", "maxOccupancy": -1, "occupancy": 3}`,
+			expectedFields: []models.FieldError{
+				{Field: "label", Reason: "must be at most 16 characters"},
+				{Field: "occupancy", Reason: "is set by the park and can't be given"},
+				{Field: "maxOccupancy", Reason: "must be greater than 0"},
+			},
+		},
+		{
+			description: "create a cage with a capacity that is not a number",
+			method:      "POST",
+			path:        "/jurassicpark/v1/cages",
+			body:        `{"label": "C-2", "maxOccupancy": "ten"}`,
+			expectedFields: []models.FieldError{
+				{Field: "maxOccupancy", Reason: "must be a whole number"},
+			},
+		},
+		{
+			description: "rename a cage to an empty label",
+			method:      "PATCH",
+			path:        "/jurassicpark/v1/cages/C-1",
+			body:        `{"label": "", "maxOccupancy": 0}`,
+			expectedFields: []models.FieldError{
+				{Field: "label", Reason: "can't be empty"},
+				{Field: "maxOccupancy", Reason: "must be greater than 0"},
+			},
+		},
+		{
+			description: "add a dinosaur without a name, with a diet and a cage",
+			method:      "POST",
+			path:        "/jurassicpark/v1/dinosaurs",
+			body:        `{"species": "Velociraptor", "diet": "Herbivore", "cage": "C-1"}`,
+			expectedFields: []models.FieldError{
+				{Field: "name", Reason: "is required"},
+				{Field: "diet", Reason: "is set by the park and can't be given"},
+				{Field: "cage", Reason: "is set by the park and can't be given"},
+			},
+		},
+		{
+			description: "add a dinosaur to a cage without naming it",
+			method:      "POST",
+			path:        "/jurassicpark/v1/cages/C-1/dinosaurs",
+			body:        `{}`,
+			expectedFields: []models.FieldError{
+				{Field: "name", Reason: "is required"},
+			},
+		},
+		{
+			description: "add a species without a diet",
+			method:      "POST",
+			path:        "/jurassicpark/v1/species",
+			body:        `{"name": "Dilophosaurus"}`,
+			expectedFields: []models.FieldError{
+				{Field: "diet", Reason: "is required"},
+			},
+		},
+		{
+			description: "add a feed without a name or unit and with negative stock",
+			method:      "POST",
+			path:        "/jurassicpark/v1/feeding/feeds",
+			body:        `{"diet": "Carnivore", "stock": -1, "lowStockThreshold": -2}`,
+			expectedFields: []models.FieldError{
+				{Field: "name", Reason: "is required"},
+				{Field: "unit", Reason: "is required"},
+				{Field: "stock", Reason: "must be at least 0"},
+				{Field: "lowStockThreshold", Reason: "must be at least 0"},
+			},
+		},
+		{
+			description: "restock a feed with nothing",
+			method:      "POST",
+			path:        "/jurassicpark/v1/feeding/feeds/Goat/restock",
+			body:        `{"quantity": 0}`,
+			expectedFields: []models.FieldError{
+				{Field: "quantity", Reason: "must be greater than 0"},
+			},
+		},
+		{
+			description: "schedule a feeding without a cage or feed at a time that does not exist",
+			method:      "POST",
+			path:        "/jurassicpark/v1/feeding/schedules",
+			body:        `{"time": "25:00", "quantity": 0}`,
+			expectedFields: []models.FieldError{
+				{Field: "cage", Reason: "is required"},
+				{Field: "feed", Reason: "is required"},
+				{Field: "time", Reason: "must be a 24 hour time such as 06:00"},
+				{Field: "quantity", Reason: "must be greater than 0"},
+			},
+		},
+		{
+			description: "add a zone without a name and with a description that is too long",
+			method:      "POST",
+			path:        "/jurassicpark/v1/zones",
+			body:        `{"description": "` + strings.Repeat("d", 256) + `"}`,
+			expectedFields: []models.FieldError{
+				{Field: "name", Reason: "is required"},
+				{Field: "description", Reason: "must be at most 255 characters"},
+			},
+		},
+		{
+			description: "describe a zone at too much length",
+			method:      "PATCH",
+			path:        "/jurassicpark/v1/zones/North",
+			body:        `{"description": "` + strings.Repeat("d", 256) + `"}`,
+			expectedFields: []models.FieldError{
+				{Field: "description", Reason: "must be at most 255 characters"},
+			},
+		},
+		{
+			description: "record a medication in the future without the medication and with a weight",
+			method:      "POST",
+			path:        "/jurassicpark/v1/dinosaurs/Blue/health-records",
+			body:        `{"type": "medication", "time": "2999-01-01T00:00:00Z", "weightKg": -3}`,
+			expectedFields: []models.FieldError{
+				{Field: "time", Reason: "can't be in the future"},
+				{Field: "weightKg", Reason: "must be greater than 0"},
+				{Field: "weightKg", Reason: "can only be given on weight records"},
+				{Field: "medication", Reason: "is required on medication records"},
+			},
+		},
+		{
+			description: "record a health record of an unknown type with a dosage",
+			method:      "POST",
+			path:        "/jurassicpark/v1/dinosaurs/Blue/health-records",
+			body:        `{"type": "checkup", "dosage": "10 mg"}`,
+			expectedFields: []models.FieldError{
+				{Field: "type", Reason: "must be one of weight, examination, treatment, medication, quarantine or release"},
+				{Field: "dosage", Reason: "can only be given on medication records"},
+			},
+		},
+		{
+			description: "subscribe an ftp URL to an unknown event with a secret that is too long",
+			method:      "POST",
+			path:        "/jurassicpark/v1/webhooks",
+			body:        `{"url": "ftp://security.example.com", "events": ["cage.created", "cage.escaped"], "secret": "` + strings.Repeat("s", 129) + `"}`,
+			expectedFields: []models.FieldError{
+				{Field: "url", Reason: "must be an absolute http or https URL"},
+				{Field: "events[1]", Reason: "must be one of " + strings.Join(events.Types[:len(events.Types)-1], ", ") + " or " + events.Types[len(events.Types)-1]},
+				{Field: "secret", Reason: "must be at most 128 characters"},
+			},
+		},
+		{
+			description: "subscribe to no events",
+			method:      "POST",
+			path:        "/jurassicpark/v1/webhooks",
+			body:        `{"url": "https://security.example.com/page"}`,
+			expectedFields: []models.FieldError{
+				{Field: "events", Reason: "can't be empty"},
+			},
+		},
+		{
+			description: "issue an API key with a name padded by spaces and an unknown role",
+			method:      "POST",
+			path:        "/jurassicpark/v1/apikeys",
+			body:        `{"name": " ops ", "roles": ["keeper", "janitor"]}`,
+			expectedFields: []models.FieldError{
+				{Field: "name", Reason: "can't start or end with spaces"},
+				{Field: "roles[1]", Reason: "must be one of viewer, keeper, power-operator, vet or admin"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := sendWithCredentials(r, c.method, c.path, "X-Actor", "muldoon", json.RawMessage(c.body))
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status code %d got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+			}
+			var problem models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("unexpected error decoding the problem: %s", err)
+			}
+			if problem.Code != models.CodeValidationFailed {
+				t.Errorf("expected code %s got %s", models.CodeValidationFailed, problem.Code)
+			}
+			if !reflect.DeepEqual(problem.Fields, c.expectedFields) {
+				t.Errorf("expected fields %v got %v", c.expectedFields, problem.Fields)
+			}
+		})
+	}
+
+	w := sendWithCredentials(r, "GET", "/jurassicpark/v1/cages/C-1", "X-Actor", "muldoon", nil)
+	var cage models.Cage
+	json.Unmarshal(w.Body.Bytes(), &cage)
+	if cage.Label != "C-1" || cage.MaxOccupancy != 2 {
+		t.Errorf("expected the invalid requests to leave C-1 unchanged, got %v", cage)
+	}
+}
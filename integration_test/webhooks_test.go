@@ -0,0 +1,438 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/api"
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// fastRetries keeps the retry tests quick.
+var fastRetries = webhooks.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     40 * time.Millisecond,
+}
+
+// receivedDelivery is a delivery as it arrived at the test receiver.
+type receivedDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver is a local receiver that responds to each delivery with the next of its status codes, repeating
+// the last one once they run out.
+type webhookReceiver struct {
+	server      *httptest.Server
+	mu          sync.Mutex
+	statusCodes []int
+	received    []receivedDelivery
+}
+
+func newWebhookReceiver(statusCodes ...int) *webhookReceiver {
+	receiver := &webhookReceiver{statusCodes: statusCodes}
+	receiver.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+
+		receiver.received = append(receiver.received, receivedDelivery{header: r.Header.Clone(), body: body})
+		statusCode := receiver.statusCodes[0]
+		if len(receiver.statusCodes) > 1 {
+			receiver.statusCodes = receiver.statusCodes[1:]
+		}
+		w.WriteHeader(statusCode)
+	}))
+	return receiver
+}
+
+func (r *webhookReceiver) deliveries() []receivedDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]receivedDelivery{}, r.received...)
+}
+
+// runDispatcher delivers the events published to the broker until the returned function is called.
+func runDispatcher(backend parkBackend, broker *events.Broker) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	dispatcher := webhooks.NewDispatcher(backend.Park(), broker,
+		webhooks.WithRetryPolicy(fastRetries),
+		webhooks.WithPollInterval(10*time.Millisecond))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// waitForDeliveries waits for the webhook to have count deliveries with the status.
+func waitForDeliveries(t *testing.T, dao testPark, webhookId int, status models.DeliveryStatus, count int) []models.WebhookDelivery {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, _, err := dao.GetWebhookDeliveries(webhookId, models.WebhookDeliveryFilter{Status: &status})
+		if err != nil {
+			t.Fatalf("error when reading deliveries: %s", err)
+		}
+		if len(deliveries) >= count {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d %s deliveries got %d", count, status, len(deliveries))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhookSubscriptions(t *testing.T) {
+	forEachBackend(t, testWebhookSubscriptions)
+}
+
+func testWebhookSubscriptions(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	createCases := []struct {
+		description        string
+		webhook            models.Webhook
+		expectedStatusCode int
+	}{
+		{
+			description:        "valid webhook",
+			webhook:            models.Webhook{URL: "https://security.example.com/page", Events: []string{events.CagePowerCutRefused}},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			description:        "relative url",
+			webhook:            models.Webhook{URL: "/page", Events: []string{events.CagePowerCutRefused}},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "unsupported scheme",
+			webhook:            models.Webhook{URL: "ftp://security.example.com/page", Events: []string{events.CagePowerCutRefused}},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "no events",
+			webhook:            models.Webhook{URL: "https://security.example.com/page"},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "unknown event",
+			webhook:            models.Webhook{URL: "https://security.example.com/page", Events: []string{"cage.exploded"}},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "secret too long",
+			webhook:            models.Webhook{URL: "https://security.example.com/page", Events: []string{events.CageCreated}, Secret: string(bytes.Repeat([]byte("s"), 129))},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+	var created models.Webhook
+	for _, c := range createCases {
+		t.Run(c.description, func(t *testing.T) {
+			body, _ := json.Marshal(c.webhook)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/jurassicpark/v1/webhooks", bytes.NewReader(body))
+			r.ServeHTTP(w, req)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+				return
+			}
+			if w.Code == http.StatusCreated {
+				json.Unmarshal(w.Body.Bytes(), &created)
+			}
+		})
+	}
+	if len(created.Secret) != 64 {
+		t.Errorf("expected a generated secret to be returned when the webhook is created got %q", created.Secret)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jurassicpark/v1/webhooks", nil)
+	r.ServeHTTP(w, req)
+	listed := []models.Webhook{}
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if w.Code != http.StatusOK || len(listed) != 1 {
+		t.Errorf("expected the webhook to be listed got status code %d and %v", w.Code, listed)
+	} else if listed[0].ID != created.ID || listed[0].Secret != "" || !reflect.DeepEqual(listed[0].Events, []string{events.CagePowerCutRefused}) {
+		t.Errorf("expected webhook %d without its secret got %+v", created.ID, listed[0])
+	}
+
+	getCases := []struct {
+		description        string
+		method             string
+		path               string
+		expectedStatusCode int
+	}{
+		{"get webhook", "GET", fmt.Sprintf("/jurassicpark/v2/webhooks/%d", created.ID), http.StatusOK},
+		{"get deliveries", "GET", fmt.Sprintf("/jurassicpark/v1/webhooks/%d/deliveries", created.ID), http.StatusOK},
+		{"invalid delivery status", "GET", fmt.Sprintf("/jurassicpark/v1/webhooks/%d/deliveries?status=LOST", created.ID), http.StatusUnprocessableEntity},
+		{"id that isn't a number", "GET", "/jurassicpark/v1/webhooks/first", http.StatusNotFound},
+		{"delete webhook", "DELETE", fmt.Sprintf("/jurassicpark/v1/webhooks/%d", created.ID), http.StatusOK},
+		{"get deleted webhook", "GET", fmt.Sprintf("/jurassicpark/v1/webhooks/%d", created.ID), http.StatusNotFound},
+		{"deliveries of deleted webhook", "GET", fmt.Sprintf("/jurassicpark/v1/webhooks/%d/deliveries", created.ID), http.StatusNotFound},
+		{"delete deleted webhook", "DELETE", fmt.Sprintf("/jurassicpark/v1/webhooks/%d", created.ID), http.StatusNotFound},
+	}
+	for _, c := range getCases {
+		t.Run(c.description, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(c.method, c.path, nil)
+			r.ServeHTTP(w, req)
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("expected status code %d got %d", c.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	forEachBackend(t, testWebhookDelivery)
+}
+
+func testWebhookDelivery(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	receiver := newWebhookReceiver(http.StatusNoContent)
+	defer receiver.server.Close()
+
+	broker := events.NewBroker(events.DefaultReplaySize)
+	r := gin.New()
+	backend.NewAPI(r, api.WithEvents(broker))
+	stop := runDispatcher(backend, broker)
+	defer stop()
+
+	dao := backend.Park()
+	webhook, err := dao.AddWebhook(context.Background(), models.Webhook{
+		URL:    receiver.server.URL,
+		Events: []string{events.CagePowerCutRefused, events.CagePowerChanged},
+		Secret: "security-secret",
+	})
+	if err != nil {
+		t.Errorf("error when adding the webhook: %s", err)
+		return
+	}
+	if err := dao.AddCage(context.Background(), models.Cage{Label: "Rex-Paddock", MaxOccupancy: 1, PowerStatus: models.PowerStatusActive}); err != nil {
+		t.Errorf("error when adding the cage: %s", err)
+		return
+	}
+	if err := dao.AddDinosaur(context.Background(), models.Dinosaur{Name: "Rexy", Species: "Tyrannosaurus"}); err != nil {
+		t.Errorf("error when adding the dinosaur: %s", err)
+		return
+	}
+	if err := dao.AddDinosaurToCage(context.Background(), "Rexy", "Rex-Paddock"); err != nil {
+		t.Errorf("error when adding the dinosaur to the cage: %s", err)
+		return
+	}
+
+	updates := []struct {
+		powerStatus        models.PowerStatus
+		expectedStatusCode int
+	}{
+		{models.PowerStatusDown, http.StatusConflict},
+		{models.PowerStatusMaintenance, http.StatusOK},
+	}
+	for _, update := range updates {
+		powerStatus := update.powerStatus
+		body, _ := json.Marshal(models.UpdateCageV2Request{PowerStatus: &powerStatus})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", "/jurassicpark/v2/cages/Rex-Paddock", bytes.NewReader(body))
+		r.ServeHTTP(w, req)
+		if w.Code != update.expectedStatusCode {
+			t.Errorf("expected status code %d when moving to %s got %d", update.expectedStatusCode, powerStatus, w.Code)
+			return
+		}
+	}
+
+	delivered := waitForDeliveries(t, dao, webhook.ID, models.DeliveryDelivered, 2)
+	for _, delivery := range delivered {
+		if delivery.Attempts != 1 || delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusNoContent {
+			t.Errorf("expected delivery %d to succeed on the first attempt got %+v", delivery.ID, delivery)
+		}
+	}
+
+	expectedEvents := []struct {
+		eventType string
+		data      string
+	}{
+		{events.CagePowerCutRefused, `{"label":"Rex-Paddock","occupancy":1,"requestedPowerStatus":"DOWN"}`},
+		{events.CagePowerChanged, `{"label":"Rex-Paddock","occupancy":1,"maxOccupancy":1,"powerStatus":"MAINTENANCE"}`},
+	}
+	received := receiver.deliveries()
+	if len(received) != len(expectedEvents) {
+		t.Errorf("expected %d deliveries to be received got %d", len(expectedEvents), len(received))
+		return
+	}
+	// the deliveries are sent at the same time, so they can arrive in either order
+	sort.Slice(received, func(i, j int) bool {
+		a, _ := strconv.Atoi(received[i].header.Get(webhooks.DeliveryHeader))
+		b, _ := strconv.Atoi(received[j].header.Get(webhooks.DeliveryHeader))
+		return a < b
+	})
+	for i, expected := range expectedEvents {
+		header := received[i].header
+		if !webhooks.Verify("security-secret", header.Get(webhooks.TimestampHeader), received[i].body, header.Get(webhooks.SignatureHeader)) {
+			t.Errorf("expected delivery %d to have a valid signature", i)
+		}
+		if header.Get(webhooks.EventHeader) != expected.eventType {
+			t.Errorf("expected event %s got %s", expected.eventType, header.Get(webhooks.EventHeader))
+		}
+		if header.Get(webhooks.DeliveryHeader) == "" {
+			t.Errorf("expected delivery %d to have a delivery id", i)
+		}
+		var payload struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		json.Unmarshal(received[i].body, &payload)
+		if payload.Type != expected.eventType || string(payload.Data) != expected.data {
+			t.Errorf("expected %s with %s got %s with %s", expected.eventType, expected.data, payload.Type, payload.Data)
+		}
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	forEachBackend(t, testWebhookRetries)
+}
+
+func testWebhookRetries(t *testing.T, backend parkBackend) {
+	cases := []struct {
+		description            string
+		statusCodes            []int
+		expectedStatus         models.DeliveryStatus
+		expectedAttempts       int
+		expectedResponseStatus int
+	}{
+		{
+			description:            "delivered after failing twice",
+			statusCodes:            []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			expectedStatus:         models.DeliveryDelivered,
+			expectedAttempts:       3,
+			expectedResponseStatus: http.StatusOK,
+		},
+		{
+			description:            "dead-lettered once out of attempts",
+			statusCodes:            []int{http.StatusInternalServerError},
+			expectedStatus:         models.DeliveryDeadLettered,
+			expectedAttempts:       fastRetries.MaxAttempts,
+			expectedResponseStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := backend.Reset()
+			if err != nil {
+				t.Errorf("error when clearing out test database: %s", err)
+				return
+			}
+			receiver := newWebhookReceiver(c.statusCodes...)
+			defer receiver.server.Close()
+
+			broker := events.NewBroker(events.DefaultReplaySize)
+			r := gin.New()
+			backend.NewAPI(r, api.WithEvents(broker))
+			stop := runDispatcher(backend, broker)
+			defer stop()
+
+			body, _ := json.Marshal(models.Webhook{URL: receiver.server.URL, Events: []string{events.CageDeleted}})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/jurassicpark/v1/webhooks", bytes.NewReader(body))
+			r.ServeHTTP(w, req)
+			var webhook models.Webhook
+			json.Unmarshal(w.Body.Bytes(), &webhook)
+
+			broker.Publish(events.CageDeleted, models.DeletedCage{Label: "Alpha-Pen"})
+			waitForDeliveries(t, backend.Park(), webhook.ID, c.expectedStatus, 1)
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/jurassicpark/v1/webhooks/%d/deliveries?status=%s&includeTotal=true", webhook.ID, c.expectedStatus), nil)
+			r.ServeHTTP(w, req)
+			deliveries := []models.WebhookDelivery{}
+			json.Unmarshal(w.Body.Bytes(), &deliveries)
+			if w.Code != http.StatusOK || len(deliveries) != 1 || w.Header().Get("X-Total-Count") != "1" {
+				t.Errorf("expected one %s delivery in the log got status code %d and %v", c.expectedStatus, w.Code, deliveries)
+				return
+			}
+			delivery := deliveries[0]
+			if delivery.Attempts != c.expectedAttempts {
+				t.Errorf("expected %d attempts got %d", c.expectedAttempts, delivery.Attempts)
+			}
+			if delivery.ResponseStatus == nil || *delivery.ResponseStatus != c.expectedResponseStatus {
+				t.Errorf("expected the last response to be %d got %v", c.expectedResponseStatus, delivery.ResponseStatus)
+			}
+			if delivery.CompletedTime == nil || delivery.NextAttemptTime != nil {
+				t.Errorf("expected the delivery to be complete got %+v", delivery)
+			}
+			if len(receiver.deliveries()) != c.expectedAttempts {
+				t.Errorf("expected the receiver to get %d attempts got %d", c.expectedAttempts, len(receiver.deliveries()))
+			}
+			if c.expectedStatus == models.DeliveryDeadLettered && delivery.LastError == "" {
+				t.Errorf("expected the error of the last attempt to be recorded")
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := webhooks.RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, backoff := range expected {
+		attempts := i + 1
+		if actual := policy.Backoff(attempts); actual != backoff {
+			t.Errorf("expected a backoff of %s after %d attempts got %s", backoff, attempts, actual)
+		}
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	body := []byte(`{"id":1,"type":"cage.powerCutRefused"}`)
+	signature := webhooks.Sign("security-secret", timestamp, body)
+
+	cases := []struct {
+		description string
+		secret      string
+		timestamp   string
+		body        []byte
+		expected    bool
+	}{
+		{"valid signature", "security-secret", timestamp, body, true},
+		{"wrong secret", "other-secret", timestamp, body, false},
+		{"tampered body", "security-secret", timestamp, []byte(`{"id":1,"type":"cage.powerChanged"}`), false},
+		{"different timestamp", "security-secret", "0", body, false},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if actual := webhooks.Verify(c.secret, c.timestamp, c.body, signature); actual != c.expected {
+				t.Errorf("expected the signature to be valid: %t got %t", c.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestZones(t *testing.T) {
+	forEachBackend(t, testZones)
+}
+
+func testZones(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) []byte {
+		w := sendWithCredentials(r, method, path, "X-Actor", "muldoon", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Sector 4", Description: "the raptor sector"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Paddock 9", Parent: wrapString("Sector 4")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Raptor Pen", Parent: wrapString("Paddock 9")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Aviary"}, http.StatusCreated)
+
+	cases := []struct {
+		description        string
+		method             string
+		path               string
+		body               any
+		expectedStatusCode int
+	}{
+		{
+			description:        "add a zone without a name",
+			method:             "POST",
+			path:               "/jurassicpark/v1/zones",
+			body:               models.Zone{Description: "nowhere"},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "add a zone with a name that is too long",
+			method:             "POST",
+			path:               "/jurassicpark/v1/zones",
+			body:               models.Zone{Name: strings.Repeat("a", 65)},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "add a zone that already exists",
+			method:             "POST",
+			path:               "/jurassicpark/v1/zones",
+			body:               models.Zone{Name: "Aviary"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "add a zone in a zone that does not exist",
+			method:             "POST",
+			path:               "/jurassicpark/v1/zones",
+			body:               models.Zone{Name: "Paddock 10", Parent: wrapString("Sector 5")},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "add a zone in itself",
+			method:             "POST",
+			path:               "/jurassicpark/v1/zones",
+			body:               models.Zone{Name: "Paddock 10", Parent: wrapString("Paddock 10")},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "get a zone that does not exist",
+			method:             "GET",
+			path:               "/jurassicpark/v1/zones/Sector%205",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "move a zone into a zone inside it",
+			method:             "PATCH",
+			path:               "/jurassicpark/v1/zones/Sector%204",
+			body:               models.UpdateZoneRequest{Parent: wrapString("Raptor Pen")},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "move a zone into itself",
+			method:             "PATCH",
+			path:               "/jurassicpark/v1/zones/Aviary",
+			body:               models.UpdateZoneRequest{Parent: wrapString("Aviary")},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "update a zone without any changes",
+			method:             "PATCH",
+			path:               "/jurassicpark/v1/zones/Aviary",
+			body:               models.UpdateZoneRequest{},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			description:        "update a zone that does not exist",
+			method:             "PATCH",
+			path:               "/jurassicpark/v1/zones/Sector%205",
+			body:               models.UpdateZoneRequest{Description: wrapString("gone")},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			description:        "delete a zone with zones in it",
+			method:             "DELETE",
+			path:               "/jurassicpark/v1/zones/Paddock%209",
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			description:        "delete a zone that does not exist",
+			method:             "DELETE",
+			path:               "/jurassicpark/v1/zones/Sector%205",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			send(c.method, c.path, c.body, c.expectedStatusCode)
+		})
+	}
+
+	t.Run("move a zone", func(t *testing.T) {
+		var zone models.Zone
+		body := send("PATCH", "/jurassicpark/v1/zones/Raptor%20Pen", models.UpdateZoneRequest{Parent: wrapString("Sector 4")}, http.StatusOK)
+		if err := json.Unmarshal(body, &zone); err != nil {
+			t.Fatalf("failed to read the zone: %s", err)
+		}
+		if zone.Parent == nil || *zone.Parent != "Sector 4" {
+			t.Errorf("expected Raptor Pen to be in Sector 4 got %v", zone.Parent)
+		}
+		var moved models.Zone
+		body = send("PATCH", "/jurassicpark/v1/zones/Raptor%20Pen", models.UpdateZoneRequest{Parent: wrapString("")}, http.StatusOK)
+		if err := json.Unmarshal(body, &moved); err != nil {
+			t.Fatalf("failed to read the zone: %s", err)
+		}
+		if moved.Parent != nil {
+			t.Errorf("expected Raptor Pen to be at the top of the park got %s", *moved.Parent)
+		}
+	})
+
+	t.Run("delete a zone", func(t *testing.T) {
+		send("DELETE", "/jurassicpark/v1/zones/Raptor%20Pen", nil, http.StatusOK)
+		send("DELETE", "/jurassicpark/v1/zones/Paddock%209", nil, http.StatusOK)
+		send("GET", "/jurassicpark/v1/zones/Paddock%209", nil, http.StatusNotFound)
+	})
+
+	t.Run("audit log", func(t *testing.T) {
+		events, _, err := backend.Park().GetAuditEvents(models.AuditFilter{Entity: wrapString("zone:Raptor Pen")})
+		if err != nil {
+			t.Fatalf("error when reading the audit log: %s", err)
+		}
+		actions := []models.AuditAction{}
+		for _, event := range events {
+			actions = append(actions, event.Action)
+		}
+		expectedActions := []models.AuditAction{models.AuditCreate, models.AuditUpdate, models.AuditUpdate, models.AuditDelete}
+		if !reflect.DeepEqual(expectedActions, actions) {
+			t.Errorf("expected the actions %v got %v", expectedActions, actions)
+		}
+	})
+}
+
+func TestZoneCages(t *testing.T) {
+	forEachBackend(t, testZoneCages)
+}
+
+func testZoneCages(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) []byte {
+		w := sendWithCredentials(r, method, path, "X-Actor", "muldoon", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Sector 4"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Paddock 9", Parent: wrapString("Sector 4")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "Aviary"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-1", MaxOccupancy: 4, HasPower: true, Zone: wrapString("Sector 4")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v2/cages", models.CageV2{Label: "C-2", MaxOccupancy: 6, PowerStatus: models.PowerStatusMaintenance, Zone: wrapString("Paddock 9")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-3", MaxOccupancy: 2, HasPower: true}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-4", MaxOccupancy: 2, HasPower: true, Zone: wrapString("Sector 5")}, http.StatusUnprocessableEntity)
+	ctx := context.Background()
+	for _, name := range []string{"Blue", "Charlie"} {
+		if err := backend.Park().AddDinosaur(ctx, models.Dinosaur{Name: name, Species: "Velociraptor", Sex: models.SexFemale}); err != nil {
+			t.Fatalf("error when adding the dinosaur: %s", err)
+		}
+		if err := backend.Park().AddDinosaurToCage(ctx, name, "C-1"); err != nil {
+			t.Fatalf("error when adding the dinosaur to the cage: %s", err)
+		}
+	}
+
+	cagesIn := func(path string) []string {
+		var cages []models.CageV2
+		if err := json.Unmarshal(send("GET", path, nil, http.StatusOK), &cages); err != nil {
+			t.Fatalf("failed to read the cages: %s", err)
+		}
+		labels := []string{}
+		for _, cage := range cages {
+			labels = append(labels, cage.Label)
+		}
+		return labels
+	}
+	zoneSummary := func(name string) models.ZoneSummary {
+		var summary models.ZoneSummary
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/zones/"+name, nil, http.StatusOK), &summary); err != nil {
+			t.Fatalf("failed to read the zone: %s", err)
+		}
+		return summary
+	}
+
+	t.Run("filter cages by zone", func(t *testing.T) {
+		if labels := cagesIn("/jurassicpark/v1/cages?zone=Sector%204"); !reflect.DeepEqual(labels, []string{"C-1", "C-2"}) {
+			t.Errorf("expected the cages in Sector 4 and Paddock 9 got %v", labels)
+		}
+		if labels := cagesIn("/jurassicpark/v2/cages?zone=Paddock%209"); !reflect.DeepEqual(labels, []string{"C-2"}) {
+			t.Errorf("expected the cages in Paddock 9 got %v", labels)
+		}
+		if labels := cagesIn("/jurassicpark/v1/cages?zone=Aviary"); len(labels) != 0 {
+			t.Errorf("expected no cages in the Aviary got %v", labels)
+		}
+		send("GET", "/jurassicpark/v1/cages?zone=Sector%205", nil, http.StatusNotFound)
+	})
+
+	t.Run("summarize zones", func(t *testing.T) {
+		expected := models.ZoneSummary{
+			Zone:          models.Zone{Name: "Sector 4"},
+			Zones:         []string{"Paddock 9"},
+			Cages:         2,
+			Occupancy:     2,
+			MaxOccupancy:  10,
+			PowerStatuses: map[models.PowerStatus]int{models.PowerStatusActive: 1, models.PowerStatusMaintenance: 1},
+		}
+		if summary := zoneSummary("Sector%204"); !reflect.DeepEqual(expected, summary) {
+			t.Errorf("expected %+v got %+v", expected, summary)
+		}
+
+		var summaries []models.ZoneSummary
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/zones", nil, http.StatusOK), &summaries); err != nil {
+			t.Fatalf("failed to read the zones: %s", err)
+		}
+		names := []string{}
+		for _, summary := range summaries {
+			names = append(names, summary.Name)
+		}
+		if !reflect.DeepEqual([]string{"Aviary", "Paddock 9", "Sector 4"}, names) {
+			t.Errorf("expected every zone by name got %v", names)
+		}
+	})
+
+	t.Run("move cages between zones", func(t *testing.T) {
+		var cage models.CageV2
+		body := send("PATCH", "/jurassicpark/v2/cages/C-3", models.UpdateCageV2Request{Zone: wrapString("Aviary")}, http.StatusOK)
+		if err := json.Unmarshal(body, &cage); err != nil {
+			t.Fatalf("failed to read the cage: %s", err)
+		}
+		if cage.Zone == nil || *cage.Zone != "Aviary" {
+			t.Errorf("expected C-3 to be in the Aviary got %v", cage.Zone)
+		}
+		send("PATCH", "/jurassicpark/v1/cages/C-3", models.UpdateCageRequest{Zone: wrapString("Sector 5")}, http.StatusUnprocessableEntity)
+		send("DELETE", "/jurassicpark/v1/zones/Aviary", nil, http.StatusConflict)
+		if summary := zoneSummary("Aviary"); summary.Cages != 1 || summary.MaxOccupancy != 2 {
+			t.Errorf("expected C-3 to be counted in the Aviary got %+v", summary)
+		}
+
+		var unzoned models.Cage
+		body = send("PATCH", "/jurassicpark/v1/cages/C-3", models.UpdateCageRequest{Zone: wrapString("")}, http.StatusOK)
+		if err := json.Unmarshal(body, &unzoned); err != nil {
+			t.Fatalf("failed to read the cage: %s", err)
+		}
+		if unzoned.Zone != nil {
+			t.Errorf("expected C-3 to be taken out of its zone got %s", *unzoned.Zone)
+		}
+		send("DELETE", "/jurassicpark/v1/zones/Aviary", nil, http.StatusOK)
+	})
+}
+
+func TestZoneCageLabels(t *testing.T) {
+	forEachBackend(t, testZoneCageLabels)
+}
+
+func testZoneCageLabels(t *testing.T, backend parkBackend) {
+	err := backend.Reset()
+	if err != nil {
+		t.Errorf("error when clearing out test database: %s", err)
+		return
+	}
+	ctx := context.Background()
+	if err := backend.SetCageLabelScope(models.CageLabelsUniqueInZone); err != nil {
+		t.Fatalf("error when making cage labels unique in their zone: %s", err)
+	}
+	defer func() {
+		backend.Reset()
+		if err := backend.SetCageLabelScope(models.CageLabelsUniqueInPark); err != nil {
+			t.Errorf("error when making cage labels unique in the park: %s", err)
+		}
+	}()
+	r := gin.New()
+	backend.NewAPI(r)
+
+	send := func(method, path string, body any, expectedStatusCode int) []byte {
+		w := sendWithCredentials(r, method, path, "X-Actor", "muldoon", body)
+		if w.Code != expectedStatusCode {
+			t.Fatalf("expected %s %s to respond with %d got %d: %s", method, path, expectedStatusCode, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "North"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/zones", models.Zone{Name: "South"}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-1", MaxOccupancy: 2, HasPower: true, Zone: wrapString("North")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v2/cages", models.CageV2{Label: "C-1", MaxOccupancy: 4, PowerStatus: models.PowerStatusActive, Zone: wrapString("South")}, http.StatusCreated)
+	send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-1", MaxOccupancy: 6, HasPower: true}, http.StatusCreated)
+
+	t.Run("labels are unique within a zone", func(t *testing.T) {
+		send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-1", MaxOccupancy: 2, HasPower: true, Zone: wrapString("North")}, http.StatusConflict)
+		send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C-1", MaxOccupancy: 2, HasPower: true}, http.StatusConflict)
+		send("POST", "/jurassicpark/v1/cages", models.Cage{Label: "C:2", MaxOccupancy: 2, HasPower: true}, http.StatusUnprocessableEntity)
+		send("PATCH", "/jurassicpark/v2/cages/South:C-1", models.UpdateCageV2Request{Zone: wrapString("North")}, http.StatusConflict)
+		send("PATCH", "/jurassicpark/v2/cages/South:C-1", models.UpdateCageV2Request{Label: wrapString("C:2")}, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("cages are referred to by zone and label", func(t *testing.T) {
+		for path, expectedMaxOccupancy := range map[string]int{"North:C-1": 2, "South:C-1": 4, "C-1": 6} {
+			var cage models.Cage
+			if err := json.Unmarshal(send("GET", "/jurassicpark/v1/cages/"+path, nil, http.StatusOK), &cage); err != nil {
+				t.Fatalf("failed to read the cage: %s", err)
+			}
+			if cage.Label != "C-1" || cage.MaxOccupancy != expectedMaxOccupancy {
+				t.Errorf("expected %s to be C-1 with room for %d got %+v", path, expectedMaxOccupancy, cage)
+			}
+		}
+		send("GET", "/jurassicpark/v1/cages/East:C-1", nil, http.StatusNotFound)
+
+		if err := backend.Park().AddDinosaur(ctx, models.Dinosaur{Name: "Blue", Species: "Velociraptor", Sex: models.SexFemale}); err != nil {
+			t.Fatalf("error when adding the dinosaur: %s", err)
+		}
+		send("POST", "/jurassicpark/v1/cages/South:C-1/dinosaurs", models.AddDinosaurToCageRequest{Name: "Blue"}, http.StatusCreated)
+		var dinosaur models.Dinosaur
+		if err := json.Unmarshal(send("GET", "/jurassicpark/v1/dinosaurs/Blue", nil, http.StatusOK), &dinosaur); err != nil {
+			t.Fatalf("failed to read the dinosaur: %s", err)
+		}
+		if dinosaur.Cage == nil || *dinosaur.Cage != "South:C-1" {
+			t.Errorf("expected Blue to be in South:C-1 got %v", dinosaur.Cage)
+		}
+		if dinosaurs, _, err := backend.Park().GetDinosaursInCage("North:C-1", models.Pagination{}); err != nil || len(dinosaurs) != 0 {
+			t.Errorf("expected North:C-1 to be empty got %v %v", dinosaurs, err)
+		}
+	})
+
+	t.Run("labels can't be made unique in the park while they are shared", func(t *testing.T) {
+		err := backend.SetCageLabelScope(models.CageLabelsUniqueInPark)
+		if !errors.Is(err, models.EntityAlreadyExists) {
+			t.Errorf("expected %s got %v", models.EntityAlreadyExists, err)
+		}
+	})
+}
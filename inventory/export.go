@@ -0,0 +1,186 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/zones"
+)
+
+// exportPageSize is the number of cages or dinosaurs read at a time while exporting. Zones are read all at once.
+const exportPageSize = 500
+
+// Reader reads the zones, cages and dinosaurs that are exported.
+type Reader interface {
+	GetZones() ([]models.Zone, error)
+	GetCages(filter models.CageFilter) ([]models.Cage, models.PageInfo, error)
+	GetDinosaurs(filter models.DinosaurFilter) ([]models.Dinosaur, models.PageInfo, error)
+}
+
+// Writer writes rows to an export file.
+type Writer struct {
+	format models.InventoryFormat
+	out    io.Writer
+	csv    *csv.Writer
+}
+
+// NewWriter returns a writer for a file in the format. A CSV file starts with a header row naming every column.
+func NewWriter(format models.InventoryFormat, out io.Writer) (*Writer, error) {
+	w := &Writer{format: format, out: out}
+	switch format {
+	case models.InventoryFormatCSV:
+		w.csv = csv.NewWriter(out)
+		if err := w.csv.Write(Columns); err != nil {
+			return nil, err
+		}
+	case models.InventoryFormatNDJSON:
+	default:
+		return nil, fmt.Errorf("unknown inventory format %s", format)
+	}
+	return w, nil
+}
+
+// Write writes a row of the file.
+func (w *Writer) Write(row models.InventoryRow) error {
+	if w.csv != nil {
+		return w.csv.Write(csvRecord(row))
+	}
+	line, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	_, err = w.out.Write(append(line, '\n'))
+	return err
+}
+
+// Flush writes the buffered rows, and flushes the output if it is a response that is being streamed.
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := w.out.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func csvRecord(row models.InventoryRow) []string {
+	record := make([]string, 0, len(Columns))
+	for _, column := range Columns {
+		value := ""
+		switch column {
+		case "kind":
+			value = string(row.Kind)
+		case "label":
+			value = row.Label
+		case "maxOccupancy":
+			if row.MaxOccupancy != 0 {
+				value = strconv.Itoa(row.MaxOccupancy)
+			}
+		case "powerStatus":
+			value = string(row.PowerStatus)
+		case "zone":
+			if row.Zone != nil {
+				value = *row.Zone
+			}
+		case "name":
+			value = row.Name
+		case "species":
+			value = row.Species
+		case "sex":
+			value = string(row.Sex)
+		case "dinosaur":
+			value = row.Dinosaur
+		case "cage":
+			value = row.Cage
+		case "parent":
+			if row.Parent != nil {
+				value = *row.Parent
+			}
+		case "description":
+			value = row.Description
+		}
+		record = append(record, value)
+	}
+	return record
+}
+
+// Export writes every zone with parents before the zones nested in them, then every cage, then every dinosaur, then
+// the cage that each caged dinosaur is in, which is the order they can be imported in. The rows are read and written
+// a page at a time, and flushed after each page.
+func Export(reader Reader, w *Writer) error {
+	allZones, err := reader.GetZones()
+	if err != nil {
+		return err
+	}
+	for _, zone := range zones.ParentFirst(allZones) {
+		if err := w.Write(models.ZoneRow(zone)); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	cagePage := models.Pagination{Limit: exportPageSize}
+	for {
+		cages, pageInfo, err := reader.GetCages(models.CageFilter{Page: cagePage})
+		if err != nil {
+			return err
+		}
+		for _, cage := range cages {
+			if err := w.Write(models.CageRow(cage)); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if pageInfo.Next == nil {
+			break
+		}
+		cagePage.After = pageInfo.Next
+	}
+
+	err = exportDinosaurs(reader, w, models.DinosaurFilter{}, func(dinosaur models.Dinosaur) models.InventoryRow {
+		return models.DinosaurRow(dinosaur)
+	})
+	if err != nil {
+		return err
+	}
+	caged := false
+	return exportDinosaurs(reader, w, models.DinosaurFilter{NeedsCageAssignment: &caged}, func(dinosaur models.Dinosaur) models.InventoryRow {
+		return models.AssignmentRow(dinosaur.Name, *dinosaur.Cage)
+	})
+}
+
+// exportDinosaurs writes a row for every dinosaur that matches the filter.
+func exportDinosaurs(reader Reader, w *Writer, filter models.DinosaurFilter, toRow func(models.Dinosaur) models.InventoryRow) error {
+	filter.Page = models.Pagination{Limit: exportPageSize}
+	for {
+		dinosaurs, pageInfo, err := reader.GetDinosaurs(filter)
+		if err != nil {
+			return err
+		}
+		for _, dinosaur := range dinosaurs {
+			if err := w.Write(toRow(dinosaur)); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if pageInfo.Next == nil {
+			return nil
+		}
+		filter.Page.After = pageInfo.Next
+	}
+}
@@ -0,0 +1,276 @@
+// Package inventory imports and exports the park's zones, cages, dinosaurs and the cages that the dinosaurs are in,
+// as CSV or NDJSON files. Every imported row is added through the park with the same rules as the rest of the API.
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// MaxRows is the largest number of rows that can be imported at once.
+const MaxRows = 10000
+
+// maxLineLength is the longest line of an NDJSON file that can be read.
+const maxLineLength = 64 * 1024
+
+// Columns are the columns of a CSV file, in the order that they are exported in. An imported file can have its
+// columns in any order and leave out the ones it doesn't use, but it must have a kind column.
+var Columns = []string{"kind", "label", "maxOccupancy", "powerStatus", "zone", "name", "species", "sex", "dinosaur", "cage", "parent", "description"}
+
+// errRolledBack is returned from the import's transaction to roll it back once every row has been tried.
+var errRolledBack = errors.New("import rolled back")
+
+// Store makes the changes that rows are imported with.
+type Store interface {
+	AddZone(ctx context.Context, zone models.Zone) error
+	AddCage(ctx context.Context, cage models.Cage) error
+	AddDinosaur(ctx context.Context, dinosaur models.Dinosaur) error
+	AddDinosaurToCage(ctx context.Context, dinosaurName, targetCage string) error
+}
+
+// Park is a park that rows can be imported into.
+type Park interface {
+	// ImportTransaction calls fn with a store whose changes are made in a single transaction, which is committed if
+	// fn returns nil and rolled back otherwise. A change to the store that fails leaves the transaction as it was
+	// before the change, so the rows after it can still be tried.
+	ImportTransaction(ctx context.Context, fn func(store Store) error) error
+}
+
+// Row is a row read from an import file. Err is set when the row's values couldn't be read, in which case the row
+// fails without being tried.
+type Row struct {
+	Line int
+	models.InventoryRow
+	Err error
+}
+
+// Read reads the rows of an import file. Values that can't be read fail their row, but a file that isn't in the
+// format, or has more than MaxRows rows, is refused with an ImportFileError.
+func Read(format models.InventoryFormat, r io.Reader) ([]Row, error) {
+	switch format {
+	case models.InventoryFormatCSV:
+		return readCSV(r)
+	case models.InventoryFormatNDJSON:
+		return readNDJSON(r)
+	default:
+		return nil, fmt.Errorf("unknown inventory format %s", format)
+	}
+}
+
+func readCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []Row{}, nil
+	}
+	if err != nil {
+		return nil, csvFileError(err)
+	}
+	hasKind := false
+	for _, column := range header {
+		if !isColumn(column) {
+			return nil, &models.ImportFileError{Line: 1, Reason: fmt.Sprintf("unknown column %s, the columns are %s", column, strings.Join(Columns, ", "))}
+		}
+		hasKind = hasKind || column == "kind"
+	}
+	if !hasKind {
+		return nil, &models.ImportFileError{Line: 1, Reason: "the header must have a kind column"}
+	}
+
+	rows := []Row{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, csvFileError(err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rows) == MaxRows {
+			return nil, tooManyRows(line)
+		}
+		row := Row{Line: line}
+		fields := []models.FieldError{}
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+			switch header[i] {
+			case "kind":
+				row.Kind = models.InventoryKind(value)
+			case "label":
+				row.Label = value
+			case "maxOccupancy":
+				maxOccupancy, err := strconv.Atoi(value)
+				if err != nil {
+					fields = append(fields, models.FieldError{Field: "maxOccupancy", Reason: "must be a whole number"})
+				}
+				row.MaxOccupancy = maxOccupancy
+			case "powerStatus":
+				row.PowerStatus = models.PowerStatus(value)
+			case "zone":
+				zone := value
+				row.Zone = &zone
+			case "name":
+				row.Name = value
+			case "species":
+				row.Species = value
+			case "sex":
+				row.Sex = models.Sex(value)
+			case "dinosaur":
+				row.Dinosaur = value
+			case "cage":
+				row.Cage = value
+			case "parent":
+				parent := value
+				row.Parent = &parent
+			case "description":
+				row.Description = value
+			}
+		}
+		if len(fields) > 0 {
+			row.Err = &models.ValidationError{Fields: fields}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func isColumn(name string) bool {
+	for _, column := range Columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+func csvFileError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &models.ImportFileError{Line: parseErr.StartLine, Reason: parseErr.Err.Error()}
+	}
+	return err
+}
+
+func readNDJSON(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineLength)
+	rows := []Row{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows) == MaxRows {
+			return nil, tooManyRows(line)
+		}
+		row := Row{Line: line}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&row.InventoryRow)
+		if err == nil && decoder.More() {
+			err = errors.New("more than one JSON object on the line")
+		}
+		if err != nil {
+			typeErr := models.JSONTypeError(err)
+			if typeErr == nil {
+				return nil, &models.ImportFileError{Line: line, Reason: err.Error()}
+			}
+			row.Err = typeErr
+		}
+		rows = append(rows, row)
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return nil, &models.ImportFileError{Line: line + 1, Reason: fmt.Sprintf("the line is longer than %d bytes", maxLineLength)}
+	}
+	return rows, scanner.Err()
+}
+
+func tooManyRows(line int) error {
+	return &models.ImportFileError{Line: line, Reason: fmt.Sprintf("a file can't have more than %d rows", MaxRows)}
+}
+
+// Import tries every row in a single transaction, in the order they are in the file, so a row can refer to the
+// zones, cages and dinosaurs that the rows before it added. The transaction is committed unless the import is a dry run,
+// or in atomic mode when any row failed.
+func Import(ctx context.Context, park Park, rows []Row, options models.ImportOptions) (*models.ImportReport, error) {
+	report := &models.ImportReport{
+		Mode:   options.Mode,
+		DryRun: options.DryRun,
+	}
+	err := park.ImportTransaction(ctx, func(store Store) error {
+		report.Rows = []models.ImportRowResult{}
+		report.Applied, report.Failed = 0, 0
+		for _, row := range rows {
+			result := models.ImportRowResult{Line: row.Line, Row: row.InventoryRow, Status: models.ImportStatusApplied}
+			result.Err = row.Err
+			if result.Err == nil {
+				result.Err = apply(ctx, store, row.InventoryRow)
+			}
+			if result.Err != nil {
+				result.Status = models.ImportStatusFailed
+				report.Failed++
+			} else {
+				report.Applied++
+			}
+			report.Rows = append(report.Rows, result)
+		}
+		if options.DryRun || (options.Mode == models.ImportModeAtomic && report.Failed > 0) {
+			return errRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRolledBack) {
+		return nil, err
+	}
+	report.Committed = err == nil
+	return report, nil
+}
+
+// apply checks a row against the validate tags of what it adds, and adds it to the store.
+func apply(ctx context.Context, store Store, row models.InventoryRow) error {
+	switch row.Kind {
+	case models.InventoryKindZone:
+		zone := row.ZoneModel()
+		if err := models.ValidateRequest(zone); err != nil {
+			return err
+		}
+		return store.AddZone(ctx, zone)
+	case models.InventoryKindCage:
+		cage := row.CageV2()
+		if err := models.ValidateRequest(cage); err != nil {
+			return err
+		}
+		return store.AddCage(ctx, cage.Cage())
+	case models.InventoryKindDinosaur:
+		dinosaur := row.DinosaurModel()
+		if err := models.ValidateRequest(dinosaur); err != nil {
+			return err
+		}
+		return store.AddDinosaur(ctx, dinosaur)
+	case models.InventoryKindAssignment:
+		assignment := row.Assignment()
+		if err := models.ValidateRequest(assignment); err != nil {
+			return err
+		}
+		return store.AddDinosaurToCage(ctx, assignment.Dinosaur, assignment.Cage)
+	default:
+		return &models.ValidationError{Fields: []models.FieldError{{
+			Field:  "kind",
+			Reason: fmt.Sprintf("must be one of %s, %s, %s or %s", models.InventoryKindZone, models.InventoryKindCage, models.InventoryKindDinosaur, models.InventoryKindAssignment),
+		}}}
+	}
+}
@@ -1,10 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/EdgarH78/jurassic-park/api"
+	"github.com/EdgarH78/jurassic-park/auth"
 	"github.com/EdgarH78/jurassic-park/data"
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/rules"
+	"github.com/EdgarH78/jurassic-park/webhooks"
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,6 +32,25 @@ var (
 	sqlUser         = getEnvWithFallback("SQL_USER", "admin")
 	sqlPassword     = getEnvWithFallback("SQL_PASSWORD", "password")
 	sqlDatabaseName = getEnvWithFallback("SQL_DATABASE_NAME", "jurassicpark")
+	// set to false when migrations are run separately with the migrate subcommand
+	migrateOnStartup = getEnvWithFallback("MIGRATE_ON_STARTUP", "true") == "true"
+	// set to false to let males and females of the same species share a cage
+	preventBreeding = getEnvWithFallback("PREVENT_BREEDING", "true") == "true"
+	// set to zone to let cages in different zones share a label, which are then referred to as zone:label
+	cageLabelScope = models.CageLabelScope(getEnvWithFallback("CAGE_LABEL_SCOPE", string(models.CageLabelsUniqueInPark)))
+	// a YAML file of species compatibility rules, which are read from the database when it isn't set
+	rulesFile = getEnvWithFallback("RULES_FILE", "")
+	// the JWKS that bearer tokens are checked against, read from a file or fetched from a URL at startup. Bearer
+	// tokens are only accepted when one of them is set.
+	jwksFile = getEnvWithFallback("JWKS_FILE", "")
+	jwksURL  = getEnvWithFallback("JWKS_URL", "")
+	// the iss and aud claims that bearer tokens must have
+	jwtIssuer   = getEnvWithFallback("JWT_ISSUER", "")
+	jwtAudience = getEnvWithFallback("JWT_AUDIENCE", "")
+	// the claim that the roles of a bearer token are read from
+	jwtRolesClaim = getEnvWithFallback("JWT_ROLES_CLAIM", auth.DefaultRolesClaim)
+	// maps values of the roles claim to park roles, as in park-ops=keeper+power-operator,security=viewer
+	jwtRoleMapping = getEnvWithFallback("JWT_ROLE_MAPPING", "")
 )
 
 func main() {
@@ -30,11 +60,129 @@ func main() {
 		Host:         sqlHost,
 		DatabaseName: sqlDatabaseName,
 	}
-	parkSqlDao, err := data.NewParkSqlDao(sqlConfig)
+	db, err := data.OpenDB(sqlConfig)
 	if err != nil {
 		panic(err)
 	}
+
+	if !cageLabelScope.IsValid() {
+		panic(fmt.Sprintf("CAGE_LABEL_SCOPE must be %s or %s", models.CageLabelsUniqueInPark, models.CageLabelsUniqueInZone))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(db, cageLabelScope, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if migrateOnStartup {
+		migrator, err := data.NewMigrator(db, data.WithCageLabelScope(cageLabelScope))
+		if err != nil {
+			panic(err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			panic(err)
+		}
+	}
+
+	parkSqlDao := data.NewParkSqlDaoFromDB(db)
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKey(parkSqlDao, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	parkSqlDao.SetBreedingPolicy(models.BreedingPolicy{
+		PreventBreeding: preventBreeding,
+	})
+	if err := parkSqlDao.SetCageLabelScope(context.Background(), cageLabelScope); err != nil {
+		panic(err)
+	}
+	compatibilityRules, err := loadCompatibilityRules(parkSqlDao)
+	if err != nil {
+		panic(err)
+	}
+	parkSqlDao.SetCompatibilityRules(rules.NewEngine(compatibilityRules))
+
+	broker := events.NewBroker(events.DefaultReplaySize)
+	go webhooks.NewDispatcher(parkSqlDao, broker).Run(context.Background())
+
+	authenticators := []auth.Authenticator{auth.NewAPIKeyAuthenticator(parkSqlDao)}
+	jwtAuthenticator, err := loadJWTAuthenticator()
+	if err != nil {
+		panic(err)
+	}
+	if jwtAuthenticator != nil {
+		authenticators = append(authenticators, jwtAuthenticator)
+	}
+
 	engine := gin.Default()
-	api := api.NewAPI(parkSqlDao, engine)
+	api := api.NewAPI(parkSqlDao, engine,
+		api.WithEvents(broker),
+		api.WithAuthenticators(authenticators...))
 	api.Run()
 }
+
+func loadCompatibilityRules(parkSqlDao *data.ParkSqlDao) (rules.Rules, error) {
+	if rulesFile != "" {
+		return rules.LoadFile(rulesFile)
+	}
+	return parkSqlDao.GetCompatibilityRules()
+}
+
+// loadJWTAuthenticator loads the JWKS that bearer tokens are checked against. It returns nil when bearer tokens
+// aren't configured.
+func loadJWTAuthenticator() (*auth.JWTAuthenticator, error) {
+	if jwksFile == "" && jwksURL == "" {
+		return nil, nil
+	}
+	if jwtIssuer == "" || jwtAudience == "" {
+		return nil, errors.New("JWT_ISSUER and JWT_AUDIENCE must be set to accept bearer tokens")
+	}
+	roleMapping, err := parseRoleMapping(jwtRoleMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys *auth.KeySet
+	if jwksFile != "" {
+		keys, err = auth.LoadJWKSFile(jwksFile)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		keys, err = auth.FetchJWKS(ctx, http.DefaultClient, jwksURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewJWTAuthenticator(keys, auth.JWTConfig{
+		Issuer:      jwtIssuer,
+		Audience:    jwtAudience,
+		RolesClaim:  jwtRolesClaim,
+		RoleMapping: roleMapping,
+		Leeway:      time.Minute,
+	}), nil
+}
+
+// parseRoleMapping reads JWT_ROLE_MAPPING, a comma separated list of claim values that are each followed by an
+// equals sign and the park roles they grant, separated by plus signs. It returns nil when the mapping is empty.
+func parseRoleMapping(mapping string) (map[string][]models.Role, error) {
+	if mapping == "" {
+		return nil, nil
+	}
+	roleMapping := map[string][]models.Role{}
+	for _, entry := range strings.Split(mapping, ",") {
+		value, roles, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || value == "" {
+			return nil, fmt.Errorf("JWT_ROLE_MAPPING entry %q must be a claim value followed by = and its roles", entry)
+		}
+		for _, role := range strings.Split(roles, "+") {
+			if !models.IsValidRole(models.Role(role)) {
+				return nil, fmt.Errorf("JWT_ROLE_MAPPING entry %q has the unknown role %q", entry, role)
+			}
+			roleMapping[value] = append(roleMapping[value], models.Role(role))
+		}
+	}
+	return roleMapping, nil
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func (m *ParkMemoryDao) AddAPIKey(ctx context.Context, apiKey models.APIKey) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.apiKeys {
+		if existing.Name == apiKey.Name || existing.KeyHash == apiKey.KeyHash {
+			return nil, models.EntityAlreadyExists
+		}
+	}
+	m.lastId++
+	created := models.APIKey{
+		ID:          m.lastId,
+		Name:        apiKey.Name,
+		Roles:       append([]models.Role{}, apiKey.Roles...),
+		Prefix:      apiKey.Prefix,
+		KeyHash:     apiKey.KeyHash,
+		CreatedTime: time.Now().UTC(),
+	}
+	m.apiKeys = append(m.apiKeys, &created)
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityAPIKey, strconv.Itoa(created.ID)), models.AuditCreate, nil, created)
+	if err != nil {
+		return nil, err
+	}
+	return copyAPIKey(created), nil
+}
+
+func (m *ParkMemoryDao) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, apiKey := range m.apiKeys {
+		if apiKey.KeyHash == keyHash {
+			return copyAPIKey(*apiKey), nil
+		}
+	}
+	return nil, models.EntityNotFound
+}
+
+// GetAPIKeys lists every API key, including the revoked ones.
+func (m *ParkMemoryDao) GetAPIKeys() ([]models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	apiKeys := []models.APIKey{}
+	for _, apiKey := range m.apiKeys {
+		apiKeys = append(apiKeys, *copyAPIKey(*apiKey))
+	}
+	return apiKeys, nil
+}
+
+// RevokeAPIKey stops the API key from authenticating. Revoking a key that is already revoked changes nothing.
+func (m *ParkMemoryDao) RevokeAPIKey(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, apiKey := range m.apiKeys {
+		if apiKey.ID != id {
+			continue
+		}
+		if apiKey.RevokedTime != nil {
+			return nil
+		}
+		before := *copyAPIKey(*apiKey)
+		revokedTime := time.Now().UTC()
+		apiKey.RevokedTime = &revokedTime
+		return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityAPIKey, strconv.Itoa(id)), models.AuditRevoke, before, *apiKey)
+	}
+	return models.EntityNotFound
+}
+
+// copyAPIKey copies a stored key, so that callers can't change it through its roles or revoked time.
+func copyAPIKey(apiKey models.APIKey) *models.APIKey {
+	apiKey.Roles = append([]models.Role{}, apiKey.Roles...)
+	if apiKey.RevokedTime != nil {
+		revokedTime := *apiKey.RevokedTime
+		apiKey.RevokedTime = &revokedTime
+	}
+	return &apiKey
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// recordAudit appends a change made by the actor in ctx to the audit log. It is called while the change is being
+// made, under the same lock.
+func (m *ParkMemoryDao) recordAudit(ctx context.Context, entity string, action models.AuditAction, before, after any) error {
+	event, err := models.NewAuditEvent(ctx, entity, action, before, after)
+	if err != nil {
+		return err
+	}
+	m.lastId++
+	event.ID = m.lastId
+	m.auditEvents = append(m.auditEvents, event)
+	return nil
+}
+
+// GetAuditEvents reads the audit log, oldest first.
+func (m *ParkMemoryDao) GetAuditEvents(filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if filter.Page.Sort.Field != "" {
+		return nil, models.PageInfo{}, models.InvalidSort
+	}
+
+	keyed := []keyedItem[models.AuditEvent]{}
+	for _, event := range m.auditEvents {
+		if filter.Entity != nil && event.Entity != *filter.Entity {
+			continue
+		}
+		if filter.Since != nil && event.Time.Before(*filter.Since) {
+			continue
+		}
+		keyed = append(keyed, keyedItem[models.AuditEvent]{item: event, id: event.ID})
+	}
+	return paginate(keyset{}, keyed, filter.Page)
+}
@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func (m *ParkMemoryDao) AddFeed(ctx context.Context, feed models.Feed) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findFeed(feed.Name) != nil {
+		return models.EntityAlreadyExists
+	}
+	if !diets[feed.Diet] {
+		return models.InvalidSpeciesDiet
+	}
+	created := feed
+	m.feeds = append(m.feeds, &created)
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityFeed, feed.Name), models.AuditCreate, nil, feed)
+}
+
+func (m *ParkMemoryDao) GetFeed(name string) (*models.Feed, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	feed := m.findFeed(name)
+	if feed == nil {
+		return nil, models.EntityNotFound
+	}
+	found := *feed
+	return &found, nil
+}
+
+// GetFeeds lists the feeds by name, optionally only those that are or aren't low on stock.
+func (m *ParkMemoryDao) GetFeeds(filter models.FeedFilter) ([]models.Feed, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	feeds := []models.Feed{}
+	for _, feed := range m.feeds {
+		if filter.LowStock != nil && feed.IsLowOnStock() != *filter.LowStock {
+			continue
+		}
+		feeds = append(feeds, *feed)
+	}
+	sort.Slice(feeds, func(i, j int) bool {
+		return feeds[i].Name < feeds[j].Name
+	})
+	return feeds, nil
+}
+
+// RestockFeed adds the quantity to the stock of the feed, and returns the restocked feed.
+func (m *ParkMemoryDao) RestockFeed(ctx context.Context, name string, quantity int) (*models.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	feed := m.findFeed(name)
+	if feed == nil {
+		return nil, models.EntityNotFound
+	}
+	before := *feed
+	feed.Stock += quantity
+	restocked := *feed
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityFeed, name), models.AuditUpdate, before, restocked)
+	if err != nil {
+		return nil, err
+	}
+	return &restocked, nil
+}
+
+func (m *ParkMemoryDao) findFeed(name string) *models.Feed {
+	for _, feed := range m.feeds {
+		if feed.Name == name {
+			return feed
+		}
+	}
+	return nil
+}
+
+func (m *ParkMemoryDao) AddFeedingSchedule(ctx context.Context, schedule models.FeedingSchedule) (*models.FeedingSchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.findCage(schedule.Cage)
+	if c == nil || m.findFeed(schedule.Feed) == nil {
+		return nil, models.EntityNotFound
+	}
+	m.lastId++
+	created := schedule
+	created.ID = m.lastId
+	created.Cage = m.cageRef(c)
+	m.schedules = append(m.schedules, &created)
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntitySchedule, strconv.Itoa(created.ID)), models.AuditCreate, nil, created)
+	if err != nil {
+		return nil, err
+	}
+	result := created
+	return &result, nil
+}
+
+func (m *ParkMemoryDao) GetFeedingSchedule(id int) (*models.FeedingSchedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, schedule := range m.schedules {
+		if schedule.ID == id {
+			found := *schedule
+			return &found, nil
+		}
+	}
+	return nil, models.EntityNotFound
+}
+
+// GetFeedingSchedules lists the feeding schedules by time and then cage, optionally only those of one cage.
+func (m *ParkMemoryDao) GetFeedingSchedules(filter models.FeedingScheduleFilter) ([]models.FeedingSchedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	schedules := []models.FeedingSchedule{}
+	for _, schedule := range m.schedules {
+		if filter.Cage != nil && schedule.Cage != *filter.Cage {
+			continue
+		}
+		schedules = append(schedules, *schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].Time != schedules[j].Time {
+			return schedules[i].Time < schedules[j].Time
+		}
+		if schedules[i].Cage != schedules[j].Cage {
+			return schedules[i].Cage < schedules[j].Cage
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules, nil
+}
+
+func (m *ParkMemoryDao) DeleteFeedingSchedule(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, schedule := range m.schedules {
+		if schedule.ID != id {
+			continue
+		}
+		m.schedules = append(m.schedules[:i], m.schedules[i+1:]...)
+		return m.recordAudit(ctx, models.AuditEntity(models.AuditEntitySchedule, strconv.Itoa(id)), models.AuditDelete, *schedule, nil)
+	}
+	return models.EntityNotFound
+}
+
+// deleteSchedulesOf removes the schedules of a cage that is being deleted, as the foreign key on feedingSchedule
+// does in MySQL.
+func (m *ParkMemoryDao) deleteSchedulesOf(cageLabel string) {
+	schedules := []*models.FeedingSchedule{}
+	for _, schedule := range m.schedules {
+		if schedule.Cage != cageLabel {
+			schedules = append(schedules, schedule)
+		}
+	}
+	m.schedules = schedules
+}
+
+// renameSchedulesOf moves the schedules of a cage that was renamed or moved to another zone to the cage's new
+// reference, as the join on the cage's id does in MySQL.
+func (m *ParkMemoryDao) renameSchedulesOf(before, after string) {
+	for _, schedule := range m.schedules {
+		if schedule.Cage == before {
+			schedule.Cage = after
+		}
+	}
+}
+
+// RecordFeeding records a feeding made by the actor in ctx and takes the feed it used out of stock, returning the
+// feeding along with the feed's remaining stock. A schedule can only be fed once a day, and only with feed that
+// is in stock.
+func (m *ParkMemoryDao) RecordFeeding(ctx context.Context, feeding models.Feeding) (*models.Feeding, *models.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	feed := m.findFeed(feeding.Feed)
+	if feed == nil {
+		return nil, nil, models.EntityNotFound
+	}
+	if feed.Stock < feeding.Quantity {
+		return nil, nil, models.InsufficientFeedStock
+	}
+	for _, existing := range m.feedings {
+		if existing.ScheduleID == feeding.ScheduleID && existing.Date == feeding.Date {
+			return nil, nil, models.EntityAlreadyExists
+		}
+	}
+
+	m.lastId++
+	feeding.ID = m.lastId
+	feeding.FedTime = time.Now().UTC()
+	feeding.FedBy = models.ActorFromContext(ctx)
+	m.feedings = append(m.feedings, feeding)
+
+	before := *feed
+	feed.Stock -= feeding.Quantity
+	remaining := *feed
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityFeeding, strconv.Itoa(feeding.ID)), models.AuditCreate, nil, feeding)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = m.recordAudit(ctx, models.AuditEntity(models.AuditEntityFeed, feed.Name), models.AuditUpdate, before, remaining)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &feeding, &remaining, nil
+}
+
+// GetFeedings lists the feedings in the order they were made, optionally only those on a date or in a cage.
+func (m *ParkMemoryDao) GetFeedings(filter models.FeedingFilter) ([]models.Feeding, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	feedings := []models.Feeding{}
+	for _, feeding := range m.feedings {
+		if filter.Date != nil && feeding.Date != *filter.Date {
+			continue
+		}
+		if filter.Cage != nil && feeding.Cage != *filter.Cage {
+			continue
+		}
+		feedings = append(feedings, feeding)
+	}
+	return feedings, nil
+}
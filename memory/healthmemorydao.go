@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// AddHealthRecord adds a record to the dinosaur's medical history, recorded by the actor in ctx, and returns it.
+func (m *ParkMemoryDao) AddHealthRecord(ctx context.Context, record models.HealthRecord) (*models.HealthRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.findDinosaur(record.Dinosaur)
+	if d == nil {
+		return nil, models.EntityNotFound
+	}
+	m.lastId++
+	created := record
+	created.ID = m.lastId
+	created.Time = record.Time.UTC()
+	created.RecordedBy = models.ActorFromContext(ctx)
+	m.healthRecords = append(m.healthRecords, &created)
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityHealth, strconv.Itoa(created.ID)), models.AuditCreate, nil, created)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.refreshQuarantine(ctx, d); err != nil {
+		return nil, err
+	}
+	result := created
+	return &result, nil
+}
+
+func (m *ParkMemoryDao) GetHealthRecord(dinosaurName string, id int) (*models.HealthRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	record := m.findHealthRecord(dinosaurName, id)
+	if record == nil {
+		return nil, models.EntityNotFound
+	}
+	found := *record
+	return &found, nil
+}
+
+// GetHealthRecords reads the dinosaur's medical history, oldest first.
+func (m *ParkMemoryDao) GetHealthRecords(dinosaurName string, filter models.HealthRecordFilter) ([]models.HealthRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.findDinosaur(dinosaurName) == nil {
+		return nil, models.EntityNotFound
+	}
+	records := []models.HealthRecord{}
+	for _, record := range m.sortedHealthRecords(dinosaurName) {
+		if filter.Type != nil && record.Type != *filter.Type {
+			continue
+		}
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+// UpdateHealthRecord replaces the type, time and details of the record with those of the update, and returns the
+// updated record. Who recorded it is kept.
+func (m *ParkMemoryDao) UpdateHealthRecord(ctx context.Context, update models.HealthRecord) (*models.HealthRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.findDinosaur(update.Dinosaur)
+	record := m.findHealthRecord(update.Dinosaur, update.ID)
+	if d == nil || record == nil {
+		return nil, models.EntityNotFound
+	}
+	before := *record
+	record.Type = update.Type
+	record.Time = update.Time.UTC()
+	record.WeightKg = update.WeightKg
+	record.Medication = update.Medication
+	record.Dosage = update.Dosage
+	record.Notes = update.Notes
+	updated := *record
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityHealth, strconv.Itoa(update.ID)), models.AuditUpdate, before, updated)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.refreshQuarantine(ctx, d); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (m *ParkMemoryDao) DeleteHealthRecord(ctx context.Context, dinosaurName string, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.findDinosaur(dinosaurName)
+	if d == nil {
+		return models.EntityNotFound
+	}
+	for i, record := range m.healthRecords {
+		if record.ID != id || record.Dinosaur != dinosaurName {
+			continue
+		}
+		m.healthRecords = append(m.healthRecords[:i], m.healthRecords[i+1:]...)
+		err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityHealth, strconv.Itoa(id)), models.AuditDelete, *record, nil)
+		if err != nil {
+			return err
+		}
+		return m.refreshQuarantine(ctx, d)
+	}
+	return models.EntityNotFound
+}
+
+func (m *ParkMemoryDao) findHealthRecord(dinosaurName string, id int) *models.HealthRecord {
+	for _, record := range m.healthRecords {
+		if record.ID == id && record.Dinosaur == dinosaurName {
+			return record
+		}
+	}
+	return nil
+}
+
+// sortedHealthRecords returns the dinosaur's records by time and then id, as data.ParkSqlDao orders them.
+func (m *ParkMemoryDao) sortedHealthRecords(dinosaurName string) []*models.HealthRecord {
+	records := []*models.HealthRecord{}
+	for _, record := range m.healthRecords {
+		if record.Dinosaur == dinosaurName {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].Time.Equal(records[j].Time) {
+			return records[i].Time.Before(records[j].Time)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records
+}
+
+// refreshQuarantine sets the dinosaur's quarantine status from its latest quarantine or release record, and records
+// the change if there was one.
+func (m *ParkMemoryDao) refreshQuarantine(ctx context.Context, d *dinosaur) error {
+	quarantined := false
+	for _, record := range m.sortedHealthRecords(d.name) {
+		if record.Type.ChangesQuarantine() {
+			quarantined = record.Type == models.HealthRecordQuarantine
+		}
+	}
+	if quarantined == d.quarantined {
+		return nil
+	}
+	before := m.toDinosaurModel(d)
+	d.quarantined = quarantined
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityDinosaur, d.name), models.AuditUpdate, before, m.toDinosaurModel(d))
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/EdgarH78/jurassic-park/inventory"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// ImportTransaction calls fn with a store whose changes are made while holding the lock, so that no other change
+// is made while the rows are imported. If fn returns an error the park is restored to how it was before fn was
+// called.
+func (m *ParkMemoryDao) ImportTransaction(ctx context.Context, fn func(store inventory.Store) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before := m.importSnapshot()
+	if err := fn(&importStore{dao: m}); err != nil {
+		before.restore(m)
+		return err
+	}
+	return nil
+}
+
+// importSnapshot is the part of the park that an import can change. Zones, cages, dinosaurs and audit events are
+// only ever appended by an import, so they are restored by cutting the lists back to their lengths.
+type importSnapshot struct {
+	zones       int
+	cages       int
+	dinosaurs   int
+	auditEvents int
+	lastId      int
+	placements  map[*dinosaur]*cage
+}
+
+func (m *ParkMemoryDao) importSnapshot() importSnapshot {
+	snapshot := importSnapshot{
+		zones:       len(m.zones),
+		cages:       len(m.cages),
+		dinosaurs:   len(m.dinosaurs),
+		auditEvents: len(m.auditEvents),
+		lastId:      m.lastId,
+		placements:  map[*dinosaur]*cage{},
+	}
+	for _, d := range m.dinosaurs {
+		snapshot.placements[d] = d.cage
+	}
+	return snapshot
+}
+
+func (s importSnapshot) restore(m *ParkMemoryDao) {
+	m.zones = m.zones[:s.zones]
+	m.cages = m.cages[:s.cages]
+	m.dinosaurs = m.dinosaurs[:s.dinosaurs]
+	m.auditEvents = m.auditEvents[:s.auditEvents]
+	m.lastId = s.lastId
+	for d, c := range s.placements {
+		d.cage = c
+	}
+}
+
+// importStore makes the changes of an import while the lock is held. The changes check every rule before they
+// change anything, so a change that fails leaves the park as it was.
+type importStore struct {
+	dao *ParkMemoryDao
+}
+
+func (i *importStore) AddZone(ctx context.Context, zone models.Zone) error {
+	return i.dao.addZone(ctx, zone)
+}
+
+func (i *importStore) AddCage(ctx context.Context, cage models.Cage) error {
+	return i.dao.addCage(ctx, cage)
+}
+
+func (i *importStore) AddDinosaur(ctx context.Context, dinosaur models.Dinosaur) error {
+	return i.dao.addDinosaur(ctx, dinosaur)
+}
+
+func (i *importStore) AddDinosaurToCage(ctx context.Context, dinosaurName, targetCage string) error {
+	return i.dao.addDinosaurToCage(ctx, dinosaurName, targetCage)
+}
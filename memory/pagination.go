@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// sortKey is the value an item is sorted on. Only one of text and number is used, depending on the sort.
+type sortKey struct {
+	text   string
+	number int
+}
+
+func (k sortKey) compare(other sortKey) int {
+	if c := strings.Compare(k.text, other.text); c != 0 {
+		return c
+	}
+	return k.number - other.number
+}
+
+// keyedItem is an item along with its sort key and the id that orders items with the same key.
+type keyedItem[T any] struct {
+	item T
+	key  sortKey
+	id   int
+}
+
+// keyset pages through a list in the same order as data.ParkSqlDao: by sort key, then by id.
+type keyset struct {
+	// sort is recorded in the cursors, so that a cursor can't be used with a different sort
+	sort       string
+	numeric    bool
+	descending bool
+}
+
+func (k keyset) less(a, b sortKey, aId, bId int) bool {
+	c := a.compare(b)
+	if k.descending {
+		c = -c
+	}
+	if c != 0 {
+		return c < 0
+	}
+	return aId < bId
+}
+
+func (k keyset) cursor(key sortKey, id int) *models.Cursor {
+	value := key.text
+	if k.numeric {
+		value = strconv.Itoa(key.number)
+	}
+	return &models.Cursor{
+		Sort:  k.sort,
+		Value: value,
+		ID:    id,
+	}
+}
+
+func (k keyset) cursorKey(cursor models.Cursor) (sortKey, error) {
+	if cursor.Sort != k.sort {
+		return sortKey{}, models.InvalidCursor
+	}
+	if !k.numeric {
+		return sortKey{text: cursor.Value}, nil
+	}
+	number, err := strconv.Atoi(cursor.Value)
+	if err != nil {
+		return sortKey{}, models.InvalidCursor
+	}
+	return sortKey{number: number}, nil
+}
+
+// paginate sorts the items and returns the page of them selected by page.
+func paginate[T any](k keyset, items []keyedItem[T], page models.Pagination) ([]T, models.PageInfo, error) {
+	pageInfo := models.PageInfo{}
+	if page.IncludeTotal {
+		total := len(items)
+		pageInfo.Total = &total
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return k.less(items[i].key, items[j].key, items[i].id, items[j].id)
+	})
+	if page.After != nil {
+		after, err := k.cursorKey(*page.After)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+		start := sort.Search(len(items), func(i int) bool {
+			return k.less(after, items[i].key, page.After.ID, items[i].id)
+		})
+		items = items[start:]
+	}
+	if page.Limit > 0 && len(items) > page.Limit {
+		items = items[:page.Limit]
+		last := items[len(items)-1]
+		pageInfo.Next = k.cursor(last.key, last.id)
+	}
+
+	paged := []T{}
+	for _, item := range items {
+		paged = append(paged, item.item)
+	}
+	return paged, pageInfo, nil
+}
@@ -0,0 +1,686 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/rules"
+	"github.com/EdgarH78/jurassic-park/zones"
+)
+
+// defaultSpecies mirrors the species seeded by the migrations in data/migrations.
+var defaultSpecies = []species{
+	{name: "Tyrannosaurus", diet: "Carnivore"},
+	{name: "Velociraptor", diet: "Carnivore"},
+	{name: "Spinosaurus", diet: "Carnivore"},
+	{name: "Megalosaurus", diet: "Carnivore"},
+	{name: "Brachiosaurus", diet: "Herbivore"},
+	{name: "Stegosaurus", diet: "Herbivore"},
+	{name: "Ankylosaurus", diet: "Herbivore"},
+	{name: "Triceratops", diet: "Herbivore"},
+}
+
+type species struct {
+	name string
+	diet string
+}
+
+type cage struct {
+	// id stands in for the auto increment id of the SQL tables, which orders items that sort the same
+	id          int
+	label       string
+	capacity    int
+	powerStatus models.PowerStatus
+	// zone is the name of the zone the cage is in, or empty if it isn't in one
+	zone string
+}
+
+type dinosaur struct {
+	id          int
+	name        string
+	species     string
+	sex         models.Sex
+	cage        *cage
+	quarantined bool
+}
+
+// ParkMemoryDao is a concurrency safe, in-memory implementation of the park manager. It enforces the same rules
+// and returns the same errors as data.ParkSqlDao, which makes it suitable for tests and demos that can't run MySQL.
+type ParkMemoryDao struct {
+	mu        sync.RWMutex
+	species   map[string]species
+	cages     []*cage
+	dinosaurs []*dinosaur
+	lastId    int
+
+	webhooks    []models.Webhook
+	deliveries  []*models.WebhookDelivery
+	auditEvents []models.AuditEvent
+	apiKeys     []*models.APIKey
+
+	feeds     []*models.Feed
+	schedules []*models.FeedingSchedule
+	feedings  []models.Feeding
+
+	healthRecords []*models.HealthRecord
+
+	zones []*models.Zone
+
+	breedingPolicy models.BreedingPolicy
+	compatibility  *rules.Engine
+	cageLabelScope models.CageLabelScope
+}
+
+func NewParkMemoryDao() *ParkMemoryDao {
+	dao := &ParkMemoryDao{
+		species:        map[string]species{},
+		breedingPolicy: models.DefaultBreedingPolicy,
+		compatibility:  rules.NewEngine(rules.Rules{}),
+		cageLabelScope: models.CageLabelsUniqueInPark,
+	}
+	for _, s := range defaultSpecies {
+		dao.species[s.name] = s
+	}
+	return dao
+}
+
+// SetBreedingPolicy replaces the breeding policy that is enforced when dinosaurs are added to cages.
+func (m *ParkMemoryDao) SetBreedingPolicy(policy models.BreedingPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.breedingPolicy = policy
+}
+
+// SetCompatibilityRules replaces the species compatibility rules that are enforced when dinosaurs are added to cages.
+func (m *ParkMemoryDao) SetCompatibilityRules(engine *rules.Engine) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.compatibility = engine
+}
+
+// SetCageLabelScope sets where cage labels have to be unique. Like the migrations in MySQL, labels can't be made
+// unique in the park while cages in different zones share a label.
+func (m *ParkMemoryDao) SetCageLabelScope(ctx context.Context, scope models.CageLabelScope) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if scope == models.CageLabelsUniqueInPark {
+		labels := map[string]bool{}
+		for _, c := range m.cages {
+			if labels[c.label] {
+				return models.EntityAlreadyExists
+			}
+			labels[c.label] = true
+		}
+	}
+	m.cageLabelScope = scope
+	return nil
+}
+
+func (m *ParkMemoryDao) AddCage(ctx context.Context, c models.Cage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.addCage(ctx, c)
+}
+
+func (m *ParkMemoryDao) addCage(ctx context.Context, c models.Cage) error {
+	powerStatus := c.RequestedPowerStatus()
+	if !powerStatus.IsValid() {
+		return models.InvalidPowerStatus
+	}
+	if err := m.cageLabelScope.CheckLabel(c.Label); err != nil {
+		return err
+	}
+	zone := ""
+	if c.Zone != nil {
+		zone = *c.Zone
+	}
+	if m.labelTaken(c.Label, zone, nil) {
+		return models.EntityAlreadyExists
+	}
+	if c.Zone != nil && m.findZone(*c.Zone) == nil {
+		return models.InvalidCageZone
+	}
+	m.lastId++
+	created := &cage{
+		id:          m.lastId,
+		label:       c.Label,
+		capacity:    c.MaxOccupancy,
+		powerStatus: powerStatus,
+	}
+	if c.Zone != nil {
+		created.zone = *c.Zone
+	}
+	m.cages = append(m.cages, created)
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityCage, m.cageRef(created)), models.AuditCreate, nil, models.NewCageV2(m.toCageModel(created)))
+}
+
+func (m *ParkMemoryDao) GetCage(cageLabel string) (*models.Cage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := m.findCage(cageLabel)
+	if c == nil {
+		return nil, models.EntityNotFound
+	}
+	cage := m.toCageModel(c)
+	return &cage, nil
+}
+
+func (m *ParkMemoryDao) GetCages(filter models.CageFilter) ([]models.Cage, models.PageInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := keyset{
+		sort:       filter.Page.Sort.String(),
+		descending: filter.Page.Sort.Descending,
+	}
+	var sortKeyOf func(c *cage) sortKey
+	if filter.Page.Sort.Field != "" {
+		if filter.CanHouse != nil {
+			return nil, models.PageInfo{}, models.InvalidSort
+		}
+		switch filter.Page.Sort.Field {
+		case models.SortByLabel:
+			sortKeyOf = func(c *cage) sortKey { return sortKey{text: c.label} }
+		case models.SortByOccupancy:
+			keys.numeric = true
+			sortKeyOf = func(c *cage) sortKey { return sortKey{number: m.occupancy(c)} }
+		case models.SortByMaxOccupancy:
+			keys.numeric = true
+			sortKeyOf = func(c *cage) sortKey { return sortKey{number: c.capacity} }
+		default:
+			return nil, models.PageInfo{}, models.InvalidSort
+		}
+	}
+
+	var inZone map[string]bool
+	if filter.Zone != nil {
+		if m.findZone(*filter.Zone) == nil {
+			return nil, models.PageInfo{}, models.EntityNotFound
+		}
+		inZone = map[string]bool{}
+		for _, zone := range zones.Subtree(m.zoneModels(), *filter.Zone) {
+			inZone[zone] = true
+		}
+	}
+
+	var canHouse *dinosaur
+	if filter.CanHouse != nil {
+		canHouse = m.findDinosaur(*filter.CanHouse)
+		if canHouse == nil {
+			return nil, models.PageInfo{}, models.EntityNotFound
+		}
+		// same ranking as data.ParkSqlDao: cages holding the same species, then other occupied cages, then empty cages
+		keys.sort = "canHouse"
+		keys.numeric = true
+		sortKeyOf = func(c *cage) sortKey {
+			occupants := m.dinosaursIn(c)
+			for _, occupant := range occupants {
+				if occupant.species == canHouse.species {
+					return sortKey{number: 0}
+				}
+			}
+			if len(occupants) > 0 {
+				return sortKey{number: 1}
+			}
+			return sortKey{number: 2}
+		}
+	}
+
+	candidates := []keyedItem[*cage]{}
+	for _, c := range m.cages {
+		if canHouse != nil && (canHouse.cage == c || m.checkCageCanHouse(canHouse, c) != nil) {
+			continue
+		}
+		if filter.HasPower != nil && c.powerStatus.HasPower() != *filter.HasPower {
+			continue
+		}
+		if filter.PowerStatus != nil && c.powerStatus != *filter.PowerStatus {
+			continue
+		}
+		if inZone != nil && !inZone[c.zone] {
+			continue
+		}
+		candidate := keyedItem[*cage]{item: c, id: c.id}
+		if sortKeyOf != nil {
+			candidate.key = sortKeyOf(c)
+		}
+		candidates = append(candidates, candidate)
+	}
+	page, pageInfo, err := paginate(keys, candidates, filter.Page)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	cages := []models.Cage{}
+	for _, c := range page {
+		cages = append(cages, m.toCageModel(c))
+	}
+	return cages, pageInfo, nil
+}
+
+func (m *ParkMemoryDao) AddDinosaur(ctx context.Context, d models.Dinosaur) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.addDinosaur(ctx, d)
+}
+
+func (m *ParkMemoryDao) addDinosaur(ctx context.Context, d models.Dinosaur) error {
+	if _, ok := m.species[d.Species]; !ok {
+		return models.InvalidDinosaurSpecies
+	}
+	sex := d.RequestedSex()
+	if !sex.IsValid() {
+		return models.InvalidDinosaurSex
+	}
+	if m.findDinosaur(d.Name) != nil {
+		return models.EntityAlreadyExists
+	}
+	m.lastId++
+	created := &dinosaur{
+		id:      m.lastId,
+		name:    d.Name,
+		species: d.Species,
+		sex:     sex,
+	}
+	m.dinosaurs = append(m.dinosaurs, created)
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityDinosaur, d.Name), models.AuditCreate, nil, m.toDinosaurModel(created))
+}
+
+func (m *ParkMemoryDao) GetDinosaurs(filter models.DinosaurFilter) ([]models.Dinosaur, models.PageInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dinosaurs := []*dinosaur{}
+	for _, d := range m.dinosaurs {
+		if filter.Diet != nil && m.species[d.species].diet != *filter.Diet {
+			continue
+		}
+		if filter.NeedsCageAssignment != nil && (d.cage == nil) != *filter.NeedsCageAssignment {
+			continue
+		}
+		if filter.Species != nil && d.species != *filter.Species {
+			continue
+		}
+		if filter.Sex != nil && d.sex != *filter.Sex {
+			continue
+		}
+		if filter.Quarantined != nil && d.quarantined != *filter.Quarantined {
+			continue
+		}
+		dinosaurs = append(dinosaurs, d)
+	}
+	return m.getDinosaurPage(dinosaurs, filter.Page)
+}
+
+// getDinosaurPage sorts the dinosaurs and returns the page of them selected by page.
+func (m *ParkMemoryDao) getDinosaurPage(dinosaurs []*dinosaur, page models.Pagination) ([]models.Dinosaur, models.PageInfo, error) {
+	keys := keyset{
+		sort:       page.Sort.String(),
+		descending: page.Sort.Descending,
+	}
+	var sortKeyOf func(d *dinosaur) sortKey
+	switch page.Sort.Field {
+	case "":
+	case models.SortByName:
+		sortKeyOf = func(d *dinosaur) sortKey { return sortKey{text: d.name} }
+	case models.SortBySpecies:
+		sortKeyOf = func(d *dinosaur) sortKey { return sortKey{text: d.species} }
+	default:
+		return nil, models.PageInfo{}, models.InvalidSort
+	}
+
+	keyed := []keyedItem[*dinosaur]{}
+	for _, d := range dinosaurs {
+		item := keyedItem[*dinosaur]{item: d, id: d.id}
+		if sortKeyOf != nil {
+			item.key = sortKeyOf(d)
+		}
+		keyed = append(keyed, item)
+	}
+	paged, pageInfo, err := paginate(keys, keyed, page)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	result := []models.Dinosaur{}
+	for _, d := range paged {
+		result = append(result, m.toDinosaurModel(d))
+	}
+	return result, pageInfo, nil
+}
+
+func (m *ParkMemoryDao) GetDinosaur(name string) (*models.Dinosaur, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d := m.findDinosaur(name)
+	if d == nil {
+		return nil, models.EntityNotFound
+	}
+	dinosaur := m.toDinosaurModel(d)
+	return &dinosaur, nil
+}
+
+// UpdateDinosaur changes the fields of the dinosaur that are set in the update. A dinosaur in a cage can only change
+// sex if the breeding policy still allows it to share the cage.
+func (m *ParkMemoryDao) UpdateDinosaur(ctx context.Context, name string, update models.UpdateDinosaurRequest) (*models.Dinosaur, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.findDinosaur(name)
+	if d == nil {
+		return nil, models.EntityNotFound
+	}
+	before := m.toDinosaurModel(d)
+	after := before
+	if update.Sex != nil {
+		if !update.Sex.IsValid() {
+			return nil, models.InvalidDinosaurSex
+		}
+		after.Sex = *update.Sex
+	}
+	if d.cage != nil {
+		for _, occupant := range m.dinosaursIn(d.cage) {
+			if occupant != d && !m.breedingPolicy.AllowsPair(after, m.toDinosaurModel(occupant)) {
+				return nil, models.BreedingPairNotAllowed
+			}
+		}
+	}
+
+	d.sex = after.Sex
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityDinosaur, name), models.AuditUpdate, before, after)
+	if err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+func (m *ParkMemoryDao) AddDinosaurToCage(ctx context.Context, dinosaurName, targetCage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.addDinosaurToCage(ctx, dinosaurName, targetCage)
+}
+
+func (m *ParkMemoryDao) addDinosaurToCage(ctx context.Context, dinosaurName, targetCage string) error {
+	c := m.findCage(targetCage)
+	if c == nil {
+		return models.EntityNotFound
+	}
+	d := m.findDinosaur(dinosaurName)
+	if d == nil {
+		return models.EntityNotFound
+	}
+	if err := m.checkCageCanHouse(d, c); err != nil {
+		return err
+	}
+	return m.moveDinosaur(ctx, d, c, models.AuditAssign)
+}
+
+func (m *ParkMemoryDao) RemoveDinosaurFromCage(ctx context.Context, dinosaurName, cageLabel string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.findCage(cageLabel)
+	if c == nil {
+		return models.EntityNotFound
+	}
+	d := m.findDinosaur(dinosaurName)
+	if d == nil {
+		return models.EntityNotFound
+	}
+	if d.cage != c {
+		return models.DinosaurNotInCage
+	}
+	return m.moveDinosaur(ctx, d, nil, models.AuditRemove)
+}
+
+func (m *ParkMemoryDao) TransferDinosaur(ctx context.Context, dinosaurName, targetCage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.findDinosaur(dinosaurName)
+	if d == nil {
+		return models.EntityNotFound
+	}
+	if d.cage == nil {
+		return models.DinosaurNotCaged
+	}
+	c := m.findCage(targetCage)
+	if c == nil {
+		return models.EntityNotFound
+	}
+	if d.cage == c {
+		return nil
+	}
+	if err := m.checkCageCanHouse(d, c); err != nil {
+		return err
+	}
+	return m.moveDinosaur(ctx, d, c, models.AuditTransfer)
+}
+
+// moveDinosaur puts the dinosaur in the cage, or takes it out of its cage when c is nil, and records the move.
+func (m *ParkMemoryDao) moveDinosaur(ctx context.Context, d *dinosaur, c *cage, action models.AuditAction) error {
+	before := m.toDinosaurModel(d)
+	d.cage = c
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityDinosaur, d.name), action, before, m.toDinosaurModel(d))
+}
+
+// checkCageCanHouse applies the same capacity, power, quarantine, compatibility and breeding rules as data.ParkSqlDao.
+func (m *ParkMemoryDao) checkCageCanHouse(d *dinosaur, c *cage) error {
+	if m.occupancy(c) >= c.capacity {
+		return models.CageCapacityExceeded
+	}
+	if !c.powerStatus.AcceptsNewDinosaurs() {
+		return models.IncompatibleCagePowerState
+	}
+	occupants := []models.Dinosaur{}
+	for _, occupant := range m.dinosaursIn(c) {
+		occupants = append(occupants, m.toDinosaurModel(occupant))
+	}
+	dinosaur := m.toDinosaurModel(d)
+	if err := models.CheckQuarantine(dinosaur, occupants); err != nil {
+		return err
+	}
+	if violations := m.compatibility.Check(dinosaur, c.capacity, occupants); len(violations) > 0 {
+		return &models.CompatibilityError{Violations: violations}
+	}
+	for _, occupant := range occupants {
+		if !m.breedingPolicy.AllowsPair(dinosaur, occupant) {
+			return models.BreedingPairNotAllowed
+		}
+	}
+	return nil
+}
+
+func (m *ParkMemoryDao) GetDinosaursInCage(cageLabel string, page models.Pagination) ([]models.Dinosaur, models.PageInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := m.findCage(cageLabel)
+	if c == nil {
+		return nil, models.PageInfo{}, models.EntityNotFound
+	}
+	return m.getDinosaurPage(m.dinosaursIn(c), page)
+}
+
+func (m *ParkMemoryDao) UpdateCagePowerStatus(ctx context.Context, cageLabel string, powerOn bool) error {
+	_, err := m.UpdateCage(ctx, cageLabel, models.UpdateCageRequest{HasPower: &powerOn})
+	return err
+}
+
+func (m *ParkMemoryDao) UpdateCage(ctx context.Context, cageLabel string, update models.UpdateCageRequest) (*models.Cage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.findCage(cageLabel)
+	if c == nil {
+		return nil, models.EntityNotFound
+	}
+	occupancy := m.occupancy(c)
+	if update.MaxOccupancy != nil && *update.MaxOccupancy < occupancy {
+		return nil, models.CageCapacityBelowOccupancy
+	}
+	if update.MaxOccupancy != nil && *update.MaxOccupancy < c.capacity {
+		occupants := []models.Dinosaur{}
+		for _, occupant := range m.dinosaursIn(c) {
+			occupants = append(occupants, m.toDinosaurModel(occupant))
+		}
+		if violations := m.compatibility.CheckCapacity(*update.MaxOccupancy, occupants); len(violations) > 0 {
+			return nil, &models.CompatibilityError{Violations: violations}
+		}
+	}
+	powerStatus := update.RequestedPowerStatus()
+	if powerStatus != nil {
+		if err := c.powerStatus.CheckTransition(*powerStatus, occupancy); err != nil {
+			return nil, err
+		}
+	}
+	label, zone := c.label, c.zone
+	if update.Label != nil {
+		if err := m.cageLabelScope.CheckLabel(*update.Label); err != nil {
+			return nil, err
+		}
+		label = *update.Label
+	}
+	if update.Zone != nil {
+		zone = *update.Zone
+	}
+	if m.labelTaken(label, zone, c) {
+		return nil, models.EntityAlreadyExists
+	}
+	if update.Zone != nil && *update.Zone != "" && m.findZone(*update.Zone) == nil {
+		return nil, models.InvalidCageZone
+	}
+
+	before := models.NewCageV2(m.toCageModel(c))
+	ref := m.cageRef(c)
+	if update.MaxOccupancy != nil {
+		c.capacity = *update.MaxOccupancy
+	}
+	if powerStatus != nil {
+		c.powerStatus = *powerStatus
+	}
+	if update.Label != nil {
+		c.label = *update.Label
+	}
+	if update.Zone != nil {
+		c.zone = *update.Zone
+	}
+	m.renameSchedulesOf(ref, m.cageRef(c))
+	cage := m.toCageModel(c)
+	// the change is recorded against the cage as it was referred to, so a renamed cage is found under its old label
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityCage, ref), models.AuditUpdate, before, models.NewCageV2(cage))
+	if err != nil {
+		return nil, err
+	}
+	return &cage, nil
+}
+
+func (m *ParkMemoryDao) DeleteCage(ctx context.Context, cageLabel string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.findCage(cageLabel)
+	if c == nil {
+		return models.EntityNotFound
+	}
+	if m.occupancy(c) > 0 {
+		return models.CageNotEmpty
+	}
+	for i := range m.cages {
+		if m.cages[i] == c {
+			m.cages = append(m.cages[:i], m.cages[i+1:]...)
+			break
+		}
+	}
+	ref := m.cageRef(c)
+	m.deleteSchedulesOf(ref)
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityCage, ref), models.AuditDelete, models.NewCageV2(m.toCageModel(c)), nil)
+}
+
+// findCage finds the cage that ref refers to, as described by models.CageLabelScope.
+func (m *ParkMemoryDao) findCage(ref string) *cage {
+	zone, label := m.cageLabelScope.ParseRef(ref)
+	for _, c := range m.cages {
+		if c.label == label && (m.cageLabelScope != models.CageLabelsUniqueInZone || c.zone == zone) {
+			return c
+		}
+	}
+	return nil
+}
+
+// labelTaken reports whether a cage other than except already has the label where labels have to be unique.
+func (m *ParkMemoryDao) labelTaken(label, zone string, except *cage) bool {
+	for _, c := range m.cages {
+		if c != except && c.label == label && (m.cageLabelScope != models.CageLabelsUniqueInZone || c.zone == zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// cageRef returns how the cage is referred to.
+func (m *ParkMemoryDao) cageRef(c *cage) string {
+	return m.cageLabelScope.Ref(&c.zone, c.label)
+}
+
+func (m *ParkMemoryDao) findDinosaur(name string) *dinosaur {
+	for _, d := range m.dinosaurs {
+		if d.name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+func (m *ParkMemoryDao) dinosaursIn(c *cage) []*dinosaur {
+	dinosaurs := []*dinosaur{}
+	for _, d := range m.dinosaurs {
+		if d.cage == c {
+			dinosaurs = append(dinosaurs, d)
+		}
+	}
+	return dinosaurs
+}
+
+func (m *ParkMemoryDao) occupancy(c *cage) int {
+	return len(m.dinosaursIn(c))
+}
+
+func (m *ParkMemoryDao) toCageModel(c *cage) models.Cage {
+	cage := models.Cage{
+		Label:        c.label,
+		Occupancy:    m.occupancy(c),
+		MaxOccupancy: c.capacity,
+		HasPower:     c.powerStatus.HasPower(),
+		PowerStatus:  c.powerStatus,
+	}
+	if c.zone != "" {
+		zone := c.zone
+		cage.Zone = &zone
+	}
+	return cage
+}
+
+func (m *ParkMemoryDao) toDinosaurModel(d *dinosaur) models.Dinosaur {
+	dinosaur := models.Dinosaur{
+		Name:        d.name,
+		Species:     d.species,
+		Sex:         d.sex,
+		Diet:        m.species[d.species].diet,
+		Quarantined: d.quarantined,
+	}
+	if d.cage != nil {
+		ref := m.cageRef(d.cage)
+		dinosaur.Cage = &ref
+	}
+	return dinosaur
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// diets mirrors the speciesDiet table seeded by the migrations in data/migrations.
+var diets = map[string]bool{
+	"Carnivore": true,
+	"Herbivore": true,
+}
+
+func (m *ParkMemoryDao) AddSpecies(ctx context.Context, s models.Species) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.species[s.Name]; ok {
+		return models.EntityAlreadyExists
+	}
+	if !diets[s.Diet] {
+		return models.InvalidSpeciesDiet
+	}
+	m.species[s.Name] = species{name: s.Name, diet: s.Diet}
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntitySpecies, s.Name), models.AuditCreate, nil, s)
+}
+
+func (m *ParkMemoryDao) GetSpecies(name string) (*models.Species, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.species[name]
+	if !ok {
+		return nil, models.EntityNotFound
+	}
+	return &models.Species{Name: s.name, Diet: s.diet}, nil
+}
+
+func (m *ParkMemoryDao) GetAllSpecies() ([]models.Species, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	allSpecies := []models.Species{}
+	for _, s := range m.species {
+		allSpecies = append(allSpecies, models.Species{Name: s.name, Diet: s.diet})
+	}
+	sort.Slice(allSpecies, func(i, j int) bool {
+		return allSpecies[i].Name < allSpecies[j].Name
+	})
+	return allSpecies, nil
+}
+
+func (m *ParkMemoryDao) UpdateSpecies(ctx context.Context, s models.Species) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.species[s.Name]
+	if !ok {
+		return models.EntityNotFound
+	}
+	if current.diet == s.Diet {
+		return nil
+	}
+	if m.hasDinosaursOfSpecies(s.Name) {
+		return models.EntityInUse
+	}
+	if !diets[s.Diet] {
+		return models.InvalidSpeciesDiet
+	}
+	m.species[s.Name] = species{name: s.Name, diet: s.Diet}
+	before := models.Species{Name: current.name, Diet: current.diet}
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntitySpecies, s.Name), models.AuditUpdate, before, s)
+}
+
+func (m *ParkMemoryDao) DeleteSpecies(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.species[name]
+	if !ok {
+		return models.EntityNotFound
+	}
+	if m.hasDinosaursOfSpecies(name) {
+		return models.EntityInUse
+	}
+	delete(m.species, name)
+	before := models.Species{Name: current.name, Diet: current.diet}
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntitySpecies, name), models.AuditDelete, before, nil)
+}
+
+func (m *ParkMemoryDao) hasDinosaursOfSpecies(name string) bool {
+	for _, d := range m.dinosaurs {
+		if d.species == name {
+			return true
+		}
+	}
+	return false
+}
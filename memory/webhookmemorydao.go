@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+func (m *ParkMemoryDao) AddWebhook(ctx context.Context, webhook models.Webhook) (*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastId++
+	webhook.ID = m.lastId
+	webhook.Events = append([]string{}, webhook.Events...)
+	webhook.CreatedTime = time.Now().UTC()
+	m.webhooks = append(m.webhooks, webhook)
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityWebhook, strconv.Itoa(webhook.ID)), models.AuditCreate, nil, withoutSecret(webhook))
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (m *ParkMemoryDao) GetWebhook(id int) (*models.Webhook, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, webhook := range m.webhooks {
+		if webhook.ID == id {
+			return &webhook, nil
+		}
+	}
+	return nil, models.EntityNotFound
+}
+
+func (m *ParkMemoryDao) GetWebhooks() ([]models.Webhook, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]models.Webhook{}, m.webhooks...), nil
+}
+
+// DeleteWebhook deletes the webhook along with its delivery log.
+func (m *ParkMemoryDao) DeleteWebhook(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, webhook := range m.webhooks {
+		if webhook.ID != id {
+			continue
+		}
+		m.webhooks = append(m.webhooks[:i], m.webhooks[i+1:]...)
+		deliveries := []*models.WebhookDelivery{}
+		for _, d := range m.deliveries {
+			if d.WebhookID != id {
+				deliveries = append(deliveries, d)
+			}
+		}
+		m.deliveries = deliveries
+		return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityWebhook, strconv.Itoa(id)), models.AuditDelete, withoutSecret(webhook), nil)
+	}
+	return models.EntityNotFound
+}
+
+// withoutSecret is the snapshot of a webhook in the audit log, which leaves out the secret.
+func withoutSecret(webhook models.Webhook) models.Webhook {
+	webhook.Secret = ""
+	return webhook
+}
+
+func (m *ParkMemoryDao) AddWebhookDeliveries(deliveries []models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range deliveries {
+		if !m.hasWebhook(d.WebhookID) {
+			// the webhook was deleted after the event was published
+			return models.EntityNotFound
+		}
+	}
+	for _, d := range deliveries {
+		d := d
+		m.lastId++
+		d.ID = m.lastId
+		m.deliveries = append(m.deliveries, &d)
+	}
+	return nil
+}
+
+// ClaimWebhookDeliveries returns the pending deliveries that are due, oldest first, and pushes their next attempt
+// back by the lease.
+func (m *ParkMemoryDao) ClaimWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := []*models.WebhookDelivery{}
+	for _, d := range m.deliveries {
+		if d.Status == models.DeliveryPending && d.NextAttemptTime != nil && !d.NextAttemptTime.After(now) {
+			due = append(due, d)
+		}
+	}
+	keyed := []keyedItem[*models.WebhookDelivery]{}
+	for _, d := range due {
+		keyed = append(keyed, keyedItem[*models.WebhookDelivery]{
+			item: d,
+			key:  sortKey{number: int(d.NextAttemptTime.UnixNano())},
+			id:   d.ID,
+		})
+	}
+	claimed, _, err := paginate(keyset{numeric: true}, keyed, models.Pagination{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	leaseExpiry := now.Add(lease)
+	deliveries := []models.WebhookDelivery{}
+	for _, d := range claimed {
+		deliveries = append(deliveries, *d)
+		d.NextAttemptTime = &leaseExpiry
+	}
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery records the outcome of an attempt to deliver.
+func (m *ParkMemoryDao) UpdateWebhookDelivery(delivery models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range m.deliveries {
+		if d.ID == delivery.ID {
+			d.Status = delivery.Status
+			d.Attempts = delivery.Attempts
+			d.ResponseStatus = delivery.ResponseStatus
+			d.LastError = delivery.LastError
+			d.NextAttemptTime = delivery.NextAttemptTime
+			d.CompletedTime = delivery.CompletedTime
+			return nil
+		}
+	}
+	return nil
+}
+
+// GetWebhookDeliveries reads the delivery log of a webhook, oldest first.
+func (m *ParkMemoryDao) GetWebhookDeliveries(webhookId int, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, models.PageInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.hasWebhook(webhookId) {
+		return nil, models.PageInfo{}, models.EntityNotFound
+	}
+	if filter.Page.Sort.Field != "" {
+		return nil, models.PageInfo{}, models.InvalidSort
+	}
+
+	keyed := []keyedItem[models.WebhookDelivery]{}
+	for _, d := range m.deliveries {
+		if d.WebhookID != webhookId || (filter.Status != nil && d.Status != *filter.Status) {
+			continue
+		}
+		keyed = append(keyed, keyedItem[models.WebhookDelivery]{item: *d, id: d.ID})
+	}
+	return paginate(keyset{}, keyed, filter.Page)
+}
+
+func (m *ParkMemoryDao) hasWebhook(id int) bool {
+	for _, webhook := range m.webhooks {
+		if webhook.ID == id {
+			return true
+		}
+	}
+	return false
+}
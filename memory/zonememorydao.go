@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/EdgarH78/jurassic-park/models"
+	"github.com/EdgarH78/jurassic-park/zones"
+)
+
+// AddZone adds the zone, nested in its parent if it has one.
+func (m *ParkMemoryDao) AddZone(ctx context.Context, zone models.Zone) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.addZone(ctx, zone)
+}
+
+func (m *ParkMemoryDao) addZone(ctx context.Context, zone models.Zone) error {
+	if m.findZone(zone.Name) != nil {
+		return models.EntityAlreadyExists
+	}
+	if zone.Parent != nil {
+		if err := zones.CheckParent(m.zoneModels(), zone.Name, *zone.Parent); err != nil {
+			return err
+		}
+	}
+	created := zone
+	m.zones = append(m.zones, &created)
+	return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityZone, zone.Name), models.AuditCreate, nil, zone)
+}
+
+func (m *ParkMemoryDao) GetZone(name string) (*models.Zone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	zone := m.findZone(name)
+	if zone == nil {
+		return nil, models.EntityNotFound
+	}
+	found := *zone
+	return &found, nil
+}
+
+// GetZones lists every zone by name.
+func (m *ParkMemoryDao) GetZones() ([]models.Zone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.zoneModels(), nil
+}
+
+// UpdateZone applies the changes in the update request to the zone and returns the updated zone. A zone can't be
+// nested in itself or in a zone nested in it.
+func (m *ParkMemoryDao) UpdateZone(ctx context.Context, name string, update models.UpdateZoneRequest) (*models.Zone, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	zone := m.findZone(name)
+	if zone == nil {
+		return nil, models.EntityNotFound
+	}
+	if update.Parent != nil && *update.Parent != "" {
+		if err := zones.CheckParent(m.zoneModels(), name, *update.Parent); err != nil {
+			return nil, err
+		}
+	}
+
+	before := *zone
+	if update.Parent != nil {
+		zone.Parent = nil
+		if *update.Parent != "" {
+			parent := *update.Parent
+			zone.Parent = &parent
+		}
+	}
+	if update.Description != nil {
+		zone.Description = *update.Description
+	}
+	updated := *zone
+	err := m.recordAudit(ctx, models.AuditEntity(models.AuditEntityZone, name), models.AuditUpdate, before, updated)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteZone deletes the zone. Only zones without cages or zones in them can be deleted.
+func (m *ParkMemoryDao) DeleteZone(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, zone := range m.zones {
+		if zone.Name != name {
+			continue
+		}
+		if len(zones.Subtree(m.zoneModels(), name)) > 1 {
+			return models.ZoneNotEmpty
+		}
+		for _, c := range m.cages {
+			if c.zone == name {
+				return models.ZoneNotEmpty
+			}
+		}
+		m.zones = append(m.zones[:i], m.zones[i+1:]...)
+		return m.recordAudit(ctx, models.AuditEntity(models.AuditEntityZone, name), models.AuditDelete, *zone, nil)
+	}
+	return models.EntityNotFound
+}
+
+func (m *ParkMemoryDao) findZone(name string) *models.Zone {
+	for _, zone := range m.zones {
+		if zone.Name == name {
+			return zone
+		}
+	}
+	return nil
+}
+
+// zoneModels copies the zones, ordered by name as data.ParkSqlDao orders them.
+func (m *ParkMemoryDao) zoneModels() []models.Zone {
+	result := []models.Zone{}
+	for _, zone := range m.zones {
+		result = append(result, *zone)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/EdgarH78/jurassic-park/data"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+const migrateUsage = `usage: jurassic-park migrate [up | down [steps] | status]
+  up      applies every migration that hasn't been applied yet (the default), and makes the schema match
+          CAGE_LABEL_SCOPE
+  down    rolls back the last steps migrations, 1 if steps is left out
+  status  lists the migrations and whether they have been applied`
+
+// runMigrate runs the migrate subcommand, which manages the database schema without starting the server.
+func runMigrate(db *sql.DB, cageLabelScope models.CageLabelScope, args []string) error {
+	migrator, err := data.NewMigrator(db, data.WithCageLabelScope(cageLabelScope))
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch {
+	case command == "up" && len(args) <= 1:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("the database is up to date")
+		}
+		for _, migration := range applied {
+			fmt.Printf("applied %04d %s\n", migration.Version, migration.Name)
+		}
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number\n%s", migrateUsage)
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		for _, migration := range rolledBack {
+			fmt.Printf("rolled back %04d %s\n", migration.Version, migration.Name)
+		}
+	case command == "status" && len(args) == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied"
+			} else if status.Optional {
+				applied = "optional"
+			}
+			fmt.Printf("%04d %-30s %s\n", status.Version, status.Name, applied)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+	// AuditRevoke stops an API key from authenticating.
+	AuditRevoke AuditAction = "revoke"
+	// AuditAssign, AuditRemove and AuditTransfer move a dinosaur into, out of and between cages.
+	AuditAssign   AuditAction = "assign"
+	AuditRemove   AuditAction = "remove"
+	AuditTransfer AuditAction = "transfer"
+)
+
+// The types of entity that changes are audited for. An entity is identified by its type and key, as in cage:C-1.
+const (
+	AuditEntityCage     = "cage"
+	AuditEntityDinosaur = "dinosaur"
+	AuditEntitySpecies  = "species"
+	AuditEntityWebhook  = "webhook"
+	AuditEntityAPIKey   = "apikey"
+	AuditEntityFeed     = "feed"
+	// AuditEntitySchedule is a feeding schedule, and AuditEntityFeeding a feeding that was made.
+	AuditEntitySchedule = "schedule"
+	AuditEntityFeeding  = "feeding"
+	AuditEntityHealth   = "healthrecord"
+	AuditEntityZone     = "zone"
+)
+
+var auditEntityTypes = []string{AuditEntityCage, AuditEntityDinosaur, AuditEntitySpecies, AuditEntityWebhook,
+	AuditEntityAPIKey, AuditEntityFeed, AuditEntitySchedule, AuditEntityFeeding, AuditEntityHealth, AuditEntityZone}
+
+// AuditEntity identifies an entity in the audit log.
+func AuditEntity(entityType, key string) string {
+	return entityType + ":" + key
+}
+
+// IsValidAuditEntity reports whether entity is an entity type that is audited, followed by a colon and a key.
+func IsValidAuditEntity(entity string) bool {
+	entityType, key, found := strings.Cut(entity, ":")
+	if !found || key == "" {
+		return false
+	}
+	for _, t := range auditEntityTypes {
+		if t == entityType {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditEvent records a change to the park: who made it, when, and the state of the entity before and after.
+// Before is left out for entities that were created, and After for entities that were deleted.
+type AuditEvent struct {
+	ID     int             `json:"id"`
+	Actor  string          `json:"actor"`
+	Time   time.Time       `json:"time"`
+	Entity string          `json:"entity"`
+	Action AuditAction     `json:"action"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// NewAuditEvent records a change made by the actor in ctx. before and after are snapshots of the entity, and are
+// nil when there is no entity on that side of the change.
+func NewAuditEvent(ctx context.Context, entity string, action AuditAction, before, after any) (AuditEvent, error) {
+	event := AuditEvent{
+		Actor:  ActorFromContext(ctx),
+		Time:   time.Now().UTC(),
+		Entity: entity,
+		Action: action,
+	}
+	var err error
+	if before != nil {
+		if event.Before, err = json.Marshal(before); err != nil {
+			return AuditEvent{}, err
+		}
+	}
+	if after != nil {
+		if event.After, err = json.Marshal(after); err != nil {
+			return AuditEvent{}, err
+		}
+	}
+	return event, nil
+}
+
+type AuditFilter struct {
+	Entity *string
+	// Since leaves out the changes made before it
+	Since *time.Time
+	Page  Pagination
+}
+
+// SystemActor is the actor of changes made outside of a request, for example by tests and tools that use a park
+// manager directly.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that records changes made with it against the actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor that changes made with ctx are recorded against.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
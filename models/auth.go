@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Role grants access to a part of the API.
+type Role string
+
+const (
+	// RoleViewer reads the park, but can't change it.
+	RoleViewer Role = "viewer"
+	// RoleKeeper manages cages, dinosaurs, species and feeding, apart from cage power.
+	RoleKeeper Role = "keeper"
+	// RolePowerOperator changes the power of cages.
+	RolePowerOperator Role = "power-operator"
+	// RoleVet keeps the health records of dinosaurs, which also puts them in and out of quarantine.
+	RoleVet Role = "vet"
+	// RoleAdmin holds every other role, and also manages API keys and webhooks and reads the audit log.
+	RoleAdmin Role = "admin"
+)
+
+var Roles = []Role{RoleViewer, RoleKeeper, RolePowerOperator, RoleVet, RoleAdmin}
+
+func IsValidRole(role Role) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal is who a request was authenticated as. Its name is the name of the API key, or the subject of the
+// bearer token, and is the actor that the changes it makes are recorded against in the audit log.
+type Principal struct {
+	Name  string
+	Roles []Role
+}
+
+// HasRole reports whether the principal holds the role. Admins hold every role, and every role can view the park.
+func (p Principal) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if r == role || r == RoleAdmin || role == RoleViewer {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey authenticates requests as the principal with its name and roles. Only a hash of the key is stored, so the
+// key itself is only returned when it is created. Revoked keys are kept so that the audit log can still be read
+// against them, but they no longer authenticate.
+type APIKey struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name" validate:"required,trimmed,max=64"`
+	Roles []Role `json:"roles" validate:"min=1,dive,role"`
+	Key   string `json:"key,omitempty"`
+	// Prefix is the start of the key, which is enough to recognize it but not to use it.
+	Prefix      string     `json:"prefix"`
+	KeyHash     string     `json:"-"`
+	CreatedTime time.Time  `json:"createdTime"`
+	RevokedTime *time.Time `json:"revokedTime,omitempty"`
+}
+
+// Principal returns who the key authenticates requests as.
+func (k APIKey) Principal() Principal {
+	return Principal{Name: k.Name, Roles: append([]Role{}, k.Roles...)}
+}
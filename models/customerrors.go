@@ -1,12 +1,115 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
-	EntityNotFound             = errors.New("Entity Not Found")
-	InvalidDinosaurSpecies     = errors.New("Invalid Dinosaur Species")
-	EntityAlreadyExists        = errors.New("Entity already exists")
-	CageCapacityExceeded       = errors.New("Cage capacity exceeded")
-	IncompatibleSpecies        = errors.New("Incompatible Species")
-	IncompatibleCagePowerState = errors.New("Incompatible Cage Power State")
+	EntityNotFound               = errors.New("Entity Not Found")
+	InvalidDinosaurSpecies       = errors.New("Invalid Dinosaur Species")
+	InvalidDinosaurSex           = errors.New("Invalid Dinosaur Sex")
+	EntityAlreadyExists          = errors.New("Entity already exists")
+	CageCapacityExceeded         = errors.New("Cage capacity exceeded")
+	IncompatibleSpecies          = errors.New("Incompatible Species")
+	BreedingPairNotAllowed       = errors.New("Breeding Pair Not Allowed")
+	IncompatibleCagePowerState   = errors.New("Incompatible Cage Power State")
+	InvalidSpeciesDiet           = errors.New("Invalid Species Diet")
+	EntityInUse                  = errors.New("Entity in use")
+	DinosaurNotInCage            = errors.New("Dinosaur not in cage")
+	DinosaurNotCaged             = errors.New("Dinosaur not caged")
+	CageCapacityBelowOccupancy   = errors.New("Cage capacity below occupancy")
+	CageNotEmpty                 = errors.New("Cage not empty")
+	InvalidPowerStatus           = errors.New("Invalid Power Status")
+	InvalidPowerStatusTransition = errors.New("Invalid Power Status Transition")
+	InvalidCursor                = errors.New("Invalid Cursor")
+	InvalidSort                  = errors.New("Invalid Sort")
+	MissingCredentials           = errors.New("Missing Credentials")
+	InvalidCredentials           = errors.New("Invalid Credentials")
+	InvalidFeedingDate           = errors.New("Invalid Feeding Date")
+	InsufficientFeedStock        = errors.New("Insufficient Feed Stock")
+	NoDinosaursToFeed            = errors.New("No Dinosaurs To Feed")
+	DinosaurQuarantined          = errors.New("Dinosaur Quarantined")
+	InvalidZoneParent            = errors.New("Invalid Zone Parent")
+	InvalidCageZone              = errors.New("Invalid Cage Zone")
+	ZoneNotEmpty                 = errors.New("Zone not empty")
+	InvalidRequest               = errors.New("Invalid Request")
+	InvalidImportFile            = errors.New("Invalid Import File")
 )
+
+// RuleViolation is a species compatibility rule that adding a dinosaur to a cage would break.
+type RuleViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// CompatibilityError lists the compatibility rules that adding a dinosaur to a cage would break. It matches
+// IncompatibleSpecies with errors.Is.
+type CompatibilityError struct {
+	Violations []RuleViolation
+}
+
+func (e *CompatibilityError) Error() string {
+	messages := []string{}
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return IncompatibleSpecies.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *CompatibilityError) Is(target error) bool {
+	return target == IncompatibleSpecies
+}
+
+// CredentialsError explains why the credentials a request was sent with were refused. It matches
+// InvalidCredentials with errors.Is.
+type CredentialsError struct {
+	Reason string
+}
+
+func (e *CredentialsError) Error() string {
+	return InvalidCredentials.Error() + ": " + e.Reason
+}
+
+func (e *CredentialsError) Is(target error) bool {
+	return target == InvalidCredentials
+}
+
+// FieldError is a field of a request that is invalid, by its name in JSON, and why.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError lists every invalid field of a request. It matches InvalidRequest with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := []string{}
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+" "+field.Reason)
+	}
+	return InvalidRequest.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == InvalidRequest
+}
+
+// ImportFileError explains why an import file couldn't be read, and the line it couldn't be read at. It matches
+// InvalidImportFile with errors.Is.
+type ImportFileError struct {
+	Line   int
+	Reason string
+}
+
+func (e *ImportFileError) Error() string {
+	return fmt.Sprintf("%s: line %d: %s", InvalidImportFile.Error(), e.Line, e.Reason)
+}
+
+func (e *ImportFileError) Is(target error) bool {
+	return target == InvalidImportFile
+}
@@ -0,0 +1,21 @@
+package models
+
+// CageAssignment is the data of the events for a dinosaur being added to, removed from or transferred to a cage.
+// The validate tags check an assignment that is being imported.
+type CageAssignment struct {
+	Dinosaur string `json:"dinosaur" validate:"required"`
+	Cage     string `json:"cage" validate:"required"`
+}
+
+// DeletedCage is the data of the event for a cage being decommissioned.
+type DeletedCage struct {
+	Label string `json:"label"`
+}
+
+// RefusedPowerCut is the data of the event for a request to cut the power to a cage that was refused, because the
+// cage has dinosaurs in it.
+type RefusedPowerCut struct {
+	Label                string      `json:"label"`
+	Occupancy            int         `json:"occupancy"`
+	RequestedPowerStatus PowerStatus `json:"requestedPowerStatus"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// FeedingDateFormat is the format of the dates that feedings are made on, such as 2023-06-01.
+const FeedingDateFormat = "2006-01-02"
+
+// Feed is something dinosaurs are fed, such as goats or hay, along with how much of it is in stock. Stock is counted
+// in the feed's unit, such as head or kg.
+type Feed struct {
+	Name string `json:"name" validate:"required,max=32"`
+	// Diet is the diet of the dinosaurs that eat the feed, Carnivore or Herbivore. It is checked when it is stored.
+	Diet  string `json:"diet"`
+	Unit  string `json:"unit" validate:"required,max=16"`
+	Stock int    `json:"stock" validate:"min=0"`
+	// LowStockThreshold is the stock at or below which the feed is low on stock and has to be restocked.
+	LowStockThreshold int `json:"lowStockThreshold" validate:"min=0"`
+}
+
+func (f Feed) IsLowOnStock() bool {
+	return f.Stock <= f.LowStockThreshold
+}
+
+type FeedFilter struct {
+	LowStock *bool
+}
+
+// RestockRequest adds to the stock of a feed.
+type RestockRequest struct {
+	Quantity int `json:"quantity" validate:"gt=0"`
+}
+
+// FeedingSchedule feeds the dinosaurs in a cage every day at a time. Each dinosaur in the cage with the feed's
+// diet is fed Quantity of the feed.
+type FeedingSchedule struct {
+	ID   int    `json:"id"`
+	Cage string `json:"cage" validate:"required"`
+	Feed string `json:"feed" validate:"required"`
+	// Time is the time of day the feeding is due, as in 06:00.
+	Time     string `json:"time" validate:"timeofday"`
+	Quantity int    `json:"quantity" validate:"gt=0"`
+}
+
+type FeedingScheduleFilter struct {
+	Cage *string
+}
+
+// Feeding records that a scheduled feeding was made. The cage and feed are recorded by name, so that the feeding
+// is still recorded after its schedule or cage is gone.
+type Feeding struct {
+	ID         int       `json:"id"`
+	ScheduleID int       `json:"scheduleId"`
+	Cage       string    `json:"cage"`
+	Feed       string    `json:"feed"`
+	Quantity   int       `json:"quantity"`
+	Date       string    `json:"date"`
+	FedTime    time.Time `json:"fedTime"`
+	FedBy      string    `json:"fedBy"`
+}
+
+type FeedingFilter struct {
+	Date *string
+	Cage *string
+}
+
+// RecordFeedingRequest records that a scheduled feeding was made. Date defaults to today.
+type RecordFeedingRequest struct {
+	ScheduleID int    `json:"scheduleId"`
+	Date       string `json:"date"`
+}
+
+// RosterEntry is a scheduled feeding on the day's roster, with the dinosaurs that are fed and how much feed they
+// need between them. Feeding is set once the feeding has been made.
+type RosterEntry struct {
+	ScheduleID int      `json:"scheduleId"`
+	Cage       string   `json:"cage"`
+	Time       string   `json:"time"`
+	Feed       string   `json:"feed"`
+	Unit       string   `json:"unit"`
+	Quantity   int      `json:"quantity"`
+	Dinosaurs  []string `json:"dinosaurs"`
+	Feeding    *Feeding `json:"feeding,omitempty"`
+}
+
+// LowFeedStock is the data of the event for a feed running low on stock.
+type LowFeedStock struct {
+	Feed              string `json:"feed"`
+	Unit              string `json:"unit"`
+	Stock             int    `json:"stock"`
+	LowStockThreshold int    `json:"lowStockThreshold"`
+}
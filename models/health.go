@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// HealthRecordType is the kind of entry in a dinosaur's medical history.
+type HealthRecordType string
+
+const (
+	HealthRecordWeight      HealthRecordType = "weight"
+	HealthRecordExamination HealthRecordType = "examination"
+	HealthRecordTreatment   HealthRecordType = "treatment"
+	HealthRecordMedication  HealthRecordType = "medication"
+	// HealthRecordQuarantine puts the dinosaur in quarantine, until a later HealthRecordRelease.
+	HealthRecordQuarantine HealthRecordType = "quarantine"
+	HealthRecordRelease    HealthRecordType = "release"
+)
+
+var HealthRecordTypes = []HealthRecordType{HealthRecordWeight, HealthRecordExamination, HealthRecordTreatment,
+	HealthRecordMedication, HealthRecordQuarantine, HealthRecordRelease}
+
+func (t HealthRecordType) IsValid() bool {
+	for _, recordType := range HealthRecordTypes {
+		if recordType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangesQuarantine reports whether records of the type put dinosaurs in or out of quarantine.
+func (t HealthRecordType) ChangesQuarantine() bool {
+	return t == HealthRecordQuarantine || t == HealthRecordRelease
+}
+
+// HealthRecord is an entry in a dinosaur's medical history. A dinosaur is quarantined when its latest quarantine or
+// release record is a quarantine record.
+type HealthRecord struct {
+	ID       int              `json:"id"`
+	Dinosaur string           `json:"dinosaur"`
+	Type     HealthRecordType `json:"type" validate:"healthrecordtype"`
+	// Time is when the dinosaur was weighed, examined, treated or medicated, or put in or out of quarantine.
+	Time time.Time `json:"time" validate:"notfuture"`
+	// WeightKg is only set on weight records, and Medication and Dosage on medication records.
+	WeightKg   *float64 `json:"weightKg,omitempty" validate:"omitempty,gt=0"`
+	Medication string   `json:"medication,omitempty" validate:"max=64"`
+	Dosage     string   `json:"dosage,omitempty" validate:"max=64"`
+	Notes      string   `json:"notes,omitempty" validate:"max=1024"`
+	RecordedBy string   `json:"recordedBy"`
+}
+
+// checkFields checks that the details are only given on the records they belong to.
+func (r HealthRecord) checkFields() []FieldError {
+	fields := []FieldError{}
+	if r.Type == HealthRecordWeight && r.WeightKg == nil {
+		fields = append(fields, FieldError{Field: "weightKg", Reason: "is required on weight records"})
+	} else if r.Type != HealthRecordWeight && r.WeightKg != nil {
+		fields = append(fields, FieldError{Field: "weightKg", Reason: "can only be given on weight records"})
+	}
+	if r.Type == HealthRecordMedication && r.Medication == "" {
+		fields = append(fields, FieldError{Field: "medication", Reason: "is required on medication records"})
+	} else if r.Type != HealthRecordMedication && r.Medication != "" {
+		fields = append(fields, FieldError{Field: "medication", Reason: "can only be given on medication records"})
+	}
+	if r.Type != HealthRecordMedication && r.Dosage != "" {
+		fields = append(fields, FieldError{Field: "dosage", Reason: "can only be given on medication records"})
+	}
+	return fields
+}
+
+type HealthRecordFilter struct {
+	Type *HealthRecordType
+}
+
+// CheckQuarantine returns DinosaurQuarantined if putting the dinosaur in a cage with the occupants would have a
+// quarantined dinosaur share its cage.
+func CheckQuarantine(dinosaur Dinosaur, occupants []Dinosaur) error {
+	if dinosaur.Quarantined && len(occupants) > 0 {
+		return DinosaurQuarantined
+	}
+	for _, occupant := range occupants {
+		if occupant.Quarantined {
+			return DinosaurQuarantined
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package models
+
+// InventoryFormat is the format of an import or export file.
+type InventoryFormat string
+
+const (
+	// InventoryFormatCSV is a CSV file with a header row naming its columns.
+	InventoryFormatCSV InventoryFormat = "csv"
+	// InventoryFormatNDJSON is a file with one JSON object on each line.
+	InventoryFormatNDJSON InventoryFormat = "ndjson"
+)
+
+var InventoryFormats = []InventoryFormat{InventoryFormatCSV, InventoryFormatNDJSON}
+
+// InventoryKind is the kind of item that an inventory row describes.
+type InventoryKind string
+
+const (
+	InventoryKindZone       InventoryKind = "zone"
+	InventoryKindCage       InventoryKind = "cage"
+	InventoryKindDinosaur   InventoryKind = "dinosaur"
+	InventoryKindAssignment InventoryKind = "assignment"
+)
+
+// InventoryRow is a row of an import or export file. Zones use name, parent and description, cages use label,
+// maxOccupancy, powerStatus and zone, dinosaurs use name, species and sex, and the assignment of a dinosaur to a cage
+// uses dinosaur and cage.
+type InventoryRow struct {
+	Kind         InventoryKind `json:"kind"`
+	Label        string        `json:"label,omitempty"`
+	MaxOccupancy int           `json:"maxOccupancy,omitempty"`
+	PowerStatus  PowerStatus   `json:"powerStatus,omitempty"`
+	Zone         *string       `json:"zone,omitempty"`
+	Name         string        `json:"name,omitempty"`
+	Species      string        `json:"species,omitempty"`
+	Sex          Sex           `json:"sex,omitempty"`
+	Dinosaur     string        `json:"dinosaur,omitempty"`
+	Cage         string        `json:"cage,omitempty"`
+	Parent       *string       `json:"parent,omitempty"`
+	Description  string        `json:"description,omitempty"`
+}
+
+// ZoneRow returns the row for a zone.
+func ZoneRow(zone Zone) InventoryRow {
+	return InventoryRow{
+		Kind:        InventoryKindZone,
+		Name:        zone.Name,
+		Parent:      zone.Parent,
+		Description: zone.Description,
+	}
+}
+
+// CageRow returns the row for a cage.
+func CageRow(cage Cage) InventoryRow {
+	return InventoryRow{
+		Kind:         InventoryKindCage,
+		Label:        cage.Label,
+		MaxOccupancy: cage.MaxOccupancy,
+		PowerStatus:  cage.PowerStatus,
+		Zone:         cage.Zone,
+	}
+}
+
+// DinosaurRow returns the row for a dinosaur. The cage it is in is a separate assignment row.
+func DinosaurRow(dinosaur Dinosaur) InventoryRow {
+	return InventoryRow{
+		Kind:    InventoryKindDinosaur,
+		Name:    dinosaur.Name,
+		Species: dinosaur.Species,
+		Sex:     dinosaur.Sex,
+	}
+}
+
+// AssignmentRow returns the row for a dinosaur being in a cage.
+func AssignmentRow(dinosaurName, cageLabel string) InventoryRow {
+	return InventoryRow{
+		Kind:     InventoryKindAssignment,
+		Dinosaur: dinosaurName,
+		Cage:     cageLabel,
+	}
+}
+
+// ZoneModel returns the zone that a zone row adds.
+func (r InventoryRow) ZoneModel() Zone {
+	return Zone{
+		Name:        r.Name,
+		Parent:      r.Parent,
+		Description: r.Description,
+	}
+}
+
+// CageV2 returns the cage that a cage row adds. Like a cage added through the v2 API, a cage is active unless it is
+// given another power status.
+func (r InventoryRow) CageV2() CageV2 {
+	cage := CageV2{
+		Label:        r.Label,
+		MaxOccupancy: r.MaxOccupancy,
+		PowerStatus:  r.PowerStatus,
+		Zone:         r.Zone,
+	}
+	if cage.PowerStatus == "" {
+		cage.PowerStatus = PowerStatusActive
+	}
+	return cage
+}
+
+// DinosaurModel returns the dinosaur that a dinosaur row adds.
+func (r InventoryRow) DinosaurModel() Dinosaur {
+	return Dinosaur{
+		Name:    r.Name,
+		Species: r.Species,
+		Sex:     r.Sex,
+	}
+}
+
+// Assignment returns the cage assignment that an assignment row makes.
+func (r InventoryRow) Assignment() CageAssignment {
+	return CageAssignment{
+		Dinosaur: r.Dinosaur,
+		Cage:     r.Cage,
+	}
+}
+
+// ImportMode decides what happens to the rows of an import when some of them fail.
+type ImportMode string
+
+const (
+	// ImportModeAtomic imports every row or none of them.
+	ImportModeAtomic ImportMode = "atomic"
+	// ImportModePerRow imports the rows that succeed and reports the rows that fail.
+	ImportModePerRow ImportMode = "per-row"
+)
+
+var ImportModes = []ImportMode{ImportModeAtomic, ImportModePerRow}
+
+// ImportOptions configures an import. A dry run checks every row as if it were imported, and then rolls the
+// import back whatever the mode.
+type ImportOptions struct {
+	Mode   ImportMode
+	DryRun bool
+}
+
+// ImportStatus is the outcome of importing a row.
+type ImportStatus string
+
+const (
+	// ImportStatusApplied rows were applied. They are only kept if the import was committed.
+	ImportStatusApplied ImportStatus = "applied"
+	ImportStatusFailed  ImportStatus = "failed"
+)
+
+// ImportRowResult is the outcome of importing a row of the file. Line is the line of the file the row is on.
+type ImportRowResult struct {
+	Line   int          `json:"line"`
+	Row    InventoryRow `json:"row"`
+	Status ImportStatus `json:"status"`
+	// Code, Detail, Fields and Violations explain why a row failed, the same way an error response would.
+	Code       ErrorCode       `json:"code,omitempty"`
+	Detail     string          `json:"detail,omitempty"`
+	Fields     []FieldError    `json:"fields,omitempty"`
+	Violations []RuleViolation `json:"violations,omitempty"`
+	// Err is the error that the row failed with.
+	Err error `json:"-"`
+}
+
+// ImportReport is the outcome of an import. Committed is true when the applied rows were kept, which never happens
+// in a dry run, and only happens in atomic mode when every row was applied.
+type ImportReport struct {
+	Format    InventoryFormat   `json:"format"`
+	Mode      ImportMode        `json:"mode"`
+	DryRun    bool              `json:"dryRun"`
+	Committed bool              `json:"committed"`
+	Applied   int               `json:"applied"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
@@ -1,37 +1,155 @@
 package models
 
+// Cage is the v1 representation of a cage. HasPower is a view over PowerStatus, which is only exposed by the v2
+// API through CageV2. The validate tags check a cage that is being added, whose occupancy is counted by the park.
 type Cage struct {
-	Label        string `json:"label"`
-	Occupancy    int    `json:"occupancy"`
-	MaxOccupancy int    `json:"maxOccupancy"`
-	HasPower     bool   `json:"hasPower"`
+	Label        string      `json:"label" validate:"required,max=16"`
+	Occupancy    int         `json:"occupancy" validate:"isdefault"`
+	MaxOccupancy int         `json:"maxOccupancy" validate:"gt=0"`
+	HasPower     bool        `json:"hasPower"`
+	PowerStatus  PowerStatus `json:"-"`
+	// Zone is the name of the zone the cage is in, if it is in one.
+	Zone *string `json:"zone,omitempty"`
 }
 
+// RequestedPowerStatus returns the power status for a new cage, falling back to the v1 HasPower flag when no
+// PowerStatus was given.
+func (c Cage) RequestedPowerStatus() PowerStatus {
+	if c.PowerStatus != "" {
+		return c.PowerStatus
+	}
+	return PowerStatusFromHasPower(c.HasPower)
+}
+
+// CageV2 is the v2 representation of a cage, where power is tracked as a PowerStatus.
+type CageV2 struct {
+	Label        string      `json:"label" validate:"required,max=16"`
+	Occupancy    int         `json:"occupancy" validate:"isdefault"`
+	MaxOccupancy int         `json:"maxOccupancy" validate:"gt=0"`
+	PowerStatus  PowerStatus `json:"powerStatus"`
+	Zone         *string     `json:"zone,omitempty"`
+}
+
+func NewCageV2(cage Cage) CageV2 {
+	return CageV2{
+		Label:        cage.Label,
+		Occupancy:    cage.Occupancy,
+		MaxOccupancy: cage.MaxOccupancy,
+		PowerStatus:  cage.PowerStatus,
+		Zone:         cage.Zone,
+	}
+}
+
+// Cage converts the v2 representation into a Cage, filling in the v1 HasPower view.
+func (c CageV2) Cage() Cage {
+	return Cage{
+		Label:        c.Label,
+		Occupancy:    c.Occupancy,
+		MaxOccupancy: c.MaxOccupancy,
+		HasPower:     c.PowerStatus.HasPower(),
+		PowerStatus:  c.PowerStatus,
+		Zone:         c.Zone,
+	}
+}
+
+// Dinosaur is a dinosaur in the park. The validate tags check a dinosaur that is being added, whose diet comes from
+// its species and whose cage is assigned once it is in the park.
 type Dinosaur struct {
-	Name    string  `json:"name"`
-	Species string  `json:"species"`
-	Diet    string  `json:"diet"`
-	Cage    *string `json:"cage,omitempty"`
+	Name    string  `json:"name" validate:"required,max=16"`
+	Species string  `json:"species" validate:"required"`
+	Sex     Sex     `json:"sex"`
+	Diet    string  `json:"diet" validate:"isdefault"`
+	Cage    *string `json:"cage,omitempty" validate:"isdefault"`
+	// Quarantined dinosaurs can't share a cage. It is set by the dinosaur's health records.
+	Quarantined bool `json:"quarantined,omitempty" validate:"isdefault"`
+}
+
+// RequestedSex returns the sex for a new dinosaur. Dinosaurs are female unless they are known to be male.
+func (d Dinosaur) RequestedSex() Sex {
+	if d.Sex != "" {
+		return d.Sex
+	}
+	return SexFemale
+}
+
+// UpdateDinosaurRequest changes the fields of a dinosaur that are set. Sex is changed to record a dinosaur that has
+// changed sex.
+type UpdateDinosaurRequest struct {
+	Sex *Sex `json:"sex,omitempty"`
+}
+
+type Species struct {
+	Name string `json:"name" validate:"required,max=16"`
+	Diet string `json:"diet" validate:"required"`
 }
 
 type AddDinosaurToCageRequest struct {
-	Name string `json:"name"`
+	Name string `json:"name" validate:"required"`
+}
+
+type TransferDinosaurRequest struct {
+	Cage string `json:"cage" validate:"required"`
 }
 
 type UpdateCagePowerStatusRequest struct {
 	HasPower bool `json:"hasPower"`
 }
 
+// UpdateCageRequest changes the fields of a cage that are set. Fields that are nil are left unchanged. HasPower is
+// the v1 way of changing power and is only used when PowerStatus is nil. An empty Zone takes the cage out of its zone.
+type UpdateCageRequest struct {
+	Label        *string      `json:"label,omitempty" validate:"omitempty,min=1,max=16"`
+	MaxOccupancy *int         `json:"maxOccupancy,omitempty" validate:"omitempty,gt=0"`
+	HasPower     *bool        `json:"hasPower,omitempty"`
+	PowerStatus  *PowerStatus `json:"-"`
+	Zone         *string      `json:"zone,omitempty"`
+}
+
+// RequestedPowerStatus returns the power status the update asks for, or nil if power isn't being changed.
+func (u UpdateCageRequest) RequestedPowerStatus() *PowerStatus {
+	if u.PowerStatus != nil {
+		return u.PowerStatus
+	}
+	if u.HasPower != nil {
+		powerStatus := PowerStatusFromHasPower(*u.HasPower)
+		return &powerStatus
+	}
+	return nil
+}
+
+type UpdateCageV2Request struct {
+	Label        *string      `json:"label,omitempty" validate:"omitempty,min=1,max=16"`
+	MaxOccupancy *int         `json:"maxOccupancy,omitempty" validate:"omitempty,gt=0"`
+	PowerStatus  *PowerStatus `json:"powerStatus,omitempty"`
+	Zone         *string      `json:"zone,omitempty"`
+}
+
+func (u UpdateCageV2Request) UpdateCageRequest() UpdateCageRequest {
+	return UpdateCageRequest{
+		Label:        u.Label,
+		MaxOccupancy: u.MaxOccupancy,
+		PowerStatus:  u.PowerStatus,
+		Zone:         u.Zone,
+	}
+}
+
 type DinosaurFilter struct {
 	Species             *string
+	Sex                 *Sex
 	Diet                *string
 	NeedsCageAssignment *bool
+	Quarantined         *bool
+	Page                Pagination
 }
 
 type CageFilter struct {
-	HasPower *bool
-}
-
-type ErrorResponse struct {
-	ErrorMessage string `json:"errorMessage"`
+	HasPower    *bool
+	PowerStatus *PowerStatus
+	// CanHouse is the name of a dinosaur. Only cages that the dinosaur could be added to are returned, ranked
+	// with cages that already hold its species first.
+	CanHouse *string
+	// Zone is the name of a zone. Only cages in the zone or in the zones nested in it are returned.
+	Zone *string
+	// Page can't be sorted when CanHouse is set, because the cages are already ranked.
+	Page Pagination
 }
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// The fields that lists can be sorted on.
+const (
+	SortByLabel        = "label"
+	SortByOccupancy    = "occupancy"
+	SortByMaxOccupancy = "maxOccupancy"
+	SortByName         = "name"
+	SortBySpecies      = "species"
+)
+
+var (
+	CageSortFields     = []string{SortByLabel, SortByOccupancy, SortByMaxOccupancy}
+	DinosaurSortFields = []string{SortByName, SortBySpecies}
+)
+
+// Pagination selects a page of a list. The zero value selects the whole list in its default order.
+type Pagination struct {
+	// Limit is the largest number of items to return. Zero means no limit.
+	Limit int
+	Sort  Sort
+	// After continues the list from the cursor returned with a previous page.
+	After *Cursor
+	// IncludeTotal asks for the number of items across all pages.
+	IncludeTotal bool
+}
+
+// Sort orders a list by one of its fields. Items with the same value are kept in the order they were created in,
+// which is also the order of the zero value.
+type Sort struct {
+	Field      string
+	Descending bool
+}
+
+// ParseSort reads a sort such as "occupancy" or "-occupancy", where a leading - sorts in descending order. The field
+// must be one of fields.
+func ParseSort(sort string, fields []string) (Sort, error) {
+	descending := strings.HasPrefix(sort, "-")
+	field := strings.TrimPrefix(sort, "-")
+	for _, f := range fields {
+		if f == field {
+			return Sort{Field: field, Descending: descending}, nil
+		}
+	}
+	return Sort{}, InvalidSort
+}
+
+func (s Sort) String() string {
+	if s.Descending {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Cursor marks the last item of a page, so that the next page can carry on after it even if items are added or
+// removed in between. Clients only ever see it encoded.
+type Cursor struct {
+	// Sort is the sort the page was read with. A cursor can only be used with the same sort.
+	Sort string `json:"sort,omitempty"`
+	// Value is the last item's value for the sort field.
+	Value string `json:"value,omitempty"`
+	// ID is the last item's internal id, which orders items with the same Value.
+	ID int `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	encoded, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, InvalidCursor
+	}
+	cursor := Cursor{}
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, InvalidCursor
+	}
+	return &cursor, nil
+}
+
+// PageInfo describes where a page sits in the full list.
+type PageInfo struct {
+	// Next is the cursor for the following page, or nil if this is the last page.
+	Next *Cursor
+	// Total is the number of items across all pages. It is only set when the Pagination asked for it.
+	Total *int
+}
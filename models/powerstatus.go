@@ -0,0 +1,73 @@
+package models
+
+// PowerStatus is the state of the power supply to a cage.
+type PowerStatus string
+
+const (
+	// PowerStatusActive is a fully powered cage. It is the only state that accepts new dinosaurs.
+	PowerStatusActive PowerStatus = "ACTIVE"
+	// PowerStatusMaintenance is a powered cage that is being serviced. Dinosaurs already in the cage may stay, but
+	// no new dinosaurs can be added.
+	PowerStatusMaintenance PowerStatus = "MAINTENANCE"
+	// PowerStatusFailing is a powered cage whose power supply is unreliable. Dinosaurs already in the cage may
+	// stay, but no new dinosaurs can be added.
+	PowerStatusFailing PowerStatus = "FAILING"
+	// PowerStatusDown is a cage without power. Only empty cages can be down.
+	PowerStatusDown PowerStatus = "DOWN"
+)
+
+// powerStatusTransitions lists the states that each state can move to. A cage can always stay in its current state.
+var powerStatusTransitions = map[PowerStatus][]PowerStatus{
+	PowerStatusActive:      {PowerStatusMaintenance, PowerStatusFailing, PowerStatusDown},
+	PowerStatusMaintenance: {PowerStatusActive, PowerStatusFailing, PowerStatusDown},
+	PowerStatusFailing:     {PowerStatusActive, PowerStatusMaintenance, PowerStatusDown},
+	// a cage without power can't start failing
+	PowerStatusDown: {PowerStatusActive, PowerStatusMaintenance},
+}
+
+// PowerStatusFromHasPower maps the v1 hasPower flag onto a power status.
+func PowerStatusFromHasPower(hasPower bool) PowerStatus {
+	if hasPower {
+		return PowerStatusActive
+	}
+	return PowerStatusDown
+}
+
+func (p PowerStatus) IsValid() bool {
+	_, ok := powerStatusTransitions[p]
+	return ok
+}
+
+// HasPower is the v1 view of the power status. Every state other than DOWN supplies power to the cage.
+func (p PowerStatus) HasPower() bool {
+	return p != PowerStatusDown
+}
+
+// AcceptsNewDinosaurs reports whether dinosaurs can be added to a cage in this state.
+func (p PowerStatus) AcceptsNewDinosaurs() bool {
+	return p == PowerStatusActive
+}
+
+// CanHoldDinosaurs reports whether a cage in this state may have dinosaurs in it.
+func (p PowerStatus) CanHoldDinosaurs() bool {
+	return p != PowerStatusDown
+}
+
+// CheckTransition returns an error if a cage holding occupancy dinosaurs can't move from this state to next.
+func (p PowerStatus) CheckTransition(next PowerStatus, occupancy int) error {
+	if !next.IsValid() {
+		return InvalidPowerStatus
+	}
+	if !next.CanHoldDinosaurs() && occupancy > 0 {
+		return IncompatibleCagePowerState
+	}
+	if p == next {
+		return nil
+	}
+	for _, allowed := range powerStatusTransitions[p] {
+		if allowed == next {
+			return nil
+		}
+	}
+	return InvalidPowerStatusTransition
+}
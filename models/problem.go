@@ -0,0 +1,106 @@
+package models
+
+// ProblemContentType is the media type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// ErrorCode identifies the kind of error in an error response. Codes are stable, so programs can tell errors apart
+// by their code rather than by their message.
+type ErrorCode string
+
+const (
+	CodeNotFound                     ErrorCode = "NOT_FOUND"
+	CodeAlreadyExists                ErrorCode = "ALREADY_EXISTS"
+	CodeInvalidRequestBody           ErrorCode = "INVALID_REQUEST_BODY"
+	CodeValidationFailed             ErrorCode = "VALIDATION_FAILED"
+	CodeInvalidParameter             ErrorCode = "INVALID_PARAMETER"
+	CodeImportFileTooLarge           ErrorCode = "IMPORT_FILE_TOO_LARGE"
+	CodeInvalidCursor                ErrorCode = "INVALID_CURSOR"
+	CodeInvalidSort                  ErrorCode = "INVALID_SORT"
+	CodeMissingCredentials           ErrorCode = "MISSING_CREDENTIALS"
+	CodeInvalidCredentials           ErrorCode = "INVALID_CREDENTIALS"
+	CodeForbidden                    ErrorCode = "FORBIDDEN"
+	CodeInternalError                ErrorCode = "INTERNAL_ERROR"
+	CodeInvalidDinosaurSpecies       ErrorCode = "INVALID_DINOSAUR_SPECIES"
+	CodeInvalidDinosaurSex           ErrorCode = "INVALID_DINOSAUR_SEX"
+	CodeInvalidSpeciesDiet           ErrorCode = "INVALID_SPECIES_DIET"
+	CodeEntityInUse                  ErrorCode = "ENTITY_IN_USE"
+	CodeCageCapacityExceeded         ErrorCode = "CAGE_CAPACITY_EXCEEDED"
+	CodeCageCapacityBelowOccupancy   ErrorCode = "CAGE_CAPACITY_BELOW_OCCUPANCY"
+	CodeCageNotEmpty                 ErrorCode = "CAGE_NOT_EMPTY"
+	CodeIncompatibleSpecies          ErrorCode = "INCOMPATIBLE_SPECIES"
+	CodeBreedingPairNotAllowed       ErrorCode = "BREEDING_PAIR_NOT_ALLOWED"
+	CodeDinosaurNotInCage            ErrorCode = "DINOSAUR_NOT_IN_CAGE"
+	CodeDinosaurNotCaged             ErrorCode = "DINOSAUR_NOT_CAGED"
+	CodeDinosaurQuarantined          ErrorCode = "DINOSAUR_QUARANTINED"
+	CodeIncompatibleCagePowerState   ErrorCode = "INCOMPATIBLE_CAGE_POWER_STATE"
+	CodeInvalidPowerStatus           ErrorCode = "INVALID_POWER_STATUS"
+	CodeInvalidPowerStatusTransition ErrorCode = "INVALID_POWER_STATUS_TRANSITION"
+	CodeInvalidFeedingDate           ErrorCode = "INVALID_FEEDING_DATE"
+	CodeInsufficientFeedStock        ErrorCode = "INSUFFICIENT_FEED_STOCK"
+	CodeNoDinosaursToFeed            ErrorCode = "NO_DINOSAURS_TO_FEED"
+	CodeInvalidZoneParent            ErrorCode = "INVALID_ZONE_PARENT"
+	CodeInvalidCageZone              ErrorCode = "INVALID_CAGE_ZONE"
+	CodeZoneNotEmpty                 ErrorCode = "ZONE_NOT_EMPTY"
+	CodeInvalidImportFile            ErrorCode = "INVALID_IMPORT_FILE"
+)
+
+// ErrorResponse is the body of every error response. It is a problem details object, as described by RFC 7807,
+// and is sent as application/problem+json.
+type ErrorResponse struct {
+	// Type is about:blank for every error, so Title is the HTTP status text and Code tells errors apart.
+	Type   string    `json:"type"`
+	Title  string    `json:"title"`
+	Status int       `json:"status"`
+	Detail string    `json:"detail"`
+	Code   ErrorCode `json:"code"`
+	// Instance is the path of the request that failed.
+	Instance string `json:"instance,omitempty"`
+	// Context holds the values that the error is about, such as the label and capacity of a full cage.
+	Context map[string]any `json:"context,omitempty"`
+	// ErrorMessage repeats Detail for clients written before errors were sent as problem details.
+	ErrorMessage string `json:"errorMessage"`
+	// Violations lists the compatibility rules that a cage assignment would break.
+	Violations []RuleViolation `json:"violations,omitempty"`
+	// Fields lists every invalid field of a request that failed validation.
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// codeErrors maps the codes of error responses back to the errors in models that they report. Codes that aren't
+// reported for an error in models, such as INVALID_PARAMETER, aren't in it.
+var codeErrors = map[ErrorCode]error{
+	CodeNotFound:                     EntityNotFound,
+	CodeAlreadyExists:                EntityAlreadyExists,
+	CodeEntityInUse:                  EntityInUse,
+	CodeInvalidCursor:                InvalidCursor,
+	CodeInvalidSort:                  InvalidSort,
+	CodeValidationFailed:             InvalidRequest,
+	CodeMissingCredentials:           MissingCredentials,
+	CodeInvalidCredentials:           InvalidCredentials,
+	CodeInvalidDinosaurSpecies:       InvalidDinosaurSpecies,
+	CodeInvalidDinosaurSex:           InvalidDinosaurSex,
+	CodeInvalidSpeciesDiet:           InvalidSpeciesDiet,
+	CodeCageCapacityExceeded:         CageCapacityExceeded,
+	CodeCageCapacityBelowOccupancy:   CageCapacityBelowOccupancy,
+	CodeCageNotEmpty:                 CageNotEmpty,
+	CodeIncompatibleSpecies:          IncompatibleSpecies,
+	CodeBreedingPairNotAllowed:       BreedingPairNotAllowed,
+	CodeDinosaurNotInCage:            DinosaurNotInCage,
+	CodeDinosaurNotCaged:             DinosaurNotCaged,
+	CodeDinosaurQuarantined:          DinosaurQuarantined,
+	CodeIncompatibleCagePowerState:   IncompatibleCagePowerState,
+	CodeInvalidPowerStatus:           InvalidPowerStatus,
+	CodeInvalidPowerStatusTransition: InvalidPowerStatusTransition,
+	CodeInvalidFeedingDate:           InvalidFeedingDate,
+	CodeInsufficientFeedStock:        InsufficientFeedStock,
+	CodeNoDinosaursToFeed:            NoDinosaursToFeed,
+	CodeInvalidZoneParent:            InvalidZoneParent,
+	CodeInvalidCageZone:              InvalidCageZone,
+	CodeZoneNotEmpty:                 ZoneNotEmpty,
+	CodeInvalidImportFile:            InvalidImportFile,
+}
+
+// ErrorForCode returns the error in models that an error response with the code reports, or nil if the code isn't
+// reported for an error in models.
+func ErrorForCode(code ErrorCode) error {
+	return codeErrors[code]
+}
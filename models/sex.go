@@ -0,0 +1,33 @@
+package models
+
+// Sex is the sex of a dinosaur. Dinosaurs are bred female, but some have changed sex because of the frog DNA used
+// to complete their genomes.
+type Sex string
+
+const (
+	SexFemale Sex = "Female"
+	SexMale   Sex = "Male"
+)
+
+func (s Sex) IsValid() bool {
+	return s == SexFemale || s == SexMale
+}
+
+// BreedingPolicy decides which dinosaurs may share a cage based on their sex.
+type BreedingPolicy struct {
+	// PreventBreeding stops a male and a female of the same species from sharing a cage.
+	PreventBreeding bool
+}
+
+// DefaultBreedingPolicy is the policy the park managers start with.
+var DefaultBreedingPolicy = BreedingPolicy{
+	PreventBreeding: true,
+}
+
+// AllowsPair reports whether the two dinosaurs may share a cage.
+func (p BreedingPolicy) AllowsPair(a, b Dinosaur) bool {
+	if !p.PreventBreeding || a.Species != b.Species {
+		return true
+	}
+	return a.RequestedSex() == b.RequestedSex()
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/go-playground/validator/v10"
+)
+
+// timeOfDay matches the 24 hour times that feedings are scheduled at, such as 06:00.
+var timeOfDay = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// customRule is a validate tag that the park adds to the validator's own, along with the reason a field breaks it.
+type customRule struct {
+	valid  func(field reflect.Value) bool
+	reason string
+}
+
+var customRules = map[string]customRule{
+	"timeofday": {
+		valid:  func(field reflect.Value) bool { return timeOfDay.MatchString(field.String()) },
+		reason: "must be a 24 hour time such as 06:00",
+	},
+	"notfuture": {
+		valid: func(field reflect.Value) bool {
+			t, ok := field.Interface().(time.Time)
+			return ok && !t.After(time.Now())
+		},
+		reason: "can't be in the future",
+	},
+	"trimmed": {
+		valid:  func(field reflect.Value) bool { return strings.TrimSpace(field.String()) == field.String() },
+		reason: "can't start or end with spaces",
+	},
+	"role": {
+		valid:  func(field reflect.Value) bool { return IsValidRole(Role(field.String())) },
+		reason: "must be one of " + oneOf(Roles),
+	},
+	"eventtype": {
+		valid: func(field reflect.Value) bool {
+			for _, eventType := range events.Types {
+				if eventType == field.String() {
+					return true
+				}
+			}
+			return false
+		},
+		reason: "must be one of " + oneOf(events.Types),
+	},
+	"healthrecordtype": {
+		valid:  func(field reflect.Value) bool { return HealthRecordType(field.String()).IsValid() },
+		reason: "must be one of " + oneOf(HealthRecordTypes),
+	},
+}
+
+// fieldChecker is a request with rules that span several of its fields, which validate tags can't express. It
+// returns the fields that break them.
+type fieldChecker interface {
+	checkFields() []FieldError
+}
+
+// requestValidator checks requests against the validate tags on their fields, and names the fields by their names
+// in JSON.
+var requestValidator = newRequestValidator()
+
+func newRequestValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	for tag, rule := range customRules {
+		valid := rule.valid
+		validate.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return valid(fl.Field())
+		})
+	}
+	return validate
+}
+
+// ValidateRequest checks a request against the validate tags on its fields, and the rules that span its fields,
+// returning a ValidationError that lists every field that is invalid.
+func ValidateRequest(request any) error {
+	err := requestValidator.Struct(request)
+	var validationErrs validator.ValidationErrors
+	if err != nil && !errors.As(err, &validationErrs) {
+		return err
+	}
+	fields := []FieldError{}
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, FieldError{Field: fieldErr.Field(), Reason: fieldReason(fieldErr)})
+	}
+	if checker, ok := request.(fieldChecker); ok {
+		fields = append(fields, checker.checkFields()...)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// JSONTypeError reports a JSON value of the wrong type for a field as an invalid field. It returns nil if err isn't
+// about the type of a field.
+func JSONTypeError(err error) *ValidationError {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field == "" {
+		return nil
+	}
+	return &ValidationError{Fields: []FieldError{{
+		Field:  typeErr.Field,
+		Reason: fmt.Sprintf("must be %s", jsonTypeName(typeErr.Type)),
+	}}}
+}
+
+// jsonTypeName names the JSON type that a Go type is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// fieldReason explains why a field broke the rule in its validate tag.
+func fieldReason(fieldErr validator.FieldError) string {
+	isText := fieldErr.Kind() == reflect.String
+	isList := fieldErr.Kind() == reflect.Slice
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "isdefault":
+		return "is set by the park and can't be given"
+	case "min":
+		if (isText || isList) && fieldErr.Param() == "1" {
+			return "can't be empty"
+		}
+		if isText {
+			return fmt.Sprintf("must be at least %s characters", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "max":
+		if isText {
+			return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldErr.Param())
+	case "http_url":
+		return "must be an absolute http or https URL"
+	}
+	if rule, ok := customRules[fieldErr.Tag()]; ok {
+		return rule.reason
+	}
+	return "is not valid"
+}
+
+// oneOf lists the values that a field can have, as in a, b or c.
+func oneOf[T ~string](values []T) string {
+	names := []string{}
+	for _, value := range values {
+		names = append(names, string(value))
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
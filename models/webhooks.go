@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Webhook subscribes a URL to events in the park. Every event of the subscribed types is posted to the URL.
+type Webhook struct {
+	ID     int      `json:"id"`
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"min=1,dive,eventtype"`
+	// Secret signs the deliveries, so that the receiver can check that they came from the park. It is generated
+	// when a webhook is created without one, and is only returned when the webhook is created.
+	Secret      string    `json:"secret,omitempty" validate:"max=128"`
+	CreatedTime time.Time `json:"createdTime"`
+}
+
+// Subscribes reports whether the webhook receives events of the type.
+func (w Webhook) Subscribes(eventType string) bool {
+	for _, subscribed := range w.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their first attempt or to be retried.
+	DeliveryPending DeliveryStatus = "PENDING"
+	// DeliveryDelivered deliveries were accepted by the receiver.
+	DeliveryDelivered DeliveryStatus = "DELIVERED"
+	// DeliveryDeadLettered deliveries failed on every attempt, and won't be retried.
+	DeliveryDeadLettered DeliveryStatus = "DEAD_LETTERED"
+)
+
+// WebhookDelivery is an event being delivered to a webhook, along with the outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID        int            `json:"id"`
+	WebhookID int            `json:"webhookId"`
+	EventID   uint64         `json:"eventId"`
+	EventType string         `json:"eventType"`
+	Payload   string         `json:"payload"`
+	Status    DeliveryStatus `json:"status"`
+	Attempts  int            `json:"attempts"`
+	// ResponseStatus is the status code of the latest attempt, if the receiver responded.
+	ResponseStatus  *int       `json:"responseStatus,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+	NextAttemptTime *time.Time `json:"nextAttemptTime,omitempty"`
+	CreatedTime     time.Time  `json:"createdTime"`
+	CompletedTime   *time.Time `json:"completedTime,omitempty"`
+}
+
+type WebhookDeliveryFilter struct {
+	Status *DeliveryStatus
+	Page   Pagination
+}
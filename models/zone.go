@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Zone is an area of the park, such as a sector, a paddock or the aviary. Zones can be nested in a parent zone, and
+// cages can be placed in a zone.
+type Zone struct {
+	Name string `json:"name" validate:"required,max=64"`
+	// Parent is checked when the zone is stored.
+	Parent      *string `json:"parent,omitempty"`
+	Description string  `json:"description,omitempty" validate:"max=255"`
+}
+
+// UpdateZoneRequest changes the fields of a zone that are set. An empty Parent moves the zone to the top of the park.
+type UpdateZoneRequest struct {
+	Parent      *string `json:"parent,omitempty"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=255"`
+}
+
+// ZoneSummary is a zone along with totals for the cages in it and in the zones nested in it.
+type ZoneSummary struct {
+	Zone
+	// Zones are the names of the zones directly inside the zone.
+	Zones        []string `json:"zones"`
+	Cages        int      `json:"cages"`
+	Occupancy    int      `json:"occupancy"`
+	MaxOccupancy int      `json:"maxOccupancy"`
+	// PowerStatuses counts the cages in each power status.
+	PowerStatuses map[PowerStatus]int `json:"powerStatuses"`
+}
+
+// CageLabelScope is where cage labels have to be unique.
+type CageLabelScope string
+
+const (
+	// CageLabelsUniqueInPark keeps cage labels unique across the park, and a cage is referred to by its label.
+	CageLabelsUniqueInPark CageLabelScope = "park"
+	// CageLabelsUniqueInZone lets cages in different zones share a label. A cage in a zone is referred to by its zone
+	// and label, such as Paddock 9:C-1, and a cage that isn't in a zone by its label.
+	CageLabelsUniqueInZone CageLabelScope = "zone"
+)
+
+// CageRefSeparator separates the zone from the label when a cage is referred to by both.
+const CageRefSeparator = ":"
+
+func (s CageLabelScope) IsValid() bool {
+	return s == CageLabelsUniqueInPark || s == CageLabelsUniqueInZone
+}
+
+// Ref returns how the cage with the label in the zone is referred to in URLs, in request bodies, and as the cage of a
+// dinosaur or feeding schedule.
+func (s CageLabelScope) Ref(zone *string, label string) string {
+	if s != CageLabelsUniqueInZone || zone == nil || *zone == "" {
+		return label
+	}
+	return *zone + CageRefSeparator + label
+}
+
+// ParseRef returns the zone and label of the cage that ref refers to. The zone is empty for a cage that isn't in a
+// zone. When labels are unique in the park a reference is just a label, and the zone should be ignored.
+func (s CageLabelScope) ParseRef(ref string) (zone, label string) {
+	if s != CageLabelsUniqueInZone {
+		return "", ref
+	}
+	// zone names can contain the separator, but labels can't
+	if i := strings.LastIndex(ref, CageRefSeparator); i >= 0 {
+		return ref[:i], ref[i+len(CageRefSeparator):]
+	}
+	return "", ref
+}
+
+// CheckLabel returns a ValidationError for a label that couldn't be told apart from a reference to a cage in a zone.
+func (s CageLabelScope) CheckLabel(label string) error {
+	if s == CageLabelsUniqueInZone && strings.Contains(label, CageRefSeparator) {
+		return &ValidationError{Fields: []FieldError{{
+			Field:  "label",
+			Reason: fmt.Sprintf("must not contain %q while cage labels are unique within their zone", CageRefSeparator),
+		}}}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+const carnivore = "Carnivore"
+
+// Engine evaluates a set of rules. It is safe for concurrent use once created.
+type Engine struct {
+	pairs   map[pairKey]PairRule
+	species map[string]SpeciesRule
+}
+
+func NewEngine(rules Rules) *Engine {
+	engine := &Engine{
+		pairs:   map[pairKey]PairRule{},
+		species: map[string]SpeciesRule{},
+	}
+	for _, pair := range rules.Pairs {
+		engine.pairs[newPairKey(pair.Species[0], pair.Species[1])] = pair
+	}
+	for species, rule := range rules.Species {
+		engine.species[species] = rule
+	}
+	return engine
+}
+
+// Compatible reports whether two species can share a cage. When they can't, the violation explains why.
+func (e *Engine) Compatible(a, b models.Species) (bool, models.RuleViolation) {
+	key := newPairKey(a.Name, b.Name)
+	if pair, ok := e.pairs[key]; ok {
+		if pair.Compatible {
+			return true, models.RuleViolation{}
+		}
+		message := fmt.Sprintf("%s can't share a cage with %s", key.first, key.second)
+		if key.first == key.second {
+			message = fmt.Sprintf("%s can't share a cage with another %s", key.first, key.second)
+		}
+		if pair.Reason != "" {
+			message += ": " + pair.Reason
+		}
+		return false, models.RuleViolation{
+			Rule:    fmt.Sprintf("pair:%s/%s", key.first, key.second),
+			Message: message,
+		}
+	}
+
+	if a.Name == b.Name {
+		return true, models.RuleViolation{}
+	}
+	if a.Diet == carnivore && b.Diet == carnivore {
+		return false, models.RuleViolation{
+			Rule:    "diet:carnivore",
+			Message: fmt.Sprintf("%s and %s are carnivores, which only share a cage with their own species", a.Name, b.Name),
+		}
+	}
+	if a.Diet == carnivore || b.Diet == carnivore {
+		herbivoreName, carnivoreName := a.Name, b.Name
+		if a.Diet == carnivore {
+			herbivoreName, carnivoreName = b.Name, a.Name
+		}
+		return false, models.RuleViolation{
+			Rule:    "diet:herbivore",
+			Message: fmt.Sprintf("%s is a herbivore and can't share a cage with %s, which is a carnivore", herbivoreName, carnivoreName),
+		}
+	}
+	return true, models.RuleViolation{}
+}
+
+// Check returns the rules that adding the dinosaur to a cage with the given capacity and occupants would break.
+// The dinosaur and the occupants must have their diets set.
+func (e *Engine) Check(dinosaur models.Dinosaur, capacity int, occupants []models.Dinosaur) []models.RuleViolation {
+	violations := []models.RuleViolation{}
+
+	// the number of each species in the cage once the dinosaur has been added, in the order they were found
+	counts := map[string]int{dinosaur.Species: 1}
+	speciesInCage := []models.Species{{Name: dinosaur.Species, Diet: dinosaur.Diet}}
+	for _, occupant := range occupants {
+		if counts[occupant.Species] == 0 {
+			speciesInCage = append(speciesInCage, models.Species{Name: occupant.Species, Diet: occupant.Diet})
+		}
+		counts[occupant.Species]++
+	}
+
+	for _, other := range speciesInCage {
+		if other.Name == dinosaur.Species && counts[other.Name] == 1 {
+			// the dinosaur is the only one of its species
+			continue
+		}
+		if ok, violation := e.Compatible(speciesInCage[0], other); !ok {
+			violations = append(violations, violation)
+		}
+	}
+
+	if rule := e.species[dinosaur.Species]; rule.MaxPerCage > 0 && counts[dinosaur.Species] > rule.MaxPerCage {
+		violations = append(violations, models.RuleViolation{
+			Rule:    "maxPerCage:" + dinosaur.Species,
+			Message: fmt.Sprintf("no more than %d %s can share a cage", rule.MaxPerCage, dinosaur.Species),
+		})
+	}
+
+	return append(violations, e.packViolations(capacity, len(occupants)+1, counts, speciesInCage)...)
+}
+
+// CheckCapacity returns the rules that changing the capacity of a cage with the given occupants would break, which
+// are the pack sizes of the species in it. The occupants must have their diets set.
+func (e *Engine) CheckCapacity(capacity int, occupants []models.Dinosaur) []models.RuleViolation {
+	counts := map[string]int{}
+	speciesInCage := []models.Species{}
+	for _, occupant := range occupants {
+		if counts[occupant.Species] == 0 {
+			speciesInCage = append(speciesInCage, models.Species{Name: occupant.Species, Diet: occupant.Diet})
+		}
+		counts[occupant.Species]++
+	}
+	return e.packViolations(capacity, len(occupants), counts, speciesInCage)
+}
+
+// packViolations returns the species in a cage of total dinosaurs that wouldn't have room for a full pack next to
+// the other occupants.
+func (e *Engine) packViolations(capacity, total int, counts map[string]int, speciesInCage []models.Species) []models.RuleViolation {
+	violations := []models.RuleViolation{}
+	for _, species := range speciesInCage {
+		rule := e.species[species.Name]
+		if rule.MinPackSize <= 1 {
+			continue
+		}
+		room := capacity - (total - counts[species.Name])
+		if room < rule.MinPackSize {
+			violations = append(violations, models.RuleViolation{
+				Rule: "minPackSize:" + species.Name,
+				Message: fmt.Sprintf("%s live in packs of at least %d, and the cage would only have room for %d of them",
+					species.Name, rule.MinPackSize, room),
+			})
+		}
+	}
+	return violations
+}
+
+// IncompatibleSpecies returns the names of the species that can't share a cage with the dinosaur, out of
+// allSpecies.
+func (e *Engine) IncompatibleSpecies(dinosaur models.Dinosaur, allSpecies []models.Species) []string {
+	incompatible := []string{}
+	for _, other := range allSpecies {
+		if ok, _ := e.Compatible(models.Species{Name: dinosaur.Species, Diet: dinosaur.Diet}, other); !ok {
+			incompatible = append(incompatible, other.Name)
+		}
+	}
+	return incompatible
+}
+
+// SpeciesRule returns the rule for a species, which is the zero rule if it has none.
+func (e *Engine) SpeciesRule(species string) SpeciesRule {
+	return e.species[species]
+}
+
+// Species returns the names of the species with their own rules, in alphabetical order.
+func (e *Engine) Species() []string {
+	names := []string{}
+	for species := range e.species {
+		names = append(names, species)
+	}
+	sort.Strings(names)
+	return names
+}
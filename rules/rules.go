@@ -0,0 +1,109 @@
+// Package rules decides which species of dinosaur can share a cage. The rules are data, either loaded from a YAML
+// file or from the speciesCompatibility and speciesConstraint tables, so that exhibits can be tuned without a
+// code change.
+package rules
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules is a pairwise compatibility matrix along with constraints for single species. Pairs of species that aren't
+// in the matrix fall back on their diets: carnivores only share a cage with their own species, and herbivores never
+// share a cage with carnivores.
+type Rules struct {
+	Pairs   []PairRule             `yaml:"pairs"`
+	Species map[string]SpeciesRule `yaml:"species"`
+}
+
+// PairRule decides whether two species can share a cage. The order of the species doesn't matter, and both can be
+// the same species to keep a species apart from its own kind.
+type PairRule struct {
+	Species    [2]string `yaml:"species"`
+	Compatible bool      `yaml:"compatible"`
+	// Reason explains the rule in the violations that it causes.
+	Reason string `yaml:"reason"`
+}
+
+// SpeciesRule constrains how a species is caged. Zero values are not enforced.
+type SpeciesRule struct {
+	// MinPackSize is the smallest group the species can live in. Packs have to be moved in one dinosaur at a time,
+	// so a cage holding the species must have room for a full pack next to its other occupants.
+	MinPackSize int `yaml:"minPackSize"`
+	// MaxPerCage is the most dinosaurs of the species that can share a cage.
+	MaxPerCage int `yaml:"maxPerCage"`
+}
+
+// Load reads rules written in YAML, for example:
+//
+//	pairs:
+//	  - species: [Ankylosaurus, Stegosaurus]
+//	    compatible: false
+//	    reason: they fight over territory
+//	species:
+//	  Velociraptor:
+//	    minPackSize: 4
+func Load(r io.Reader) (Rules, error) {
+	rules := Rules{}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&rules); err != nil && err != io.EOF {
+		return Rules{}, err
+	}
+	if err := rules.Validate(); err != nil {
+		return Rules{}, err
+	}
+	return rules, nil
+}
+
+func LoadFile(path string) (Rules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Rules{}, err
+	}
+	defer file.Close()
+
+	rules, err := Load(file)
+	if err != nil {
+		return Rules{}, fmt.Errorf("loading rules from %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+func (r Rules) Validate() error {
+	seen := map[pairKey]bool{}
+	for _, pair := range r.Pairs {
+		if pair.Species[0] == "" || pair.Species[1] == "" {
+			return fmt.Errorf("every pair rule must name two species")
+		}
+		key := newPairKey(pair.Species[0], pair.Species[1])
+		if seen[key] {
+			return fmt.Errorf("there is more than one rule for %s and %s", pair.Species[0], pair.Species[1])
+		}
+		seen[key] = true
+	}
+	for species, rule := range r.Species {
+		if rule.MinPackSize < 0 || rule.MaxPerCage < 0 {
+			return fmt.Errorf("the rule for %s can't be negative", species)
+		}
+		if rule.MaxPerCage > 0 && rule.MinPackSize > rule.MaxPerCage {
+			return fmt.Errorf("the pack size for %s is larger than the number that can share a cage", species)
+		}
+	}
+	return nil
+}
+
+// pairKey identifies a pair of species regardless of their order.
+type pairKey struct {
+	first, second string
+}
+
+func newPairKey(a, b string) pairKey {
+	if a > b {
+		a, b = b, a
+	}
+	return pairKey{first: a, second: b}
+}
@@ -13,7 +13,6 @@ docker run \
   -e MYSQL_USER=admin \
   -e MYSQL_PASSWORD=password \
   -e MYSQL_DATABASE=jurassicpark \
-  -v $(pwd):/resources \
   mysql:5.7.36
 
 until docker exec -it jurassic-park-sql mysql -uadmin -ppassword --execute "SHOW DATABASES;" >/dev/null 2>&1; do
@@ -23,5 +22,5 @@ done
 
 echo "Creating database in local mysql docker container..." >&2
 docker exec -it jurassic-park-sql mysql -uadmin -ppassword --execute "CREATE DATABASE jurassicpark;">/dev/null 2>&1 
-echo "Creating the tables in local mysql docker container..." >&2
-docker exec -it jurassic-park-sql mysql -uadmin -ppassword --execute "use jurassicpark;SOURCE /resources/jurassic-park-db.sql;" >/dev/null 2>&1;
\ No newline at end of file
+echo "Migrating the database schema..." >&2
+(cd .. && SQL_HOST=localhost:3306 go run . migrate up)
@@ -0,0 +1,301 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/events"
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	// deliveryTimeout is how long a receiver has to respond before the attempt fails.
+	deliveryTimeout = 10 * time.Second
+	// deliveryLease is how long a claimed delivery is held before another attempt is allowed. It is longer than
+	// deliveryTimeout so that a delivery isn't attempted twice at once.
+	deliveryLease  = 3 * deliveryTimeout
+	claimBatchSize = 50
+	// maxErrorLength is the most of an error or response body that is kept in the delivery log.
+	maxErrorLength = 1024
+)
+
+// Dispatcher turns the events published to a broker into deliveries, and delivers them.
+type Dispatcher struct {
+	store        Store
+	broker       *events.Broker
+	client       *http.Client
+	retryPolicy  RetryPolicy
+	pollInterval time.Duration
+	// wake starts delivering straight away rather than at the next poll
+	wake chan struct{}
+
+	stream      <-chan events.Event
+	unsubscribe func()
+}
+
+// Option configures a Dispatcher.
+type Option func(d *Dispatcher)
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(d *Dispatcher) {
+		d.retryPolicy = policy
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithPollInterval sets how often the dispatcher looks for deliveries that are due to be retried.
+func WithPollInterval(interval time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.pollInterval = interval
+	}
+}
+
+// NewDispatcher subscribes to the broker straight away, so that no events are missed between creating the
+// dispatcher and running it.
+func NewDispatcher(store Store, broker *events.Broker, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		store:        store,
+		broker:       broker,
+		client:       &http.Client{Timeout: deliveryTimeout},
+		retryPolicy:  DefaultRetryPolicy,
+		pollInterval: defaultPollInterval,
+		wake:         make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	_, d.stream, d.unsubscribe = broker.Subscribe(nil)
+	return d
+}
+
+// Run records a delivery for every event published to the broker that a webhook subscribes to, and delivers them
+// until ctx is done. Deliveries that are still pending when the server stops are picked up by the next server
+// that runs.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.deliverLoop(ctx)
+	}()
+	d.enqueueLoop(ctx)
+	wg.Wait()
+}
+
+func (d *Dispatcher) enqueueLoop(ctx context.Context) {
+	stream, unsubscribe := d.stream, d.unsubscribe
+	defer func() { unsubscribe() }()
+
+	var lastEventID *uint64
+	for {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				// the broker dropped the dispatcher for falling behind, so resume from the replay buffer
+				var replay []events.Event
+				replay, stream, unsubscribe = d.broker.Subscribe(lastEventID)
+				for _, missed := range replay {
+					d.enqueue(missed)
+					id := missed.ID
+					lastEventID = &id
+				}
+				continue
+			}
+			d.enqueue(event)
+			lastEventID = &event.ID
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// enqueue records a pending delivery of the event for each webhook that subscribes to it.
+func (d *Dispatcher) enqueue(event events.Event) {
+	webhooks, err := d.store.GetWebhooks()
+	if err != nil {
+		log.Printf("webhooks: unable to read webhooks for event %d: %s", event.ID, err)
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhooks: unable to encode event %d: %s", event.ID, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	deliveries := []models.WebhookDelivery{}
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:       webhook.ID,
+			EventID:         event.ID,
+			EventType:       event.Type,
+			Payload:         string(payload),
+			Status:          models.DeliveryPending,
+			NextAttemptTime: &now,
+			CreatedTime:     now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := d.store.AddWebhookDeliveries(deliveries); err != nil {
+		log.Printf("webhooks: unable to record deliveries for event %d: %s", event.ID, err)
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	for {
+		d.deliverDue(ctx)
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverDue delivers every delivery that is due, in batches. The deliveries in a batch are sent at the same time,
+// so that a slow receiver doesn't hold up the others.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.store.ClaimWebhookDeliveries(time.Now().UTC(), deliveryLease, claimBatchSize)
+		if err != nil {
+			log.Printf("webhooks: unable to claim deliveries: %s", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+		webhooks, err := d.webhooksByID()
+		if err != nil {
+			log.Printf("webhooks: unable to read webhooks: %s", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			webhook, ok := webhooks[delivery.WebhookID]
+			if !ok {
+				// the webhook was deleted along with its deliveries after they were claimed
+				continue
+			}
+			wg.Add(1)
+			go func(delivery models.WebhookDelivery) {
+				defer wg.Done()
+				delivery = d.attempt(ctx, webhook, delivery)
+				if ctx.Err() != nil {
+					// the server is stopping, so the attempt doesn't count and is made again once the lease runs out
+					return
+				}
+				if err := d.store.UpdateWebhookDelivery(delivery); err != nil {
+					log.Printf("webhooks: unable to record delivery %d: %s", delivery.ID, err)
+				}
+			}(delivery)
+		}
+		wg.Wait()
+	}
+}
+
+func (d *Dispatcher) webhooksByID() (map[int]models.Webhook, error) {
+	webhooks, err := d.store.GetWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	byID := map[int]models.Webhook{}
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+	return byID, nil
+}
+
+// attempt posts the delivery to the webhook, and returns the delivery updated with the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) models.WebhookDelivery {
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	delivery.LastError = ""
+
+	statusCode, err := d.post(ctx, webhook, delivery)
+	now := time.Now().UTC()
+	if statusCode != 0 {
+		delivery.ResponseStatus = &statusCode
+	}
+	if err == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.NextAttemptTime = nil
+		delivery.CompletedTime = &now
+		return delivery
+	}
+
+	delivery.LastError = truncate(err.Error(), maxErrorLength)
+	if delivery.Attempts >= d.retryPolicy.MaxAttempts {
+		delivery.Status = models.DeliveryDeadLettered
+		delivery.NextAttemptTime = nil
+		delivery.CompletedTime = &now
+		return delivery
+	}
+	next := now.Add(d.retryPolicy.Backoff(delivery.Attempts))
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptTime = &next
+	return delivery
+}
+
+// post sends the delivery and returns the status code of the response, which is 0 if there was no response. Any
+// status code other than 2xx is an error.
+func (d *Dispatcher) post(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "jurassic-park-webhooks")
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		response, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return resp.StatusCode, fmt.Errorf("the receiver responded with %d: %s", resp.StatusCode, response)
+	}
+	return resp.StatusCode, nil
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length]
+}
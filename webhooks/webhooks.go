@@ -0,0 +1,86 @@
+// Package webhooks posts events in the park to the URLs that subscribed to them. Deliveries are signed, retried
+// with exponential backoff when they fail, and dead-lettered once they run out of attempts. Every delivery is
+// recorded, so the outcome can be looked up afterwards.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// The headers sent with every delivery.
+const (
+	DeliveryHeader  = "X-Jurassic-Park-Delivery"
+	EventHeader     = "X-Jurassic-Park-Event"
+	TimestampHeader = "X-Jurassic-Park-Timestamp"
+	SignatureHeader = "X-Jurassic-Park-Signature"
+)
+
+// Store keeps the webhooks and their deliveries.
+type Store interface {
+	GetWebhooks() ([]models.Webhook, error)
+	AddWebhookDeliveries(deliveries []models.WebhookDelivery) error
+	// ClaimWebhookDeliveries returns up to limit pending deliveries that are due at now, and pushes their next
+	// attempt back by lease so that no one else claims them while they are being delivered. If the delivery isn't
+	// updated before the lease runs out, because the server stopped part way, it is attempted again.
+	ClaimWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	UpdateWebhookDelivery(delivery models.WebhookDelivery) error
+}
+
+// NewSecret generates a secret for signing deliveries.
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Sign returns the signature of a delivery, which is the hex encoded HMAC-SHA256 of the timestamp, a period and
+// the body, keyed with the webhook's secret. It is sent in the SignatureHeader prefixed with "sha256=".
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature of a delivery is valid. Receivers should also reject timestamps that are too
+// old, so that deliveries can't be replayed.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// RetryPolicy decides how often a delivery is attempted, and how long to wait between attempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failed attempt. It doubles after every further failure, up to
+	// MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries a delivery for about 15 hours before it is dead-lettered.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    12,
+	InitialBackoff: 30 * time.Second,
+	MaxBackoff:     6 * time.Hour,
+}
+
+// Backoff returns the wait before the next attempt, once attempts have failed.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return backoff
+}
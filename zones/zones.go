@@ -0,0 +1,144 @@
+// Package zones works with the hierarchy of zones that the park is organized into. Zones are nested in at most one
+// parent zone, and a zone's totals include the cages in every zone nested in it.
+package zones
+
+import (
+	"sort"
+
+	"github.com/EdgarH78/jurassic-park/models"
+)
+
+// Store reads the zones and cages that summaries are made from.
+type Store interface {
+	GetZones() ([]models.Zone, error)
+	GetCages(filter models.CageFilter) ([]models.Cage, models.PageInfo, error)
+}
+
+// Subtree returns the name of the zone followed by the names of every zone nested in it.
+func Subtree(all []models.Zone, name string) []string {
+	children := map[string][]string{}
+	for _, zone := range all {
+		if zone.Parent != nil {
+			children[*zone.Parent] = append(children[*zone.Parent], zone.Name)
+		}
+	}
+	subtree := []string{name}
+	for i := 0; i < len(subtree); i++ {
+		subtree = append(subtree, children[subtree[i]]...)
+	}
+	return subtree
+}
+
+// ParentFirst orders the zones so that every zone comes after its parent, which is the order they can be added in.
+// Zones at the top of the park, and zones in the same parent, keep the order they were given in.
+func ParentFirst(all []models.Zone) []models.Zone {
+	children := map[string][]models.Zone{}
+	ordered := []models.Zone{}
+	for _, zone := range all {
+		if zone.Parent == nil {
+			ordered = append(ordered, zone)
+		} else {
+			children[*zone.Parent] = append(children[*zone.Parent], zone)
+		}
+	}
+	for i := 0; i < len(ordered); i++ {
+		ordered = append(ordered, children[ordered[i].Name]...)
+	}
+	return ordered
+}
+
+// CheckParent returns InvalidZoneParent unless parent is a zone that the named zone can be nested in. The parent
+// must exist, and it can't be the zone itself or nested in it, which would make a cycle.
+func CheckParent(all []models.Zone, name, parent string) error {
+	if !Exists(all, parent) {
+		return models.InvalidZoneParent
+	}
+	for _, nested := range Subtree(all, name) {
+		if nested == parent {
+			return models.InvalidZoneParent
+		}
+	}
+	return nil
+}
+
+// Exists reports whether there is a zone with the name.
+func Exists(all []models.Zone, name string) bool {
+	for _, zone := range all {
+		if zone.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Summaries summarizes every zone, ordered by name.
+func Summaries(store Store) ([]models.ZoneSummary, error) {
+	all, cages, err := read(store)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []models.ZoneSummary{}
+	for _, zone := range all {
+		summaries = append(summaries, summarize(all, cages, zone))
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
+
+// Summary summarizes the named zone, returning EntityNotFound if there is no such zone.
+func Summary(store Store, name string) (*models.ZoneSummary, error) {
+	all, cages, err := read(store)
+	if err != nil {
+		return nil, err
+	}
+	for _, zone := range all {
+		if zone.Name == name {
+			summary := summarize(all, cages, zone)
+			return &summary, nil
+		}
+	}
+	return nil, models.EntityNotFound
+}
+
+func read(store Store) ([]models.Zone, []models.Cage, error) {
+	all, err := store.GetZones()
+	if err != nil {
+		return nil, nil, err
+	}
+	cages, _, err := store.GetCages(models.CageFilter{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return all, cages, nil
+}
+
+func summarize(all []models.Zone, cages []models.Cage, zone models.Zone) models.ZoneSummary {
+	summary := models.ZoneSummary{
+		Zone:          zone,
+		Zones:         []string{},
+		PowerStatuses: map[models.PowerStatus]int{},
+	}
+	for _, other := range all {
+		if other.Parent != nil && *other.Parent == zone.Name {
+			summary.Zones = append(summary.Zones, other.Name)
+		}
+	}
+	sort.Strings(summary.Zones)
+
+	inZone := map[string]bool{}
+	for _, name := range Subtree(all, zone.Name) {
+		inZone[name] = true
+	}
+	for _, cage := range cages {
+		if cage.Zone == nil || !inZone[*cage.Zone] {
+			continue
+		}
+		summary.Cages++
+		summary.Occupancy += cage.Occupancy
+		summary.MaxOccupancy += cage.MaxOccupancy
+		summary.PowerStatuses[cage.PowerStatus]++
+	}
+	return summary
+}